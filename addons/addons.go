@@ -0,0 +1,173 @@
+// Package addons talks to third party addons through the interfaces they
+// publish: named client data areas and LVARs
+//
+// A Link opens the client data channels an addon publishes and passes
+// their contents to handlers; typed wrappers for particular addons are
+// built on it, or on LVAR reads and writes for addons that only expose
+// those. The addon must be installed and running for a channel to carry
+// anything; opening a channel nobody publishes is not an error.
+package addons
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// AddonError is the error type for the package
+type AddonError string
+
+func (e AddonError) Error() string { return string(e) }
+
+const (
+	// ErrNotStarted is returned before the connector has started the link
+	ErrNotStarted AddonError = "link not started"
+	// ErrUnknownChannel is returned for a channel the link was not given
+	ErrUnknownChannel AddonError = "unknown channel"
+)
+
+// Channel is a named client data area published by an addon
+type Channel struct {
+	Name string
+	// Size is the size of the area in bytes
+	Size client.DWORD
+	// Create makes the area if the addon has not, for channels the client
+	// writes into, eg a command channel read by a WASM module
+	Create bool
+	// Watch requests the area each time it is set
+	Watch bool
+}
+
+type channel struct {
+	Channel
+	clientDataID client.DWORD
+	defineID     client.DWORD
+	requestID    client.DWORD
+	handlers     []func([]byte)
+}
+
+// Link is a receiver that opens addon client data channels
+type Link struct {
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	channels map[string]*channel
+}
+
+// NewLink creates a link over the channels
+func NewLink(channels ...Channel) *Link {
+	l := &Link{channels: map[string]*channel{}}
+	for _, c := range channels {
+		l.channels[c.Name] = &channel{Channel: c}
+	}
+	return l
+}
+
+// Start maps, creates and watches the channels; it runs again on reconnect
+func (l *Link) Start(ctx context.Context, sc *client.SimConnect) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.channels {
+		if err := l.open(sc, c); err != nil {
+			slog.Error("Cannot open addon channel", "channel", c.Name, "error", err)
+		}
+	}
+	l.sc = sc
+	simconnect.Go(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+		l.mu.Lock()
+		l.sc = nil
+		l.mu.Unlock()
+	})
+}
+
+func (l *Link) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// open sets up a channel; it must be called with the lock held
+func (l *Link) open(sc *client.SimConnect, c *channel) error {
+	// client data IDs and definition IDs are separate namespaces, so one
+	// named ID serves as both
+	c.clientDataID = sc.GetDefineIDByName("addons:" + c.Name)
+	c.defineID = c.clientDataID
+	if err := sc.MapClientDataNameToID(c.Name, c.clientDataID); err != nil {
+		return err
+	}
+	if c.Create {
+		if err := sc.CreateClientData(c.clientDataID, c.Size, client.CREATE_CLIENT_DATA_FLAG_DEFAULT); err != nil {
+			return err
+		}
+	}
+	if err := sc.AddToClientDataDefinition(c.defineID, 0, c.Size, 0, client.UNUSED); err != nil {
+		return err
+	}
+	if !c.Watch {
+		return nil
+	}
+	c.requestID = sc.NewRequestID()
+	sc.HandleClientData(c.requestID, func(x *client.RecvClientData, data []byte) {
+		l.deliver(c, data)
+	})
+	return sc.RequestClientData(c.clientDataID, c.requestID, c.defineID,
+		client.CLIENT_DATA_PERIOD_ON_SET, client.CLIENT_DATA_REQUEST_FLAG_DEFAULT, 0, 0, 0)
+}
+
+func (l *Link) deliver(c *channel, data []byte) {
+	l.mu.Lock()
+	handlers := append([]func([]byte){}, c.handlers...)
+	l.mu.Unlock()
+	for _, fn := range handlers {
+		fn(data)
+	}
+}
+
+// OnData adds a handler for a watched channel
+// handlers run on the dispatch goroutine and must copy data to keep it
+func (l *Link) OnData(name string, fn func(data []byte)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c, ok := l.channels[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+	}
+	c.handlers = append(c.handlers, fn)
+	return nil
+}
+
+// Send writes data to the start of a channel; data longer than the channel is an error
+// and shorter data is padded with zeros
+func (l *Link) Send(name string, data []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sc == nil {
+		return ErrNotStarted
+	}
+	c, ok := l.channels[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+	}
+	if client.DWORD(len(data)) > c.Size {
+		return fmt.Errorf("%s: %d bytes is larger than the channel's %d", name, len(data), c.Size)
+	}
+	buf := make([]byte, c.Size)
+	copy(buf, data)
+	return l.sc.SetClientDataBytes(c.clientDataID, c.defineID, buf)
+}
+
+// SendString writes a NUL terminated string to a channel
+func (l *Link) SendString(name, s string) error {
+	return l.Send(name, append([]byte(s), 0))
+}
+
+// cstring returns the text of a NUL terminated buffer
+func cstring(data []byte) string {
+	for i, b := range data {
+		if b == 0 {
+			return string(data[:i])
+		}
+	}
+	return string(data)
+}
@@ -0,0 +1,82 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// GSXService names a GSX service by its state LVAR
+type GSXService string
+
+const (
+	GSXBoarding   GSXService = "L:FSDT_GSX_BOARDING_STATE"
+	GSXDeboarding GSXService = "L:FSDT_GSX_DEBOARDING_STATE"
+	GSXDeparture  GSXService = "L:FSDT_GSX_DEPARTURE_STATE"
+	GSXCatering   GSXService = "L:FSDT_GSX_CATERING_STATE"
+	GSXRefueling  GSXService = "L:FSDT_GSX_REFUELING_STATE"
+)
+
+// GSXState is the published state of a GSX service
+type GSXState int
+
+const (
+	GSXUnknown GSXState = iota
+	GSXAvailable
+	GSXNotAvailable
+	GSXBypassed
+	GSXRequested
+	GSXPerforming
+	GSXCompleted
+)
+
+func (s GSXState) String() string {
+	switch s {
+	case GSXAvailable:
+		return "available"
+	case GSXNotAvailable:
+		return "not_available"
+	case GSXBypassed:
+		return "bypassed"
+	case GSXRequested:
+		return "requested"
+	case GSXPerforming:
+		return "performing"
+	case GSXCompleted:
+		return "completed"
+	default:
+		return "unknown"
+	}
+}
+
+// GSX reads the ground services state GSX publishes in LVARs and drives
+// its menu the way its own documented LVAR interface does
+// the reads and writes need a running dispatch loop, eg the Connector
+type GSX struct {
+	sc *client.SimConnect
+}
+
+// NewGSX creates a GSX wrapper on a connection
+func NewGSX(sc *client.SimConnect) *GSX {
+	return &GSX{sc: sc}
+}
+
+// State returns the state of a service
+func (g *GSX) State(ctx context.Context, svc GSXService) (GSXState, error) {
+	v, err := g.sc.ReadFloat(ctx, string(svc), "Number")
+	if err != nil {
+		return GSXUnknown, fmt.Errorf("gsx state %s: %w", svc, err)
+	}
+	return GSXState(v), nil
+}
+
+// OpenMenu opens the GSX menu, as the toolbar or hotkey would
+func (g *GSX) OpenMenu(ctx context.Context) error {
+	return g.sc.WriteFloat(ctx, "L:FSDT_GSX_MENU_OPEN", "Number", 1)
+}
+
+// Choose picks an entry of the open menu; choice is zero based
+func (g *GSX) Choose(ctx context.Context, choice int) error {
+	return g.sc.WriteFloat(ctx, "L:FSDT_GSX_MENU_CHOICE", "Number", float64(choice))
+}
@@ -0,0 +1,84 @@
+package addons
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// channels published by the MobiFlight WASM module
+const (
+	MobiFlightCommand  = "MobiFlight.Command"
+	MobiFlightResponse = "MobiFlight.Response"
+	// mobiFlightMessageSize is the size of both channels
+	mobiFlightMessageSize = 256
+)
+
+// MobiFlight drives the MobiFlight WASM module, which runs calculator code
+// inside the sim; this gives access to LVARs, H events and B events that
+// SimConnect cannot reach directly
+// add it to the connector as a receiver
+type MobiFlight struct {
+	*Link
+
+	mu         sync.Mutex
+	onResponse []func(string)
+}
+
+// NewMobiFlight creates a MobiFlight link
+func NewMobiFlight() *MobiFlight {
+	m := &MobiFlight{
+		Link: NewLink(
+			Channel{Name: MobiFlightCommand, Size: mobiFlightMessageSize},
+			Channel{Name: MobiFlightResponse, Size: mobiFlightMessageSize, Watch: true},
+		),
+	}
+	m.Link.OnData(MobiFlightResponse, func(data []byte) {
+		msg := cstring(data)
+		m.mu.Lock()
+		handlers := append([]func(string){}, m.onResponse...)
+		m.mu.Unlock()
+		for _, fn := range handlers {
+			fn(msg)
+		}
+	})
+	return m
+}
+
+// OnResponse adds a handler for messages from the module, eg "MF.Pong"
+func (m *MobiFlight) OnResponse(fn func(msg string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onResponse = append(m.onResponse, fn)
+}
+
+// Command sends a raw command, eg "MF.Ping"
+func (m *MobiFlight) Command(cmd string) error {
+	if len(cmd) >= mobiFlightMessageSize {
+		return fmt.Errorf("mobiflight command is longer than %d bytes", mobiFlightMessageSize-1)
+	}
+	return m.SendString(MobiFlightCommand, cmd)
+}
+
+// Ping asks the module to answer "MF.Pong", to see if it is installed
+func (m *MobiFlight) Ping() error {
+	return m.Command("MF.Ping")
+}
+
+// Execute runs RPN calculator code, eg "1 (>L:A32NX_ENGINE_MASTER_1)"
+// or "(>H:A320_Neo_CDU_1_BTN_DIR)"
+func (m *MobiFlight) Execute(code string) error {
+	return m.Command("MF.SimVars.Set." + code)
+}
+
+// SetLVar sets an LVAR; the L: prefix is added if missing
+func (m *MobiFlight) SetLVar(name string, v float64) error {
+	name = strings.TrimPrefix(name, "L:")
+	return m.Execute(fmt.Sprintf("%g (>L:%s)", v, name))
+}
+
+// SendHEvent fires an H event, the custom events of many addon aircraft
+func (m *MobiFlight) SendHEvent(name string) error {
+	name = strings.TrimPrefix(name, "H:")
+	return m.Execute("(>H:" + name + ")")
+}
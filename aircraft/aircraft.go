@@ -0,0 +1,278 @@
+// Package aircraft detects the loaded aircraft and swaps per-aircraft
+// configuration when the user changes aircraft mid-session
+package aircraft
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"unsafe"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/store"
+)
+
+// IdentityReport is the data structure used to identify the aircraft
+type IdentityReport struct {
+	client.RecvSimobjectDataByType
+	Title    [256]byte `name:"TITLE"`
+	ATCModel [256]byte `name:"ATC MODEL"`
+	ATCType  [256]byte `name:"ATC TYPE"`
+}
+
+// Info identifies the loaded aircraft
+type Info struct {
+	Title    string
+	ATCModel string
+	ATCType  string
+	// Path is the aircraft file reported by the AircraftLoaded event, if any
+	Path string
+}
+
+// Listener is called when the loaded aircraft changes
+// it is also called after every (re)connect once the aircraft is known
+type Listener func(ctx context.Context, sc *client.SimConnect, info Info)
+
+// Detector is a receiver that tracks the loaded aircraft
+type Detector struct {
+	mu        sync.Mutex
+	listeners []Listener
+	info      Info
+	known     bool
+	path      string
+	loadedID  client.DWORD
+}
+
+// Option is a function that sets options on the Detector
+type Option func(*Detector)
+
+// WithListener adds a listener to the detector
+func WithListener(l Listener) Option {
+	return func(d *Detector) {
+		d.listeners = append(d.listeners, l)
+	}
+}
+
+// NewDetector creates a new detector
+func NewDetector(opts ...Option) *Detector {
+	d := &Detector{}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// Listen adds a listener to the detector
+func (d *Detector) Listen(l Listener) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.listeners = append(d.listeners, l)
+}
+
+// Start registers the identity definition, subscribes to AircraftLoaded
+// and requests the current aircraft
+func (d *Detector) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := sc.RegisterDataDefinition(&IdentityReport{}); err != nil {
+		slog.Error("Cannot register aircraft identity", "error", err)
+		return
+	}
+	id := sc.GetEventID()
+	if err := sc.SubscribeToSystemEvent(id, "AircraftLoaded"); err != nil {
+		slog.Error("Cannot subscribe to AircraftLoaded", "error", err)
+		return
+	}
+	d.mu.Lock()
+	d.loadedID = id
+	d.known = false
+	d.mu.Unlock()
+
+	if err := simconnect.RequestData[IdentityReport](sc); err != nil {
+		slog.Error("Cannot request aircraft identity", "error", err)
+	}
+}
+
+// Event requests the aircraft identity when a new aircraft is loaded
+func (d *Detector) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	d.mu.Lock()
+	loadedID := d.loadedID
+	d.mu.Unlock()
+	if ev.EventID != loadedID {
+		return
+	}
+	if ev.ID == client.RECV_ID_EVENT_FILENAME {
+		fn := (*client.RecvEventFilename)(unsafe.Pointer(ev))
+		d.mu.Lock()
+		d.path = client.BytesToString(fn.FileName[:])
+		d.mu.Unlock()
+	}
+	if err := simconnect.RequestData[IdentityReport](sc); err != nil {
+		slog.Error("Cannot request aircraft identity", "error", err)
+	}
+}
+
+// Update notifies the listeners when the aircraft changes
+func (d *Detector) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[IdentityReport](sc, ppData)
+	if !ok {
+		return
+	}
+	d.mu.Lock()
+	info := Info{
+		Title:    client.BytesToString(r.Title[:]),
+		ATCModel: client.BytesToString(r.ATCModel[:]),
+		ATCType:  client.BytesToString(r.ATCType[:]),
+		Path:     d.path,
+	}
+	changed := !d.known || info != d.info
+	d.info = info
+	d.known = true
+	listeners := append([]Listener{}, d.listeners...)
+	d.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	slog.Debug("Aircraft detected", "title", info.Title, "model", info.ATCModel)
+	for _, l := range listeners {
+		l(ctx, sc, info)
+	}
+}
+
+// Info returns the loaded aircraft; false if it is not known yet
+func (d *Detector) Info() (Info, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.info, d.known
+}
+
+// Profile is per-aircraft configuration
+type Profile struct {
+	Name string
+	// Match is a list of case-insensitive substrings of TITLE or ATC MODEL
+	Match []string
+	// Setup registers the profile's event maps, definitions and subscriptions
+	// the context is cancelled when the profile is unloaded or the connection is lost
+	Setup func(ctx context.Context, sc *client.SimConnect) error
+	// Teardown is called when the profile is unloaded while still connected
+	Teardown func(sc *client.SimConnect) error
+}
+
+// Matches returns true if the profile matches the aircraft
+func (p Profile) Matches(info Info) bool {
+	title, model := strings.ToLower(info.Title), strings.ToLower(info.ATCModel)
+	for _, m := range p.Match {
+		m = strings.ToLower(m)
+		if m != "" && (strings.Contains(title, m) || strings.Contains(model, m)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Loader swaps profiles as the detected aircraft changes
+type Loader struct {
+	profiles []Profile
+	fallback *Profile
+
+	mu     sync.Mutex
+	active *Profile
+	sc     *client.SimConnect
+	cancel context.CancelFunc
+}
+
+// NewLoader creates a loader for the profiles and attaches it to the detector
+// profiles are matched in order; fallback may be nil
+func NewLoader(d *Detector, fallback *Profile, profiles ...Profile) *Loader {
+	l := &Loader{profiles: profiles, fallback: fallback}
+	d.Listen(l.load)
+	return l
+}
+
+// Active returns the loaded profile; false if none is loaded
+func (l *Loader) Active() (Profile, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active == nil {
+		return Profile{}, false
+	}
+	return *l.active, true
+}
+
+func (l *Loader) load(ctx context.Context, sc *client.SimConnect, info Info) {
+	next := l.fallback
+	for i := range l.profiles {
+		if l.profiles[i].Matches(info) {
+			next = &l.profiles[i]
+			break
+		}
+	}
+
+	l.mu.Lock()
+	prev, prevSC, cancel := l.active, l.sc, l.cancel
+	l.active, l.sc, l.cancel = nil, nil, nil
+	l.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	// only tear down on the same connection; a new connection starts clean
+	if prev != nil && prev.Teardown != nil && prevSC == sc {
+		if err := prev.Teardown(sc); err != nil {
+			slog.Error("Cannot tear down aircraft profile", "profile", prev.Name, "error", err)
+		}
+	}
+	if next == nil {
+		return
+	}
+
+	pctx, pcancel := context.WithCancel(ctx)
+	if next.Setup != nil {
+		if err := next.Setup(pctx, sc); err != nil {
+			pcancel()
+			slog.Error("Cannot set up aircraft profile", "profile", next.Name, "error", err)
+			return
+		}
+	}
+	slog.Info("Aircraft profile loaded", "profile", next.Name, "title", info.Title)
+
+	l.mu.Lock()
+	l.active, l.sc, l.cancel = next, sc, pcancel
+	l.mu.Unlock()
+}
+
+// matchKey is the store key of a profile's match list
+func matchKey(name string) string {
+	return "aircraft/" + name
+}
+
+// LoadMatches replaces the Match lists of the profiles with those saved in
+// the store, so users can map more titles to a profile without a rebuild
+// profiles with nothing saved keep their list
+func LoadMatches(st store.Store, profiles []Profile) error {
+	for i := range profiles {
+		var match []string
+		err := store.GetJSON(st, matchKey(profiles[i].Name), &match)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		profiles[i].Match = match
+	}
+	return nil
+}
+
+// SaveMatches writes the Match lists of the profiles to the store, under
+// "aircraft/<name>"
+func SaveMatches(st store.Store, profiles ...Profile) error {
+	for _, p := range profiles {
+		if err := store.PutJSON(st, matchKey(p.Name), p.Match); err != nil {
+			return err
+		}
+	}
+	return nil
+}
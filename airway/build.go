@@ -0,0 +1,179 @@
+package airway
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// waypointFields is the facility definition for a waypoint and its routes
+// the order must match decodeWaypoint
+var waypointFields = []string{
+	"OPEN WAYPOINT",
+	"LATITUDE",
+	"LONGITUDE",
+	"MAGVAR",
+	"OPEN ROUTE",
+	"NAME",
+	"NEXT_ICAO",
+	"NEXT_REGION",
+	"NEXT_LATITUDE",
+	"NEXT_LONGITUDE",
+	"PREV_ICAO",
+	"PREV_REGION",
+	"PREV_LATITUDE",
+	"PREV_LONGITUDE",
+	"CLOSE ROUTE",
+	"CLOSE WAYPOINT",
+}
+
+// Option is a function that configures the build
+type Option func(*builder)
+
+// WithMaxFixes limits the number of fixes fetched; the default is 500
+func WithMaxFixes(n int) Option {
+	return func(b *builder) {
+		b.maxFixes = n
+	}
+}
+
+// WithRadius limits the crawl to fixes within the radius, in nautical
+// miles, of the first start fix; the default is unlimited
+func WithRadius(nm float64) Option {
+	return func(b *builder) {
+		b.radius = nm
+	}
+}
+
+// WithRequestTimeout sets how long to wait for each facility; the default is 5 seconds
+func WithRequestTimeout(d time.Duration) Option {
+	return func(b *builder) {
+		b.timeout = d
+	}
+}
+
+// WithGraph adds to an existing graph instead of a new one
+func WithGraph(g *Graph) Option {
+	return func(b *builder) {
+		b.graph = g
+	}
+}
+
+type builder struct {
+	sc       *client.SimConnect
+	graph    *Graph
+	maxFixes int
+	radius   float64
+	timeout  time.Duration
+}
+
+// Build crawls the airways reachable from the start fixes
+// each fix is requested from the sim's facility data and its routes are
+// followed to the next and previous fixes, breadth first, until the limits
+// are reached; fixes that cannot be fetched are skipped
+// the facility replies are only delivered while the Connector is running
+func Build(ctx context.Context, sc *client.SimConnect, start []FixID, opts ...Option) (*Graph, error) {
+	b := &builder{
+		sc:       sc,
+		maxFixes: 500,
+		timeout:  5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.graph == nil {
+		b.graph = NewGraph()
+	}
+	defineID, err := sc.FacilityDefinition("airway:waypoint", waypointFields...)
+	if err != nil {
+		return nil, err
+	}
+
+	var center *Fix
+	seen := map[FixID]bool{}
+	queue := append([]FixID(nil), start...)
+	for _, id := range start {
+		seen[id] = true
+	}
+	fetched := 0
+	for len(queue) > 0 && fetched < b.maxFixes {
+		if err := ctx.Err(); err != nil {
+			return b.graph, err
+		}
+		id := queue[0]
+		queue = queue[1:]
+
+		rctx, cancel := context.WithTimeout(ctx, b.timeout)
+		items, err := sc.RequestFacility(rctx, defineID, id.Ident, id.Region)
+		cancel()
+		if err != nil {
+			slog.Warn("Cannot fetch fix", "fix", id, "error", err)
+			continue
+		}
+		fetched++
+		fix, routes, err := decodeWaypoint(id, items)
+		if err != nil {
+			slog.Warn("Cannot decode fix", "fix", id, "error", err)
+			continue
+		}
+		b.graph.AddFix(fix)
+		if center == nil {
+			center = &fix
+		}
+		for _, r := range routes {
+			for _, n := range []Fix{r.next, r.prev} {
+				if n.Ident == "" {
+					continue
+				}
+				b.graph.AddSegment(r.name, fix, n)
+				if seen[n.FixID] {
+					continue
+				}
+				if b.radius > 0 && distance(*center, n) > b.radius {
+					continue
+				}
+				seen[n.FixID] = true
+				queue = append(queue, n.FixID)
+			}
+		}
+	}
+	return b.graph, nil
+}
+
+type route struct {
+	name       string
+	next, prev Fix
+}
+
+// decodeWaypoint decodes the items of a waypointFields reply
+func decodeWaypoint(id FixID, items []client.FacilityItem) (Fix, []route, error) {
+	fix := Fix{FixID: id}
+	var routes []route
+	for _, it := range items {
+		r := client.NewFacilityReader(it.Data)
+		switch it.Type {
+		case client.FACILITY_DATA_WAYPOINT:
+			fix.Latitude = r.Float64()
+			fix.Longitude = r.Float64()
+			fix.MagVar = float64(r.Float32())
+		case client.FACILITY_DATA_ROUTE:
+			var x route
+			x.name = r.String(32)
+			x.next.Ident = r.String(8)
+			x.next.Region = r.String(8)
+			x.next.Latitude = r.Float64()
+			x.next.Longitude = r.Float64()
+			x.prev.Ident = r.String(8)
+			x.prev.Region = r.String(8)
+			x.prev.Latitude = r.Float64()
+			x.prev.Longitude = r.Float64()
+			routes = append(routes, x)
+		}
+		if err := r.Err(); err != nil {
+			return fix, nil, err
+		}
+	}
+	return fix, routes, nil
+}
@@ -0,0 +1,235 @@
+// Package airway builds an airway graph from the sim's own navdata and
+// routes between fixes on it
+//
+//	g, err := airway.Build(ctx, sc, []airway.FixID{{Ident: "KAYYS", Region: "K1"}})
+//	legs, err := g.Route(airway.FixID{Ident: "KAYYS", Region: "K1"}, airway.FixID{Ident: "HUBEE", Region: "K1"})
+package airway
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+
+	"github.com/bmurray/simconnect-go/geo"
+)
+
+// AirwayError is the error type for the package
+type AirwayError string
+
+func (e AirwayError) Error() string { return string(e) }
+
+const (
+	// ErrUnknownFix is returned when a fix is not in the graph
+	ErrUnknownFix AirwayError = "unknown fix"
+	// ErrNoRoute is returned when the fixes are not connected
+	ErrNoRoute AirwayError = "no route"
+)
+
+// FixID identifies a fix; idents are only unique within a region
+type FixID struct {
+	Ident  string
+	Region string
+}
+
+func (f FixID) String() string {
+	return f.Ident + "/" + f.Region
+}
+
+// Fix is a waypoint in the graph
+type Fix struct {
+	FixID
+	Latitude  float64 // degrees
+	Longitude float64 // degrees
+	MagVar    float64 // degrees
+}
+
+// Position returns the position of the fix
+func (f Fix) Position() geo.Position {
+	return geo.Position{Latitude: f.Latitude, Longitude: f.Longitude}
+}
+
+// Edge is an airway segment leaving a fix
+type Edge struct {
+	To       FixID
+	Airway   string
+	Distance float64 // nautical miles
+}
+
+// Leg is one step of a route
+type Leg struct {
+	From     Fix
+	To       Fix
+	Airway   string
+	Distance float64 // nautical miles
+}
+
+// Graph is an in-memory airway graph; it is safe for concurrent use
+type Graph struct {
+	mu    sync.RWMutex
+	fixes map[FixID]Fix
+	edges map[FixID][]Edge
+	// added holds the fixes added with AddFix, rather than only seen as
+	// the end of a segment, so their variation is known
+	added map[FixID]bool
+}
+
+// NewGraph creates an empty graph
+func NewGraph() *Graph {
+	return &Graph{
+		fixes: map[FixID]Fix{},
+		edges: map[FixID][]Edge{},
+		added: map[FixID]bool{},
+	}
+}
+
+// AddFix adds or updates a fix
+func (g *Graph) AddFix(f Fix) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.fixes[f.FixID] = f
+	g.added[f.FixID] = true
+}
+
+// AddSegment adds an airway segment between two fixes, in both directions
+// the fixes are added if they are not already known
+func (g *Graph) AddSegment(airway string, a, b Fix) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.fixes[a.FixID]; !ok {
+		g.fixes[a.FixID] = a
+	}
+	if _, ok := g.fixes[b.FixID]; !ok {
+		g.fixes[b.FixID] = b
+	}
+	d := distance(a, b)
+	g.addEdge(a.FixID, Edge{To: b.FixID, Airway: airway, Distance: d})
+	g.addEdge(b.FixID, Edge{To: a.FixID, Airway: airway, Distance: d})
+}
+
+func (g *Graph) addEdge(from FixID, e Edge) {
+	for _, x := range g.edges[from] {
+		if x.To == e.To && x.Airway == e.Airway {
+			return
+		}
+	}
+	g.edges[from] = append(g.edges[from], e)
+}
+
+// Fix returns a fix by ID
+func (g *Graph) Fix(id FixID) (Fix, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	f, ok := g.fixes[id]
+	return f, ok
+}
+
+// Edges returns the segments leaving a fix
+func (g *Graph) Edges(id FixID) []Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append([]Edge(nil), g.edges[id]...)
+}
+
+// Variation returns a variation table sampled from the fixes added with AddFix
+func (g *Graph) Variation() *geo.Table {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	t := &geo.Table{}
+	for id := range g.added {
+		f := g.fixes[id]
+		t.Add(f.Position(), f.MagVar)
+	}
+	return t
+}
+
+// Len returns the number of fixes
+func (g *Graph) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.fixes)
+}
+
+// Route returns the shortest route between two fixes
+// it is an A* search using the great circle distance as the heuristic
+func (g *Graph) Route(from, to FixID) ([]Leg, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	start, ok := g.fixes[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFix, from)
+	}
+	goal, ok := g.fixes[to]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFix, to)
+	}
+
+	type via struct {
+		from FixID
+		edge Edge
+	}
+	cost := map[FixID]float64{from: 0}
+	prev := map[FixID]via{}
+	closed := map[FixID]bool{}
+	open := &queue{{id: from, priority: distance(start, goal)}}
+
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(item).id
+		if cur == to {
+			var legs []Leg
+			for cur != from {
+				v := prev[cur]
+				legs = append(legs, Leg{
+					From:     g.fixes[v.from],
+					To:       g.fixes[cur],
+					Airway:   v.edge.Airway,
+					Distance: v.edge.Distance,
+				})
+				cur = v.from
+			}
+			for i, j := 0, len(legs)-1; i < j; i, j = i+1, j-1 {
+				legs[i], legs[j] = legs[j], legs[i]
+			}
+			return legs, nil
+		}
+		if closed[cur] {
+			continue
+		}
+		closed[cur] = true
+		for _, e := range g.edges[cur] {
+			if closed[e.To] {
+				continue
+			}
+			c := cost[cur] + e.Distance
+			if old, ok := cost[e.To]; ok && old <= c {
+				continue
+			}
+			cost[e.To] = c
+			prev[e.To] = via{from: cur, edge: e}
+			heap.Push(open, item{id: e.To, priority: c + distance(g.fixes[e.To], goal)})
+		}
+	}
+	return nil, fmt.Errorf("%w: %s to %s", ErrNoRoute, from, to)
+}
+
+// distance is the great circle distance in nautical miles
+func distance(a, b Fix) float64 {
+	return geo.Distance(a.Position(), b.Position())
+}
+
+type item struct {
+	id       FixID
+	priority float64
+}
+
+type queue []item
+
+func (q queue) Len() int           { return len(q) }
+func (q queue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q queue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x any)        { *q = append(*q, x.(item)) }
+func (q *queue) Pop() any {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,329 @@
+// Package alias maps logical variables to the simvar, LVAR or event that
+// implements them on the loaded aircraft
+//
+// Different aircraft expose equivalent data under different names; an
+// application asks for "AP_SPEED_TARGET" and the profile selected for the
+// aircraft (by TITLE or ATC MODEL) decides where it comes from.
+package alias
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/aircraft"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Kind is the kind of a Target
+type Kind int
+
+const (
+	KindSimVar Kind = iota
+	KindLVar
+	KindEvent
+)
+
+// Target is the concrete variable or event behind a logical name
+type Target struct {
+	Kind Kind
+	Name string
+	Unit string
+}
+
+// SimVar returns a simvar target
+func SimVar(name, unit string) Target {
+	return Target{Kind: KindSimVar, Name: name, Unit: unit}
+}
+
+// LVar returns a local variable target; the L: prefix is added if missing
+func LVar(name, unit string) Target {
+	if !strings.HasPrefix(name, "L:") {
+		name = "L:" + name
+	}
+	return Target{Kind: KindLVar, Name: name, Unit: unit}
+}
+
+// Event returns a key event target; events can be set but not read
+func Event(name string) Target {
+	return Target{Kind: KindEvent, Name: name}
+}
+
+// Profile maps logical names for a family of aircraft
+type Profile struct {
+	Name string
+	// Match is a list of case-insensitive substrings of TITLE or ATC MODEL
+	Match   []string
+	Aliases map[string]Target
+}
+
+// Matches returns true if the profile matches the aircraft title or model
+func (p Profile) Matches(title, model string) bool {
+	title, model = strings.ToLower(title), strings.ToLower(model)
+	for _, m := range p.Match {
+		m = strings.ToLower(m)
+		if m != "" && (strings.Contains(title, m) || strings.Contains(model, m)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Map is a receiver that resolves logical names on the loaded aircraft
+type Map struct {
+	detector *aircraft.Detector
+	profiles []Profile
+	fallback Profile
+	interval time.Duration
+	onSelect func(Profile)
+
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	active   *Profile
+	names    []string
+	valuesID client.DWORD
+	setIDs   map[string]client.DWORD
+	eventIDs map[string]client.DWORD
+	values   map[string]float64
+	applied  map[string]applied
+}
+
+// applied holds the IDs registered for a profile on the current connection
+type applied struct {
+	names    []string
+	valuesID client.DWORD
+	setIDs   map[string]client.DWORD
+	eventIDs map[string]client.DWORD
+}
+
+// Option is a function that sets options on the Map
+type Option func(*Map)
+
+// WithProfile adds a profile; profiles are matched in the order they are added
+func WithProfile(p Profile) Option {
+	return func(m *Map) {
+		m.profiles = append(m.profiles, p)
+	}
+}
+
+// WithDefault sets the profile used when no other profile matches
+func WithDefault(p Profile) Option {
+	return func(m *Map) {
+		m.fallback = p
+	}
+}
+
+// WithInterval sets how often the aliased values are requested
+func WithInterval(d time.Duration) Option {
+	return func(m *Map) {
+		m.interval = d
+	}
+}
+
+// WithOnSelect sets a callback that is called when a profile is selected
+func WithOnSelect(fn func(Profile)) Option {
+	return func(m *Map) {
+		m.onSelect = fn
+	}
+}
+
+// New creates a new alias map
+func New(opts ...Option) *Map {
+	m := &Map{
+		fallback: Profile{Name: "default"},
+		interval: time.Second,
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	m.detector = aircraft.NewDetector(aircraft.WithListener(func(ctx context.Context, sc *client.SimConnect, info aircraft.Info) {
+		m.Select(info.Title, info.ATCModel)
+	}))
+	return m
+}
+
+// Start detects the aircraft and starts requesting the aliased values
+// the profile is reselected whenever a new aircraft is loaded
+func (m *Map) Start(ctx context.Context, sc *client.SimConnect) {
+	m.mu.Lock()
+	m.sc = sc
+	m.active = nil
+	m.applied = map[string]applied{}
+	m.mu.Unlock()
+
+	m.detector.Start(ctx, sc)
+
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(m.interval):
+				m.mu.Lock()
+				ready, id := m.active != nil && len(m.names) > 0, m.valuesID
+				m.mu.Unlock()
+				if !ready {
+					continue
+				}
+				if err := sc.RequestDataOnSimObjectType(id, id, 0, client.SIMOBJECT_TYPE_USER); err != nil {
+					slog.Error("Cannot request aliased values", "error", err)
+				}
+			}
+		}
+	})
+}
+
+// Update tracks the aircraft and decodes the aliased values
+func (m *Map) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	m.detector.Update(ctx, sc, ppData)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active == nil || ppData.DefineID != m.valuesID {
+		return
+	}
+	data := unsafe.Slice((*float64)(ppData.DataPointer()), len(m.names))
+	values := make(map[string]float64, len(m.names))
+	for i, name := range m.names {
+		values[name] = data[i]
+	}
+	m.values = values
+}
+
+// Event forwards aircraft loaded events to the detector
+func (m *Map) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	m.detector.Event(ctx, sc, ev)
+}
+
+// Select picks the profile for the aircraft and registers its definitions
+func (m *Map) Select(title, model string) {
+	p := m.fallback
+	for _, c := range m.profiles {
+		if c.Matches(title, model) {
+			p = c
+			break
+		}
+	}
+
+	m.mu.Lock()
+	sc := m.sc
+	m.mu.Unlock()
+	if sc == nil {
+		return
+	}
+	if err := m.apply(sc, p); err != nil {
+		slog.Error("Cannot apply alias profile", "profile", p.Name, "error", err)
+		return
+	}
+	slog.Debug("Alias profile selected", "profile", p.Name, "title", title, "model", model)
+	if m.onSelect != nil {
+		m.onSelect(p)
+	}
+}
+
+func (m *Map) apply(sc *client.SimConnect, p Profile) error {
+	m.mu.Lock()
+	a, ok := m.applied[p.Name]
+	m.mu.Unlock()
+	if !ok {
+		var err error
+		if a, err = register(sc, p); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applied[p.Name] = a
+	m.active = &p
+	m.names = a.names
+	m.valuesID = a.valuesID
+	m.setIDs = a.setIDs
+	m.eventIDs = a.eventIDs
+	m.values = nil
+	return nil
+}
+
+// register adds the profile's definitions and events to the connection
+func register(sc *client.SimConnect, p Profile) (applied, error) {
+	a := applied{
+		valuesID: sc.GetDefineIDByName("alias:" + p.Name),
+		setIDs:   map[string]client.DWORD{},
+		eventIDs: map[string]client.DWORD{},
+	}
+	for name, t := range p.Aliases {
+		if t.Kind != KindEvent {
+			a.names = append(a.names, name)
+		}
+	}
+	sort.Strings(a.names)
+
+	for _, name := range a.names {
+		t := p.Aliases[name]
+		if err := sc.AddToDataDefinition(a.valuesID, t.Name, t.Unit, client.DATATYPE_FLOAT64); err != nil {
+			return a, err
+		}
+		setID := sc.GetDefineIDByName("alias:" + p.Name + ":" + name)
+		if err := sc.AddToDataDefinition(setID, t.Name, t.Unit, client.DATATYPE_FLOAT64); err != nil {
+			return a, err
+		}
+		a.setIDs[name] = setID
+	}
+	for name, t := range p.Aliases {
+		if t.Kind != KindEvent {
+			continue
+		}
+		id := sc.GetEventID()
+		if err := sc.MapClientEventToSimEvent(id, t.Name); err != nil {
+			return a, err
+		}
+		a.eventIDs[name] = id
+	}
+	return a, nil
+}
+
+// Profile returns the selected profile; false if none is selected yet
+func (m *Map) Profile() (Profile, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active == nil {
+		return Profile{}, false
+	}
+	return *m.active, true
+}
+
+// Get returns the latest value of a logical variable
+func (m *Map) Get(name string) (float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.values[name]
+	return v, ok
+}
+
+// Set writes a logical variable, or transmits the event with v as its data
+func (m *Map) Set(name string, v float64) error {
+	m.mu.Lock()
+	sc := m.sc
+	active := m.active
+	setID, isVar := m.setIDs[name]
+	eventID, isEvent := m.eventIDs[name]
+	m.mu.Unlock()
+
+	if sc == nil || active == nil {
+		return fmt.Errorf("no alias profile selected")
+	}
+	switch {
+	case isVar:
+		return sc.SetDataOnSimObject(setID, client.OBJECT_ID_USER, 0, 0, 8, unsafe.Pointer(&v))
+	case isEvent:
+		return sc.TransmitClientEvent(client.OBJECT_ID_USER, eventID, client.DWORD(int32(v)), client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+	default:
+		return fmt.Errorf("%s is not aliased by profile %s", name, active.Name)
+	}
+}
@@ -0,0 +1,123 @@
+// Package annunciate provides audible and visible cues tied to sim state
+//
+// SimConnect has no API to play arbitrary sounds, so the mechanisms are
+// limited to in-sim text, key events that the sim itself voices or plays
+// (eg ATC or tug requests), and a client-side hook for text-to-speech
+package annunciate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Annunciator produces a cue for a message
+type Annunciator interface {
+	Annunciate(ctx context.Context, msg string) error
+}
+
+// Func adapts a function to an Annunciator
+// use this to plug in a client-side text-to-speech engine
+type Func func(ctx context.Context, msg string) error
+
+// Annunciate calls f(ctx, msg)
+func (f Func) Annunciate(ctx context.Context, msg string) error {
+	return f(ctx, msg)
+}
+
+// Multi sends the message to every annunciator, returning all errors
+type Multi []Annunciator
+
+// Annunciate sends the message to every annunciator
+func (m Multi) Annunciate(ctx context.Context, msg string) error {
+	var errs []error
+	for _, a := range m {
+		if err := a.Annunciate(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Text shows the message as in-sim text
+// it must be started by the connector to get a SimConnect handle
+type Text struct {
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	textType client.DWORD
+	duration float64
+}
+
+// NewText creates a text annunciator with the text type and duration in seconds
+func NewText(textType client.DWORD, duration float64) *Text {
+	return &Text{textType: textType, duration: duration}
+}
+
+// Start stores the connection
+func (t *Text) Start(ctx context.Context, sc *client.SimConnect) {
+	t.mu.Lock()
+	t.sc = sc
+	t.mu.Unlock()
+}
+
+// Update does nothing
+func (t *Text) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// Annunciate shows the message
+func (t *Text) Annunciate(ctx context.Context, msg string) error {
+	t.mu.Lock()
+	sc := t.sc
+	t.mu.Unlock()
+	if sc == nil {
+		return fmt.Errorf("text annunciator not started")
+	}
+	return sc.ShowText(t.textType, t.duration, client.UNUSED, msg)
+}
+
+// Event fires a sim event whose side effect is audible
+// the message is ignored; the event and data are fixed at creation
+type Event struct {
+	mu      sync.Mutex
+	sc      *client.SimConnect
+	eventID client.DWORD
+	name    string
+	data    client.DWORD
+}
+
+// NewEvent creates an event annunciator for the named sim event
+func NewEvent(name string, data client.DWORD) *Event {
+	return &Event{name: name, data: data}
+}
+
+// Start maps the sim event
+func (e *Event) Start(ctx context.Context, sc *client.SimConnect) {
+	id := sc.GetEventID()
+	if err := sc.MapClientEventToSimEvent(id, e.name); err != nil {
+		slog.Error("Cannot map annunciator event", "event", e.name, "error", err)
+		return
+	}
+	e.mu.Lock()
+	e.sc = sc
+	e.eventID = id
+	e.mu.Unlock()
+}
+
+// Update does nothing
+func (e *Event) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// Annunciate fires the event
+func (e *Event) Annunciate(ctx context.Context, msg string) error {
+	e.mu.Lock()
+	sc, id := e.sc, e.eventID
+	e.mu.Unlock()
+	if sc == nil {
+		return fmt.Errorf("event annunciator %s not started", e.name)
+	}
+	return sc.TransmitClientEvent(client.OBJECT_ID_USER, id, e.data, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
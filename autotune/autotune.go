@@ -0,0 +1,292 @@
+// Package autotune calibrates a subscription against live data and picks
+// per-field epsilons and a request interval that hit a target message rate
+//
+// During calibration the definition is requested every sim frame. Each
+// field's frame-to-frame deltas are measured, epsilons are chosen so the
+// CHANGED flag suppresses noise, and the result is checked by replaying the
+// samples the way SimConnect compares against the last sent value. If that
+// is still too chatty, the request interval is raised.
+package autotune
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Config is a tuned subscription
+type Config struct {
+	Period   client.DWORD
+	Flags    client.DWORD
+	Interval client.DWORD
+	// Epsilon is keyed by simvar name
+	Epsilon map[string]float32
+	// FrameRate is the sim frames per second seen while calibrating
+	FrameRate float64
+	// ExpectedRate is the estimated messages per second with this config
+	ExpectedRate float64
+}
+
+// String reports the chosen configuration
+func (c Config) String() string {
+	names := make([]string, 0, len(c.Epsilon))
+	for n := range c.Epsilon {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	fmt.Fprintf(&b, "interval=%d frames expected=%.1f/s (frame rate %.1f/s)", c.Interval, c.ExpectedRate, c.FrameRate)
+	for _, n := range names {
+		fmt.Fprintf(&b, "\n  %s epsilon=%g", n, c.Epsilon[n])
+	}
+	return b.String()
+}
+
+// Option is a function that sets options on the Tuner
+type Option func(*settings)
+
+type settings struct {
+	window  time.Duration
+	onTuned func(Config)
+}
+
+// WithWindow sets how long to calibrate for
+func WithWindow(d time.Duration) Option {
+	return func(s *settings) {
+		s.window = d
+	}
+}
+
+// WithOnTuned sets a callback that is called with the chosen configuration
+func WithOnTuned(fn func(Config)) Option {
+	return func(s *settings) {
+		s.onTuned = fn
+	}
+}
+
+// Tuner is a receiver that owns the registration and subscription of T
+// do not register T yourself; use simconnect.IsReport[T] in your receivers
+// once tuned, the configuration is reused on reconnect
+type Tuner[T any] struct {
+	target float64
+	settings
+
+	mu          sync.Mutex
+	cfg         *Config
+	names       []string
+	units       []string
+	calID       client.DWORD
+	calibrating bool
+	samples     [][]float64
+	started     time.Time
+}
+
+// New creates a tuner for T that targets rate messages per second
+// every field of T with a name tag must be a float64
+func New[T any](rate float64, opts ...Option) (*Tuner[T], error) {
+	t := &Tuner[T]{target: rate, settings: settings{window: 30 * time.Second}}
+	for _, o := range opts {
+		o(&t.settings)
+	}
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("not a struct: %s", typ.Kind())
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := f.Tag.Get("name")
+		if name == "" {
+			continue
+		}
+		if f.Type.Kind() != reflect.Float64 {
+			return nil, fmt.Errorf("not a float64: %s -- %s", f.Name, f.Type.Kind())
+		}
+		t.names = append(t.names, name)
+		t.units = append(t.units, f.Tag.Get("unit"))
+	}
+	if len(t.names) == 0 {
+		return nil, fmt.Errorf("no fields with name tags: %s", typ.Name())
+	}
+	return t, nil
+}
+
+// Config returns the tuned configuration; false until calibration completes
+func (t *Tuner[T]) Config() (Config, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cfg == nil {
+		return Config{}, false
+	}
+	return *t.cfg, true
+}
+
+// Start calibrates, or applies the configuration from a previous connection
+func (t *Tuner[T]) Start(ctx context.Context, sc *client.SimConnect) {
+	t.mu.Lock()
+	cfg := t.cfg
+	t.mu.Unlock()
+	if cfg != nil {
+		if err := t.apply(sc, *cfg); err != nil {
+			slog.Error("Cannot apply tuned subscription", "error", err)
+		}
+		return
+	}
+
+	var typed *T
+	calID := sc.GetDefineIDByName("autotune:" + reflect.TypeOf(typed).Elem().Name())
+	for i, name := range t.names {
+		if err := sc.AddToDataDefinition(calID, name, t.units[i], client.DATATYPE_FLOAT64); err != nil {
+			slog.Error("Cannot add calibration datum", "name", name, "error", err)
+			return
+		}
+	}
+	t.mu.Lock()
+	t.calID = calID
+	t.calibrating = true
+	t.samples = nil
+	t.started = time.Now()
+	t.mu.Unlock()
+
+	if err := sc.RequestDataOnSimObject(calID, calID, client.OBJECT_ID_USER, client.PERIOD_SIM_FRAME, 0, 0, 0, 0); err != nil {
+		slog.Error("Cannot start calibration", "error", err)
+		return
+	}
+	slog.Info("Calibrating subscription", "window", t.window, "fields", len(t.names))
+
+	simconnect.Go(ctx, func(ctx context.Context) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(t.window):
+		}
+		if err := sc.RequestDataOnSimObject(calID, calID, client.OBJECT_ID_USER, client.PERIOD_NEVER, 0, 0, 0, 0); err != nil {
+			slog.Error("Cannot stop calibration", "error", err)
+		}
+		t.mu.Lock()
+		t.calibrating = false
+		samples, elapsed := t.samples, time.Since(t.started)
+		t.samples = nil
+		t.mu.Unlock()
+
+		cfg := Tune(t.names, samples, elapsed, t.target)
+		t.mu.Lock()
+		t.cfg = &cfg
+		t.mu.Unlock()
+		slog.Info("Subscription tuned", "config", cfg.String())
+		if err := t.apply(sc, cfg); err != nil {
+			slog.Error("Cannot apply tuned subscription", "error", err)
+			return
+		}
+		if t.onTuned != nil {
+			t.onTuned(cfg)
+		}
+	})
+}
+
+// Update records calibration samples
+func (t *Tuner[T]) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.calibrating || ppData.DefineID != t.calID {
+		return
+	}
+	data := unsafe.Slice((*float64)(ppData.DataPointer()), len(t.names))
+	t.samples = append(t.samples, append([]float64(nil), data...))
+}
+
+func (t *Tuner[T]) apply(sc *client.SimConnect, cfg Config) error {
+	var typed *T
+	if err := sc.RegisterDataDefinitionWithEpsilon(typed, cfg.Epsilon); err != nil {
+		return err
+	}
+	id := sc.GetDefineID(typed)
+	return sc.RequestDataOnSimObject(id, id, client.OBJECT_ID_USER, cfg.Period, cfg.Flags, 0, cfg.Interval, 0)
+}
+
+// Tune chooses a configuration from calibration samples taken every sim frame
+func Tune(names []string, samples [][]float64, elapsed time.Duration, target float64) Config {
+	cfg := Config{
+		Period:   client.PERIOD_SIM_FRAME,
+		Flags:    client.DATA_REQUEST_FLAG_CHANGED,
+		Interval: 0,
+		Epsilon:  map[string]float32{},
+	}
+	if len(samples) < 2 || elapsed <= 0 || target <= 0 {
+		for _, n := range names {
+			cfg.Epsilon[n] = 0
+		}
+		cfg.Period = client.PERIOD_SECOND
+		return cfg
+	}
+	cfg.FrameRate = float64(len(samples)) / elapsed.Seconds()
+
+	// allow each field an equal share of the target rate
+	share := target / (cfg.FrameRate * float64(len(names)))
+	eps := make([]float64, len(names))
+	for i := range names {
+		deltas := make([]float64, 0, len(samples)-1)
+		for k := 1; k < len(samples); k++ {
+			deltas = append(deltas, math.Abs(samples[k][i]-samples[k-1][i]))
+		}
+		eps[i] = quantile(deltas, 1-share)
+	}
+
+	// replay against the last sent values, widening until under target
+	rate := replay(samples, eps) * cfg.FrameRate
+	for iter := 0; rate > target && iter < 20; iter++ {
+		for i := range eps {
+			if eps[i] == 0 {
+				eps[i] = 1e-6
+			}
+			eps[i] *= 1.5
+		}
+		rate = replay(samples, eps) * cfg.FrameRate
+	}
+	if rate > target {
+		interval := math.Ceil(rate / target)
+		cfg.Interval = client.DWORD(interval - 1)
+		rate /= interval
+	}
+	for i, n := range names {
+		cfg.Epsilon[n] = float32(eps[i])
+	}
+	cfg.ExpectedRate = rate
+	return cfg
+}
+
+// replay returns the fraction of frames that would be sent with the epsilons
+func replay(samples [][]float64, eps []float64) float64 {
+	sent := samples[0]
+	count := 1
+	for _, row := range samples[1:] {
+		for i, v := range row {
+			if math.Abs(v-sent[i]) > eps[i] {
+				sent = row
+				count++
+				break
+			}
+		}
+	}
+	return float64(count) / float64(len(samples))
+}
+
+func quantile(v []float64, q float64) float64 {
+	if len(v) == 0 || q <= 0 {
+		return 0
+	}
+	sort.Float64s(v)
+	if q >= 1 {
+		return v[len(v)-1]
+	}
+	return v[int(q*float64(len(v)-1))]
+}
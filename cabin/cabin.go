@@ -0,0 +1,297 @@
+// Package cabin is a ready-made safety monitor for pressurisation, oxygen
+// and icing, for long flights where these are easy to miss
+//
+//	text := annunciate.NewText(client.TEXT_TYPE_PRINT_RED, 10)
+//	mon := cabin.New(cabin.WithAnnunciator(text))
+//	con := simconnect.NewConnector("cabin", simconnect.WithReceiver(text), simconnect.WithReceiver(mon))
+//
+// Each rule alerts once when its condition starts and again, as cleared,
+// when it ends
+package cabin
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/annunciate"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Report is the cabin and environment state the monitor needs
+type Report struct {
+	client.RecvSimobjectDataByType
+	CabinAltitude     float64 `name:"PRESSURIZATION CABIN ALTITUDE" unit:"Feet"`
+	CabinRate         float64 `name:"PRESSURIZATION CABIN ALTITUDE RATE" unit:"Feet per minute"`
+	Differential      float64 `name:"PRESSURIZATION PRESSURE DIFFERENTIAL" unit:"Psi"`
+	DumpSwitch        float64 `name:"PRESSURIZATION DUMP SWITCH" unit:"Bool"`
+	Altitude          float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	AmbientTemp       float64 `name:"AMBIENT TEMPERATURE" unit:"Celsius"`
+	InCloud           float64 `name:"AMBIENT IN CLOUD" unit:"Bool"`
+	StructuralIce     float64 `name:"STRUCTURAL ICE PCT" unit:"Percent over 100"`
+	PitotIce          float64 `name:"PITOT ICE PCT" unit:"Percent over 100"`
+	PitotHeat         float64 `name:"PITOT HEAT" unit:"Bool"`
+	StructuralDeice   float64 `name:"STRUCTURAL DEICE SWITCH" unit:"Bool"`
+	EngineAntiIce     float64 `name:"ENG ANTI ICE:1" unit:"Bool"`
+	SimOnGround       float64 `name:"SIM ON GROUND" unit:"Bool"`
+	SimulationSeconds float64 `name:"SIMULATION TIME" unit:"Seconds"`
+}
+
+// Level is the severity of an alert
+type Level int
+
+const (
+	Advisory Level = iota
+	Caution
+	Warning
+)
+
+func (l Level) String() string {
+	switch l {
+	case Advisory:
+		return "advisory"
+	case Caution:
+		return "caution"
+	case Warning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+// Thresholds are the limits the rules alert on
+type Thresholds struct {
+	CabinAltitudeCaution float64       // feet
+	CabinAltitudeWarning float64       // feet
+	CabinRate            float64       // feet per minute, either direction
+	MaxDifferential      float64       // psi
+	OxygenAltitude       float64       // cabin feet above which OxygenTime starts
+	OxygenTime           time.Duration // time above OxygenAltitude before oxygen is needed
+	OxygenAlways         float64       // cabin feet above which oxygen is always needed
+	IcingTempHigh        float64       // celsius
+	IcingTempLow         float64       // celsius
+	IceAccretion         float64       // structural ice fraction
+}
+
+// DefaultThresholds follow common transport category limits and the FAR 91.211
+// supplemental oxygen rule
+var DefaultThresholds = Thresholds{
+	CabinAltitudeCaution: 8500,
+	CabinAltitudeWarning: 10000,
+	CabinRate:            2000,
+	MaxDifferential:      8.6,
+	OxygenAltitude:       12500,
+	OxygenTime:           30 * time.Minute,
+	OxygenAlways:         14000,
+	IcingTempHigh:        5,
+	IcingTempLow:         -20,
+	IceAccretion:         0.05,
+}
+
+// Alert is raised when a rule starts or stops matching
+type Alert struct {
+	Rule    string
+	Level   Level
+	Message string
+	Cleared bool
+	At      time.Time
+	Report  Report
+}
+
+// Rule is a condition the monitor alerts on
+type Rule struct {
+	Name    string
+	Level   Level
+	Message string
+	Check   func(r Report, s *State) bool
+}
+
+// State is what rules may track between reports
+type State struct {
+	Thresholds Thresholds
+	// AboveOxygenSince is the sim time the cabin went above OxygenAltitude, or zero
+	AboveOxygenSince float64
+}
+
+// DefaultRules are the rules used unless WithRules is given
+func DefaultRules() []Rule {
+	return []Rule{
+		{"cabin-altitude", Warning, "CABIN ALTITUDE", func(r Report, s *State) bool {
+			return r.CabinAltitude > s.Thresholds.CabinAltitudeWarning
+		}},
+		{"cabin-altitude-high", Caution, "CABIN ALTITUDE HIGH", func(r Report, s *State) bool {
+			return r.CabinAltitude > s.Thresholds.CabinAltitudeCaution && r.CabinAltitude <= s.Thresholds.CabinAltitudeWarning
+		}},
+		{"cabin-rate", Caution, "CABIN RATE", func(r Report, s *State) bool {
+			return r.CabinRate > s.Thresholds.CabinRate || r.CabinRate < -s.Thresholds.CabinRate
+		}},
+		{"overpressure", Warning, "CABIN DIFF PRESSURE", func(r Report, s *State) bool {
+			return r.Differential > s.Thresholds.MaxDifferential
+		}},
+		{"dump", Advisory, "PRESSURIZATION DUMP ON", func(r Report, s *State) bool {
+			return r.DumpSwitch != 0 && r.SimOnGround == 0
+		}},
+		{"oxygen", Warning, "OXYGEN REQUIRED", func(r Report, s *State) bool {
+			if r.CabinAltitude > s.Thresholds.OxygenAlways {
+				return true
+			}
+			return s.AboveOxygenSince != 0 && r.SimulationSeconds-s.AboveOxygenSince > s.Thresholds.OxygenTime.Seconds()
+		}},
+		{"icing-conditions", Advisory, "ICING CONDITIONS", func(r Report, s *State) bool {
+			return r.InCloud != 0 && r.AmbientTemp <= s.Thresholds.IcingTempHigh && r.AmbientTemp >= s.Thresholds.IcingTempLow &&
+				(r.PitotHeat == 0 || r.EngineAntiIce == 0)
+		}},
+		{"ice", Caution, "ICE DETECTED", func(r Report, s *State) bool {
+			return r.StructuralIce > s.Thresholds.IceAccretion
+		}},
+		{"pitot-ice", Warning, "PITOT ICE", func(r Report, s *State) bool {
+			return r.PitotIce > 0.5
+		}},
+	}
+}
+
+// Monitor is a receiver that checks the rules against the cabin state
+type Monitor struct {
+	interval   time.Duration
+	rules      []Rule
+	annunciate annunciate.Annunciator
+	onAlert    func(Alert)
+
+	mu     sync.Mutex
+	state  State
+	active map[string]bool
+}
+
+// Option is a function that sets options on the Monitor
+type Option func(*Monitor)
+
+// WithInterval sets how often the state is checked; the default is 5 seconds
+func WithInterval(d time.Duration) Option {
+	return func(m *Monitor) {
+		m.interval = d
+	}
+}
+
+// WithThresholds replaces the default thresholds
+func WithThresholds(t Thresholds) Option {
+	return func(m *Monitor) {
+		m.state.Thresholds = t
+	}
+}
+
+// WithRules replaces the default rules
+func WithRules(rules ...Rule) Option {
+	return func(m *Monitor) {
+		m.rules = rules
+	}
+}
+
+// WithAnnunciator speaks or shows the alert messages
+func WithAnnunciator(a annunciate.Annunciator) Option {
+	return func(m *Monitor) {
+		m.annunciate = a
+	}
+}
+
+// WithAlertHandler is called with every alert, including cleared ones
+func WithAlertHandler(f func(Alert)) Option {
+	return func(m *Monitor) {
+		m.onAlert = f
+	}
+}
+
+// New creates a monitor
+func New(opts ...Option) *Monitor {
+	m := &Monitor{
+		interval: 5 * time.Second,
+		rules:    DefaultRules(),
+		state:    State{Thresholds: DefaultThresholds},
+		active:   map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *Monitor) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := simconnect.Subscribe[Report](ctx, sc, m.interval); err != nil {
+		slog.Error("Cannot subscribe to cabin report", "error", err)
+		return
+	}
+}
+
+func (m *Monitor) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[Report](sc, ppData)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	var alerts []Alert
+
+	m.mu.Lock()
+	if r.CabinAltitude > m.state.Thresholds.OxygenAltitude {
+		if m.state.AboveOxygenSince == 0 {
+			m.state.AboveOxygenSince = r.SimulationSeconds
+		}
+	} else {
+		m.state.AboveOxygenSince = 0
+	}
+	for _, rule := range m.rules {
+		match := rule.Check(*r, &m.state)
+		if match == m.active[rule.Name] {
+			continue
+		}
+		m.active[rule.Name] = match
+		alerts = append(alerts, Alert{
+			Rule:    rule.Name,
+			Level:   rule.Level,
+			Message: rule.Message,
+			Cleared: !match,
+			At:      now,
+			Report:  *r,
+		})
+	}
+	m.mu.Unlock()
+
+	// annunciating may block, so it stays off the dispatch loop
+	if len(alerts) > 0 {
+		simconnect.Go(ctx, func(ctx context.Context) {
+			for _, a := range alerts {
+				m.send(ctx, a)
+			}
+		})
+	}
+}
+
+// Active returns the names of the rules currently alerting
+func (m *Monitor) Active() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []string
+	for _, rule := range m.rules {
+		if m.active[rule.Name] {
+			out = append(out, rule.Name)
+		}
+	}
+	return out
+}
+
+func (m *Monitor) send(ctx context.Context, a Alert) {
+	slog.Info("Cabin alert", "rule", a.Rule, "level", a.Level, "cleared", a.Cleared)
+	if m.onAlert != nil {
+		m.onAlert(a)
+	}
+	if m.annunciate == nil {
+		return
+	}
+	msg := a.Message
+	if a.Cleared {
+		msg += " CLEARED"
+	}
+	if err := m.annunciate.Annunciate(ctx, msg); err != nil {
+		slog.Error("Cannot annunciate cabin alert", "error", err)
+	}
+}
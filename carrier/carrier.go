@@ -0,0 +1,256 @@
+// Package carrier scores landings on a moving platform, eg a carrier or
+// any boat simobject, relative to its deck
+//
+// The Scorer samples the user aircraft and the platform and, when the
+// aircraft touches down, reports where it landed relative to the target
+// point and how fast it was moving relative to the deck.
+package carrier
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/formation"
+	"github.com/bmurray/simconnect-go/geo"
+)
+
+// feetPerSecondToKnots converts the world velocities to knots
+const feetPerSecondToKnots = 3600 / 6076.12
+
+// PlatformReport is the state of the platform, requested on its object ID
+type PlatformReport struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude  float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	Heading   float64 `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees"`
+	East      float64 `name:"VELOCITY WORLD X" unit:"Feet per second"`
+	Up        float64 `name:"VELOCITY WORLD Y" unit:"Feet per second"`
+	North     float64 `name:"VELOCITY WORLD Z" unit:"Feet per second"`
+}
+
+// AircraftReport is the state of the user aircraft
+type AircraftReport struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude  float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	Heading   float64 `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees"`
+	East      float64 `name:"VELOCITY WORLD X" unit:"Feet per second"`
+	Up        float64 `name:"VELOCITY WORLD Y" unit:"Feet per second"`
+	North     float64 `name:"VELOCITY WORLD Z" unit:"Feet per second"`
+	OnGround  float64 `name:"SIM ON GROUND" unit:"Bool"`
+}
+
+// Deck describes the landing area of the platform
+type Deck struct {
+	// Target is the aim point relative to the platform's reference point
+	Target formation.Offset
+	// Angle is the landing area's heading relative to the platform, in
+	// degrees, negative for a deck angled to the left
+	Angle float64
+	// Wires are the distances of the arresting wires past Target, in feet
+	Wires []float64
+}
+
+// Nimitz is an approximation of a Nimitz class deck; the offsets are from
+// the ship's reference point and depend on the model
+func Nimitz() Deck {
+	return Deck{
+		Target: formation.Offset{Forward: -300, Right: -30, Up: 60},
+		Angle:  -9,
+		Wires:  []float64{-40, 0, 40, 80},
+	}
+}
+
+// Touchdown is a scored landing
+type Touchdown struct {
+	// Position is where the aircraft touched down relative to the target,
+	// along the landing area heading
+	Position formation.Offset
+	// the velocity relative to the deck: along the landing area in knots,
+	// across it in knots (positive right) and the sink rate in feet per minute
+	Closure  float64
+	Drift    float64
+	SinkRate float64
+	// HeadingError is the aircraft heading less the landing area heading
+	HeadingError float64
+	// Wire is the wire caught, counting from 1, or 0 for a bolter
+	Wire int
+	At   time.Time
+}
+
+// Scorer is a receiver that scores landings on a platform
+type Scorer struct {
+	deck        Deck
+	interval    time.Duration
+	onTouchdown func(Touchdown)
+
+	mu          sync.Mutex
+	platform    client.DWORD
+	hasPlatform bool
+	latest      *PlatformReport
+	airborne    bool
+	touchdowns  []Touchdown
+}
+
+// Option is a function that sets options on the Scorer
+type Option func(*Scorer)
+
+// WithInterval sets how often the aircraft and platform are sampled; the default is 100ms
+func WithInterval(d time.Duration) Option {
+	return func(s *Scorer) {
+		s.interval = d
+	}
+}
+
+// WithOnTouchdown sets a callback that is called with every scored landing
+// it runs on the dispatch goroutine, so it must not block
+func WithOnTouchdown(fn func(Touchdown)) Option {
+	return func(s *Scorer) {
+		s.onTouchdown = fn
+	}
+}
+
+// New creates a scorer for the deck
+func New(deck Deck, opts ...Option) *Scorer {
+	s := &Scorer{deck: deck, interval: 100 * time.Millisecond}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// SetPlatform selects the platform by object ID, eg from AICreateSimulatedObject
+func (s *Scorer) SetPlatform(objectID client.DWORD) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.platform = objectID
+	s.hasPlatform = true
+	s.latest = nil
+}
+
+// Touchdowns returns the landings scored so far
+func (s *Scorer) Touchdowns() []Touchdown {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Touchdown(nil), s.touchdowns...)
+}
+
+// Start registers the definitions and samples the aircraft and platform
+func (s *Scorer) Start(ctx context.Context, sc *client.SimConnect) {
+	for _, def := range []any{&PlatformReport{}, &AircraftReport{}} {
+		if err := sc.RegisterDataDefinition(def); err != nil {
+			slog.Error("Cannot register carrier definition", "error", err)
+			return
+		}
+	}
+	platformID := sc.GetDefineID(&PlatformReport{})
+	requestID := sc.NewRequestID()
+
+	s.mu.Lock()
+	s.latest = nil
+	s.airborne = false
+	s.mu.Unlock()
+
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.interval):
+				s.mu.Lock()
+				platform, ok := s.platform, s.hasPlatform
+				s.mu.Unlock()
+				if !ok {
+					continue
+				}
+				if err := sc.RequestDataOnSimObject(requestID, platformID, platform, client.PERIOD_ONCE, client.DATA_REQUEST_FLAG_DEFAULT, 0, 0, 0); err != nil {
+					slog.Error("Cannot request platform state", "objectID", platform, "error", err)
+				}
+				if err := simconnect.RequestData[AircraftReport](sc); err != nil {
+					slog.Error("Cannot request aircraft state", "error", err)
+				}
+			}
+		}
+	})
+}
+
+// Update tracks the platform and scores a touchdown when the aircraft lands
+func (s *Scorer) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	if r, ok := simconnect.IsReport[PlatformReport](sc, ppData); ok {
+		s.mu.Lock()
+		if s.hasPlatform && ppData.ObjectID == s.platform {
+			s.latest = r
+		}
+		s.mu.Unlock()
+		return
+	}
+	r, ok := simconnect.IsReport[AircraftReport](sc, ppData)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	wasAirborne := s.airborne
+	s.airborne = r.OnGround == 0
+	platform := s.latest
+	if !wasAirborne || s.airborne || platform == nil {
+		s.mu.Unlock()
+		return
+	}
+	td := score(s.deck, platform, r)
+	s.touchdowns = append(s.touchdowns, td)
+	s.mu.Unlock()
+
+	if s.onTouchdown != nil {
+		s.onTouchdown(td)
+	}
+}
+
+// score measures the touchdown in the landing area frame
+func score(deck Deck, p *PlatformReport, a *AircraftReport) Touchdown {
+	ship := formation.State{
+		Position: geo.Position{Latitude: p.Latitude, Longitude: p.Longitude, Altitude: p.Altitude},
+		Heading:  p.Heading,
+	}
+	own := geo.Position{Latitude: a.Latitude, Longitude: a.Longitude, Altitude: a.Altitude}
+	rel := formation.Relative(ship, own).Sub(deck.Target)
+	forward, right := rotate(rel.Forward, rel.Right, deck.Angle)
+
+	landing := geo.Normalize(p.Heading + deck.Angle)
+	// world velocities relative to the deck, then into the landing area frame
+	east, north := a.East-p.East, a.North-p.North
+	h := landing * math.Pi / 180
+	along := north*math.Cos(h) + east*math.Sin(h)
+	across := east*math.Cos(h) - north*math.Sin(h)
+
+	td := Touchdown{
+		Position:     formation.Offset{Forward: forward, Right: right, Up: rel.Up},
+		Closure:      along * feetPerSecondToKnots,
+		Drift:        across * feetPerSecondToKnots,
+		SinkRate:     -(a.Up - p.Up) * 60,
+		HeadingError: math.Mod(a.Heading-landing+540, 360) - 180,
+		At:           time.Now(),
+	}
+	// the hook catches the first wire past the touchdown point
+	for i, w := range deck.Wires {
+		if w >= forward {
+			td.Wire = i + 1
+			break
+		}
+	}
+	return td
+}
+
+// rotate turns a forward and right offset from the platform frame into a
+// frame turned by angle degrees
+func rotate(forward, right, angle float64) (float64, float64) {
+	a := angle * math.Pi / 180
+	return forward*math.Cos(a) + right*math.Sin(a), right*math.Cos(a) - forward*math.Sin(a)
+}
@@ -0,0 +1,320 @@
+// Package cfit estimates terrain clearance from the terrain probe and the
+// aircraft position, and raises EGPWS-style callouts
+//
+// The estimate is synthetic: it is the altitude above the probed ground
+// elevation, now and at points projected along the ground track, so it is
+// only as good as the terrain the sim has loaded
+package cfit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/annunciate"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/geo"
+	"github.com/bmurray/simconnect-go/terrain"
+)
+
+// Report is the aircraft state the monitor needs
+type Report struct {
+	client.RecvSimobjectDataByType
+	Latitude     float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude    float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude     float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	Track        float64 `name:"GPS GROUND TRUE TRACK" unit:"Degrees"`
+	GroundSpeed  float64 `name:"GROUND VELOCITY" unit:"Knots"`
+	VerticalRate float64 `name:"VERTICAL SPEED" unit:"Feet per minute"`
+	OnGround     float64 `name:"SIM ON GROUND" unit:"Bool"`
+}
+
+// Position returns the position of the report
+func (r Report) Position() geo.Position {
+	return geo.Position{Latitude: r.Latitude, Longitude: r.Longitude, Altitude: r.Altitude}
+}
+
+// Kind is the kind of alert
+type Kind int
+
+const (
+	// Callout is an altitude callout while descending, eg "FIVE HUNDRED"
+	Callout Kind = iota
+	// SinkRate is an excessive descent rate close to the ground
+	SinkRate
+	// Caution is terrain ahead inside the caution margin
+	Caution
+	// Warning is terrain ahead inside the warning margin
+	Warning
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Callout:
+		return "callout"
+	case SinkRate:
+		return "sink rate"
+	case Caution:
+		return "caution"
+	case Warning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+// Alert is raised by the monitor
+type Alert struct {
+	Kind      Kind
+	Message   string
+	AGL       float64 // feet above the probed ground
+	Clearance float64 // lowest projected clearance ahead, feet
+	At        time.Time
+}
+
+// Estimate is the latest clearance estimate
+type Estimate struct {
+	AGL       float64
+	Clearance float64
+	At        time.Time
+}
+
+// callouts are the heights called out while descending
+var callouts = []struct {
+	height float64
+	msg    string
+}{
+	{1000, "ONE THOUSAND"},
+	{500, "FIVE HUNDRED"},
+	{100, "ONE HUNDRED"},
+	{50, "FIFTY"},
+	{30, "THIRTY"},
+	{10, "TEN"},
+}
+
+// Monitor is a receiver that watches terrain clearance
+// the terrain probe must also be added to the connector
+type Monitor struct {
+	probe      *terrain.Probe
+	interval   time.Duration
+	lookahead  []time.Duration
+	caution    float64
+	warning    float64
+	repeat     time.Duration
+	annunciate annunciate.Annunciator
+	onAlert    func(Alert)
+
+	mu       sync.Mutex
+	latest   chan Report
+	estimate Estimate
+	called   map[float64]bool
+	lastSent map[Kind]time.Time
+}
+
+// Option is a function that sets options on the Monitor
+type Option func(*Monitor)
+
+// WithInterval sets how often the clearance is estimated; the default is 2 seconds
+func WithInterval(d time.Duration) Option {
+	return func(m *Monitor) {
+		m.interval = d
+	}
+}
+
+// WithLookahead sets the times ahead to project the track to
+// the default is 30 and 60 seconds
+func WithLookahead(d ...time.Duration) Option {
+	return func(m *Monitor) {
+		m.lookahead = d
+	}
+}
+
+// WithMargins sets the caution and warning clearances in feet
+// the defaults are 700 and 300
+func WithMargins(caution, warning float64) Option {
+	return func(m *Monitor) {
+		m.caution = caution
+		m.warning = warning
+	}
+}
+
+// WithAnnunciator speaks or shows the alert messages, eg annunciate.NewText
+func WithAnnunciator(a annunciate.Annunciator) Option {
+	return func(m *Monitor) {
+		m.annunciate = a
+	}
+}
+
+// WithAlertHandler is called with every alert
+func WithAlertHandler(f func(Alert)) Option {
+	return func(m *Monitor) {
+		m.onAlert = f
+	}
+}
+
+// New creates a monitor using the probe
+func New(probe *terrain.Probe, opts ...Option) *Monitor {
+	m := &Monitor{
+		probe:     probe,
+		interval:  2 * time.Second,
+		lookahead: []time.Duration{30 * time.Second, 60 * time.Second},
+		caution:   700,
+		warning:   300,
+		repeat:    5 * time.Second,
+		latest:    make(chan Report, 1),
+		called:    map[float64]bool{},
+		lastSent:  map[Kind]time.Time{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *Monitor) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := simconnect.Subscribe[Report](ctx, sc, m.interval); err != nil {
+		slog.Error("Cannot subscribe to cfit report", "error", err)
+		return
+	}
+	// probing waits on the dispatch loop, so it cannot run in Update
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case r := <-m.latest:
+				m.evaluate(ctx, r)
+			}
+		}
+	})
+}
+
+// Update queues the latest report, dropping one not yet evaluated
+func (m *Monitor) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[Report](sc, ppData)
+	if !ok {
+		return
+	}
+	select {
+	case <-m.latest:
+	default:
+	}
+	m.latest <- *r
+}
+
+// Estimate returns the latest clearance estimate
+func (m *Monitor) Estimate() Estimate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.estimate
+}
+
+func (m *Monitor) evaluate(ctx context.Context, r Report) {
+	if r.OnGround != 0 {
+		m.mu.Lock()
+		m.called = map[float64]bool{}
+		m.mu.Unlock()
+		return
+	}
+	pos := r.Position()
+	ground, err := m.probe.Elevation(ctx, pos)
+	if err != nil {
+		slog.Warn("Cannot probe terrain", "error", err)
+		return
+	}
+	agl := r.Altitude - ground
+
+	clearance := agl
+	var warnAhead, cautionAhead bool
+	for _, d := range m.lookahead {
+		t := d.Seconds()
+		ahead := geo.Destination(pos, r.Track, r.GroundSpeed*t/3600)
+		elev, err := m.probe.Elevation(ctx, ahead)
+		if err != nil {
+			slog.Warn("Cannot probe terrain ahead", "error", err)
+			continue
+		}
+		c := r.Altitude + r.VerticalRate*t/60 - elev
+		if c < clearance {
+			clearance = c
+		}
+		// the warning only applies to the nearest half of the lookahead
+		if c < m.warning && d <= m.lookahead[len(m.lookahead)-1]/2 {
+			warnAhead = true
+		} else if c < m.caution {
+			cautionAhead = true
+		}
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	m.estimate = Estimate{AGL: agl, Clearance: clearance, At: now}
+	m.mu.Unlock()
+	alert := Alert{AGL: agl, Clearance: clearance, At: now}
+
+	switch {
+	case warnAhead:
+		alert.Kind, alert.Message = Warning, "TERRAIN TERRAIN PULL UP"
+		m.raise(ctx, alert)
+	case cautionAhead:
+		alert.Kind, alert.Message = Caution, "CAUTION TERRAIN"
+		m.raise(ctx, alert)
+	case sinkRate(agl, r.VerticalRate):
+		alert.Kind, alert.Message = SinkRate, "SINK RATE"
+		m.raise(ctx, alert)
+	}
+
+	// when several heights were passed since the last estimate only the
+	// lowest is called out
+	msg := ""
+	m.mu.Lock()
+	for _, c := range callouts {
+		switch {
+		case agl > c.height+100:
+			// climbed back above; allow the callout again
+			m.called[c.height] = false
+		case agl <= c.height && !m.called[c.height] && r.VerticalRate < 0:
+			m.called[c.height] = true
+			msg = c.msg
+		}
+	}
+	m.mu.Unlock()
+	if msg != "" {
+		alert.Kind, alert.Message = Callout, msg
+		m.send(ctx, alert)
+	}
+}
+
+// sinkRate is a simplified GPWS mode 1 envelope
+func sinkRate(agl, verticalRate float64) bool {
+	if agl > 2500 || agl < 50 {
+		return false
+	}
+	return -verticalRate > 1000+agl*1.2
+}
+
+// raise sends the alert unless the same kind was sent recently
+func (m *Monitor) raise(ctx context.Context, a Alert) {
+	m.mu.Lock()
+	if a.At.Sub(m.lastSent[a.Kind]) < m.repeat {
+		m.mu.Unlock()
+		return
+	}
+	m.lastSent[a.Kind] = a.At
+	m.mu.Unlock()
+	m.send(ctx, a)
+}
+
+func (m *Monitor) send(ctx context.Context, a Alert) {
+	slog.Info("Terrain alert", "kind", a.Kind, "message", a.Message, "agl", a.AGL, "clearance", a.Clearance)
+	if m.onAlert != nil {
+		m.onAlert(a)
+	}
+	if m.annunciate != nil {
+		if err := m.annunciate.Annunciate(ctx, a.Message); err != nil {
+			slog.Error("Cannot annunciate terrain alert", "error", err)
+		}
+	}
+}
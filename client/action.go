@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// ActionResult is a decoded SIMCONNECT_RECV_ACTION_CALLBACK, the sim's
+// answer to ExecuteAction
+type ActionResult struct {
+	ActionID  string
+	RequestID DWORD
+}
+
+// ExecuteAction runs an action of the MSFS 2024 action system with the
+// parameter blob; see ActionParams
+// it is only exported by the MSFS 2024 dll, see LoadNewDefaultDLL
+func (s *SimConnect) ExecuteAction(requestID DWORD, actionID string, params []byte) error {
+	return s.ExecuteActionContext(context.Background(), requestID, actionID, params)
+}
+
+// ExecuteActionContext is ExecuteAction with ctx bounding the wait for its lane
+func (s *SimConnect) ExecuteActionContext(ctx context.Context, requestID DWORD, actionID string, params []byte) error {
+	leave, err := s.enterContext(ctx, LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	// SimConnect_ExecuteAction(
+	//   HANDLE hSimConnect,
+	//   DWORD cbRequestID,
+	//   const char * szActionID,
+	//   DWORD cbUnitSize,
+	//   void * pParamValues
+	// );
+
+	if s.dryRun {
+		s.log.Info("Dry run: not executing action", "action", actionID, "bytes", len(params))
+		return nil
+	}
+	if err := available(s.dll.proc_SimConnect_ExecuteAction); err != nil {
+		return fmt.Errorf("SimConnect_ExecuteAction: %w", err)
+	}
+	_actionID := []byte(actionID + "\x00")
+	var values uintptr
+	if len(params) > 0 {
+		values = uintptr(unsafe.Pointer(&params[0]))
+	}
+
+	r1, _, err := s.dll.proc_SimConnect_ExecuteAction.Call(
+		uintptr(s.handle),
+		uintptr(requestID),
+		uintptr(unsafe.Pointer(&_actionID[0])),
+		uintptr(len(params)),
+		values,
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_ExecuteAction for %s error: %d %s", actionID, r1, err)
+	}
+	return nil
+}
+
+// ActionParams builds the parameter blob of an action: each value is
+// written in order, little endian, with strings null terminated
+// supported values are int32, uint32, DWORD, int64, float32, float64 and string
+func ActionParams(values ...any) ([]byte, error) {
+	var b []byte
+	for i, v := range values {
+		switch v := v.(type) {
+		case int32:
+			b = binary.LittleEndian.AppendUint32(b, uint32(v))
+		case uint32:
+			b = binary.LittleEndian.AppendUint32(b, v)
+		case DWORD:
+			b = binary.LittleEndian.AppendUint32(b, uint32(v))
+		case int64:
+			b = binary.LittleEndian.AppendUint64(b, uint64(v))
+		case float32:
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(v))
+		case float64:
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+		case string:
+			b = AppendString(b, v)
+		default:
+			return nil, fmt.Errorf("action parameter %d: unsupported type %T", i, v)
+		}
+	}
+	return b, nil
+}
+
+// Action executes an action and waits for its callback
+// the reply is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) Action(ctx context.Context, actionID string, params ...any) (ActionResult, error) {
+	blob, err := ActionParams(params...)
+	if err != nil {
+		return ActionResult{}, err
+	}
+	ch := make(chan ActionResult, 1)
+	s.mu.Lock()
+	requestID := s.nextRequestID()
+	s.actions[requestID] = ch
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		delete(s.actions, requestID)
+		s.mu.Unlock()
+	}
+	if err := s.ExecuteActionContext(ctx, requestID, actionID, blob); err != nil {
+		cancel()
+		return ActionResult{}, err
+	}
+	if s.dryRun {
+		cancel()
+		return ActionResult{ActionID: actionID, RequestID: requestID}, nil
+	}
+	select {
+	case <-ctx.Done():
+		cancel()
+		return ActionResult{}, fmt.Errorf("action %s: %w", actionID, ctx.Err())
+	case r := <-ch:
+		return r, nil
+	}
+}
+
+// DeliverAction hands an action callback to the waiting Action
+// it returns true if the message was consumed; the connector calls this
+// for ACTION_CALLBACK messages
+// the callback follows the list header with the 260 byte action ID and the request ID
+func (s *SimConnect) DeliverAction(ppData unsafe.Pointer) bool {
+	x := (*RecvFacilityList)(ppData)
+	header := int(unsafe.Sizeof(*x))
+	if int(x.Size) < header+260+4 {
+		return false
+	}
+	data := unsafe.Slice((*byte)(unsafe.Add(ppData, header)), int(x.Size)-header)
+	r := ActionResult{
+		ActionID:  BytesToString(data[:260]),
+		RequestID: DWORD(binary.LittleEndian.Uint32(data[260:])),
+	}
+	s.mu.Lock()
+	ch, ok := s.actions[r.RequestID]
+	delete(s.actions, r.RequestID)
+	s.mu.Unlock()
+	if ok {
+		ch <- r
+	}
+	return ok
+}
@@ -0,0 +1,391 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+	"unsafe"
+)
+
+// InitPosition is SIMCONNECT_DATA_INITPOSITION
+type InitPosition struct {
+	Latitude  float64 // degrees
+	Longitude float64 // degrees
+	Altitude  float64 // feet
+	Pitch     float64 // degrees
+	Bank      float64 // degrees
+	Heading   float64 // degrees
+	OnGround  DWORD   // 1=force to be on the ground
+	Airspeed  DWORD   // knots
+}
+
+type RecvAssignedObjectID struct {
+	Recv
+	RequestID DWORD
+	ObjectID  DWORD
+}
+
+func (s *SimConnect) AICreateSimulatedObject(title string, pos InitPosition, requestID DWORD) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AICreateSimulatedObject(
+	//   HANDLE hSimConnect,
+	//   const char * szContainerTitle,
+	//   SIMCONNECT_DATA_INITPOSITION InitPos,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+	// InitPos is larger than a register, so it is passed by reference
+
+	_title := []byte(title + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_AICreateSimulatedObject.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&_title[0])),
+		uintptr(unsafe.Pointer(&pos)),
+		uintptr(requestID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AICreateSimulatedObject for %s error: %d %s", title, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) AICreateNonATCAircraft(title, tailNumber string, pos InitPosition, requestID DWORD) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AICreateNonATCAircraft(
+	//   HANDLE hSimConnect,
+	//   const char * szContainerTitle,
+	//   const char * szTailNumber,
+	//   SIMCONNECT_DATA_INITPOSITION InitPos,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+	// InitPos is larger than a register, so it is passed by reference
+
+	_title := []byte(title + "\x00")
+	_tailNumber := []byte(tailNumber + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_AICreateNonATCAircraft.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&_title[0])),
+		uintptr(unsafe.Pointer(&_tailNumber[0])),
+		uintptr(unsafe.Pointer(&pos)),
+		uintptr(requestID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AICreateNonATCAircraft for %s error: %d %s", title, r1, err)
+	}
+	return nil
+}
+
+// AICreateNonATCAircraftEX1 is AICreateNonATCAircraft with the livery
+// picked separately from the title, as Liveries lists them
+// it is only exported by the MSFS 2024 dll, see LoadNewDefaultDLL
+func (s *SimConnect) AICreateNonATCAircraftEX1(title, livery, tailNumber string, pos InitPosition, requestID DWORD) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AICreateNonATCAircraft_EX1(
+	//   HANDLE hSimConnect,
+	//   const char * szContainerTitle,
+	//   const char * szLivery,
+	//   const char * szTailNumber,
+	//   SIMCONNECT_DATA_INITPOSITION InitPos,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+
+	if err := available(s.dll.proc_SimConnect_AICreateNonATCAircraft_EX1); err != nil {
+		return fmt.Errorf("SimConnect_AICreateNonATCAircraft_EX1: %w", err)
+	}
+	_title := []byte(title + "\x00")
+	_livery := []byte(livery + "\x00")
+	_tailNumber := []byte(tailNumber + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_AICreateNonATCAircraft_EX1.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&_title[0])),
+		uintptr(unsafe.Pointer(&_livery[0])),
+		uintptr(unsafe.Pointer(&_tailNumber[0])),
+		uintptr(unsafe.Pointer(&pos)),
+		uintptr(requestID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AICreateNonATCAircraft_EX1 for %s livery %s error: %d %s", title, livery, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) AICreateParkedATCAircraft(title, tailNumber, airportICAO string, requestID DWORD) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AICreateParkedATCAircraft(
+	//   HANDLE hSimConnect,
+	//   const char * szContainerTitle,
+	//   const char * szTailNumber,
+	//   const char * szAirportID,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+
+	_title := []byte(title + "\x00")
+	_tailNumber := []byte(tailNumber + "\x00")
+	_airportICAO := []byte(airportICAO + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_AICreateParkedATCAircraft.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&_title[0])),
+		uintptr(unsafe.Pointer(&_tailNumber[0])),
+		uintptr(unsafe.Pointer(&_airportICAO[0])),
+		uintptr(requestID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AICreateParkedATCAircraft for %s at %s error: %d %s", title, airportICAO, r1, err)
+	}
+	return nil
+}
+
+// AICreateEnrouteATCAircraft creates an aircraft flying the flight plan at
+// flightPlanPath, a .pln path without the extension; flightPlanPosition is
+// the leg to start on, with the fraction the distance along it
+func (s *SimConnect) AICreateEnrouteATCAircraft(title, tailNumber string, flightNumber int, flightPlanPath string, flightPlanPosition float64, touchAndGo bool, requestID DWORD) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AICreateEnrouteATCAircraft(
+	//   HANDLE hSimConnect,
+	//   const char * szContainerTitle,
+	//   const char * szTailNumber,
+	//   int iFlightNumber,
+	//   const char * szFlightPlanPath,
+	//   double dFlightPlanPosition,
+	//   BOOL bTouchAndGo,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+
+	_title := []byte(title + "\x00")
+	_tailNumber := []byte(tailNumber + "\x00")
+	_flightPlanPath := []byte(flightPlanPath + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_AICreateEnrouteATCAircraft.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&_title[0])),
+		uintptr(unsafe.Pointer(&_tailNumber[0])),
+		uintptr(int32(flightNumber)),
+		uintptr(unsafe.Pointer(&_flightPlanPath[0])),
+		uintptr(math.Float64bits(flightPlanPosition)),
+		boolArg(touchAndGo),
+		uintptr(requestID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AICreateEnrouteATCAircraft for %s error: %d %s", title, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) AIReleaseControl(objectID, requestID DWORD) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AIReleaseControl(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_OBJECT_ID ObjectID,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_AIReleaseControl.Call(
+		uintptr(s.handle),
+		uintptr(objectID),
+		uintptr(requestID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AIReleaseControl for objectID %d error: %d %s", objectID, r1, err)
+	}
+	return nil
+}
+
+// AISetAircraftFlightPlan gives an AI aircraft the flight plan at
+// flightPlanPath, a .pln path without the extension
+// a plan that cannot be loaded raises LOAD_FLIGHTPLAN_FAILED later; see
+// SetAircraftFlightPlan to wait for it
+func (s *SimConnect) AISetAircraftFlightPlan(objectID DWORD, flightPlanPath string, requestID DWORD) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AISetAircraftFlightPlan(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_OBJECT_ID ObjectID,
+	//   const char * szFlightPlanPath,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+
+	_flightPlanPath := []byte(flightPlanPath + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_AISetAircraftFlightPlan.Call(
+		uintptr(s.handle),
+		uintptr(objectID),
+		uintptr(unsafe.Pointer(&_flightPlanPath[0])),
+		uintptr(requestID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AISetAircraftFlightPlan for objectID %d error: %d %s", objectID, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) AIRemoveObject(objectID, requestID DWORD) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AIRemoveObject(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_OBJECT_ID ObjectID,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_AIRemoveObject.Call(
+		uintptr(s.handle),
+		uintptr(objectID),
+		uintptr(requestID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AIRemoveObject for objectID %d error: %d %s", objectID, r1, err)
+	}
+	return nil
+}
+
+// CreateSimulatedObject creates a simulated object and waits for its object ID
+// the reply is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) CreateSimulatedObject(ctx context.Context, title string, pos InitPosition) (DWORD, error) {
+	return s.awaitObject(ctx, title, func(requestID DWORD) error {
+		return s.AICreateSimulatedObject(title, pos, requestID)
+	})
+}
+
+// CreateNonATCAircraft creates an aircraft that is not under ATC control
+// and waits for its object ID
+func (s *SimConnect) CreateNonATCAircraft(ctx context.Context, title, tailNumber string, pos InitPosition) (DWORD, error) {
+	return s.awaitObject(ctx, title, func(requestID DWORD) error {
+		return s.AICreateNonATCAircraft(title, tailNumber, pos, requestID)
+	})
+}
+
+// CreateNonATCAircraftLivery is CreateNonATCAircraft with a livery, eg
+// from Liveries; an empty livery uses CreateNonATCAircraft, so it also
+// works with the MSFS 2020 dll
+func (s *SimConnect) CreateNonATCAircraftLivery(ctx context.Context, l Livery, tailNumber string, pos InitPosition) (DWORD, error) {
+	if l.Livery == "" {
+		return s.CreateNonATCAircraft(ctx, l.Title, tailNumber, pos)
+	}
+	return s.awaitObject(ctx, l.Title, func(requestID DWORD) error {
+		return s.AICreateNonATCAircraftEX1(l.Title, l.Livery, tailNumber, pos, requestID)
+	})
+}
+
+// CreateParkedATCAircraft creates an aircraft parked at an airport, eg
+// "KSEA", and waits for its object ID
+func (s *SimConnect) CreateParkedATCAircraft(ctx context.Context, title, tailNumber, airportICAO string) (DWORD, error) {
+	return s.awaitObject(ctx, title, func(requestID DWORD) error {
+		return s.AICreateParkedATCAircraft(title, tailNumber, airportICAO, requestID)
+	})
+}
+
+// CreateEnrouteATCAircraft creates an aircraft flying a flight plan and
+// waits for its object ID; see AICreateEnrouteATCAircraft
+func (s *SimConnect) CreateEnrouteATCAircraft(ctx context.Context, title, tailNumber string, flightNumber int, flightPlanPath string, flightPlanPosition float64, touchAndGo bool) (DWORD, error) {
+	return s.awaitObject(ctx, title, func(requestID DWORD) error {
+		return s.AICreateEnrouteATCAircraft(title, tailNumber, flightNumber, flightPlanPath, flightPlanPosition, touchAndGo, requestID)
+	})
+}
+
+// awaitObject sends a create request and waits for the assigned object ID
+// the reply is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) awaitObject(ctx context.Context, title string, create func(requestID DWORD) error) (DWORD, error) {
+	ch := make(chan DWORD, 1)
+	s.mu.Lock()
+	requestID := s.nextRequestID()
+	s.assigned[requestID] = ch
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		delete(s.assigned, requestID)
+		s.mu.Unlock()
+	}
+	if err := create(requestID); err != nil {
+		cancel()
+		return 0, err
+	}
+	select {
+	case <-ctx.Done():
+		cancel()
+		return 0, fmt.Errorf("create %s: %w", title, ctx.Err())
+	case id := <-ch:
+		return id, nil
+	}
+}
+
+// RemoveObject removes an AI object created by this client
+func (s *SimConnect) RemoveObject(objectID DWORD) error {
+	s.mu.Lock()
+	requestID := s.nextRequestID()
+	s.mu.Unlock()
+	return s.AIRemoveObject(objectID, requestID)
+}
+
+// ReleaseControl stops the sim's AI from flying an object, so the client can
+// move it with SetDataOn
+func (s *SimConnect) ReleaseControl(objectID DWORD) error {
+	s.mu.Lock()
+	requestID := s.nextRequestID()
+	s.mu.Unlock()
+	return s.AIReleaseControl(objectID, requestID)
+}
+
+// SetAircraftFlightPlan gives an AI aircraft a flight plan and waits up to
+// wait for the sim to reject it; a rejected plan returns an error that
+// matches ErrLoadFlightPlanFailed with errors.Is
+// the exception is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) SetAircraftFlightPlan(ctx context.Context, objectID DWORD, flightPlanPath string, wait time.Duration) error {
+	s.mu.Lock()
+	requestID := s.nextRequestID()
+	s.mu.Unlock()
+	err := s.checkSend(ctx, wait, func() error {
+		return s.AISetAircraftFlightPlan(objectID, flightPlanPath, requestID)
+	})
+	if err != nil {
+		return fmt.Errorf("flight plan %s for objectID %d: %w", flightPlanPath, objectID, err)
+	}
+	return nil
+}
+
+// DeliverAssignedObject hands an assigned object ID to a pending request
+// it returns true if the message was consumed
+func (s *SimConnect) DeliverAssignedObject(x *RecvAssignedObjectID) bool {
+	s.mu.Lock()
+	ch, ok := s.assigned[x.RequestID]
+	delete(s.assigned, x.RequestID)
+	s.mu.Unlock()
+	if ok {
+		ch <- x.ObjectID
+	}
+	return ok
+}
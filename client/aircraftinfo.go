@@ -0,0 +1,55 @@
+package client
+
+import "context"
+
+// AircraftInfo identifies the user's aircraft
+type AircraftInfo struct {
+	Title    string // TITLE
+	ATCModel string // ATC MODEL
+	ATCType  string // ATC TYPE
+	ATCID    string // ATC ID, the tail number
+	Category string // CATEGORY, eg Airplane or Helicopter
+	Livery   string // LIVERY NAME; empty on sims without it
+}
+
+// aircraftInfoVars are read in one request; LIVERY NAME is last, as sims
+// that don't know it drop it from the definition and the reply is shorter
+var aircraftInfoVars = []string{"TITLE", "ATC MODEL", "ATC TYPE", "ATC ID", "CATEGORY", "LIVERY NAME"}
+
+const aircraftInfoKey = "aircraftinfo"
+
+// AircraftInfo reads the identifying strings of the user's aircraft in a single request
+// the reply is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) AircraftInfo(ctx context.Context) (AircraftInfo, error) {
+	defineID, err := s.cachedDefinition(aircraftInfoKey, func(defineID DWORD) error {
+		for _, name := range aircraftInfoVars {
+			if err := s.AddToDataDefinition(defineID, name, "", DATATYPE_STRING256); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return AircraftInfo{}, err
+	}
+
+	data, err := s.readDefinition(ctx, OBJECT_ID_USER, defineID, "aircraft info")
+	if err != nil {
+		return AircraftInfo{}, err
+	}
+	var fields [6]string
+	for i := range fields {
+		if len(data) < (i+1)*256 {
+			break
+		}
+		fields[i] = BytesToString(data[i*256 : (i+1)*256])
+	}
+	return AircraftInfo{
+		Title:    fields[0],
+		ATCModel: fields[1],
+		ATCType:  fields[2],
+		ATCID:    fields[3],
+		Category: fields[4],
+		Livery:   fields[5],
+	}, nil
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"fmt"
+	"math"
+)
+
+// Call calls a SimConnect function the client does not wrap, by its export
+// name, eg "SimConnect_FlightLoad", passing the connection handle first and
+// args after it
+// the function is looked up on first use and ErrUnsupported returned if
+// the loaded dll does not export it; a failed HRESULT is returned as an
+// error, and exceptions about the call name it
+// args are passed as is: use Float32Arg and Float64Arg for floats and
+// CString for strings, and keep whatever a pointer refers to alive with
+// runtime.KeepAlive until Call returns
+// in a dry run nothing is called, as the client can't tell reads from writes
+func (s *SimConnect) Call(procName string, args ...uintptr) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	if s.dryRun {
+		s.log.Info("Dry run: not calling", "proc", procName, "args", len(args))
+		return nil
+	}
+	p := s.dll.lookup(procName)
+	if err := available(p); err != nil {
+		return fmt.Errorf("%s: %w", procName, err)
+	}
+	r1, _, err := p.Call(append([]uintptr{uintptr(s.handle)}, args...)...)
+	if int32(r1) < 0 {
+		return fmt.Errorf("%s error: %d %s", procName, r1, err)
+	}
+	s.recordSend(procName)
+	return nil
+}
+
+// Float32Arg passes a Go float as a C float argument to Call
+func Float32Arg(f float32) uintptr {
+	return floatArg(f)
+}
+
+// Float64Arg passes a Go float as a C double argument to Call
+// like floatArg it relies on the syscall copying the first four arguments
+// to the XMM registers; it needs a 64 bit build
+func Float64Arg(f float64) uintptr {
+	return uintptr(math.Float64bits(f))
+}
+
+// CString returns s as a null terminated C string for Call; pass
+// uintptr(unsafe.Pointer(&b[0])) and keep b alive until Call returns
+func CString(s string) []byte {
+	return AppendString(nil, s)
+}
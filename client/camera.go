@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// CAMERA_IGNORE_FIELD leaves a camera axis unchanged, see SIMCONNECT_CAMERA_IGNORE_FIELD
+const CAMERA_IGNORE_FIELD float32 = math.MaxFloat32
+
+// floatArg passes a Go float as a C float
+// the bits go in the integer register and the syscall copies the first
+// four arguments to the XMM registers, so this works for any position;
+// uintptr(f) would truncate the value to an integer
+func floatArg(f float32) uintptr {
+	return uintptr(math.Float32bits(f))
+}
+
+// CameraSetRelative6DOF moves the view relative to the user aircraft's eyepoint
+// x, y and z are offsets in meters, pitch, bank and heading are in degrees;
+// pass CAMERA_IGNORE_FIELD to leave an axis as it is
+func (s *SimConnect) CameraSetRelative6DOF(x, y, z, pitch, bank, heading float32) error {
+	return s.CameraSetRelative6DOFContext(context.Background(), x, y, z, pitch, bank, heading)
+}
+
+// CameraSetRelative6DOFContext is CameraSetRelative6DOF with ctx bounding the wait for its lane
+func (s *SimConnect) CameraSetRelative6DOFContext(ctx context.Context, x, y, z, pitch, bank, heading float32) error {
+	leave, err := s.enterContext(ctx, LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	// SimConnect_CameraSetRelative6DOF(
+	//   HANDLE hSimConnect,
+	//   float fDeltaX,
+	//   float fDeltaY,
+	//   float fDeltaZ,
+	//   float fPitchDeg,
+	//   float fBankDeg,
+	//   float fHeadingDeg
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_CameraSetRelative6DOF.Call(
+		uintptr(s.handle),
+		floatArg(x),
+		floatArg(y),
+		floatArg(z),
+		floatArg(pitch),
+		floatArg(bank),
+		floatArg(heading),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_CameraSetRelative6DOF error: %d %s", r1, err)
+	}
+	return nil
+}
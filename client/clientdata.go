@@ -0,0 +1,293 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// client data periods, see SIMCONNECT_CLIENT_DATA_PERIOD
+const (
+	CLIENT_DATA_PERIOD_NEVER DWORD = iota
+	CLIENT_DATA_PERIOD_ONCE
+	CLIENT_DATA_PERIOD_VISUAL_FRAME
+	CLIENT_DATA_PERIOD_ON_SET
+	CLIENT_DATA_PERIOD_SECOND
+)
+
+// client data request flags, see SIMCONNECT_CLIENT_DATA_REQUEST_FLAG
+const (
+	CLIENT_DATA_REQUEST_FLAG_DEFAULT DWORD = 0x00000000
+	CLIENT_DATA_REQUEST_FLAG_CHANGED DWORD = 0x00000001 // send requested data when value(s) change
+	CLIENT_DATA_REQUEST_FLAG_TAGGED  DWORD = 0x00000002 // send requested data in tagged format
+)
+
+// client data area flags, see SIMCONNECT_CREATE_CLIENT_DATA_FLAG
+const (
+	CREATE_CLIENT_DATA_FLAG_DEFAULT   DWORD = 0x00000000
+	CREATE_CLIENT_DATA_FLAG_READ_ONLY DWORD = 0x00000001 // permit only ClientData creator to write into ClientData
+)
+
+// client data set flags, see SIMCONNECT_CLIENT_DATA_SET_FLAG
+const (
+	CLIENT_DATA_SET_FLAG_DEFAULT DWORD = 0x00000000
+	CLIENT_DATA_SET_FLAG_TAGGED  DWORD = 0x00000001 // data is in tagged format
+)
+
+// client data datum types, passed as the size of AddToClientDataDefinition
+// so the sim can apply epsilons; see SIMCONNECT_CLIENTDATATYPE
+const (
+	CLIENTDATATYPE_INT8    DWORD = math.MaxUint32 - iota // -1
+	CLIENTDATATYPE_INT16                                 // -2
+	CLIENTDATATYPE_INT32                                 // -3
+	CLIENTDATATYPE_INT64                                 // -4
+	CLIENTDATATYPE_FLOAT32                               // -5
+	CLIENTDATATYPE_FLOAT64                               // -6
+)
+
+// CLIENTDATAOFFSET_AUTO places a client data datum right after the previous one
+const CLIENTDATAOFFSET_AUTO DWORD = math.MaxUint32
+
+// RecvClientData is SIMCONNECT_RECV_CLIENT_DATA, which shares the layout of
+// SIMCONNECT_RECV_SIMOBJECT_DATA
+type RecvClientData struct {
+	RecvSimobjectData
+}
+
+func (s *SimConnect) MapClientDataNameToID(clientDataName string, clientDataID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_MapClientDataNameToID(
+	//   HANDLE hSimConnect,
+	//   const char * szClientDataName,
+	//   SIMCONNECT_CLIENT_DATA_ID ClientDataID
+	// );
+
+	_clientDataName := []byte(clientDataName + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_MapClientDataNameToID.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&_clientDataName[0])),
+		uintptr(clientDataID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_MapClientDataNameToID for %s error: %d %s", clientDataName, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) CreateClientData(clientDataID, size, flags DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_CreateClientData(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_CLIENT_DATA_ID ClientDataID,
+	//   DWORD dwSize,
+	//   SIMCONNECT_CREATE_CLIENT_DATA_FLAG Flags
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_CreateClientData.Call(
+		uintptr(s.handle),
+		uintptr(clientDataID),
+		uintptr(size),
+		uintptr(flags),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_CreateClientData for clientDataID %d error: %d %s", clientDataID, r1, err)
+	}
+	return nil
+}
+
+// AddToClientDataDefinition adds a datum to a client data definition
+// sizeOrType is a size in bytes or a CLIENTDATATYPE_*; offset may be CLIENTDATAOFFSET_AUTO
+func (s *SimConnect) AddToClientDataDefinition(defineID, offset, sizeOrType DWORD, epsilon float32, datumID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AddToClientDataDefinition(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_CLIENT_DATA_DEFINITION_ID DefineID,
+	//   DWORD dwOffset,
+	//   DWORD dwSizeOrType,
+	//   float fEpsilon = 0,
+	//   DWORD DatumID = SIMCONNECT_UNUSED
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_AddToClientDataDefinition.Call(
+		uintptr(s.handle),
+		uintptr(defineID),
+		uintptr(offset),
+		uintptr(sizeOrType),
+		uintptr(math.Float32bits(epsilon)),
+		uintptr(datumID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AddToClientDataDefinition for defineID %d error: %d %s", defineID, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) ClearClientDataDefinition(defineID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_ClearClientDataDefinition(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_CLIENT_DATA_DEFINITION_ID DefineID
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_ClearClientDataDefinition.Call(
+		uintptr(s.handle),
+		uintptr(defineID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_ClearClientDataDefinition for defineID %d error: %d %s", defineID, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) RequestClientData(clientDataID, requestID, defineID, period, flags, origin, interval, limit DWORD) error {
+	return s.RequestClientDataContext(context.Background(), clientDataID, requestID, defineID, period, flags, origin, interval, limit)
+}
+
+// RequestClientDataContext is RequestClientData with ctx bounding the wait for its lane
+// with CLIENT_DATA_PERIOD_ONCE it counts towards WithMaxOutstanding until its reply
+func (s *SimConnect) RequestClientDataContext(ctx context.Context, clientDataID, requestID, defineID, period, flags, origin, interval, limit DWORD) (err error) {
+	if period == CLIENT_DATA_PERIOD_ONCE {
+		var sent func(bool)
+		if sent, err = s.oneShot(ctx, requestID, "client data"); err != nil {
+			return err
+		}
+		defer func() { sent(err == nil) }()
+	}
+	leave, err := s.enterContext(ctx, LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	// SimConnect_RequestClientData(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_CLIENT_DATA_ID ClientDataID,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
+	//   SIMCONNECT_CLIENT_DATA_DEFINITION_ID DefineID,
+	//   SIMCONNECT_CLIENT_DATA_PERIOD Period = SIMCONNECT_CLIENT_DATA_PERIOD_ONCE,
+	//   SIMCONNECT_CLIENT_DATA_REQUEST_FLAG Flags = 0,
+	//   DWORD origin = 0,
+	//   DWORD interval = 0,
+	//   DWORD limit = 0
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_RequestClientData.Call(
+		uintptr(s.handle),
+		uintptr(clientDataID),
+		uintptr(requestID),
+		uintptr(defineID),
+		uintptr(period),
+		uintptr(flags),
+		uintptr(origin),
+		uintptr(interval),
+		uintptr(limit),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf(
+			"SimConnect_RequestClientData for clientDataID %d requestID %d defineID %d error: %d %s",
+			clientDataID, requestID, defineID, r1, err,
+		)
+	}
+	return nil
+}
+
+func (s *SimConnect) SetClientData(clientDataID, defineID, flags, reserved, size DWORD, buf unsafe.Pointer) error {
+	return s.SetClientDataContext(context.Background(), clientDataID, defineID, flags, reserved, size, buf)
+}
+
+// SetClientDataContext is SetClientData with ctx bounding the wait for its lane
+func (s *SimConnect) SetClientDataContext(ctx context.Context, clientDataID, defineID, flags, reserved, size DWORD, buf unsafe.Pointer) error {
+	leave, err := s.enterContext(ctx, LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	// SimConnect_SetClientData(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_CLIENT_DATA_ID ClientDataID,
+	//   SIMCONNECT_CLIENT_DATA_DEFINITION_ID DefineID,
+	//   SIMCONNECT_CLIENT_DATA_SET_FLAG Flags,
+	//   DWORD dwReserved,
+	//   DWORD cbUnitSize,
+	//   void * pDataSet
+	// );
+
+	if s.dryRun {
+		s.log.Info("Dry run: not setting client data", "clientDataID", clientDataID, "defineID", defineID, "bytes", size)
+		return nil
+	}
+	r1, _, err := s.dll.proc_SimConnect_SetClientData.Call(
+		uintptr(s.handle),
+		uintptr(clientDataID),
+		uintptr(defineID),
+		uintptr(flags),
+		uintptr(reserved),
+		uintptr(size),
+		uintptr(buf),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_SetClientData for clientDataID %d defineID %d error: %d %s", clientDataID, defineID, r1, err)
+	}
+	return nil
+}
+
+// SetClientDataBytes writes data to a client data area through a definition
+// covering len(data) bytes
+func (s *SimConnect) SetClientDataBytes(clientDataID, defineID DWORD, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("SetClientData for clientDataID %d: no data", clientDataID)
+	}
+	return s.SetClientData(clientDataID, defineID, CLIENT_DATA_SET_FLAG_DEFAULT, 0, DWORD(len(data)), unsafe.Pointer(&data[0]))
+}
+
+// HandleClientData calls fn with the data of each client data message sent
+// for requestID; fn is called on the dispatch goroutine, so it must not
+// block, and the data is only valid during the call
+func (s *SimConnect) HandleClientData(requestID DWORD, fn func(x *RecvClientData, data []byte)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fn == nil {
+		delete(s.clientData, requestID)
+		return
+	}
+	s.clientData[requestID] = fn
+}
+
+// DeliverClientData hands a client data message to its handler
+// it returns true if the message was consumed; the connector calls this
+// for CLIENT_DATA messages
+func (s *SimConnect) DeliverClientData(x *RecvClientData) bool {
+	s.completeRequest(x.RequestID)
+	s.mu.Lock()
+	fn, ok := s.clientData[x.RequestID]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	header := DWORD(unsafe.Sizeof(*x))
+	var data []byte
+	if x.Size > header {
+		data = unsafe.Slice((*byte)(x.DataPointer()), x.Size-header)
+	}
+	fn(x, data)
+	return true
+}
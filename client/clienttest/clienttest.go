@@ -0,0 +1,212 @@
+// Package clienttest fakes the SimConnect dll, so code built on the client
+// can be tested without a sim
+//
+// A DLL records every call made through it and answers each with S_OK, or
+// with the result of a Func set with Handle. Handlers run while the call is
+// made, so they can read what the pointer arguments refer to with Bytes,
+// String and Float64s. Messages queued with Queue are handed out by
+// GetNextDispatch in order; with the queue empty it fails with E_FAIL, as
+// SimConnect does when there is nothing to dispatch.
+//
+//	dll := clienttest.New()
+//	sc, err := dll.Connect()
+//	...
+//	sc.SetData(&report)
+//	calls := dll.Calls("SimConnect_SetDataOnSimObject")
+package clienttest
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Call is a call made through the fake
+type Call struct {
+	Proc string
+	// Args are the arguments as passed, starting with the handle
+	Args []uintptr
+}
+
+// Func answers a call and returns its HRESULT
+type Func func(args []uintptr) uintptr
+
+// DLL is a fake SimConnect dll
+type DLL struct {
+	mu      sync.Mutex
+	calls   []Call
+	funcs   map[string]Func
+	queue   [][]byte
+	current []byte // the message last dispatched, kept alive until the next
+	quiet   bool
+	missing map[string]bool
+}
+
+// New creates a fake with nothing queued
+func New() *DLL {
+	return &DLL{funcs: map[string]Func{}}
+}
+
+// Connect opens a client connection through the fake
+func (d *DLL) Connect(opts ...client.SimConnectOption) (*client.SimConnect, error) {
+	return client.New("clienttest", append([]client.SimConnectOption{client.WithProcs(d.Find)}, opts...)...)
+}
+
+// Find returns the fake proc for a name, for client.WithProcs
+func (d *DLL) Find(name string) client.Proc {
+	return proc{d: d, name: name}
+}
+
+// Handle sets the function answering calls to a proc, eg
+// "SimConnect_RequestDataOnSimObjectType"
+func (d *DLL) Handle(name string, fn Func) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.funcs[name] = fn
+}
+
+// Remove makes procs missing from the fake, as from an older dll; the
+// client reports calls to them as unsupported
+func (d *DLL) Remove(names ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.missing == nil {
+		d.missing = map[string]bool{}
+	}
+	for _, n := range names {
+		d.missing[n] = true
+	}
+}
+
+// Queue adds messages for GetNextDispatch to hand out
+func (d *DLL) Queue(msgs ...[]byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queue = append(d.queue, msgs...)
+}
+
+// Calls returns the calls made to a proc, or every call for ""
+func (d *DLL) Calls(name string) []Call {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var out []Call
+	for _, c := range d.calls {
+		if name == "" || c.Proc == name {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// SetRecording turns recording calls on or off; benchmarks turn it off so
+// the recording doesn't count towards their allocations
+func (d *DLL) SetRecording(on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.quiet = !on
+}
+
+// Reset forgets the calls made so far
+func (d *DLL) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls = nil
+}
+
+func (d *DLL) call(name string, args []uintptr) uintptr {
+	d.mu.Lock()
+	if !d.quiet {
+		d.calls = append(d.calls, Call{Proc: name, Args: append([]uintptr(nil), args...)})
+	}
+	fn := d.funcs[name]
+	d.mu.Unlock()
+	if fn != nil {
+		return fn(args)
+	}
+	if name == "SimConnect_GetNextDispatch" {
+		return d.dispatch(args)
+	}
+	return 0
+}
+
+// dispatch hands out the next queued message
+func (d *DLL) dispatch(args []uintptr) uintptr {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.queue) == 0 {
+		return uintptr(client.E_FAIL)
+	}
+	d.current, d.queue = d.queue[0], d.queue[1:]
+	*(*unsafe.Pointer)(pointer(args[1])) = unsafe.Pointer(&d.current[0])
+	*(*client.DWORD)(pointer(args[2])) = client.DWORD(len(d.current))
+	return 0
+}
+
+type proc struct {
+	d    *DLL
+	name string
+}
+
+func (p proc) Call(a ...uintptr) (uintptr, uintptr, error) {
+	return p.d.call(p.name, a), 0, nil
+}
+
+// Find fails for removed procs, like a LazyProc the dll does not export
+func (p proc) Find() error {
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	if p.d.missing[p.name] {
+		return fmt.Errorf("%s not found", p.name)
+	}
+	return nil
+}
+
+// pointer turns a pointer argument back into a pointer; the caller keeps
+// what it refers to alive for the duration of the call
+func pointer(p uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&p))
+}
+
+// Bytes copies n bytes from a pointer argument
+func Bytes(p uintptr, n int) []byte {
+	return append([]byte(nil), unsafe.Slice((*byte)(pointer(p)), n)...)
+}
+
+// String reads a null terminated string argument
+func String(p uintptr) string {
+	if p == 0 {
+		return ""
+	}
+	var b []byte
+	for i := uintptr(0); ; i++ {
+		c := *(*byte)(unsafe.Add(pointer(p), i))
+		if c == 0 {
+			return string(b)
+		}
+		b = append(b, c)
+	}
+}
+
+// PutDWORD writes a DWORD through a pointer argument, eg the packet ID
+// GetLastSentPacketID returns
+func PutDWORD(p uintptr, v client.DWORD) {
+	*(*client.DWORD)(pointer(p)) = v
+}
+
+// Float64s copies n float64 values from a pointer argument
+func Float64s(p uintptr, n int) []float64 {
+	return append([]float64(nil), unsafe.Slice((*float64)(pointer(p)), n)...)
+}
+
+// Message returns the bytes of a Recv struct, with its Size set, followed by
+// any trailing data, ready to Queue
+func Message[T any](v *T, data ...byte) []byte {
+	n := int(unsafe.Sizeof(*v))
+	b := make([]byte, 0, n+len(data))
+	b = append(b, unsafe.Slice((*byte)(unsafe.Pointer(v)), n)...)
+	b = append(b, data...)
+	(*client.Recv)(unsafe.Pointer(&b[0])).Size = client.DWORD(len(b))
+	return b
+}
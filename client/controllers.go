@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"unsafe"
+)
+
+// Controller is a decoded SIMCONNECT_CONTROLLER_ITEM, an input device
+// connected to the sim
+type Controller struct {
+	DeviceName  string
+	DeviceID    DWORD
+	ProductID   DWORD
+	CompositeID DWORD
+	// HardwareVersion is major, minor, revision and build
+	HardwareVersion [4]uint16
+}
+
+// controllerSize is SIMCONNECT_CONTROLLER_ITEM: a 256 byte name, three
+// ids and four version shorts
+const controllerSize = 256 + 3*4 + 4*2
+
+func (s *SimConnect) EnumerateControllers() error {
+	leave, err := s.enter(LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_EnumerateControllers(
+	//   HANDLE hSimConnect
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_EnumerateControllers.Call(
+		uintptr(s.handle),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_EnumerateControllers error: %d %s", r1, err)
+	}
+	return nil
+}
+
+// Controllers enumerates the connected input devices and waits for the list
+// the reply does not name the request, so concurrent calls are answered in order
+// it is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) Controllers(ctx context.Context) ([]Controller, error) {
+	w := &controllerWaiter{done: make(chan struct{})}
+	s.mu.Lock()
+	s.controllers = append(s.controllers, w)
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		for i, o := range s.controllers {
+			if o == w {
+				s.controllers = append(s.controllers[:i], s.controllers[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}
+	if err := s.EnumerateControllers(); err != nil {
+		cancel()
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		cancel()
+		return nil, fmt.Errorf("controllers: %w", ctx.Err())
+	case <-w.done:
+		return w.items, nil
+	}
+}
+
+type controllerWaiter struct {
+	items []Controller
+	done  chan struct{}
+}
+
+// DeliverControllers hands a controllers list to the oldest waiting Controllers
+// it returns true if the message was consumed; the connector calls this
+// for CONTROLLERS_LIST messages
+func (s *SimConnect) DeliverControllers(ppData unsafe.Pointer) bool {
+	x := (*RecvFacilityList)(ppData)
+	header := int(unsafe.Sizeof(*x))
+	var items []Controller
+	if int(x.Size) > header && x.ArraySize > 0 {
+		data := unsafe.Slice((*byte)(unsafe.Add(ppData, header)), int(x.Size)-header)
+		items = decodeControllers(data, int(x.ArraySize))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.controllers) == 0 {
+		return false
+	}
+	w := s.controllers[0]
+	w.items = append(w.items, items...)
+	if x.EntryNumber+1 >= x.OutOf {
+		s.controllers = s.controllers[1:]
+		close(w.done)
+	}
+	return true
+}
+
+func decodeControllers(data []byte, n int) []Controller {
+	stride := len(data) / n
+	if stride < controllerSize {
+		return nil
+	}
+	u32 := func(b []byte, o int) DWORD { return DWORD(binary.LittleEndian.Uint32(b[o:])) }
+	out := make([]Controller, 0, n)
+	for i := 0; i < n; i++ {
+		b := data[i*stride : (i+1)*stride]
+		c := Controller{
+			DeviceName:  BytesToString(b[:256]),
+			DeviceID:    u32(b, 256),
+			ProductID:   u32(b, 260),
+			CompositeID: u32(b, 264),
+		}
+		for j := range c.HardwareVersion {
+			c.HardwareVersion[j] = binary.LittleEndian.Uint16(b[268+2*j:])
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -2,7 +2,10 @@ package client
 
 // cloned from github.com/lian/msfs2020-go/simconnect
 
-import "fmt"
+import (
+	"fmt"
+	"unsafe"
+)
 
 // MSFS-SDK/SimConnect\ SDK/include/SimConnect.h
 
@@ -10,6 +13,8 @@ const E_FAIL uint32 = 0x80004005
 
 type DWORD uint32
 
+const MAX_PATH = 260
+
 const UNUSED DWORD = 0xffffffff // special value to indicate unused event, ID
 const OBJECT_ID_USER DWORD = 0  // proxy value for User vehicle ObjectID
 
@@ -134,6 +139,37 @@ const (
 	RECV_ID_PICK
 )
 
+// MSFS replaced PICK with EVENT_EX1 and extended the list
+const (
+	RECV_ID_EVENT_EX1                           DWORD = 27
+	RECV_ID_FACILITY_DATA                       DWORD = 28
+	RECV_ID_FACILITY_DATA_END                   DWORD = 29
+	RECV_ID_FACILITY_MINIMAL_LIST               DWORD = 30
+	RECV_ID_JETWAY_DATA                         DWORD = 31
+	RECV_ID_CONTROLLERS_LIST                    DWORD = 32
+	RECV_ID_ACTION_CALLBACK                     DWORD = 33
+	RECV_ID_ENUMERATE_INPUT_EVENTS              DWORD = 34
+	RECV_ID_GET_INPUT_EVENT                     DWORD = 35
+	RECV_ID_SUBSCRIBE_INPUT_EVENT               DWORD = 36
+	RECV_ID_ENUMERATE_INPUT_EVENT_PARAMS        DWORD = 37
+	RECV_ID_ENUMERATE_SIMOBJECT_AND_LIVERY_LIST DWORD = 38
+	RECV_ID_FLOW_EVENT                          DWORD = 39
+)
+
+const (
+	PERIOD_NEVER DWORD = iota
+	PERIOD_ONCE
+	PERIOD_VISUAL_FRAME
+	PERIOD_SIM_FRAME
+	PERIOD_SECOND
+)
+
+const (
+	DATA_REQUEST_FLAG_DEFAULT DWORD = 0x00000000
+	DATA_REQUEST_FLAG_CHANGED DWORD = 0x00000001 // send requested data when value(s) change
+	DATA_REQUEST_FLAG_TAGGED  DWORD = 0x00000002 // send requested data in tagged format
+)
+
 const (
 	SIMOBJECT_TYPE_USER DWORD = iota
 	SIMOBJECT_TYPE_ALL
@@ -143,6 +179,9 @@ const (
 	SIMOBJECT_TYPE_GROUND
 )
 
+// MAX_RADIUS is the largest radius, in meters, of RequestDataOnSimObjectType
+const MAX_RADIUS DWORD = 200000
+
 const (
 	FACILITY_LIST_TYPE_AIRPORT DWORD = iota
 	FACILITY_LIST_TYPE_WAYPOINT
@@ -186,18 +225,29 @@ type RecvEvent struct {
 	Data    DWORD // uEventID-dependent context
 }
 
+type RecvEventFilename struct {
+	RecvEvent
+	FileName [MAX_PATH]byte // uEventID-dependent context
+	Flags    DWORD
+}
+
 type RecvSimobjectData struct {
 	Recv
 	RequestID   DWORD
 	ObjectID    DWORD
 	DefineID    DWORD
 	Flags       DWORD // SIMCONNECT_DATA_REQUEST_FLAG
-	entrynumber DWORD // if multiple objects returned, this is number <entrynumber> out of <outof>.
-	outof       DWORD // note: starts with 1, not 0.
+	EntryNumber DWORD // if multiple objects returned, this is number <EntryNumber> out of <OutOf>.
+	OutOf       DWORD // note: starts with 1, not 0.
 	DefineCount DWORD // data count (number of datums, *not* byte count)
 	//SIMCONNECT_DATAV(   dwData, dwDefineID, ); // data begins here, dwDefineCount data items
 }
 
+// DataPointer returns a pointer to the data following the header
+func (r *RecvSimobjectData) DataPointer() unsafe.Pointer {
+	return unsafe.Add(unsafe.Pointer(r), unsafe.Sizeof(*r))
+}
+
 type RecvSimobjectDataByType struct {
 	RecvSimobjectData
 }
@@ -240,3 +290,64 @@ type DataFacilityWaypoint struct {
 	DataFacilityAirport
 	MagVar float64 // Magvar in degrees
 }
+
+// BytesToString converts a fixed-size, null terminated string field to a string
+func BytesToString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+var recvIDNames = map[DWORD]string{
+	RECV_ID_NULL:                                "NULL",
+	RECV_ID_EXCEPTION:                           "EXCEPTION",
+	RECV_ID_OPEN:                                "OPEN",
+	RECV_ID_QUIT:                                "QUIT",
+	RECV_ID_EVENT:                               "EVENT",
+	RECV_ID_EVENT_OBJECT_ADDREMOVE:              "EVENT_OBJECT_ADDREMOVE",
+	RECV_ID_EVENT_FILENAME:                      "EVENT_FILENAME",
+	RECV_ID_EVENT_FRAME:                         "EVENT_FRAME",
+	RECV_ID_SIMOBJECT_DATA:                      "SIMOBJECT_DATA",
+	RECV_ID_SIMOBJECT_DATA_BYTYPE:               "SIMOBJECT_DATA_BYTYPE",
+	RECV_ID_WEATHER_OBSERVATION:                 "WEATHER_OBSERVATION",
+	RECV_ID_CLOUD_STATE:                         "CLOUD_STATE",
+	RECV_ID_ASSIGNED_OBJECT_ID:                  "ASSIGNED_OBJECT_ID",
+	RECV_ID_RESERVED_KEY:                        "RESERVED_KEY",
+	RECV_ID_CUSTOM_ACTION:                       "CUSTOM_ACTION",
+	RECV_ID_SYSTEM_STATE:                        "SYSTEM_STATE",
+	RECV_ID_CLIENT_DATA:                         "CLIENT_DATA",
+	RECV_ID_EVENT_WEATHER_MODE:                  "EVENT_WEATHER_MODE",
+	RECV_ID_AIRPORT_LIST:                        "AIRPORT_LIST",
+	RECV_ID_VOR_LIST:                            "VOR_LIST",
+	RECV_ID_NDB_LIST:                            "NDB_LIST",
+	RECV_ID_WAYPOINT_LIST:                       "WAYPOINT_LIST",
+	RECV_ID_EVENT_MULTIPLAYER_SERVER_STARTED:    "EVENT_MULTIPLAYER_SERVER_STARTED",
+	RECV_ID_EVENT_MULTIPLAYER_CLIENT_STARTED:    "EVENT_MULTIPLAYER_CLIENT_STARTED",
+	RECV_ID_EVENT_MULTIPLAYER_SESSION_ENDED:     "EVENT_MULTIPLAYER_SESSION_ENDED",
+	RECV_ID_EVENT_RACE_END:                      "EVENT_RACE_END",
+	RECV_ID_EVENT_RACE_LAP:                      "EVENT_RACE_LAP",
+	RECV_ID_EVENT_EX1:                           "EVENT_EX1",
+	RECV_ID_FACILITY_DATA:                       "FACILITY_DATA",
+	RECV_ID_FACILITY_DATA_END:                   "FACILITY_DATA_END",
+	RECV_ID_FACILITY_MINIMAL_LIST:               "FACILITY_MINIMAL_LIST",
+	RECV_ID_JETWAY_DATA:                         "JETWAY_DATA",
+	RECV_ID_CONTROLLERS_LIST:                    "CONTROLLERS_LIST",
+	RECV_ID_ACTION_CALLBACK:                     "ACTION_CALLBACK",
+	RECV_ID_ENUMERATE_INPUT_EVENTS:              "ENUMERATE_INPUT_EVENTS",
+	RECV_ID_GET_INPUT_EVENT:                     "GET_INPUT_EVENT",
+	RECV_ID_SUBSCRIBE_INPUT_EVENT:               "SUBSCRIBE_INPUT_EVENT",
+	RECV_ID_ENUMERATE_INPUT_EVENT_PARAMS:        "ENUMERATE_INPUT_EVENT_PARAMS",
+	RECV_ID_ENUMERATE_SIMOBJECT_AND_LIVERY_LIST: "ENUMERATE_SIMOBJECT_AND_LIVERY_LIST",
+	RECV_ID_FLOW_EVENT:                          "FLOW_EVENT",
+}
+
+// RecvIDName returns the name of a RECV_ID, without the RECV_ID_ prefix
+func RecvIDName(id DWORD) string {
+	if name, ok := recvIDNames[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("RECV_ID(%d)", id)
+}
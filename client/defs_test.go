@@ -0,0 +1,19 @@
+package client_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+func TestRecvIDName(t *testing.T) {
+	for id := client.RECV_ID_NULL; id <= client.RECV_ID_FLOW_EVENT; id++ {
+		if name := client.RecvIDName(id); strings.HasPrefix(name, "RECV_ID(") {
+			t.Errorf("RECV_ID %d has no name", id)
+		}
+	}
+	if name := client.RecvIDName(client.RECV_ID_FLOW_EVENT + 1); name != "RECV_ID(40)" {
+		t.Errorf("unknown RECV_ID named %q", name)
+	}
+}
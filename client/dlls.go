@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sync"
 	"syscall"
 
 	_ "embed"
@@ -85,25 +86,101 @@ func getFilePath() (string, error) {
 	return dllPath, nil
 }
 
+// Proc is a procedure in the SimConnect DLL
+// *syscall.LazyProc satisfies it; tests swap in fakes with WithProcs
+type Proc interface {
+	Call(a ...uintptr) (r1, r2 uintptr, lastErr error)
+}
+
+// ErrUnsupported is returned by calls the loaded dll does not export, eg
+// the MSFS 2024 calls with the bundled MSFS 2020 dll
+const ErrUnsupported ClientError = "not exported by the SimConnect dll"
+
+// available returns ErrUnsupported if the proc is missing from the dll
+// a LazyProc panics when a missing function is called
+func available(p Proc) error {
+	if f, ok := p.(interface{ Find() error }); ok && f.Find() != nil {
+		return ErrUnsupported
+	}
+	return nil
+}
+
 type dll struct {
-	proc_SimConnect_Open                              *syscall.LazyProc
-	proc_SimConnect_Close                             *syscall.LazyProc
-	proc_SimConnect_AddToDataDefinition               *syscall.LazyProc
-	proc_SimConnect_SubscribeToSystemEvent            *syscall.LazyProc
-	proc_SimConnect_GetNextDispatch                   *syscall.LazyProc
-	proc_SimConnect_RequestDataOnSimObject            *syscall.LazyProc
-	proc_SimConnect_RequestDataOnSimObjectType        *syscall.LazyProc
-	proc_SimConnect_SetDataOnSimObject                *syscall.LazyProc
-	proc_SimConnect_SubscribeToFacilities             *syscall.LazyProc
-	proc_SimConnect_UnsubscribeToFacilities           *syscall.LazyProc
-	proc_SimConnect_RequestFacilitiesList             *syscall.LazyProc
-	proc_SimConnect_MapClientEventToSimEvent          *syscall.LazyProc
-	proc_SimConnect_MenuAddItem                       *syscall.LazyProc
-	proc_SimConnect_MenuDeleteItem                    *syscall.LazyProc
-	proc_SimConnect_AddClientEventToNotificationGroup *syscall.LazyProc
-	proc_SimConnect_SetNotificationGroupPriority      *syscall.LazyProc
-	proc_SimConnect_Text                              *syscall.LazyProc
-	proc_SimConnect_TransmitClientEvent               *syscall.LazyProc
+	proc_SimConnect_Open                                  Proc
+	proc_SimConnect_Close                                 Proc
+	proc_SimConnect_AddToDataDefinition                   Proc
+	proc_SimConnect_SubscribeToSystemEvent                Proc
+	proc_SimConnect_GetNextDispatch                       Proc
+	proc_SimConnect_RequestDataOnSimObject                Proc
+	proc_SimConnect_RequestDataOnSimObjectType            Proc
+	proc_SimConnect_SetDataOnSimObject                    Proc
+	proc_SimConnect_SubscribeToFacilities                 Proc
+	proc_SimConnect_UnsubscribeToFacilities               Proc
+	proc_SimConnect_RequestFacilitiesList                 Proc
+	proc_SimConnect_MapClientEventToSimEvent              Proc
+	proc_SimConnect_MenuAddItem                           Proc
+	proc_SimConnect_MenuDeleteItem                        Proc
+	proc_SimConnect_MenuAddSubItem                        Proc
+	proc_SimConnect_MenuDeleteSubItem                     Proc
+	proc_SimConnect_AddClientEventToNotificationGroup     Proc
+	proc_SimConnect_SetNotificationGroupPriority          Proc
+	proc_SimConnect_RemoveClientEvent                     Proc
+	proc_SimConnect_ClearNotificationGroup                Proc
+	proc_SimConnect_RequestNotificationGroup              Proc
+	proc_SimConnect_Text                                  Proc
+	proc_SimConnect_TransmitClientEvent                   Proc
+	proc_SimConnect_AddToFacilityDefinition               Proc
+	proc_SimConnect_RequestFacilityData                   Proc
+	proc_SimConnect_AICreateSimulatedObject               Proc
+	proc_SimConnect_AIRemoveObject                        Proc
+	proc_SimConnect_AddFacilityDataDefinitionFilter       Proc
+	proc_SimConnect_ClearAllFacilityDataDefinitionFilters Proc
+	proc_SimConnect_ExecuteAction                         Proc
+	proc_SimConnect_EnumerateSimObjectsAndLiveries        Proc
+	proc_SimConnect_EnumerateControllers                  Proc
+	proc_SimConnect_EnumerateInputEvents                  Proc
+	proc_SimConnect_GetInputEvent                         Proc
+	proc_SimConnect_SetInputEvent                         Proc
+	proc_SimConnect_SubscribeInputEvent                   Proc
+	proc_SimConnect_UnsubscribeInputEvent                 Proc
+	proc_SimConnect_EnumerateInputEventParams             Proc
+	proc_SimConnect_RequestJetwayData                     Proc
+	proc_SimConnect_RequestFacilityData_EX1               Proc
+	proc_SimConnect_RequestReservedKey                    Proc
+	proc_SimConnect_CameraSetRelative6DOF                 Proc
+	proc_SimConnect_AIReleaseControl                      Proc
+	proc_SimConnect_AISetAircraftFlightPlan               Proc
+	proc_SimConnect_GetLastSentPacketID                   Proc
+	proc_SimConnect_AICreateNonATCAircraft                Proc
+	proc_SimConnect_AICreateNonATCAircraft_EX1            Proc
+	proc_SimConnect_AICreateParkedATCAircraft             Proc
+	proc_SimConnect_AICreateEnrouteATCAircraft            Proc
+	proc_SimConnect_WeatherRequestObservationAtStation    Proc
+	proc_SimConnect_WeatherSetObservation                 Proc
+	proc_SimConnect_WeatherSetModeCustom                  Proc
+	proc_SimConnect_WeatherSetModeTheme                   Proc
+	proc_SimConnect_WeatherRequestCloudState              Proc
+	proc_SimConnect_ExecuteMissionAction                  Proc
+	proc_SimConnect_CompleteCustomMissionAction           Proc
+	proc_SimConnect_MapInputEventToClientEvent            Proc
+	proc_SimConnect_SetInputGroupPriority                 Proc
+	proc_SimConnect_SetInputGroupState                    Proc
+	proc_SimConnect_RemoveInputEvent                      Proc
+	proc_SimConnect_ClearInputGroup                       Proc
+	proc_SimConnect_ClearDataDefinition                   Proc
+	proc_SimConnect_RequestSystemState                    Proc
+	proc_SimConnect_SetSystemState                        Proc
+	proc_SimConnect_MapClientDataNameToID                 Proc
+	proc_SimConnect_CreateClientData                      Proc
+	proc_SimConnect_AddToClientDataDefinition             Proc
+	proc_SimConnect_ClearClientDataDefinition             Proc
+	proc_SimConnect_RequestClientData                     Proc
+	proc_SimConnect_SetClientData                         Proc
+
+	// find and procs serve Call, looking up the procs the client does not wrap
+	find  func(name string) Proc
+	mu    sync.Mutex
+	procs map[string]Proc
 }
 
 func newDLL(path string) (*dll, error) {
@@ -112,25 +189,98 @@ func newDLL(path string) (*dll, error) {
 		return nil, err
 	}
 
+	return loadProcs(func(name string) Proc { return mod.NewProc(name) }), nil
+}
+
+// loadProcs builds the dll from a proc lookup function
+// every call into SimConnect goes through the returned procs, so a fake
+// lookup can record calls and return canned results
+func loadProcs(find func(name string) Proc) *dll {
 	return &dll{
-		proc_SimConnect_Open:                              mod.NewProc("SimConnect_Open"),
-		proc_SimConnect_Close:                             mod.NewProc("SimConnect_Close"),
-		proc_SimConnect_AddToDataDefinition:               mod.NewProc("SimConnect_AddToDataDefinition"),
-		proc_SimConnect_SubscribeToSystemEvent:            mod.NewProc("SimConnect_SubscribeToSystemEvent"),
-		proc_SimConnect_GetNextDispatch:                   mod.NewProc("SimConnect_GetNextDispatch"),
-		proc_SimConnect_RequestDataOnSimObject:            mod.NewProc("SimConnect_RequestDataOnSimObject"),
-		proc_SimConnect_RequestDataOnSimObjectType:        mod.NewProc("SimConnect_RequestDataOnSimObjectType"),
-		proc_SimConnect_SetDataOnSimObject:                mod.NewProc("SimConnect_SetDataOnSimObject"),
-		proc_SimConnect_SubscribeToFacilities:             mod.NewProc("SimConnect_SubscribeToFacilities"),
-		proc_SimConnect_UnsubscribeToFacilities:           mod.NewProc("SimConnect_UnsubscribeToFacilities"),
-		proc_SimConnect_RequestFacilitiesList:             mod.NewProc("SimConnect_RequestFacilitiesList"),
-		proc_SimConnect_MapClientEventToSimEvent:          mod.NewProc("SimConnect_MapClientEventToSimEvent"),
-		proc_SimConnect_MenuAddItem:                       mod.NewProc("SimConnect_MenuAddItem"),
-		proc_SimConnect_MenuDeleteItem:                    mod.NewProc("SimConnect_MenuDeleteItem"),
-		proc_SimConnect_AddClientEventToNotificationGroup: mod.NewProc("SimConnect_AddClientEventToNotificationGroup"),
-		proc_SimConnect_SetNotificationGroupPriority:      mod.NewProc("SimConnect_SetNotificationGroupPriority"),
-		proc_SimConnect_Text:                              mod.NewProc("SimConnect_Text"),
-		proc_SimConnect_TransmitClientEvent:               mod.NewProc("SimConnect_TransmitClientEvent"),
-	}, nil
+		proc_SimConnect_Open:                                  find("SimConnect_Open"),
+		proc_SimConnect_Close:                                 find("SimConnect_Close"),
+		proc_SimConnect_AddToDataDefinition:                   find("SimConnect_AddToDataDefinition"),
+		proc_SimConnect_SubscribeToSystemEvent:                find("SimConnect_SubscribeToSystemEvent"),
+		proc_SimConnect_GetNextDispatch:                       find("SimConnect_GetNextDispatch"),
+		proc_SimConnect_RequestDataOnSimObject:                find("SimConnect_RequestDataOnSimObject"),
+		proc_SimConnect_RequestDataOnSimObjectType:            find("SimConnect_RequestDataOnSimObjectType"),
+		proc_SimConnect_SetDataOnSimObject:                    find("SimConnect_SetDataOnSimObject"),
+		proc_SimConnect_SubscribeToFacilities:                 find("SimConnect_SubscribeToFacilities"),
+		proc_SimConnect_UnsubscribeToFacilities:               find("SimConnect_UnsubscribeToFacilities"),
+		proc_SimConnect_RequestFacilitiesList:                 find("SimConnect_RequestFacilitiesList"),
+		proc_SimConnect_MapClientEventToSimEvent:              find("SimConnect_MapClientEventToSimEvent"),
+		proc_SimConnect_MenuAddItem:                           find("SimConnect_MenuAddItem"),
+		proc_SimConnect_MenuDeleteItem:                        find("SimConnect_MenuDeleteItem"),
+		proc_SimConnect_MenuAddSubItem:                        find("SimConnect_MenuAddSubItem"),
+		proc_SimConnect_MenuDeleteSubItem:                     find("SimConnect_MenuDeleteSubItem"),
+		proc_SimConnect_AddClientEventToNotificationGroup:     find("SimConnect_AddClientEventToNotificationGroup"),
+		proc_SimConnect_SetNotificationGroupPriority:          find("SimConnect_SetNotificationGroupPriority"),
+		proc_SimConnect_RemoveClientEvent:                     find("SimConnect_RemoveClientEvent"),
+		proc_SimConnect_ClearNotificationGroup:                find("SimConnect_ClearNotificationGroup"),
+		proc_SimConnect_RequestNotificationGroup:              find("SimConnect_RequestNotificationGroup"),
+		proc_SimConnect_Text:                                  find("SimConnect_Text"),
+		proc_SimConnect_TransmitClientEvent:                   find("SimConnect_TransmitClientEvent"),
+		proc_SimConnect_AddToFacilityDefinition:               find("SimConnect_AddToFacilityDefinition"),
+		proc_SimConnect_RequestFacilityData:                   find("SimConnect_RequestFacilityData"),
+		proc_SimConnect_AICreateSimulatedObject:               find("SimConnect_AICreateSimulatedObject"),
+		proc_SimConnect_AIRemoveObject:                        find("SimConnect_AIRemoveObject"),
+		proc_SimConnect_AddFacilityDataDefinitionFilter:       find("SimConnect_AddFacilityDataDefinitionFilter"),
+		proc_SimConnect_ClearAllFacilityDataDefinitionFilters: find("SimConnect_ClearAllFacilityDataDefinitionFilters"),
+		proc_SimConnect_ExecuteAction:                         find("SimConnect_ExecuteAction"),
+		proc_SimConnect_EnumerateSimObjectsAndLiveries:        find("SimConnect_EnumerateSimObjectsAndLiveries"),
+		proc_SimConnect_EnumerateControllers:                  find("SimConnect_EnumerateControllers"),
+		proc_SimConnect_EnumerateInputEvents:                  find("SimConnect_EnumerateInputEvents"),
+		proc_SimConnect_GetInputEvent:                         find("SimConnect_GetInputEvent"),
+		proc_SimConnect_SetInputEvent:                         find("SimConnect_SetInputEvent"),
+		proc_SimConnect_SubscribeInputEvent:                   find("SimConnect_SubscribeInputEvent"),
+		proc_SimConnect_UnsubscribeInputEvent:                 find("SimConnect_UnsubscribeInputEvent"),
+		proc_SimConnect_EnumerateInputEventParams:             find("SimConnect_EnumerateInputEventParams"),
+		proc_SimConnect_RequestJetwayData:                     find("SimConnect_RequestJetwayData"),
+		proc_SimConnect_RequestFacilityData_EX1:               find("SimConnect_RequestFacilityData_EX1"),
+		proc_SimConnect_RequestReservedKey:                    find("SimConnect_RequestReservedKey"),
+		proc_SimConnect_CameraSetRelative6DOF:                 find("SimConnect_CameraSetRelative6DOF"),
+		proc_SimConnect_AIReleaseControl:                      find("SimConnect_AIReleaseControl"),
+		proc_SimConnect_AISetAircraftFlightPlan:               find("SimConnect_AISetAircraftFlightPlan"),
+		proc_SimConnect_GetLastSentPacketID:                   find("SimConnect_GetLastSentPacketID"),
+		proc_SimConnect_AICreateNonATCAircraft:                find("SimConnect_AICreateNonATCAircraft"),
+		proc_SimConnect_AICreateNonATCAircraft_EX1:            find("SimConnect_AICreateNonATCAircraft_EX1"),
+		proc_SimConnect_AICreateParkedATCAircraft:             find("SimConnect_AICreateParkedATCAircraft"),
+		proc_SimConnect_AICreateEnrouteATCAircraft:            find("SimConnect_AICreateEnrouteATCAircraft"),
+		proc_SimConnect_WeatherRequestObservationAtStation:    find("SimConnect_WeatherRequestObservationAtStation"),
+		proc_SimConnect_WeatherSetObservation:                 find("SimConnect_WeatherSetObservation"),
+		proc_SimConnect_WeatherSetModeCustom:                  find("SimConnect_WeatherSetModeCustom"),
+		proc_SimConnect_WeatherSetModeTheme:                   find("SimConnect_WeatherSetModeTheme"),
+		proc_SimConnect_WeatherRequestCloudState:              find("SimConnect_WeatherRequestCloudState"),
+		proc_SimConnect_ExecuteMissionAction:                  find("SimConnect_ExecuteMissionAction"),
+		proc_SimConnect_CompleteCustomMissionAction:           find("SimConnect_CompleteCustomMissionAction"),
+		proc_SimConnect_MapInputEventToClientEvent:            find("SimConnect_MapInputEventToClientEvent"),
+		proc_SimConnect_SetInputGroupPriority:                 find("SimConnect_SetInputGroupPriority"),
+		proc_SimConnect_SetInputGroupState:                    find("SimConnect_SetInputGroupState"),
+		proc_SimConnect_RemoveInputEvent:                      find("SimConnect_RemoveInputEvent"),
+		proc_SimConnect_ClearInputGroup:                       find("SimConnect_ClearInputGroup"),
+		proc_SimConnect_ClearDataDefinition:                   find("SimConnect_ClearDataDefinition"),
+		proc_SimConnect_RequestSystemState:                    find("SimConnect_RequestSystemState"),
+		proc_SimConnect_SetSystemState:                        find("SimConnect_SetSystemState"),
+		proc_SimConnect_MapClientDataNameToID:                 find("SimConnect_MapClientDataNameToID"),
+		proc_SimConnect_CreateClientData:                      find("SimConnect_CreateClientData"),
+		proc_SimConnect_AddToClientDataDefinition:             find("SimConnect_AddToClientDataDefinition"),
+		proc_SimConnect_ClearClientDataDefinition:             find("SimConnect_ClearClientDataDefinition"),
+		proc_SimConnect_RequestClientData:                     find("SimConnect_RequestClientData"),
+		proc_SimConnect_SetClientData:                         find("SimConnect_SetClientData"),
 
+		find:  find,
+		procs: map[string]Proc{},
+	}
+}
+
+// lookup returns a proc by name, looking it up on first use
+func (d *dll) lookup(name string) Proc {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p, ok := d.procs[name]
+	if !ok {
+		p = d.find(name)
+		d.procs[name] = p
+	}
+	return p
 }
@@ -0,0 +1,36 @@
+package client
+
+import "unsafe"
+
+// WithDryRun logs writes to the sim instead of sending them
+// SetDataOnSimObject, TransmitClientEvent, SetClientData and SetSystemState
+// return nil without calling the sim; reads, definitions and subscriptions
+// work as normal, so automation can be checked against a live flight
+func WithDryRun() SimConnectOption {
+	return func(s *SimConnect) {
+		s.dryRun = true
+	}
+}
+
+// DryRun returns true if writes are logged instead of sent
+func (s *SimConnect) DryRun() bool {
+	return s.dryRun
+}
+
+// dryRunData logs a data write, decoding float64 datums by name
+func (s *SimConnect) dryRunData(defineID, objectID, size DWORD, buf unsafe.Pointer) {
+	w := s.newWrite(defineID, objectID, size, buf)
+	values := make([]any, 0, 2*len(w.Datums))
+	for i, d := range w.Datums {
+		values = append(values, d.Name, w.Values[i])
+	}
+	s.log.Info("Dry run: not setting data", append([]any{"defineID", defineID, "objectID", objectID, "bytes", size}, values...)...)
+}
+
+// dryRunEvent logs an event transmission
+func (s *SimConnect) dryRunEvent(objectID, eventID, data DWORD) {
+	s.mu.Lock()
+	name := s.eventNames[eventID]
+	s.mu.Unlock()
+	s.log.Info("Dry run: not transmitting event", "event", name, "eventID", eventID, "objectID", objectID, "data", int32(data))
+}
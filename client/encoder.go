@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// encoder writes the float64 fields of a struct type into a reusable buffer
+// encoders are cached per type, and each keeps a pool of buffers sized for
+// its definition, and one of array buffers grown as needed, so hot SetData
+// paths don't allocate
+type encoder struct {
+	fields []int
+	pool   sync.Pool
+	arrays sync.Pool
+}
+
+var encoders sync.Map // map[reflect.Type]*encoder
+
+func encoderFor(typ reflect.Type) (*encoder, error) {
+	if e, ok := encoders.Load(typ); ok {
+		return e.(*encoder), nil
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("not a struct: %s", typ.Kind().String())
+	}
+	e := &encoder{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := field.Tag.Get("name")
+		if name == "" {
+			continue
+		}
+		if field.Type.Kind() != reflect.Float64 {
+			return nil, fmt.Errorf("not a float64: %s -- %s", field.Name, field.Type.Kind().String())
+		}
+		e.fields = append(e.fields, i)
+	}
+	if len(e.fields) == 0 {
+		return nil, fmt.Errorf("no fields with name tags: %s", typ.Name())
+	}
+	n := len(e.fields)
+	e.pool.New = func() any {
+		buf := make([]float64, n)
+		return &buf
+	}
+	e.arrays.New = func() any {
+		return new([]float64)
+	}
+	actual, _ := encoders.LoadOrStore(typ, e)
+	return actual.(*encoder), nil
+}
+
+// encode fills a pooled buffer from val; release it with put once the call returns
+func (e *encoder) encode(val reflect.Value) *[]float64 {
+	buf := e.pool.Get().(*[]float64)
+	e.encodeInto(*buf, val)
+	return buf
+}
+
+// encodeInto writes the fields of val to dst, one unit long
+func (e *encoder) encodeInto(dst []float64, val reflect.Value) {
+	for j, i := range e.fields {
+		dst[j] = val.Field(i).Float()
+	}
+}
+
+// array returns a pooled buffer of n values for an array of units, growing
+// it if the one reused is too small; release it with putArray
+func (e *encoder) array(n int) *[]float64 {
+	buf := e.arrays.Get().(*[]float64)
+	if cap(*buf) < n {
+		*buf = make([]float64, n)
+	}
+	*buf = (*buf)[:n]
+	return buf
+}
+
+func (e *encoder) putArray(buf *[]float64) {
+	e.arrays.Put(buf)
+}
+
+func (e *encoder) put(buf *[]float64) {
+	e.pool.Put(buf)
+}
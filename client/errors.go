@@ -5,7 +5,11 @@ import (
 )
 
 func (e RecvException) Error() string {
-	return fmt.Sprintf("Exception (%d), ReqID (%d): %#v", e.Exception, e.SendID, e)
+	id := RecvExceptionID(e.Exception)
+	if e.Index == UNKNOWN_INDEX {
+		return fmt.Sprintf("%s: %s (send %d)", id, id.Explain(), e.SendID)
+	}
+	return fmt.Sprintf("%s: parameter %d: %s (send %d)", id, e.Index, id.Explain(), e.SendID)
 }
 
 func (e RecvOpen) Error() string {
@@ -60,3 +64,72 @@ const (
 	SIMCONNECT_EXCEPTION_OBJECT_ATC                                        = 36
 	SIMCONNECT_EXCEPTION_OBJECT_SCHEDULE                                   = 37
 )
+
+// UNKNOWN_INDEX is the Index of an exception not tied to a parameter
+const UNKNOWN_INDEX DWORD = 0xFFFFFFFF
+
+// ExceptionDocs is the SDK page describing the exceptions
+const ExceptionDocs = "https://docs.flightsimulator.com/html/Programming_Tools/SimConnect/API_Reference/Structures_And_Enumerations/SIMCONNECT_EXCEPTION.htm"
+
+type exceptionInfo struct {
+	name    string
+	explain string
+}
+
+var exceptionInfos = map[RecvExceptionID]exceptionInfo{
+	SIMCONNECT_EXCEPTION_NONE:                              {"NONE", "no error"},
+	SIMCONNECT_EXCEPTION_ERROR:                             {"ERROR", "unspecific error in the sim"},
+	SIMCONNECT_EXCEPTION_SIZE_MISMATCH:                     {"SIZE_MISMATCH", "the data size does not match the definition"},
+	SIMCONNECT_EXCEPTION_UNRECOGNIZED_ID:                   {"UNRECOGNIZED_ID", "the client event, request, definition or object ID is not known"},
+	SIMCONNECT_EXCEPTION_UNOPENED:                          {"UNOPENED", "the connection is not open"},
+	SIMCONNECT_EXCEPTION_VERSION_MISMATCH:                  {"VERSION_MISMATCH", "the client and sim SimConnect versions differ"},
+	SIMCONNECT_EXCEPTION_TOO_MANY_GROUPS:                   {"TOO_MANY_GROUPS", "the limit of notification or input groups was reached"},
+	SIMCONNECT_EXCEPTION_NAME_UNRECOGNIZED:                 {"NAME_UNRECOGNIZED", "the event, variable or unit name is not known"},
+	SIMCONNECT_EXCEPTION_TOO_MANY_EVENT_NAMES:              {"TOO_MANY_EVENT_NAMES", "the limit of mapped event names was reached"},
+	SIMCONNECT_EXCEPTION_EVENT_ID_DUPLICATE:                {"EVENT_ID_DUPLICATE", "the client event ID is already in use"},
+	SIMCONNECT_EXCEPTION_TOO_MANY_MAPS:                     {"TOO_MANY_MAPS", "the limit of input event maps was reached"},
+	SIMCONNECT_EXCEPTION_TOO_MANY_OBJECTS:                  {"TOO_MANY_OBJECTS", "the limit of objects was reached"},
+	SIMCONNECT_EXCEPTION_TOO_MANY_REQUESTS:                 {"TOO_MANY_REQUESTS", "the limit of outstanding requests was reached"},
+	SIMCONNECT_EXCEPTION_WEATHER_INVALID_PORT:              {"WEATHER_INVALID_PORT", "invalid weather port"},
+	SIMCONNECT_EXCEPTION_WEATHER_INVALID_METAR:             {"WEATHER_INVALID_METAR", "the METAR string is malformed"},
+	SIMCONNECT_EXCEPTION_WEATHER_UNABLE_TO_GET_OBSERVATION: {"WEATHER_UNABLE_TO_GET_OBSERVATION", "no weather observation for the station"},
+	SIMCONNECT_EXCEPTION_WEATHER_UNABLE_TO_CREATE_STATION:  {"WEATHER_UNABLE_TO_CREATE_STATION", "the weather station could not be created"},
+	SIMCONNECT_EXCEPTION_WEATHER_UNABLE_TO_REMOVE_STATION:  {"WEATHER_UNABLE_TO_REMOVE_STATION", "the weather station could not be removed"},
+	SIMCONNECT_EXCEPTION_INVALID_DATA_TYPE:                 {"INVALID_DATA_TYPE", "the data type is not valid for the variable"},
+	SIMCONNECT_EXCEPTION_INVALID_DATA_SIZE:                 {"INVALID_DATA_SIZE", "the data size is not valid"},
+	SIMCONNECT_EXCEPTION_DATA_ERROR:                        {"DATA_ERROR", "generic data error"},
+	SIMCONNECT_EXCEPTION_INVALID_ARRAY:                     {"INVALID_ARRAY", "the data array is not valid"},
+	SIMCONNECT_EXCEPTION_CREATE_OBJECT_FAILED:              {"CREATE_OBJECT_FAILED", "the AI object could not be created"},
+	SIMCONNECT_EXCEPTION_LOAD_FLIGHTPLAN_FAILED:            {"LOAD_FLIGHTPLAN_FAILED", "the flight plan could not be found or loaded"},
+	SIMCONNECT_EXCEPTION_OPERATION_INVALID_FOR_OJBECT_TYPE: {"OPERATION_INVALID_FOR_OBJECT_TYPE", "the operation does not apply to the object type"},
+	SIMCONNECT_EXCEPTION_ILLEGAL_OPERATION:                 {"ILLEGAL_OPERATION", "the operation is not allowed"},
+	SIMCONNECT_EXCEPTION_ALREADY_SUBSCRIBED:                {"ALREADY_SUBSCRIBED", "the client is already subscribed to the event"},
+	SIMCONNECT_EXCEPTION_INVALID_ENUM:                      {"INVALID_ENUM", "the enum value is out of range"},
+	SIMCONNECT_EXCEPTION_DEFINITION_ERROR:                  {"DEFINITION_ERROR", "the data definition is wrong, eg a variable is not settable"},
+	SIMCONNECT_EXCEPTION_DUPLICATE_ID:                      {"DUPLICATE_ID", "the ID is already in use"},
+	SIMCONNECT_EXCEPTION_DATUM_ID:                          {"DATUM_ID", "the datum ID is not recognised"},
+	SIMCONNECT_EXCEPTION_OUT_OF_BOUNDS:                     {"OUT_OF_BOUNDS", "a value is out of range"},
+	SIMCONNECT_EXCEPTION_ALREADY_CREATED:                   {"ALREADY_CREATED", "the client data area or object already exists"},
+	SIMCONNECT_EXCEPTION_OBJECT_OUTSIDE_REALITY_BUBBLE:     {"OBJECT_OUTSIDE_REALITY_BUBBLE", "the object is outside the reality bubble"},
+	SIMCONNECT_EXCEPTION_OBJECT_CONTAINER:                  {"OBJECT_CONTAINER", "error in the object's container"},
+	SIMCONNECT_EXCEPTION_OBJECT_AI:                         {"OBJECT_AI", "error in the object's AI"},
+	SIMCONNECT_EXCEPTION_OBJECT_ATC:                        {"OBJECT_ATC", "error in the object's ATC"},
+	SIMCONNECT_EXCEPTION_OBJECT_SCHEDULE:                   {"OBJECT_SCHEDULE", "error in the object's schedule"},
+}
+
+// String returns the exception name without its SIMCONNECT_EXCEPTION_ prefix
+func (id RecvExceptionID) String() string {
+	if info, ok := exceptionInfos[id]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("EXCEPTION_%d", uint32(id))
+}
+
+// Explain returns a short explanation of the exception
+// see ExceptionDocs for the full descriptions
+func (id RecvExceptionID) Explain() string {
+	if info, ok := exceptionInfos[id]; ok {
+		return info.explain
+	}
+	return "unknown exception"
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MapClientEventByName maps a sim event to a new client event ID and remembers it by name
+// mapping the same name again returns the existing ID
+func (s *SimConnect) MapClientEventByName(eventName string) (DWORD, error) {
+	s.mu.Lock()
+	id, ok := s.clientEvents[eventName]
+	s.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+	id = s.GetEventID()
+	if err := s.MapClientEventToSimEvent(id, eventName); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	s.clientEvents[eventName] = id
+	s.mu.Unlock()
+	return id, nil
+}
+
+// ClientEventID returns the ID of a sim event mapped with MapClientEventByName
+func (s *SimConnect) ClientEventID(eventName string) (DWORD, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.clientEvents[eventName]
+	return id, ok
+}
+
+// SubscribeToSystemEventByName subscribes to a system event with a new event ID and remembers it by name
+// subscribing to the same name again returns the existing ID
+func (s *SimConnect) SubscribeToSystemEventByName(eventName string) (DWORD, error) {
+	s.mu.Lock()
+	id, ok := s.systemEvents[eventName]
+	s.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+	id = s.GetEventID()
+	if err := s.SubscribeToSystemEvent(id, eventName); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	s.systemEvents[eventName] = id
+	s.mu.Unlock()
+	return id, nil
+}
+
+// SystemEventID returns the ID of a system event subscribed with SubscribeToSystemEventByName
+func (s *SimConnect) SystemEventID(eventName string) (DWORD, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.systemEvents[eventName]
+	return id, ok
+}
+
+// claimEventID checks that an event ID is not already used for another
+// event on this connection; IDs picked by hand are reserved, so
+// GetEventID never hands them out
+func (s *SimConnect) claimEventID(eventID DWORD, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prev, ok := s.eventNames[eventID]; ok && prev != name {
+		return fmt.Errorf("%w: event ID %d is %q, not %q", ErrEventIDCollision, eventID, prev, name)
+	}
+	if eventID >= s.lastEventID {
+		s.lastEventID = eventID + 1
+	}
+	return nil
+}
+
+// Event is a sim event that can be kept across connections
+// it is mapped by name on each connection when first used there, so an ID
+// from an earlier connection is never sent on a later one, where it may
+// belong to another event
+type Event struct {
+	Name string
+
+	mu sync.Mutex
+	sc *SimConnect
+	id DWORD
+}
+
+// NewEvent creates a handle for the named sim event
+func NewEvent(name string) *Event {
+	return &Event{Name: name}
+}
+
+// ID returns the event's ID on the connection, mapping it if needed
+func (e *Event) ID(s *SimConnect) (DWORD, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.sc == s {
+		return e.id, nil
+	}
+	id, err := s.MapClientEventByName(e.Name)
+	if err != nil {
+		return 0, err
+	}
+	e.sc, e.id = s, id
+	return id, nil
+}
+
+// Is returns true if the event was received for this handle on the connection
+func (e *Event) Is(s *SimConnect, ev *RecvEvent) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.sc == s && e.id == ev.EventID
+}
+
+// Transmit sends the event to the user's aircraft at the highest priority
+func (e *Event) Transmit(s *SimConnect, data DWORD) error {
+	id, err := e.ID(s)
+	if err != nil {
+		return err
+	}
+	return s.TransmitClientEvent(OBJECT_ID_USER, id, data, GROUP_PRIORITY_HIGHEST, EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+const (
+	// ErrLoadFlightPlanFailed is the error of a LOAD_FLIGHTPLAN_FAILED exception
+	ErrLoadFlightPlanFailed ClientError = "load flight plan failed"
+	// ErrCreateObjectFailed is the error of a CREATE_OBJECT_FAILED exception
+	ErrCreateObjectFailed ClientError = "create object failed"
+	// ErrNameUnrecognized is the error of a NAME_UNRECOGNIZED exception
+	ErrNameUnrecognized ClientError = "name unrecognized"
+	// ErrDataError is the error of a DATA_ERROR exception, eg a write to a
+	// read-only simvar
+	ErrDataError ClientError = "data error"
+)
+
+var exceptionErrors = map[RecvExceptionID]error{
+	SIMCONNECT_EXCEPTION_LOAD_FLIGHTPLAN_FAILED: ErrLoadFlightPlanFailed,
+	SIMCONNECT_EXCEPTION_CREATE_OBJECT_FAILED:   ErrCreateObjectFailed,
+	SIMCONNECT_EXCEPTION_NAME_UNRECOGNIZED:      ErrNameUnrecognized,
+	SIMCONNECT_EXCEPTION_DATA_ERROR:             ErrDataError,
+}
+
+// Unwrap maps the exception to its package error, if it has one, so
+// errors.Is(err, ErrLoadFlightPlanFailed) works on dispatch errors
+func (e RecvException) Unwrap() error {
+	return exceptionErrors[RecvExceptionID(e.Exception)]
+}
+
+// ExceptionError is an exception tied back to the call that raised it
+type ExceptionError struct {
+	RecvException
+	// Call is the client call, eg "AddToDataDefinition"
+	Call string
+}
+
+func (e ExceptionError) Error() string {
+	id := RecvExceptionID(e.Exception)
+	if e.Index == UNKNOWN_INDEX {
+		return fmt.Sprintf("%s in %s: %s (send %d)", id, e.Call, id.Explain(), e.SendID)
+	}
+	return fmt.Sprintf("%s: parameter %d of %s: %s (send %d)", id, e.Index, e.Call, id.Explain(), e.SendID)
+}
+
+// Unwrap returns the exception, so errors.As still finds a RecvException
+func (e ExceptionError) Unwrap() error {
+	return e.RecvException
+}
+
+// ExplainException returns the exception as an ExceptionError when the
+// call that sent its packet is remembered, or the exception itself
+// only calls that go wrong on the sim side, eg definitions, requests and
+// event maps, are remembered
+func (s *SimConnect) ExplainException(ex RecvException) error {
+	s.mu.Lock()
+	call, ok := s.sentCalls[ex.SendID]
+	s.mu.Unlock()
+	if !ok {
+		return ex
+	}
+	return ExceptionError{RecvException: ex, Call: call}
+}
+
+// recordSend notes the call that sent the last packet
+func (s *SimConnect) recordSend(call string) {
+	sendID, err := s.GetLastSentPacketID()
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recordSendLocked(sendID, call)
+}
+
+func (s *SimConnect) recordSendLocked(sendID DWORD, call string) {
+	if len(s.sentCalls) >= maxDefinitionSends {
+		// packet IDs increase, so the oldest are the smallest
+		for id := range s.sentCalls {
+			if id+maxDefinitionSends/2 < sendID {
+				delete(s.sentCalls, id)
+			}
+		}
+	}
+	s.sentCalls[sendID] = call
+}
+
+func (s *SimConnect) GetLastSentPacketID() (DWORD, error) {
+	// SimConnect_GetLastSentPacketID(
+	//   HANDLE hSimConnect,
+	//   DWORD * pdwSendID
+	// );
+
+	var sendID DWORD
+	r1, _, err := s.dll.proc_SimConnect_GetLastSentPacketID.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&sendID)),
+	)
+	if int32(r1) < 0 {
+		return 0, fmt.Errorf("SimConnect_GetLastSentPacketID error: %d %s", r1, err)
+	}
+	return sendID, nil
+}
+
+// DeliverException hands an exception to a call waiting on its packet
+// it returns true if the exception was consumed; the connector calls this
+// before reporting the exception
+func (s *SimConnect) DeliverException(ex RecvException) bool {
+	s.completeSend(ex.SendID)
+	s.forgetRequestSend(ex.SendID)
+	s.mu.Lock()
+	ch, ok := s.sends[ex.SendID]
+	delete(s.sends, ex.SendID)
+	s.mu.Unlock()
+	if ok {
+		ch <- ex
+	}
+	return ok
+}
+
+// checkSend makes a call that has no reply on success and waits up to wait
+// for an exception naming its packet; no exception within wait is success
+// calls made by other goroutines in between can take the packet ID, so
+// this is a best effort check
+func (s *SimConnect) checkSend(ctx context.Context, wait time.Duration, send func() error) error {
+	if err := send(); err != nil {
+		return err
+	}
+	sendID, err := s.GetLastSentPacketID()
+	if err != nil {
+		return err
+	}
+	ch := make(chan RecvException, 1)
+	s.mu.Lock()
+	s.sends[sendID] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.sends, sendID)
+		s.mu.Unlock()
+	}()
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	case ex := <-ch:
+		return s.ExplainException(ex)
+	}
+}
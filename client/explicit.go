@@ -0,0 +1,159 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"unsafe"
+)
+
+// Explicit layouts declare where each field sits in the data SimConnect
+// sends, with offset tags counted from the start of the data and optional
+// size tags:
+//
+//	type Report struct {
+//		client.RecvSimobjectDataByType
+//		OnGround int32   `name:"SIM ON GROUND" unit:"Bool" offset:"0"`
+//		Altitude float64 `name:"PLANE ALTITUDE" unit:"Feet" offset:"4" size:"8"`
+//	}
+//
+// SimConnect packs datums without padding, so Altitude arrives at 4 but
+// sits at 8 in the Go struct; casting the message to the struct would read
+// garbage. The declaration is checked against the packed layout when the
+// struct is registered, and messages are decoded field by field.
+
+// explicitField places a field on the wire and in the Go struct
+type explicitField struct {
+	wire  uintptr
+	goOff uintptr
+	size  uintptr
+	index int
+}
+
+type explicitLayout struct {
+	fields []explicitField
+}
+
+// wireSize returns the packed size of a datum type
+func wireSize(dataType DWORD) uintptr {
+	return uintptr(dataTypeSizes[dataType])
+}
+
+// fieldDataType returns the datum type of a struct field
+func fieldDataType(f reflect.StructField) (DWORD, error) {
+	fieldType := f.Type.Kind().String()
+	if fieldType == "array" {
+		fieldType = fmt.Sprintf("[%d]byte", f.Type.Len())
+	}
+	return derefDataType(fieldType)
+}
+
+// explicitLayoutFor checks the offset and size tags of a struct
+// it returns nil for structs without offset tags, which use the Go layout
+func explicitLayoutFor(t reflect.Type) (*explicitLayout, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	declared := false
+	for j := 1; j < t.NumField(); j++ {
+		if _, ok := t.Field(j).Tag.Lookup("offset"); ok {
+			declared = true
+			break
+		}
+	}
+	if !declared {
+		return nil, nil
+	}
+
+	l := &explicitLayout{}
+	var wire uintptr
+	for j := 1; j < t.NumField(); j++ {
+		f := t.Field(j)
+		if _, ok := f.Tag.Lookup("name"); !ok {
+			continue
+		}
+		dataType, err := fieldDataType(f)
+		if err != nil {
+			return nil, err
+		}
+		size := wireSize(dataType)
+		if f.Type.Size() != size {
+			return nil, fmt.Errorf("%w: %s.%s is %d bytes, SimConnect sends %d", ErrLayoutMismatch, t.Name(), f.Name, f.Type.Size(), size)
+		}
+
+		offTag, ok := f.Tag.Lookup("offset")
+		if !ok {
+			return nil, fmt.Errorf("%w: %s.%s has no offset tag; declare every field or none", ErrLayoutMismatch, t.Name(), f.Name)
+		}
+		off, err := strconv.ParseUint(offTag, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s invalid offset tag: %w", f.Name, err)
+		}
+		if uintptr(off) != wire {
+			return nil, fmt.Errorf("%w: %s.%s is declared at offset %d, SimConnect sends it at %d", ErrLayoutMismatch, t.Name(), f.Name, off, wire)
+		}
+		if sizeTag, ok := f.Tag.Lookup("size"); ok {
+			n, err := strconv.ParseUint(sizeTag, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s invalid size tag: %w", f.Name, err)
+			}
+			if uintptr(n) != size {
+				return nil, fmt.Errorf("%w: %s.%s is declared as %d bytes, SimConnect sends %d", ErrLayoutMismatch, t.Name(), f.Name, n, size)
+			}
+		}
+		l.fields = append(l.fields, explicitField{wire: wire, goOff: f.Offset, size: size, index: j})
+		wire += size
+	}
+	return l, nil
+}
+
+// wireOffset returns the offset of field j from the start of the message
+func (l *explicitLayout) wireOffset(j int) (uintptr, bool) {
+	for _, f := range l.fields {
+		if f.index == j {
+			return unsafe.Sizeof(RecvSimobjectData{}) + f.wire, true
+		}
+	}
+	return 0, false
+}
+
+// Explicit returns true if the definition was registered from a struct with an explicit layout
+func (s *SimConnect) Explicit(defineID DWORD) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.explicit[defineID]
+	return ok
+}
+
+// DecodeExplicit copies a data message into out, a pointer to the struct
+// registered for its definition, following the struct's explicit layout
+// it returns false if the definition has no explicit layout, in which case
+// the message can be cast to the struct directly
+func (s *SimConnect) DecodeExplicit(ppData *RecvSimobjectDataByType, out any) bool {
+	s.mu.Lock()
+	l, ok := s.explicit[ppData.DefineID]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	base := v.UnsafePointer()
+	*(*RecvSimobjectDataByType)(base) = *ppData
+
+	data := ppData.DataPointer()
+	header := unsafe.Sizeof(ppData.RecvSimobjectData)
+	var avail uintptr
+	if uintptr(ppData.Size) > header {
+		avail = uintptr(ppData.Size) - header
+	}
+	for _, f := range l.fields {
+		if f.wire+f.size > avail {
+			break
+		}
+		copy(unsafe.Slice((*byte)(unsafe.Add(base, f.goOff)), f.size), unsafe.Slice((*byte)(unsafe.Add(data, f.wire)), f.size))
+	}
+	return true
+}
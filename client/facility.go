@@ -0,0 +1,281 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// facility data types, see SIMCONNECT_FACILITY_DATA_TYPE
+const (
+	FACILITY_DATA_AIRPORT DWORD = iota
+	FACILITY_DATA_RUNWAY
+	FACILITY_DATA_START
+	FACILITY_DATA_FREQUENCY
+	FACILITY_DATA_HELIPAD
+	FACILITY_DATA_APPROACH
+	FACILITY_DATA_APPROACH_TRANSITION
+	FACILITY_DATA_APPROACH_LEG
+	FACILITY_DATA_FINAL_APPROACH_LEG
+	FACILITY_DATA_MISSED_APPROACH_LEG
+	FACILITY_DATA_DEPARTURE
+	FACILITY_DATA_ARRIVAL
+	FACILITY_DATA_RUNWAY_TRANSITION
+	FACILITY_DATA_ENROUTE_TRANSITION
+	FACILITY_DATA_TAXI_POINT
+	FACILITY_DATA_TAXI_PARKING
+	FACILITY_DATA_TAXI_PATH
+	FACILITY_DATA_TAXI_NAME
+	FACILITY_DATA_JETWAY
+	FACILITY_DATA_VOR
+	FACILITY_DATA_NDB
+	FACILITY_DATA_WAYPOINT
+	FACILITY_DATA_ROUTE
+)
+
+type RecvFacilityData struct {
+	Recv
+	UserRequestID         DWORD
+	UniqueRequestID       DWORD
+	ParentUniqueRequestID DWORD
+	Type                  DWORD
+	IsListItem            DWORD
+	ItemIndex             DWORD
+	ListSize              DWORD
+	Data                  DWORD
+}
+
+type RecvFacilityDataEnd struct {
+	Recv
+	RequestID DWORD
+}
+
+// FacilityItem is one record of a facility data reply
+// the top level record has ParentID 0; children (eg the routes of a
+// waypoint) carry the UniqueID of their parent
+type FacilityItem struct {
+	Type     DWORD
+	UniqueID DWORD
+	ParentID DWORD
+	Index    DWORD
+	ListSize DWORD
+	Data     []byte
+}
+
+type facilityRequest struct {
+	items []FacilityItem
+	done  chan struct{}
+}
+
+func (s *SimConnect) AddToFacilityDefinition(defineID DWORD, fieldName string) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AddToFacilityDefinition(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_DEFINITION_ID DefineID,
+	//   const char * FieldName
+	// );
+
+	_fieldName := []byte(fieldName + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_AddToFacilityDefinition.Call(
+		uintptr(s.handle),
+		uintptr(defineID),
+		uintptr(unsafe.Pointer(&_fieldName[0])),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AddToFacilityDefinition for %s error: %d %s", fieldName, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) RequestFacilityData(defineID, requestID DWORD, icao, region string) error {
+	return s.RequestFacilityDataContext(context.Background(), defineID, requestID, icao, region)
+}
+
+// RequestFacilityDataContext is RequestFacilityData with ctx bounding the wait for its lane
+// the request counts towards WithMaxOutstanding until FACILITY_DATA_END
+func (s *SimConnect) RequestFacilityDataContext(ctx context.Context, defineID, requestID DWORD, icao, region string) (err error) {
+	sent, err := s.oneShot(ctx, requestID, "facility "+icao)
+	if err != nil {
+		return err
+	}
+	defer func() { sent(err == nil) }()
+	leave, err := s.enterContext(ctx, LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	// SimConnect_RequestFacilityData(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_DEFINITION_ID DefineID,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
+	//   const char * ICAO,
+	//   const char * Region = ""
+	// );
+
+	_icao := []byte(icao + "\x00")
+	_region := []byte(region + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_RequestFacilityData.Call(
+		uintptr(s.handle),
+		uintptr(defineID),
+		uintptr(requestID),
+		uintptr(unsafe.Pointer(&_icao[0])),
+		uintptr(unsafe.Pointer(&_region[0])),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_RequestFacilityData for %s error: %d %s", icao, r1, err)
+	}
+	return nil
+}
+
+// FacilityDefinition returns a facility definition built from the fields,
+// registering it on first use; name identifies the definition
+func (s *SimConnect) FacilityDefinition(name string, fields ...string) (DWORD, error) {
+	return s.cachedDefinition("facility:"+name, func(defineID DWORD) error {
+		for _, f := range fields {
+			if err := s.AddToFacilityDefinition(defineID, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RequestFacility requests the facility data and waits for all of it
+// the reply is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) RequestFacility(ctx context.Context, defineID DWORD, icao, region string) ([]FacilityItem, error) {
+	return s.requestFacility(ctx, icao, func(requestID DWORD) error {
+		return s.RequestFacilityDataContext(ctx, defineID, requestID, icao, region)
+	})
+}
+
+// requestFacility makes a facility request with send and collects the reply
+func (s *SimConnect) requestFacility(ctx context.Context, icao string, send func(requestID DWORD) error) ([]FacilityItem, error) {
+	req := &facilityRequest{done: make(chan struct{})}
+	s.mu.Lock()
+	requestID := s.nextRequestID()
+	s.facilities[requestID] = req
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		delete(s.facilities, requestID)
+		s.mu.Unlock()
+	}
+	if err := send(requestID); err != nil {
+		cancel()
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		cancel()
+		return nil, fmt.Errorf("facility %s: %w", icao, ctx.Err())
+	case <-req.done:
+		return req.items, nil
+	}
+}
+
+// DeliverFacility hands a facility data message to a pending request
+// it returns true if the message was consumed; the connector calls this
+// for FACILITY_DATA and FACILITY_DATA_END messages
+func (s *SimConnect) DeliverFacility(ppData unsafe.Pointer) bool {
+	recv := (*Recv)(ppData)
+	switch recv.ID {
+	case RECV_ID_FACILITY_DATA:
+		x := (*RecvFacilityData)(ppData)
+		header := DWORD(unsafe.Offsetof(x.Data))
+		item := FacilityItem{
+			Type:     x.Type,
+			UniqueID: x.UniqueRequestID,
+			ParentID: x.ParentUniqueRequestID,
+			Index:    x.ItemIndex,
+			ListSize: x.ListSize,
+		}
+		if x.Size > header {
+			item.Data = make([]byte, x.Size-header)
+			copy(item.Data, unsafe.Slice((*byte)(unsafe.Pointer(&x.Data)), len(item.Data)))
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		req, ok := s.facilities[x.UserRequestID]
+		if ok {
+			req.items = append(req.items, item)
+		}
+		return ok
+	case RECV_ID_FACILITY_DATA_END:
+		x := (*RecvFacilityDataEnd)(ppData)
+		s.completeRequest(x.RequestID)
+		s.mu.Lock()
+		req, ok := s.facilities[x.RequestID]
+		delete(s.facilities, x.RequestID)
+		s.mu.Unlock()
+		if ok {
+			close(req.done)
+		}
+		return ok
+	}
+	return false
+}
+
+// FacilityReader decodes the packed fields of a facility item in the
+// order they were added to the definition
+type FacilityReader struct {
+	data []byte
+	err  error
+}
+
+// NewFacilityReader returns a reader over the item data
+func NewFacilityReader(data []byte) *FacilityReader {
+	return &FacilityReader{data: data}
+}
+
+func (r *FacilityReader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if len(r.data) < n {
+		r.err = fmt.Errorf("facility data: need %d bytes, have %d", n, len(r.data))
+		return make([]byte, n)
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+// Float64 reads a FLOAT64 field
+func (r *FacilityReader) Float64() float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(r.next(8)))
+}
+
+// Float32 reads a FLOAT32 field
+func (r *FacilityReader) Float32() float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(r.next(4)))
+}
+
+// Int32 reads an INT32 field
+func (r *FacilityReader) Int32() int32 {
+	return int32(binary.LittleEndian.Uint32(r.next(4)))
+}
+
+// Int64 reads an INT64 field
+func (r *FacilityReader) Int64() int64 {
+	return int64(binary.LittleEndian.Uint64(r.next(8)))
+}
+
+// String reads a fixed size string field
+func (r *FacilityReader) String(n int) string {
+	return BytesToString(r.next(n))
+}
+
+// Err returns the first error encountered
+func (r *FacilityReader) Err() error {
+	return r.err
+}
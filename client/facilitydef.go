@@ -0,0 +1,276 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// facility object types for RequestFacilityDataEX1
+const (
+	FACILITY_TYPE_AIRPORT  byte = 'A'
+	FACILITY_TYPE_WAYPOINT byte = 'W'
+	FACILITY_TYPE_VOR      byte = 'V'
+	FACILITY_TYPE_NDB      byte = 'N'
+)
+
+// facilityObjects maps the facility definition objects, as used with OPEN
+// and CLOSE, to the type of their data items
+var facilityObjects = map[string]DWORD{
+	"AIRPORT":             FACILITY_DATA_AIRPORT,
+	"RUNWAY":              FACILITY_DATA_RUNWAY,
+	"START":               FACILITY_DATA_START,
+	"FREQUENCY":           FACILITY_DATA_FREQUENCY,
+	"HELIPAD":             FACILITY_DATA_HELIPAD,
+	"APPROACH":            FACILITY_DATA_APPROACH,
+	"APPROACH_TRANSITION": FACILITY_DATA_APPROACH_TRANSITION,
+	"APPROACH_LEG":        FACILITY_DATA_APPROACH_LEG,
+	"FINAL_APPROACH_LEG":  FACILITY_DATA_FINAL_APPROACH_LEG,
+	"MISSED_APPROACH_LEG": FACILITY_DATA_MISSED_APPROACH_LEG,
+	"DEPARTURE":           FACILITY_DATA_DEPARTURE,
+	"ARRIVAL":             FACILITY_DATA_ARRIVAL,
+	"RUNWAY_TRANSITION":   FACILITY_DATA_RUNWAY_TRANSITION,
+	"ENROUTE_TRANSITION":  FACILITY_DATA_ENROUTE_TRANSITION,
+	"TAXI_POINT":          FACILITY_DATA_TAXI_POINT,
+	"TAXI_PARKING":        FACILITY_DATA_TAXI_PARKING,
+	"TAXI_PATH":           FACILITY_DATA_TAXI_PATH,
+	"TAXI_NAME":           FACILITY_DATA_TAXI_NAME,
+	"JETWAY":              FACILITY_DATA_JETWAY,
+	"VOR":                 FACILITY_DATA_VOR,
+	"NDB":                 FACILITY_DATA_NDB,
+	"WAYPOINT":            FACILITY_DATA_WAYPOINT,
+	"ROUTE":               FACILITY_DATA_ROUTE,
+}
+
+// facilityTypes are the RequestFacilityDataEX1 types of the top level objects
+var facilityTypes = map[string]byte{
+	"AIRPORT":  FACILITY_TYPE_AIRPORT,
+	"WAYPOINT": FACILITY_TYPE_WAYPOINT,
+	"VOR":      FACILITY_TYPE_VOR,
+	"NDB":      FACILITY_TYPE_NDB,
+}
+
+func (s *SimConnect) RequestFacilityDataEX1(defineID, requestID DWORD, icao, region string, facilityType byte) error {
+	return s.RequestFacilityDataEX1Context(context.Background(), defineID, requestID, icao, region, facilityType)
+}
+
+// RequestFacilityDataEX1Context is RequestFacilityDataEX1 with ctx bounding the wait for its lane
+// the request counts towards WithMaxOutstanding until FACILITY_DATA_END
+func (s *SimConnect) RequestFacilityDataEX1Context(ctx context.Context, defineID, requestID DWORD, icao, region string, facilityType byte) (err error) {
+	sent, err := s.oneShot(ctx, requestID, "facility "+icao)
+	if err != nil {
+		return err
+	}
+	defer func() { sent(err == nil) }()
+	leave, err := s.enterContext(ctx, LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	// SimConnect_RequestFacilityData_EX1(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_DEFINITION_ID DefineID,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
+	//   const char * ICAO,
+	//   const char * Region = "",
+	//   char Type = 0
+	// );
+
+	_icao := []byte(icao + "\x00")
+	_region := []byte(region + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_RequestFacilityData_EX1.Call(
+		uintptr(s.handle),
+		uintptr(defineID),
+		uintptr(requestID),
+		uintptr(unsafe.Pointer(&_icao[0])),
+		uintptr(unsafe.Pointer(&_region[0])),
+		uintptr(facilityType),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_RequestFacilityData_EX1 for %s error: %d %s", icao, r1, err)
+	}
+	return nil
+}
+
+// RegisterFacilityDefinition builds a facility definition from a struct,
+// registering it on first use; object is the top level object, eg "AIRPORT"
+// fields carry facility tags naming the field, eg `facility:"LATITUDE"`,
+// and are float64, float32, int32, int64 or a byte array for strings;
+// a slice of structs tagged with a child object, eg `facility:"RUNWAY"`,
+// opens that object and collects one element per item:
+//
+//	type Airport struct {
+//		Latitude  float64   `facility:"LATITUDE"`
+//		Longitude float64   `facility:"LONGITUDE"`
+//		Name      [32]byte  `facility:"NAME"`
+//		Runways   []Runway  `facility:"RUNWAY"`
+//	}
+//	type Runway struct {
+//		Heading float32 `facility:"HEADING"`
+//		Length  float32 `facility:"LENGTH"`
+//	}
+func (s *SimConnect) RegisterFacilityDefinition(object string, a any) (DWORD, error) {
+	t := structType(a)
+	var fields []string
+	if err := facilityFields(object, t, &fields); err != nil {
+		return 0, err
+	}
+	return s.FacilityDefinition(object+":"+t.PkgPath()+"."+t.Name(), fields...)
+}
+
+// facilityFields appends the definition fields of an object backed by t
+func facilityFields(object string, t reflect.Type, fields *[]string) error {
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("facility %s: not a struct: %s", object, t.Kind())
+	}
+	if _, ok := facilityObjects[object]; !ok {
+		return fmt.Errorf("facility %s: unknown object", object)
+	}
+	*fields = append(*fields, "OPEN "+object)
+	var children []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := f.Tag.Lookup("facility")
+		if !ok {
+			continue
+		}
+		if f.Type.Kind() == reflect.Slice {
+			children = append(children, f)
+			continue
+		}
+		if !facilityKind(f.Type) {
+			return fmt.Errorf("facility %s: field %s has unsupported type %s", object, f.Name, f.Type)
+		}
+		*fields = append(*fields, name)
+	}
+	// SimConnect sends an object's own fields before its children, so
+	// children go last whatever their place in the struct
+	for _, f := range children {
+		if err := facilityFields(f.Tag.Get("facility"), f.Type.Elem(), fields); err != nil {
+			return err
+		}
+	}
+	*fields = append(*fields, "CLOSE "+object)
+	return nil
+}
+
+func facilityKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Float64, reflect.Float32, reflect.Int32, reflect.Int64:
+		return true
+	case reflect.Array:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// ReadFacility requests a facility and decodes it into out, a pointer to
+// a struct registered with RegisterFacilityDefinition rules
+// region may be empty; for AIRPORT, VOR, NDB and WAYPOINT the request is
+// limited to that type, so an ICAO shared by a VOR and an NDB is not ambiguous
+func (s *SimConnect) ReadFacility(ctx context.Context, object, icao, region string, out any) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("facility %s: out must be a pointer to a struct", icao)
+	}
+	defineID, err := s.RegisterFacilityDefinition(object, out)
+	if err != nil {
+		return err
+	}
+	var items []FacilityItem
+	if typ, ok := facilityTypes[object]; ok {
+		items, err = s.requestFacility(ctx, icao, func(requestID DWORD) error {
+			return s.RequestFacilityDataEX1Context(ctx, defineID, requestID, icao, region, typ)
+		})
+	} else {
+		items, err = s.RequestFacility(ctx, defineID, icao, region)
+	}
+	if err != nil {
+		return err
+	}
+	if err := decodeFacility(object, items, v.Elem()); err != nil {
+		return fmt.Errorf("facility %s: %w", icao, err)
+	}
+	return nil
+}
+
+// decodeFacility builds the object tree from the items into out
+func decodeFacility(object string, items []FacilityItem, out reflect.Value) error {
+	rootType := facilityObjects[object]
+	children := map[DWORD][]FacilityItem{}
+	var root *FacilityItem
+	for i := range items {
+		it := &items[i]
+		if root == nil && it.Type == rootType {
+			root = it
+			continue
+		}
+		children[it.ParentID] = append(children[it.ParentID], *it)
+	}
+	if root == nil {
+		return fmt.Errorf("no %s in reply", object)
+	}
+	var fill func(it FacilityItem, v reflect.Value) error
+	fill = func(it FacilityItem, v reflect.Value) error {
+		if err := decodeFacilityFields(it.Data, v); err != nil {
+			return err
+		}
+		for _, c := range children[it.UniqueID] {
+			slice, ok := facilitySlice(v, c.Type)
+			if !ok {
+				continue
+			}
+			elem := reflect.New(slice.Type().Elem()).Elem()
+			if err := fill(c, elem); err != nil {
+				return err
+			}
+			slice.Set(reflect.Append(slice, elem))
+		}
+		return nil
+	}
+	return fill(*root, out)
+}
+
+// facilitySlice returns the slice field of v holding children of a type
+func facilitySlice(v reflect.Value, typ DWORD) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type.Kind() != reflect.Slice {
+			continue
+		}
+		if obj, ok := f.Tag.Lookup("facility"); ok && facilityObjects[obj] == typ {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// decodeFacilityFields reads the packed fields of an item in struct order
+func decodeFacilityFields(data []byte, v reflect.Value) error {
+	r := NewFacilityReader(data)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := f.Tag.Lookup("facility"); !ok || f.Type.Kind() == reflect.Slice {
+			continue
+		}
+		fv := v.Field(i)
+		switch f.Type.Kind() {
+		case reflect.Float64:
+			fv.SetFloat(r.Float64())
+		case reflect.Float32:
+			fv.SetFloat(float64(r.Float32()))
+		case reflect.Int32:
+			fv.SetInt(int64(r.Int32()))
+		case reflect.Int64:
+			fv.SetInt(r.Int64())
+		case reflect.Array:
+			reflect.Copy(fv, reflect.ValueOf(r.next(f.Type.Len())))
+		}
+	}
+	return r.Err()
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// FixedString is a facility filter value for a fixed size string field,
+// eg the 8 byte ICAO of a RUNWAY_TRANSITION
+type FixedString struct {
+	Value string
+	Size  int
+}
+
+// FacilityFilterValue encodes a filter value as the field it is compared
+// with: int32, DWORD, int64, float32, float64 or FixedString
+func FacilityFilterValue(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case int32:
+		return binary.LittleEndian.AppendUint32(nil, uint32(v)), nil
+	case DWORD:
+		return binary.LittleEndian.AppendUint32(nil, uint32(v)), nil
+	case int64:
+		return binary.LittleEndian.AppendUint64(nil, uint64(v)), nil
+	case float32:
+		return binary.LittleEndian.AppendUint32(nil, math.Float32bits(v)), nil
+	case float64:
+		return binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)), nil
+	case FixedString:
+		if len(v.Value) >= v.Size {
+			return nil, fmt.Errorf("facility filter %q does not fit in %d bytes", v.Value, v.Size)
+		}
+		b := make([]byte, v.Size)
+		copy(b, v.Value)
+		return b, nil
+	default:
+		return nil, fmt.Errorf("unsupported facility filter type %T", v)
+	}
+}
+
+func (s *SimConnect) AddFacilityDataDefinitionFilter(defineID DWORD, filterPath string, data []byte) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_AddFacilityDataDefinitionFilter(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_DEFINITION_ID DefineID,
+	//   const char * szFilterPath,
+	//   DWORD cbUnitSize,
+	//   void * pFilterData
+	// );
+
+	_filterPath := []byte(filterPath + "\x00")
+	var filterData uintptr
+	if len(data) > 0 {
+		filterData = uintptr(unsafe.Pointer(&data[0]))
+	}
+
+	r1, _, err := s.dll.proc_SimConnect_AddFacilityDataDefinitionFilter.Call(
+		uintptr(s.handle),
+		uintptr(defineID),
+		uintptr(unsafe.Pointer(&_filterPath[0])),
+		uintptr(len(data)),
+		filterData,
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_AddFacilityDataDefinitionFilter for %s error: %d %s", filterPath, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) ClearAllFacilityDataDefinitionFilters(defineID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_ClearAllFacilityDataDefinitionFilters(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_DEFINITION_ID DefineID
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_ClearAllFacilityDataDefinitionFilters.Call(
+		uintptr(s.handle),
+		uintptr(defineID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_ClearAllFacilityDataDefinitionFilters for defineID %d error: %d %s", defineID, r1, err)
+	}
+	return nil
+}
+
+// AddFacilityFilter limits the items a facility definition returns to those
+// whose field at path, eg "/AIRPORT/RUNWAY/SURFACE", equals the value
+// filters on the same definition combine; see FacilityFilterValue for the types
+func (s *SimConnect) AddFacilityFilter(defineID DWORD, path string, value any) error {
+	data, err := FacilityFilterValue(value)
+	if err != nil {
+		return err
+	}
+	return s.AddFacilityDataDefinitionFilter(defineID, path, data)
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+)
+
+// FacilityListItem is one entry of an airport, waypoint, NDB or VOR list
+// fields a type doesn't have are zero
+type FacilityListItem struct {
+	Type      DWORD // FACILITY_LIST_TYPE_*
+	ICAO      string
+	Latitude  float64 // degrees
+	Longitude float64 // degrees
+	Altitude  float64 // meters
+	MagVar    float64 // degrees; waypoints, NDBs and VORs
+	Frequency DWORD   // Hz; NDBs and VORs
+}
+
+// FacilityList is one message of a facility list reply
+// long lists are split over OutOf messages, numbered by Entry
+type FacilityList struct {
+	RequestID DWORD
+	Type      DWORD
+	Entry     DWORD
+	OutOf     DWORD
+	Items     []FacilityListItem
+}
+
+// packed sizes of the SIMCONNECT_DATA_FACILITY_* entries, which are
+// byte aligned; VOR fields past the frequency are not decoded
+var facilityListEntrySizes = map[DWORD]int{
+	FACILITY_LIST_TYPE_AIRPORT:  33,
+	FACILITY_LIST_TYPE_WAYPOINT: 41,
+	FACILITY_LIST_TYPE_NDB:      45,
+	FACILITY_LIST_TYPE_VOR:      81,
+}
+
+var facilityListTypes = map[DWORD]DWORD{
+	RECV_ID_AIRPORT_LIST:  FACILITY_LIST_TYPE_AIRPORT,
+	RECV_ID_WAYPOINT_LIST: FACILITY_LIST_TYPE_WAYPOINT,
+	RECV_ID_NDB_LIST:      FACILITY_LIST_TYPE_NDB,
+	RECV_ID_VOR_LIST:      FACILITY_LIST_TYPE_VOR,
+}
+
+// NewRequestID allocates a request ID that is not used by this client,
+// eg for RequestFacilitiesList or SubscribeToFacilities
+func (s *SimConnect) NewRequestID() DWORD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextRequestID()
+}
+
+// HandleFacilityLists calls fn with the facility lists sent for requestID,
+// both replies to RequestFacilitiesList and SubscribeToFacilities updates
+// fn is called on the dispatch goroutine, so it must not block
+func (s *SimConnect) HandleFacilityLists(requestID DWORD, fn func(FacilityList)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fn == nil {
+		delete(s.facilityLists, requestID)
+		return
+	}
+	s.facilityLists[requestID] = fn
+}
+
+// DeliverFacilityList decodes a facility list message and hands it to its handler
+// it returns true if the message was consumed; the connector calls this
+// for AIRPORT_LIST, WAYPOINT_LIST, NDB_LIST and VOR_LIST messages
+func (s *SimConnect) DeliverFacilityList(ppData unsafe.Pointer) bool {
+	x := (*RecvFacilityList)(ppData)
+	typ, ok := facilityListTypes[x.ID]
+	if !ok {
+		return false
+	}
+	if x.EntryNumber+1 >= x.OutOf {
+		s.completeRequest(x.RequestID)
+	}
+	s.mu.Lock()
+	fn, ok := s.facilityLists[x.RequestID]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	list := FacilityList{RequestID: x.RequestID, Type: typ, Entry: x.EntryNumber, OutOf: x.OutOf}
+	header := int(unsafe.Sizeof(*x))
+	if int(x.Size) > header && x.ArraySize > 0 {
+		data := unsafe.Slice((*byte)(unsafe.Add(ppData, header)), int(x.Size)-header)
+		list.Items = decodeFacilityList(typ, data, int(x.ArraySize))
+	}
+	fn(list)
+	return true
+}
+
+func decodeFacilityList(typ DWORD, data []byte, n int) []FacilityListItem {
+	stride := len(data) / n
+	// entries are byte aligned in the SDK headers; if the stride says
+	// otherwise, the doubles are 8 byte aligned
+	aligned := stride != facilityListEntrySizes[typ]
+	items := make([]FacilityListItem, 0, n)
+	f64 := func(b []byte, off int) float64 {
+		if off+8 > len(b) {
+			return 0
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[off:]))
+	}
+	for i := 0; i < n; i++ {
+		b := data[i*stride : (i+1)*stride]
+		off := 9
+		if aligned {
+			off = 16
+		}
+		it := FacilityListItem{
+			Type:      typ,
+			ICAO:      BytesToString(b[:9]),
+			Latitude:  f64(b, off),
+			Longitude: f64(b, off+8),
+			Altitude:  f64(b, off+16),
+		}
+		off += 24
+		if typ != FACILITY_LIST_TYPE_AIRPORT {
+			it.MagVar = f64(b, off)
+			off += 8
+		}
+		if (typ == FACILITY_LIST_TYPE_NDB || typ == FACILITY_LIST_TYPE_VOR) && off+4 <= len(b) {
+			it.Frequency = DWORD(binary.LittleEndian.Uint32(b[off:]))
+		}
+		items = append(items, it)
+	}
+	return items
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Outstanding is a one-shot request waiting for its reply
+type Outstanding struct {
+	RequestID DWORD
+	// What names the request, eg the simvar being read
+	What string
+	// SendID is the packet of the request, which exceptions refer to
+	SendID DWORD
+	Since  time.Time
+
+	// auto is set for requests sent under an ID the caller chose, which end
+	// on their reply or exception rather than when a waiting call returns
+	auto bool
+}
+
+// requestExpiry is how long a tracked request without a reply keeps its
+// slot; a request by type with nothing in range never gets one
+const requestExpiry = time.Minute
+
+// WithMaxOutstanding caps the one-shot requests waiting for a reply
+// further requests block until one completes or their context ends, so
+// an application spamming reads backs off instead of losing replies
+// every one-shot request counts: reads, RequestDataOnSimObjectType and
+// RequestDataOnSimObject with PERIOD_ONCE, client data with
+// CLIENT_DATA_PERIOD_ONCE, facility, facility list and jetway requests;
+// one that gets no reply gives its slot up after a minute
+func WithMaxOutstanding(n int) SimConnectOption {
+	return func(s *SimConnect) {
+		if n > 0 {
+			s.slots = make(chan struct{}, n)
+		}
+	}
+}
+
+// Pending returns the one-shot requests waiting for a reply, oldest first
+func (s *SimConnect) Pending() []Outstanding {
+	s.mu.Lock()
+	out := make([]Outstanding, 0, len(s.outstanding))
+	for _, o := range s.outstanding {
+		out = append(out, *o)
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Since.Before(out[j].Since) })
+	return out
+}
+
+// beginRequest waits for a request slot and allocates a tracked request ID
+// the returned function must be called once the request completes or fails
+func (s *SimConnect) beginRequest(ctx context.Context, what string) (DWORD, func(), error) {
+	if err := s.takeSlot(ctx, what); err != nil {
+		return 0, nil, err
+	}
+	s.mu.Lock()
+	requestID := s.nextRequestID()
+	s.outstanding[requestID] = &Outstanding{RequestID: requestID, What: what, Since: time.Now()}
+	s.mu.Unlock()
+
+	end := func() {
+		s.mu.Lock()
+		if o, ok := s.outstanding[requestID]; ok {
+			if o.SendID != 0 {
+				delete(s.sends, o.SendID)
+			}
+			delete(s.outstanding, requestID)
+		}
+		s.mu.Unlock()
+		if s.slots != nil {
+			<-s.slots
+		}
+	}
+	return requestID, end, nil
+}
+
+// takeSlot waits for a request slot, if the requests are capped
+// while waiting it gives up the slots of requests that never got a reply
+func (s *SimConnect) takeSlot(ctx context.Context, what string) error {
+	if s.slots == nil {
+		return nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		s.expireRequests()
+		select {
+		case s.slots <- struct{}{}:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("%s: waiting for a request slot: %w", what, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// expireRequests ends the tracked requests that had no reply in time
+func (s *SimConnect) expireRequests() {
+	cutoff := time.Now().Add(-requestExpiry)
+	var ids []DWORD
+	s.mu.Lock()
+	for id, o := range s.outstanding {
+		if o.auto && o.Since.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	s.mu.Unlock()
+	for _, id := range ids {
+		s.completeRequest(id)
+	}
+}
+
+// trackRequest records a one-shot request about to be sent under an ID
+// the caller chose, once it has a slot; it returns false for a request a
+// waiting call already tracks, eg a read, which ends it itself
+func (s *SimConnect) trackRequest(ctx context.Context, requestID DWORD, what string) (bool, error) {
+	s.mu.Lock()
+	_, tracked := s.outstanding[requestID]
+	s.mu.Unlock()
+	if tracked {
+		return false, nil
+	}
+	if err := s.takeSlot(ctx, what); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	s.outstanding[requestID] = &Outstanding{RequestID: requestID, What: what, Since: time.Now(), auto: true}
+	s.mu.Unlock()
+	return true, nil
+}
+
+// oneShot tracks a one-shot request about to be sent; the returned
+// function is called with whether sending it succeeded
+func (s *SimConnect) oneShot(ctx context.Context, requestID DWORD, what string) (func(sent bool), error) {
+	tracked, err := s.trackRequest(ctx, requestID, what)
+	if err != nil || !tracked {
+		return func(bool) {}, err
+	}
+	return func(sent bool) {
+		if sent {
+			s.requestSent(requestID)
+		} else {
+			s.completeRequest(requestID)
+		}
+	}, nil
+}
+
+// requestSent records the packet of a tracked request just sent, so an
+// exception about it ends the request; like watchSend it is best effort
+// when other goroutines send in between
+func (s *SimConnect) requestSent(requestID DWORD) {
+	sendID, err := s.GetLastSentPacketID()
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	if o, ok := s.outstanding[requestID]; ok && o.auto {
+		o.SendID = sendID
+	}
+	s.mu.Unlock()
+}
+
+// completeRequest ends a request tracked by trackRequest, on its last
+// reply, on an exception about it or when sending it failed
+func (s *SimConnect) completeRequest(requestID DWORD) {
+	s.mu.Lock()
+	o, ok := s.outstanding[requestID]
+	ok = ok && o.auto
+	if ok {
+		delete(s.outstanding, requestID)
+	}
+	s.mu.Unlock()
+	if ok && s.slots != nil {
+		<-s.slots
+	}
+}
+
+// completeSend ends the tracked request an exception is about
+func (s *SimConnect) completeSend(sendID DWORD) {
+	if sendID == 0 {
+		return
+	}
+	s.mu.Lock()
+	var requestID DWORD
+	found := false
+	for id, o := range s.outstanding {
+		if o.auto && o.SendID == sendID {
+			requestID, found = id, true
+			break
+		}
+	}
+	s.mu.Unlock()
+	if found {
+		s.completeRequest(requestID)
+	}
+}
+
+// watchSend ties the packet just sent to a tracked request, so an
+// exception raised for it ends the wait instead of leaving it to time out
+// like checkSend, it is best effort when other goroutines send in between
+func (s *SimConnect) watchSend(requestID DWORD) <-chan RecvException {
+	ch := make(chan RecvException, 1)
+	sendID, err := s.GetLastSentPacketID()
+	if err != nil {
+		return ch
+	}
+	s.mu.Lock()
+	if o, ok := s.outstanding[requestID]; ok {
+		o.SendID = sendID
+		s.sends[sendID] = ch
+	}
+	s.mu.Unlock()
+	return ch
+}
@@ -0,0 +1,133 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/client/clienttest"
+)
+
+// connectCapped connects with room for one outstanding request, and numbers
+// the packets sent
+func connectCapped(t *testing.T) (*clienttest.DLL, *client.SimConnect) {
+	t.Helper()
+	dll := clienttest.New()
+	var sendID client.DWORD
+	dll.Handle("SimConnect_GetLastSentPacketID", func(args []uintptr) uintptr {
+		sendID++
+		clienttest.PutDWORD(args[1], sendID)
+		return 0
+	})
+	sc, err := dll.Connect(client.WithMaxOutstanding(1))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return dll, sc
+}
+
+// blocked returns true if a request can't be sent for want of a slot
+func blocked(t *testing.T, send func(ctx context.Context) error) bool {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := send(ctx)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("send: %v", err)
+	}
+	return err != nil
+}
+
+func TestOneShotRequestsTracked(t *testing.T) {
+	byType := func(sc *client.SimConnect, requestID client.DWORD) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			return sc.RequestDataOnSimObjectTypeContext(ctx, requestID, 1, 0, client.SIMOBJECT_TYPE_USER)
+		}
+	}
+
+	t.Run("data reply", func(t *testing.T) {
+		_, sc := connectCapped(t)
+		if err := sc.RequestDataOnSimObjectType(5, 1, 0, client.SIMOBJECT_TYPE_USER); err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		if p := sc.Pending(); len(p) != 1 || p[0].RequestID != 5 || p[0].SendID == 0 {
+			t.Fatalf("pending %+v, want request 5 with its packet", p)
+		}
+		if !blocked(t, byType(sc, 6)) {
+			t.Fatalf("second request sent past the cap")
+		}
+
+		// a scan of several objects ends with its last reply
+		reply := client.RecvSimobjectData{RequestID: 5, DefineID: 1, EntryNumber: 1, OutOf: 2}
+		sc.ObserveReply(&reply)
+		if len(sc.Pending()) != 1 {
+			t.Fatalf("request ended before its last reply")
+		}
+		reply.EntryNumber = 2
+		sc.ObserveReply(&reply)
+		if p := sc.Pending(); len(p) != 0 {
+			t.Fatalf("pending %+v after the last reply", p)
+		}
+		if blocked(t, byType(sc, 6)) {
+			t.Fatalf("slot not given back after the reply")
+		}
+	})
+
+	t.Run("exception", func(t *testing.T) {
+		_, sc := connectCapped(t)
+		if err := sc.RequestDataOnSimObject(5, 1, client.OBJECT_ID_USER, client.PERIOD_ONCE, client.DATA_REQUEST_FLAG_DEFAULT, 0, 0, 0); err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		p := sc.Pending()
+		if len(p) != 1 {
+			t.Fatalf("pending %+v, want the request", p)
+		}
+		sc.DeliverException(client.RecvException{SendID: p[0].SendID + 1, Exception: 3})
+		if len(sc.Pending()) != 1 {
+			t.Fatalf("request ended by an exception about another packet")
+		}
+		sc.DeliverException(client.RecvException{SendID: p[0].SendID, Exception: 3})
+		if p := sc.Pending(); len(p) != 0 {
+			t.Fatalf("pending %+v after an exception about it", p)
+		}
+	})
+
+	t.Run("facility end", func(t *testing.T) {
+		_, sc := connectCapped(t)
+		if err := sc.RequestFacilityData(1, 5, "KSEA", ""); err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		end := client.RecvFacilityDataEnd{Recv: client.Recv{ID: client.RECV_ID_FACILITY_DATA_END}, RequestID: 5}
+		msg := clienttest.Message(&end)
+		sc.DeliverFacility(unsafe.Pointer(&msg[0]))
+		if p := sc.Pending(); len(p) != 0 {
+			t.Fatalf("pending %+v after FACILITY_DATA_END", p)
+		}
+	})
+
+	t.Run("failed send", func(t *testing.T) {
+		dll, sc := connectCapped(t)
+		dll.Handle("SimConnect_RequestDataOnSimObjectType", func([]uintptr) uintptr { return uintptr(client.E_FAIL) })
+		if err := sc.RequestDataOnSimObjectType(5, 1, 0, client.SIMOBJECT_TYPE_USER); err == nil {
+			t.Fatalf("failed send: no error")
+		}
+		if p := sc.Pending(); len(p) != 0 {
+			t.Fatalf("pending %+v after a failed send", p)
+		}
+	})
+
+	t.Run("periodic", func(t *testing.T) {
+		_, sc := connectCapped(t)
+		for i := client.DWORD(5); i < 8; i++ {
+			if err := sc.RequestDataOnSimObject(i, 1, client.OBJECT_ID_USER, client.PERIOD_SECOND, client.DATA_REQUEST_FLAG_DEFAULT, 0, 0, 0); err != nil {
+				t.Fatalf("request: %v", err)
+			}
+		}
+		if p := sc.Pending(); len(p) != 0 {
+			t.Fatalf("periodic requests pending: %+v", p)
+		}
+	})
+}
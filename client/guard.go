@@ -0,0 +1,72 @@
+package client
+
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+)
+
+// Write is a data write checked by a WriteGuard
+// only leading float64 datums are decoded; Values[i] is the value of Datums[i]
+type Write struct {
+	DefineID DWORD
+	ObjectID DWORD
+	Datums   []Datum
+	Values   []float64
+
+	data []byte
+}
+
+// Set changes a value before it is sent, eg to clamp it to a limit
+func (w *Write) Set(i int, v float64) {
+	w.Values[i] = v
+	binary.LittleEndian.PutUint64(w.data[i*8:], math.Float64bits(v))
+}
+
+// WriteGuard checks a write before it is sent to the sim
+// returning an error blocks the write; SetData returns the error
+type WriteGuard func(w *Write) error
+
+// AddWriteGuard adds a guard that every SetDataOnSimObject goes through,
+// including SetData and the one-shot writes
+func (s *SimConnect) AddWriteGuard(g WriteGuard) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.guards = append(s.guards, g)
+}
+
+// newWrite decodes a write's leading float64 datums
+func (s *SimConnect) newWrite(defineID, objectID, size DWORD, buf unsafe.Pointer) *Write {
+	s.mu.Lock()
+	datums := s.datums[defineID]
+	s.mu.Unlock()
+	w := &Write{DefineID: defineID, ObjectID: objectID, data: unsafe.Slice((*byte)(buf), size)}
+	off := 0
+	for _, d := range datums {
+		if d.DataType != DATATYPE_FLOAT64 || off+8 > len(w.data) {
+			// other types vary in size, so later offsets are unknown
+			break
+		}
+		w.Datums = append(w.Datums, d)
+		w.Values = append(w.Values, math.Float64frombits(binary.LittleEndian.Uint64(w.data[off:])))
+		off += 8
+	}
+	return w
+}
+
+// guardWrite runs the write guards
+func (s *SimConnect) guardWrite(defineID, objectID, size DWORD, buf unsafe.Pointer) error {
+	s.mu.Lock()
+	guards := s.guards
+	s.mu.Unlock()
+	if len(guards) == 0 || size == 0 {
+		return nil
+	}
+	w := s.newWrite(defineID, objectID, size, buf)
+	for _, g := range guards {
+		if err := g(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
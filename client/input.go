@@ -0,0 +1,164 @@
+package client
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// input group states, see SIMCONNECT_STATE
+const (
+	STATE_OFF DWORD = iota
+	STATE_ON
+)
+
+// boolArg passes a Go bool as a Win32 BOOL
+func boolArg(b bool) uintptr {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (s *SimConnect) MapInputEventToClientEvent(groupID DWORD, inputDefinition string, downEventID, downValue, upEventID, upValue DWORD, maskable bool) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_MapInputEventToClientEvent(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_INPUT_GROUP_ID GroupID,
+	//   const char * szInputDefinition,
+	//   SIMCONNECT_CLIENT_EVENT_ID DownEventID,
+	//   DWORD DownValue = 0,
+	//   SIMCONNECT_CLIENT_EVENT_ID UpEventID = (SIMCONNECT_CLIENT_EVENT_ID)SIMCONNECT_UNUSED,
+	//   DWORD UpValue = 0,
+	//   BOOL bMaskable = FALSE
+	// );
+
+	_inputDefinition := []byte(inputDefinition + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_MapInputEventToClientEvent.Call(
+		uintptr(s.handle),
+		uintptr(groupID),
+		uintptr(unsafe.Pointer(&_inputDefinition[0])),
+		uintptr(downEventID),
+		uintptr(downValue),
+		uintptr(upEventID),
+		uintptr(upValue),
+		boolArg(maskable),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_MapInputEventToClientEvent for %s error: %d %s", inputDefinition, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) SetInputGroupPriority(groupID, priority DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_SetInputGroupPriority(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_INPUT_GROUP_ID GroupID,
+	//   DWORD uPriority
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_SetInputGroupPriority.Call(
+		uintptr(s.handle),
+		uintptr(groupID),
+		uintptr(priority),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_SetInputGroupPriority for groupID %d error: %d %s", groupID, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) SetInputGroupState(groupID, state DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_SetInputGroupState(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_INPUT_GROUP_ID GroupID,
+	//   DWORD dwState
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_SetInputGroupState.Call(
+		uintptr(s.handle),
+		uintptr(groupID),
+		uintptr(state),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_SetInputGroupState for groupID %d error: %d %s", groupID, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) RemoveInputEvent(groupID DWORD, inputDefinition string) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_RemoveInputEvent(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_INPUT_GROUP_ID GroupID,
+	//   const char * szInputDefinition
+	// );
+
+	_inputDefinition := []byte(inputDefinition + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_RemoveInputEvent.Call(
+		uintptr(s.handle),
+		uintptr(groupID),
+		uintptr(unsafe.Pointer(&_inputDefinition[0])),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_RemoveInputEvent for %s error: %d %s", inputDefinition, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) ClearInputGroup(groupID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_ClearInputGroup(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_INPUT_GROUP_ID GroupID
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_ClearInputGroup.Call(
+		uintptr(s.handle),
+		uintptr(groupID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_ClearInputGroup for groupID %d error: %d %s", groupID, r1, err)
+	}
+	return nil
+}
+
+// MapInput binds an input, eg "VK_LCONTROL+A" or "joystick:0:button:0", to
+// a new private client event in the group and enables the group
+// the returned ID arrives as a RecvEvent when the input is pressed
+func (s *SimConnect) MapInput(groupID DWORD, inputDefinition string) (DWORD, error) {
+	id := s.GetEventID()
+	if err := s.MapClientEventToSimEvent(id, ""); err != nil {
+		return 0, err
+	}
+	if err := s.MapInputEventToClientEvent(groupID, inputDefinition, id, 0, UNUSED, 0, false); err != nil {
+		return 0, err
+	}
+	if err := s.SetInputGroupState(groupID, STATE_ON); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
@@ -0,0 +1,401 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// input event value types, see SIMCONNECT_INPUT_EVENT_TYPE
+const (
+	INPUT_EVENT_TYPE_DOUBLE DWORD = iota
+	INPUT_EVENT_TYPE_STRING
+)
+
+// InputEventDescriptor is SIMCONNECT_INPUT_EVENT_DESCRIPTOR, a B: input
+// event of the loaded aircraft
+// hashes change with the aircraft, so enumerate again after it changes
+type InputEventDescriptor struct {
+	Name string
+	Hash uint64
+	// Type is the DATATYPE of the value
+	Type DWORD
+}
+
+// InputEventValue is the value of an input event, from GetInputEvent or a subscription
+type InputEventValue struct {
+	Hash   uint64 // zero for GetInputEvent replies
+	Type   DWORD  // INPUT_EVENT_TYPE_*
+	Float  float64
+	String string
+}
+
+type inputEventRequest struct {
+	list  []InputEventDescriptor
+	value InputEventValue
+	done  chan struct{}
+}
+
+func (s *SimConnect) EnumerateInputEvents(requestID DWORD) error {
+	leave, err := s.enter(LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_EnumerateInputEvents(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_EnumerateInputEvents.Call(
+		uintptr(s.handle),
+		uintptr(requestID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_EnumerateInputEvents for requestID %d error: %d %s", requestID, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) GetInputEvent(requestID DWORD, hash uint64) error {
+	leave, err := s.enter(LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_GetInputEvent(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
+	//   UINT64 Hash
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_GetInputEvent.Call(
+		uintptr(s.handle),
+		uintptr(requestID),
+		uintptr(hash),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_GetInputEvent for hash %#x error: %d %s", hash, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) SetInputEvent(hash uint64, size DWORD, value unsafe.Pointer) error {
+	return s.SetInputEventContext(context.Background(), hash, size, value)
+}
+
+// SetInputEventContext is SetInputEvent with ctx bounding the wait for its lane
+func (s *SimConnect) SetInputEventContext(ctx context.Context, hash uint64, size DWORD, value unsafe.Pointer) error {
+	leave, err := s.enterContext(ctx, LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	// SimConnect_SetInputEvent(
+	//   HANDLE hSimConnect,
+	//   UINT64 Hash,
+	//   DWORD cbUnitSize,
+	//   void * Value
+	// );
+
+	if s.dryRun {
+		s.log.Info("Dry run: not setting input event", "hash", hash, "bytes", size)
+		return nil
+	}
+	r1, _, err := s.dll.proc_SimConnect_SetInputEvent.Call(
+		uintptr(s.handle),
+		uintptr(hash),
+		uintptr(size),
+		uintptr(value),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_SetInputEvent for hash %#x error: %d %s", hash, r1, err)
+	}
+	return nil
+}
+
+// SetInputEventFloat sets an input event with a double value
+func (s *SimConnect) SetInputEventFloat(hash uint64, v float64) error {
+	return s.SetInputEvent(hash, 8, unsafe.Pointer(&v))
+}
+
+// SetInputEventString sets an input event with a string value
+func (s *SimConnect) SetInputEventString(hash uint64, v string) error {
+	b := []byte(v + "\x00")
+	return s.SetInputEvent(hash, DWORD(len(b)), unsafe.Pointer(&b[0]))
+}
+
+func (s *SimConnect) SubscribeInputEvent(hash uint64) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_SubscribeInputEvent(
+	//   HANDLE hSimConnect,
+	//   UINT64 Hash
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_SubscribeInputEvent.Call(
+		uintptr(s.handle),
+		uintptr(hash),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_SubscribeInputEvent for hash %#x error: %d %s", hash, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) UnsubscribeInputEvent(hash uint64) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_UnsubscribeInputEvent(
+	//   HANDLE hSimConnect,
+	//   UINT64 Hash
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_UnsubscribeInputEvent.Call(
+		uintptr(s.handle),
+		uintptr(hash),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_UnsubscribeInputEvent for hash %#x error: %d %s", hash, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) EnumerateInputEventParams(hash uint64) error {
+	leave, err := s.enter(LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_EnumerateInputEventParams(
+	//   HANDLE hSimConnect,
+	//   UINT64 Hash
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_EnumerateInputEventParams.Call(
+		uintptr(s.handle),
+		uintptr(hash),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_EnumerateInputEventParams for hash %#x error: %d %s", hash, r1, err)
+	}
+	return nil
+}
+
+// inputEventRequest starts a tracked request with send and waits for it
+func (s *SimConnect) inputEventRequest(ctx context.Context, what string, send func(requestID DWORD) error) (*inputEventRequest, error) {
+	req := &inputEventRequest{done: make(chan struct{})}
+	s.mu.Lock()
+	requestID := s.nextRequestID()
+	s.inputEvents[requestID] = req
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		delete(s.inputEvents, requestID)
+		s.mu.Unlock()
+	}
+	if err := send(requestID); err != nil {
+		cancel()
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		cancel()
+		return nil, fmt.Errorf("%s: %w", what, ctx.Err())
+	case <-req.done:
+		return req, nil
+	}
+}
+
+// InputEvents enumerates the input events of the loaded aircraft and
+// remembers their hashes for InputEventHash
+// the reply is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) InputEvents(ctx context.Context) ([]InputEventDescriptor, error) {
+	req, err := s.inputEventRequest(ctx, "enumerate input events", s.EnumerateInputEvents)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.inputEventHashes = make(map[string]uint64, len(req.list))
+	for _, d := range req.list {
+		s.inputEventHashes[d.Name] = d.Hash
+	}
+	s.mu.Unlock()
+	return req.list, nil
+}
+
+// InputEventHash returns the hash of a named input event, enumerating the
+// input events if it is not known
+func (s *SimConnect) InputEventHash(ctx context.Context, name string) (uint64, error) {
+	s.mu.Lock()
+	hash, ok := s.inputEventHashes[name]
+	s.mu.Unlock()
+	if ok {
+		return hash, nil
+	}
+	if _, err := s.InputEvents(ctx); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	hash, ok = s.inputEventHashes[name]
+	s.mu.Unlock()
+	if !ok {
+		return 0, fmt.Errorf("no input event %s on the loaded aircraft", name)
+	}
+	return hash, nil
+}
+
+// ReadInputEvent gets the value of an input event and waits for it
+func (s *SimConnect) ReadInputEvent(ctx context.Context, hash uint64) (InputEventValue, error) {
+	req, err := s.inputEventRequest(ctx, fmt.Sprintf("input event %#x", hash), func(requestID DWORD) error {
+		return s.GetInputEvent(requestID, hash)
+	})
+	if err != nil {
+		return InputEventValue{}, err
+	}
+	v := req.value
+	v.Hash = hash
+	return v, nil
+}
+
+// InputEventParams returns the parameter description of an input event,
+// a semicolon separated list of types
+func (s *SimConnect) InputEventParams(ctx context.Context, hash uint64) (string, error) {
+	ch := make(chan string, 1)
+	s.mu.Lock()
+	s.inputEventParams[hash] = append(s.inputEventParams[hash], ch)
+	s.mu.Unlock()
+	if err := s.EnumerateInputEventParams(hash); err != nil {
+		return "", err
+	}
+	select {
+	case <-ctx.Done():
+		return "", fmt.Errorf("input event params %#x: %w", hash, ctx.Err())
+	case p := <-ch:
+		return p, nil
+	}
+}
+
+// HandleInputEvents calls fn with the values of subscribed input events
+// fn is called on the dispatch goroutine, so it must not block
+func (s *SimConnect) HandleInputEvents(fn func(InputEventValue)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inputEventHandler = fn
+}
+
+// DeliverInputEvent hands an input event message to its request or handler
+// it returns true if the message was consumed; the connector calls this
+// for ENUMERATE_INPUT_EVENTS, GET_INPUT_EVENT, SUBSCRIBE_INPUT_EVENT and
+// ENUMERATE_INPUT_EVENT_PARAMS messages
+// the messages are byte aligned, so fields are read by offset
+func (s *SimConnect) DeliverInputEvent(ppData unsafe.Pointer) bool {
+	recv := (*Recv)(ppData)
+	msg := unsafe.Slice((*byte)(ppData), recv.Size)
+	const header = 12 // SIMCONNECT_RECV
+	if len(msg) < header+8 {
+		return false
+	}
+	switch recv.ID {
+	case RECV_ID_ENUMERATE_INPUT_EVENTS:
+		x := (*RecvFacilityList)(ppData)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		req, ok := s.inputEvents[x.RequestID]
+		if !ok {
+			return false
+		}
+		listHeader := int(unsafe.Sizeof(*x))
+		if len(msg) > listHeader && x.ArraySize > 0 {
+			req.list = append(req.list, decodeInputEventDescriptors(msg[listHeader:], int(x.ArraySize))...)
+		}
+		if x.EntryNumber+1 >= x.OutOf {
+			delete(s.inputEvents, x.RequestID)
+			close(req.done)
+		}
+		return true
+	case RECV_ID_GET_INPUT_EVENT:
+		requestID := DWORD(binary.LittleEndian.Uint32(msg[header:]))
+		v := decodeInputEventValue(msg[header+4:])
+		s.mu.Lock()
+		req, ok := s.inputEvents[requestID]
+		delete(s.inputEvents, requestID)
+		s.mu.Unlock()
+		if ok {
+			req.value = v
+			close(req.done)
+		}
+		return ok
+	case RECV_ID_SUBSCRIBE_INPUT_EVENT:
+		v := decodeInputEventValue(msg[header+8:])
+		v.Hash = binary.LittleEndian.Uint64(msg[header:])
+		s.mu.Lock()
+		fn := s.inputEventHandler
+		s.mu.Unlock()
+		if fn == nil {
+			return false
+		}
+		fn(v)
+		return true
+	case RECV_ID_ENUMERATE_INPUT_EVENT_PARAMS:
+		hash := binary.LittleEndian.Uint64(msg[header:])
+		params, _, _ := RetrieveString(msg[header+8:])
+		s.mu.Lock()
+		waiting := s.inputEventParams[hash]
+		delete(s.inputEventParams, hash)
+		s.mu.Unlock()
+		for _, ch := range waiting {
+			ch <- params
+		}
+		return len(waiting) > 0
+	}
+	return false
+}
+
+// decodeInputEventValue reads the type and value that end the get and subscribe messages
+func decodeInputEventValue(b []byte) InputEventValue {
+	if len(b) < 4 {
+		return InputEventValue{}
+	}
+	v := InputEventValue{Type: DWORD(binary.LittleEndian.Uint32(b))}
+	b = b[4:]
+	switch v.Type {
+	case INPUT_EVENT_TYPE_DOUBLE:
+		if len(b) >= 8 {
+			v.Float = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+	case INPUT_EVENT_TYPE_STRING:
+		v.String, _, _ = RetrieveString(b)
+	}
+	return v
+}
+
+// decodeInputEventDescriptors reads the descriptors of an enumeration
+// page: a 64 byte name, the hash and the type, byte aligned (76 bytes) or
+// padded to 80
+func decodeInputEventDescriptors(data []byte, n int) []InputEventDescriptor {
+	stride := len(data) / n
+	if stride < 76 {
+		return nil
+	}
+	out := make([]InputEventDescriptor, 0, n)
+	for i := 0; i < n; i++ {
+		b := data[i*stride : (i+1)*stride]
+		out = append(out, InputEventDescriptor{
+			Name: BytesToString(b[:64]),
+			Hash: binary.LittleEndian.Uint64(b[64:]),
+			Type: DWORD(binary.LittleEndian.Uint32(b[72:])),
+		})
+	}
+	return out
+}
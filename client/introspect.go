@@ -0,0 +1,96 @@
+package client
+
+import (
+	"sort"
+	"time"
+)
+
+// Datum is a field of a registered data definition
+type Datum struct {
+	Name     string
+	Unit     string
+	DataType DWORD
+	Epsilon  float32
+}
+
+// Definition is a registered data definition
+type Definition struct {
+	ID     DWORD
+	Name   string
+	Datums []Datum
+}
+
+// Subscription is an active periodic data request
+type Subscription struct {
+	RequestID DWORD
+	DefineID  DWORD
+	ObjectID  DWORD
+	Period    DWORD
+	Flags     DWORD
+	Interval  DWORD
+}
+
+func (s *SimConnect) recordDatum(defineID DWORD, d Datum) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.datums[defineID] = append(s.datums[defineID], d)
+	s.lastUsed[defineID] = time.Now()
+}
+
+func (s *SimConnect) recordSubscription(sub Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub.Period == PERIOD_NEVER || sub.Period == PERIOD_ONCE {
+		delete(s.subscriptions, sub.RequestID)
+		return
+	}
+	s.subscriptions[sub.RequestID] = sub
+}
+
+func (s *SimConnect) recordEvent(eventID DWORD, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventNames[eventID] = name
+}
+
+// Definitions returns the data definitions registered on this connection
+func (s *SimConnect) Definitions() []Definition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make(map[DWORD]string, len(s.defineMap))
+	for name, id := range s.defineMap {
+		if name != "_last" {
+			names[id] = name
+		}
+	}
+	defs := make([]Definition, 0, len(s.datums))
+	for id, datums := range s.datums {
+		defs = append(defs, Definition{ID: id, Name: names[id], Datums: append([]Datum(nil), datums...)})
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].ID < defs[j].ID })
+	return defs
+}
+
+// Subscriptions returns the active periodic data requests on this connection
+func (s *SimConnect) Subscriptions() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subs := make([]Subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].RequestID < subs[j].RequestID })
+	return subs
+}
+
+// EventNames returns the mapped client events and subscribed system events by ID
+// system events are prefixed with "system:"
+func (s *SimConnect) EventNames() map[DWORD]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[DWORD]string, len(s.eventNames))
+	for id, name := range s.eventNames {
+		out[id] = name
+	}
+	return out
+}
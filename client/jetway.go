@@ -0,0 +1,236 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// jetway states, see SIMCONNECT_JETWAY_STATUS
+const (
+	JETWAY_STATUS_REST DWORD = iota
+	JETWAY_STATUS_APPROACH_OUTSIDE
+	JETWAY_STATUS_APPROACH_DOOR
+	JETWAY_STATUS_HOOD_CONNECT
+	JETWAY_STATUS_HOOD_DISCONNECT
+	JETWAY_STATUS_RETRACT_OUTSIDE
+	JETWAY_STATUS_RETRACT_HOME
+	JETWAY_STATUS_FULLY_ATTACHED
+)
+
+// XYZ is SIMCONNECT_DATA_XYZ, a position relative to an object in meters
+type XYZ struct {
+	X, Y, Z float64
+}
+
+// Jetway is a decoded SIMCONNECT_JETWAY_DATA
+type Jetway struct {
+	AirportICAO  string
+	ParkingIndex int32
+	// Latitude and Longitude are in degrees, Altitude in meters
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+	// Pitch, Bank and Heading are in degrees
+	Pitch   float32
+	Bank    float32
+	Heading float32
+	Status  DWORD // JETWAY_STATUS_*
+	// Door is the index of the aircraft door the jetway goes to
+	Door int32
+	// the attachment points, relative to the jetway
+	ExitDoor        XYZ
+	MainHandle      XYZ
+	SecondaryHandle XYZ
+	WheelGroundLock XYZ
+	ObjectID        DWORD
+	// AttachedObjectID is the aircraft the jetway is attached to, if any
+	AttachedObjectID DWORD
+}
+
+// Attached returns true if the jetway is connected to an aircraft
+func (j Jetway) Attached() bool {
+	return j.Status == JETWAY_STATUS_FULLY_ATTACHED
+}
+
+// jetwayOffsets are the field offsets of SIMCONNECT_JETWAY_DATA, byte
+// aligned as in the SDK headers and with natural alignment, by entry size
+var jetwayOffsets = map[int][10]int{
+	// icao, parking, lla, pbh, status, door, exit, main, secondary, wheel; ids follow wheel
+	160: {0, 8, 12, 36, 48, 52, 56, 80, 104, 128},
+	168: {0, 8, 16, 40, 52, 56, 64, 88, 112, 136},
+}
+
+// RequestJetwayData requests the jetways of parking spots at an airport
+// the reply names no request, so the client tracks jetway requests in the
+// order they are sent; each counts towards WithMaxOutstanding until its
+// last reply
+func (s *SimConnect) RequestJetwayData(airportICAO string, parkingIndices []DWORD) (err error) {
+	s.mu.Lock()
+	requestID := s.nextRequestID()
+	s.mu.Unlock()
+	sent, err := s.oneShot(context.Background(), requestID, "jetways at "+airportICAO)
+	if err != nil {
+		return err
+	}
+	defer func() { sent(err == nil) }()
+	leave, err := s.enter(LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// queued before sending, as the reply can beat the call returning
+	s.mu.Lock()
+	s.jetwayRequests = append(s.jetwayRequests, requestID)
+	s.mu.Unlock()
+
+	// SimConnect_RequestJetwayData(
+	//   HANDLE hSimConnect,
+	//   const char * AirportIcao,
+	//   DWORD ArrayCount,
+	//   int * Indexes
+	// );
+
+	_airportICAO := []byte(airportICAO + "\x00")
+	var indexes uintptr
+	if len(parkingIndices) > 0 {
+		indexes = uintptr(unsafe.Pointer(&parkingIndices[0]))
+	}
+
+	r1, _, err := s.dll.proc_SimConnect_RequestJetwayData.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&_airportICAO[0])),
+		uintptr(len(parkingIndices)),
+		indexes,
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_RequestJetwayData for %s error: %d %s", airportICAO, r1, err)
+	}
+	return nil
+}
+
+// Jetways requests the jetways of the parking spots at an airport and
+// waits for all of them; no indices asks for every jetway at the airport
+// the reply does not name the request, so concurrent calls are answered in order
+// it is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) Jetways(ctx context.Context, airportICAO string, parkingIndices ...DWORD) ([]Jetway, error) {
+	w := &jetwayWaiter{done: make(chan struct{})}
+	s.mu.Lock()
+	s.jetways = append(s.jetways, w)
+	s.mu.Unlock()
+	cancel := func() {
+		s.mu.Lock()
+		for i, o := range s.jetways {
+			if o == w {
+				s.jetways = append(s.jetways[:i], s.jetways[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}
+	if err := s.RequestJetwayData(airportICAO, parkingIndices); err != nil {
+		cancel()
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		cancel()
+		return nil, fmt.Errorf("jetways at %s: %w", airportICAO, ctx.Err())
+	case <-w.done:
+		return w.items, nil
+	}
+}
+
+type jetwayWaiter struct {
+	items []Jetway
+	done  chan struct{}
+}
+
+// DeliverJetways hands a jetway data message to the oldest waiting Jetways
+// it returns true if the message was consumed; the connector calls this
+// for JETWAY_DATA messages
+func (s *SimConnect) DeliverJetways(ppData unsafe.Pointer) bool {
+	x := (*RecvFacilityList)(ppData)
+	header := int(unsafe.Sizeof(*x))
+	var items []Jetway
+	if int(x.Size) > header && x.ArraySize > 0 {
+		data := unsafe.Slice((*byte)(unsafe.Add(ppData, header)), int(x.Size)-header)
+		items = decodeJetways(data, int(x.ArraySize))
+	}
+
+	if x.EntryNumber+1 >= x.OutOf {
+		if requestID, ok := s.nextJetwayRequest(); ok {
+			s.completeRequest(requestID)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.jetways) == 0 {
+		return false
+	}
+	w := s.jetways[0]
+	w.items = append(w.items, items...)
+	if x.EntryNumber+1 >= x.OutOf {
+		s.jetways = s.jetways[1:]
+		close(w.done)
+	}
+	return true
+}
+
+// nextJetwayRequest returns the oldest jetway request still outstanding,
+// skipping those ended by an exception or a failed send
+func (s *SimConnect) nextJetwayRequest() (DWORD, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.jetwayRequests) > 0 {
+		requestID := s.jetwayRequests[0]
+		s.jetwayRequests = s.jetwayRequests[1:]
+		if _, ok := s.outstanding[requestID]; ok {
+			return requestID, true
+		}
+	}
+	return 0, false
+}
+
+func decodeJetways(data []byte, n int) []Jetway {
+	stride := len(data) / n
+	off, ok := jetwayOffsets[stride]
+	if !ok {
+		off = jetwayOffsets[160]
+		if stride < 160 {
+			return nil
+		}
+	}
+	f64 := func(b []byte, o int) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(b[o:])) }
+	f32 := func(b []byte, o int) float32 { return math.Float32frombits(binary.LittleEndian.Uint32(b[o:])) }
+	u32 := func(b []byte, o int) DWORD { return DWORD(binary.LittleEndian.Uint32(b[o:])) }
+	xyz := func(b []byte, o int) XYZ { return XYZ{f64(b, o), f64(b, o+8), f64(b, o+16)} }
+
+	out := make([]Jetway, 0, n)
+	for i := 0; i < n; i++ {
+		b := data[i*stride : (i+1)*stride]
+		ids := off[9] + 24
+		out = append(out, Jetway{
+			AirportICAO:      BytesToString(b[off[0] : off[0]+8]),
+			ParkingIndex:     int32(u32(b, off[1])),
+			Latitude:         f64(b, off[2]),
+			Longitude:        f64(b, off[2]+8),
+			Altitude:         f64(b, off[2]+16),
+			Pitch:            f32(b, off[3]),
+			Bank:             f32(b, off[3]+4),
+			Heading:          f32(b, off[3]+8),
+			Status:           u32(b, off[4]),
+			Door:             int32(u32(b, off[5])),
+			ExitDoor:         xyz(b, off[6]),
+			MainHandle:       xyz(b, off[7]),
+			SecondaryHandle:  xyz(b, off[8]),
+			WheelGroundLock:  xyz(b, off[9]),
+			ObjectID:         u32(b, ids),
+			AttachedObjectID: u32(b, ids+4),
+		})
+	}
+	return out
+}
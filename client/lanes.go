@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Lane is the priority of a call into SimConnect
+type Lane int
+
+const (
+	// LaneHigh is for commands, eg TransmitClientEvent and SetData
+	LaneHigh Lane = iota
+	// LaneNormal is for everything not in another lane, eg ShowText
+	LaneNormal
+	// LaneLow is for periodic traffic, eg data requests and GetNextDispatch
+	LaneLow
+	laneCount
+)
+
+// WithCallLanes serialises calls into SimConnect in priority lanes, so a
+// queued command runs before any queued data request or dispatch
+// without it calls run as soon as they are made
+//
+// every call that sends SimConnect a packet waits for its lane; Open,
+// Close and GetLastSentPacketID, which send nothing, do not. The XContext
+// variants, eg SetDataOnSimObjectContext, are the calls made in steady
+// traffic, and let ctx bound the wait; the setup calls, eg
+// AddToDataDefinition and MapClientEventToSimEvent, and the rest have no
+// ctx, and only WithCallTimeout bounds their wait
+func WithCallLanes() SimConnectOption {
+	return func(s *SimConnect) {
+		s.lanes = newLanes()
+	}
+}
+
+// lanes lets one call in at a time, highest lane first
+type lanes struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	busy    bool
+	waiting [laneCount]int
+}
+
+func newLanes() *lanes {
+	l := &lanes{}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// WithCallTimeout bounds how long a call waits for its lane, on top of
+// any context passed to the XContext variants; a call that times out
+// returns context.DeadlineExceeded without reaching SimConnect
+// it has no effect without WithCallLanes, as calls never wait
+func WithCallTimeout(d time.Duration) SimConnectOption {
+	return func(s *SimConnect) {
+		s.callTimeout = d
+	}
+}
+
+// enterContext waits for the lane's turn and returns the function that
+// ends it, giving up when ctx ends or the call timeout passes
+// the wait happens before any call arguments are built, so no pointer
+// passed to the DLL is held across it
+func (s *SimConnect) enterContext(ctx context.Context, lane Lane) (func(), error) {
+	l := s.lanes
+	if l == nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return func() {}, nil
+	}
+	if s.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.callTimeout)
+		defer cancel()
+	}
+	// wake the waiters when ctx ends, so this one can give up
+	stop := context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		l.cond.Broadcast()
+		l.mu.Unlock()
+	})
+	defer stop()
+
+	l.mu.Lock()
+	l.waiting[lane]++
+	for l.busy || l.higherWaiting(lane) {
+		if ctx.Err() != nil {
+			l.waiting[lane]--
+			// a lower lane may have been held back by this one
+			l.cond.Broadcast()
+			l.mu.Unlock()
+			return nil, fmt.Errorf("waiting for lane %d: %w", lane, ctx.Err())
+		}
+		l.cond.Wait()
+	}
+	l.waiting[lane]--
+	l.busy = true
+	l.mu.Unlock()
+	return func() {
+		l.mu.Lock()
+		l.busy = false
+		l.cond.Broadcast()
+		l.mu.Unlock()
+	}, nil
+}
+
+// enter is enterContext for the calls without an XContext variant
+func (s *SimConnect) enter(lane Lane) (func(), error) {
+	return s.enterContext(context.Background(), lane)
+}
+
+// higherWaiting reports whether a higher lane has callers waiting; l.mu must be held
+func (l *lanes) higherWaiting(lane Lane) bool {
+	for i := Lane(0); i < lane; i++ {
+		if l.waiting[i] > 0 {
+			return true
+		}
+	}
+	return false
+}
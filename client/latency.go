@@ -0,0 +1,154 @@
+package client
+
+import (
+	"sort"
+	"time"
+)
+
+// maxLatencySamples is the number of round trips kept per definition
+const maxLatencySamples = 256
+
+// maxRequestTimes bounds the sent requests waiting for a first reply;
+// requests that never get one, eg by type with nothing in range, are pruned
+// after a minute, and past the bound the oldest goes
+const maxRequestTimes = 1024
+
+// LatencyStats is the round trip latency of data requests for a definition,
+// measured from the request to its first reply
+type LatencyStats struct {
+	DefineID DWORD
+	Name     string
+	// Count is the number of samples the percentiles are taken over
+	Count int
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+type requestTime struct {
+	defineID DWORD
+	sendID   DWORD
+	at       time.Time
+}
+
+// latencyRing keeps the most recent samples of a definition
+type latencyRing struct {
+	samples []time.Duration
+	next    int
+}
+
+func (r *latencyRing) add(d time.Duration) {
+	if len(r.samples) < maxLatencySamples {
+		r.samples = append(r.samples, d)
+		return
+	}
+	r.samples[r.next] = d
+	r.next = (r.next + 1) % maxLatencySamples
+}
+
+// recordRequestSent timestamps a data request; s.mu must not be held
+func (s *SimConnect) recordRequestSent(requestID, defineID DWORD) {
+	now := time.Now()
+	// the send ID lets an exception about the request drop it
+	sendID, _ := s.GetLastSentPacketID()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.requestTimes[requestID]; !ok && len(s.requestTimes) >= maxRequestTimes {
+		var oldest DWORD
+		var oldestAt time.Time
+		for id, rt := range s.requestTimes {
+			if now.Sub(rt.at) > time.Minute {
+				delete(s.requestTimes, id)
+			} else if oldestAt.IsZero() || rt.at.Before(oldestAt) {
+				oldest, oldestAt = id, rt.at
+			}
+		}
+		if len(s.requestTimes) >= maxRequestTimes {
+			delete(s.requestTimes, oldest)
+		}
+	}
+	s.requestTimes[requestID] = requestTime{defineID: defineID, sendID: sendID, at: now}
+}
+
+// forgetRequestSent drops a request that will get no reply to time
+func (s *SimConnect) forgetRequestSent(requestID DWORD) {
+	s.mu.Lock()
+	delete(s.requestTimes, requestID)
+	s.mu.Unlock()
+}
+
+// forgetRequestSend drops the request an exception is about
+func (s *SimConnect) forgetRequestSend(sendID DWORD) {
+	if sendID == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, rt := range s.requestTimes {
+		if rt.sendID == sendID {
+			delete(s.requestTimes, id)
+			return
+		}
+	}
+}
+
+// ObserveReply records the latency of the first reply to a data request,
+// and ends a one-shot request on its last reply
+// the connector calls this for every data message
+func (s *SimConnect) ObserveReply(p *RecvSimobjectData) {
+	if p.EntryNumber >= p.OutOf {
+		s.completeRequest(p.RequestID)
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.requestTimes[p.RequestID]
+	if !ok {
+		return
+	}
+	delete(s.requestTimes, p.RequestID)
+	r, ok := s.latencies[rt.defineID]
+	if !ok {
+		r = &latencyRing{}
+		s.latencies[rt.defineID] = r
+	}
+	r.add(now.Sub(rt.at))
+}
+
+// Latencies returns the request latency percentiles of each definition
+// that has been requested, by name
+// a definition whose latency grows with its period is too heavy for it
+func (s *SimConnect) Latencies() []LatencyStats {
+	s.mu.Lock()
+	names := make(map[DWORD]string, len(s.defineMap))
+	for name, id := range s.defineMap {
+		if name != "_last" {
+			names[id] = name
+		}
+	}
+	out := make([]LatencyStats, 0, len(s.latencies))
+	for id, r := range s.latencies {
+		sorted := append([]time.Duration(nil), r.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		n := len(sorted)
+		if n == 0 {
+			continue
+		}
+		pct := func(p float64) time.Duration {
+			return sorted[int(p*float64(n-1))]
+		}
+		out = append(out, LatencyStats{
+			DefineID: id,
+			Name:     names[id],
+			Count:    n,
+			P50:      pct(0.5),
+			P90:      pct(0.9),
+			P99:      pct(0.99),
+			Max:      sorted[n-1],
+		})
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ClientError is the error type for the client
+type ClientError string
+
+func (e ClientError) Error() string { return string(e) }
+
+const (
+	// ErrDefinitionMismatch is returned when a struct is used with a define ID
+	// that was registered from a different layout
+	ErrDefinitionMismatch ClientError = "definition mismatch"
+	// ErrEventIDCollision is returned when an event ID is mapped to a
+	// second event on the same connection
+	ErrEventIDCollision ClientError = "event ID collision"
+	// ErrLayoutMismatch is returned when the offset or size tags of a
+	// struct don't match the layout SimConnect sends
+	ErrLayoutMismatch ClientError = "layout mismatch"
+)
+
+var fingerprints sync.Map // map[reflect.Type]string
+
+// fingerprint describes the wire layout of a struct type
+// it includes the package path so same-named types in different packages differ
+func fingerprint(t reflect.Type) string {
+	if fp, ok := fingerprints.Load(t); ok {
+		return fp.(string)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s.%s{", t.PkgPath(), t.Name())
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, ok := f.Tag.Lookup("name")
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&b, "%s|%s|%s", name, f.Tag.Get("unit"), f.Type.String())
+			if off, ok := f.Tag.Lookup("offset"); ok {
+				fmt.Fprintf(&b, "@%s", off)
+			}
+			b.WriteString(";")
+		}
+	}
+	b.WriteString("}")
+	fp := b.String()
+	fingerprints.Store(t, fp)
+	return fp
+}
+
+func structType(a any) reflect.Type {
+	t := reflect.TypeOf(a)
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		t = t.Elem()
+	}
+	return t
+}
+
+// CheckDefinition returns the define ID for a struct, or ErrDefinitionMismatch
+// if the ID was registered from a different layout
+// unregistered structs are not an error, as SimConnect reports those itself
+func (s *SimConnect) CheckDefinition(a any) (DWORD, error) {
+	t := structType(a)
+	id := s.GetDefineID(a)
+	fp := fingerprint(t)
+
+	s.mu.Lock()
+	registered, ok := s.layouts[id]
+	version := s.versions[id]
+	s.mu.Unlock()
+	if ok && registered != fp {
+		if version != "" {
+			return id, fmt.Errorf("%w: define ID %d is %s (version %s), not %s", ErrDefinitionMismatch, id, registered, version, fp)
+		}
+		return id, fmt.Errorf("%w: define ID %d is %s, not %s", ErrDefinitionMismatch, id, registered, fp)
+	}
+	return id, nil
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"unsafe"
+)
+
+// Livery is a decoded SIMCONNECT_ENUMERATE_SIMOBJECT_LIVERY, a title and
+// livery combination that AICreate* accepts
+type Livery struct {
+	Title  string
+	Livery string
+}
+
+// liverySize is SIMCONNECT_ENUMERATE_SIMOBJECT_LIVERY: two 256 byte strings
+const liverySize = 2 * 256
+
+type liveryRequest struct {
+	items []Livery
+	done  chan struct{}
+}
+
+// EnumerateSimObjectsAndLiveries lists the installed simobjects of a type
+// it is only exported by the MSFS 2024 dll, see LoadNewDefaultDLL
+func (s *SimConnect) EnumerateSimObjectsAndLiveries(requestID, objectType DWORD) error {
+	leave, err := s.enter(LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_EnumerateSimObjectsAndLiveries(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
+	//   SIMCONNECT_SIMOBJECT_TYPE Type
+	// );
+
+	if err := available(s.dll.proc_SimConnect_EnumerateSimObjectsAndLiveries); err != nil {
+		return fmt.Errorf("SimConnect_EnumerateSimObjectsAndLiveries: %w", err)
+	}
+	r1, _, err := s.dll.proc_SimConnect_EnumerateSimObjectsAndLiveries.Call(
+		uintptr(s.handle),
+		uintptr(requestID),
+		uintptr(objectType),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_EnumerateSimObjectsAndLiveries for type %d error: %d %s", objectType, r1, err)
+	}
+	return nil
+}
+
+// Liveries lists the installed simobjects of a type (SIMOBJECT_TYPE_*)
+// with their liveries and waits for the whole list
+// the reply is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) Liveries(ctx context.Context, objectType DWORD) ([]Livery, error) {
+	req := &liveryRequest{done: make(chan struct{})}
+	s.mu.Lock()
+	requestID := s.nextRequestID()
+	s.liveries[requestID] = req
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		delete(s.liveries, requestID)
+		s.mu.Unlock()
+	}
+	if err := s.EnumerateSimObjectsAndLiveries(requestID, objectType); err != nil {
+		cancel()
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		cancel()
+		return nil, fmt.Errorf("liveries of type %d: %w", objectType, ctx.Err())
+	case <-req.done:
+		return req.items, nil
+	}
+}
+
+// DeliverLiveries hands a simobject and livery list to its request
+// it returns true if the message was consumed; the connector calls this
+// for ENUMERATE_SIMOBJECT_AND_LIVERY_LIST messages
+func (s *SimConnect) DeliverLiveries(ppData unsafe.Pointer) bool {
+	x := (*RecvFacilityList)(ppData)
+	header := int(unsafe.Sizeof(*x))
+	var items []Livery
+	if int(x.Size) > header && x.ArraySize > 0 {
+		data := unsafe.Slice((*byte)(unsafe.Add(ppData, header)), int(x.Size)-header)
+		items = decodeLiveries(data, int(x.ArraySize))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	req, ok := s.liveries[x.RequestID]
+	if !ok {
+		return false
+	}
+	req.items = append(req.items, items...)
+	if x.EntryNumber+1 >= x.OutOf {
+		delete(s.liveries, x.RequestID)
+		close(req.done)
+	}
+	return true
+}
+
+func decodeLiveries(data []byte, n int) []Livery {
+	stride := len(data) / n
+	if stride < liverySize {
+		return nil
+	}
+	out := make([]Livery, 0, n)
+	for i := 0; i < n; i++ {
+		b := data[i*stride : (i+1)*stride]
+		out = append(out, Livery{
+			Title:  BytesToString(b[:256]),
+			Livery: BytesToString(b[256:512]),
+		})
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// GUID is a Windows GUID, as used by mission actions
+type GUID struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+// String formats the GUID as {xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx}
+func (g GUID) String() string {
+	return fmt.Sprintf("{%08X-%04X-%04X-%02X%02X-%02X%02X%02X%02X%02X%02X}",
+		g.Data1, g.Data2, g.Data3,
+		g.Data4[0], g.Data4[1], g.Data4[2], g.Data4[3], g.Data4[4], g.Data4[5], g.Data4[6], g.Data4[7])
+}
+
+// ParseGUID parses a GUID with or without braces, as in mission files
+func ParseGUID(s string) (GUID, error) {
+	var g GUID
+	if len(s) > 1 && s[0] == '{' && s[len(s)-1] == '}' {
+		s = s[1 : len(s)-1]
+	}
+	var d4 [2]uint8
+	var d5 [6]uint8
+	n, err := fmt.Sscanf(s, "%08X-%04X-%04X-%02X%02X-%02X%02X%02X%02X%02X%02X",
+		&g.Data1, &g.Data2, &g.Data3, &d4[0], &d4[1], &d5[0], &d5[1], &d5[2], &d5[3], &d5[4], &d5[5])
+	if err != nil || n != 11 {
+		return GUID{}, fmt.Errorf("invalid GUID %q", s)
+	}
+	copy(g.Data4[:2], d4[:])
+	copy(g.Data4[2:], d5[:])
+	return g, nil
+}
+
+type RecvCustomAction struct {
+	RecvEvent
+	InstanceID        GUID  // the mission action that triggered this
+	WaitForCompletion DWORD // the mission waits for CompleteCustomMissionAction
+	PayLoad           [1]byte
+}
+
+// CustomAction is a decoded RecvCustomAction
+type CustomAction struct {
+	EventID    DWORD
+	InstanceID GUID
+	// Wait is true if the mission waits for CompleteCustomMissionAction
+	Wait    bool
+	PayLoad string
+}
+
+// ExecuteMissionAction runs a mission action by its instance ID
+func (s *SimConnect) ExecuteMissionAction(instanceID GUID) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_ExecuteMissionAction(
+	//   HANDLE hSimConnect,
+	//   const GUID guidInstanceId
+	// );
+	// the GUID is larger than a register, so it is passed by reference
+
+	if err := available(s.dll.proc_SimConnect_ExecuteMissionAction); err != nil {
+		return fmt.Errorf("SimConnect_ExecuteMissionAction: %w", err)
+	}
+	if s.dryRun {
+		s.log.Info("Dry run: execute mission action", "instance", instanceID)
+		return nil
+	}
+	r1, _, err := s.dll.proc_SimConnect_ExecuteMissionAction.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&instanceID)),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_ExecuteMissionAction for %s error: %d %s", instanceID, r1, err)
+	}
+	return nil
+}
+
+// CompleteCustomMissionAction tells the mission a custom action that
+// waits for completion is done
+func (s *SimConnect) CompleteCustomMissionAction(instanceID GUID) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_CompleteCustomMissionAction(
+	//   HANDLE hSimConnect,
+	//   const GUID guidInstanceId
+	// );
+
+	if err := available(s.dll.proc_SimConnect_CompleteCustomMissionAction); err != nil {
+		return fmt.Errorf("SimConnect_CompleteCustomMissionAction: %w", err)
+	}
+	r1, _, err := s.dll.proc_SimConnect_CompleteCustomMissionAction.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&instanceID)),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_CompleteCustomMissionAction for %s error: %d %s", instanceID, r1, err)
+	}
+	return nil
+}
+
+// HandleCustomActions calls fn with the custom actions missions trigger
+// fn is called on the dispatch goroutine, so it must not block; an action
+// with Wait set must be completed with CompleteCustomMissionAction
+func (s *SimConnect) HandleCustomActions(fn func(CustomAction)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.customActionHandler = fn
+}
+
+// DeliverCustomAction decodes a custom action and hands it to the handler
+// it returns true if the message was consumed; the connector calls this
+// for CUSTOM_ACTION messages
+func (s *SimConnect) DeliverCustomAction(ppData unsafe.Pointer) bool {
+	x := (*RecvCustomAction)(ppData)
+	a := CustomAction{
+		EventID:    x.EventID,
+		InstanceID: x.InstanceID,
+		Wait:       x.WaitForCompletion != 0,
+	}
+	header := DWORD(unsafe.Offsetof(x.PayLoad))
+	if x.Size > header {
+		a.PayLoad, _, _ = RetrieveString(unsafe.Slice((*byte)(unsafe.Pointer(&x.PayLoad)), x.Size-header))
+	}
+	s.mu.Lock()
+	fn := s.customActionHandler
+	s.mu.Unlock()
+	if fn == nil {
+		return false
+	}
+	fn(a)
+	return true
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// firstOneShotRequestID is the first request ID used by one-shot reads
+// it is well above the define IDs that RequestData uses as request IDs
+const firstOneShotRequestID DWORD = 0x10000000
+
+// nextRequestID allocates a one-shot request ID; s.mu must be held
+func (s *SimConnect) nextRequestID() DWORD {
+	if s.lastRequestID < firstOneShotRequestID {
+		s.lastRequestID = firstOneShotRequestID
+	}
+	requestID := s.lastRequestID
+	s.lastRequestID++
+	return requestID
+}
+
+// Deliver hands a data message to a pending one-shot request
+// it returns true if the message was consumed; the connector calls this
+// before passing data to receivers
+func (s *SimConnect) Deliver(ppData *RecvSimobjectData) bool {
+	s.mu.Lock()
+	ch, ok := s.pending[ppData.RequestID]
+	if ok {
+		delete(s.pending, ppData.RequestID)
+	}
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	header := DWORD(unsafe.Sizeof(*ppData))
+	var data []byte
+	if ppData.Size > header {
+		data = make([]byte, ppData.Size-header)
+		copy(data, unsafe.Slice((*byte)(ppData.DataPointer()), len(data)))
+	}
+	ch <- data
+	return true
+}
+
+// datumDefinition returns a single-datum definition, registering it on first use
+// it is shared by the one-shot reads and writes
+func (s *SimConnect) datumDefinition(name, unit string, dataType DWORD) (DWORD, error) {
+	key := fmt.Sprintf("datum:%d:%s:%s", dataType, name, unit)
+	return s.cachedDefinition(key, func(defineID DWORD) error {
+		return s.AddToDataDefinition(defineID, name, unit, dataType)
+	})
+}
+
+// cachedDefinition returns the definition cached under key, calling add to
+// build it on first use
+// the key is published only once add succeeds, so a concurrent caller waits
+// for it rather than using a definition still being built, and after a
+// failure the next caller builds it afresh
+func (s *SimConnect) cachedDefinition(key string, add func(defineID DWORD) error) (DWORD, error) {
+	s.mu.Lock()
+	for {
+		if defineID, ok := s.defineMap[key]; ok {
+			s.mu.Unlock()
+			return defineID, nil
+		}
+		building, ok := s.building[key]
+		if !ok {
+			break
+		}
+		s.mu.Unlock()
+		<-building
+		s.mu.Lock()
+	}
+	building := make(chan struct{})
+	s.building[key] = building
+	defineID := s.defineMap["_last"]
+	s.defineMap["_last"] = defineID + 1
+	s.mu.Unlock()
+
+	err := add(defineID)
+	s.mu.Lock()
+	delete(s.building, key)
+	if err == nil {
+		s.defineMap[key] = defineID
+	}
+	s.mu.Unlock()
+	close(building)
+	if err != nil {
+		return 0, err
+	}
+	return defineID, nil
+}
+
+// readOnce performs a one-shot request for a single datum and waits for the reply
+// the reply is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) readOnce(ctx context.Context, name, unit string, dataType DWORD) ([]byte, error) {
+	return s.readObject(ctx, OBJECT_ID_USER, name, unit, dataType)
+}
+
+// readObject is readOnce for any object
+func (s *SimConnect) readObject(ctx context.Context, objectID DWORD, name, unit string, dataType DWORD) ([]byte, error) {
+	defineID, err := s.datumDefinition(name, unit, dataType)
+	if err != nil {
+		return nil, err
+	}
+	return s.readDefinition(ctx, objectID, defineID, name)
+}
+
+// readDefinition performs a one-shot request for a definition and waits for the reply
+// what names the data in errors
+func (s *SimConnect) readDefinition(ctx context.Context, objectID, defineID DWORD, what string) ([]byte, error) {
+	requestID, end, err := s.beginRequest(ctx, "read "+what)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+
+	ch := make(chan []byte, 1)
+	s.mu.Lock()
+	s.pending[requestID] = ch
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		delete(s.pending, requestID)
+		s.mu.Unlock()
+	}
+	if objectID == OBJECT_ID_USER {
+		err = s.RequestDataOnSimObjectTypeContext(ctx, requestID, defineID, 0, SIMOBJECT_TYPE_USER)
+	} else {
+		err = s.RequestDataOnSimObjectContext(ctx, requestID, defineID, objectID, PERIOD_ONCE, DATA_REQUEST_FLAG_DEFAULT, 0, 0, 0)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	exceptions := s.watchSend(requestID)
+	select {
+	case <-ctx.Done():
+		cancel()
+		return nil, fmt.Errorf("read %s: %w", what, ctx.Err())
+	case ex := <-exceptions:
+		cancel()
+		return nil, fmt.Errorf("read %s: %w", what, ex)
+	case data := <-ch:
+		return data, nil
+	}
+}
+
+// ReadFloat reads a single simvar as a float64
+func (s *SimConnect) ReadFloat(ctx context.Context, name, unit string) (float64, error) {
+	data, err := s.readOnce(ctx, name, unit, DATATYPE_FLOAT64)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 8 {
+		return 0, fmt.Errorf("read %s: short reply of %d bytes", name, len(data))
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+}
+
+// ReadObjectFloat reads a single simvar of an object, eg an AI object, as a float64
+func (s *SimConnect) ReadObjectFloat(ctx context.Context, objectID DWORD, name, unit string) (float64, error) {
+	data, err := s.readObject(ctx, objectID, name, unit, DATATYPE_FLOAT64)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 8 {
+		return 0, fmt.Errorf("read %s: short reply of %d bytes", name, len(data))
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+}
+
+// ReadInt reads a single simvar as an int64
+func (s *SimConnect) ReadInt(ctx context.Context, name, unit string) (int64, error) {
+	data, err := s.readOnce(ctx, name, unit, DATATYPE_INT64)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 8 {
+		return 0, fmt.Errorf("read %s: short reply of %d bytes", name, len(data))
+	}
+	return int64(binary.LittleEndian.Uint64(data)), nil
+}
+
+// ReadString reads a single string simvar, up to 255 bytes; see ReadStringV
+// for longer ones
+func (s *SimConnect) ReadString(ctx context.Context, name string) (string, error) {
+	data, err := s.readOnce(ctx, name, "", DATATYPE_STRING256)
+	if err != nil {
+		return "", err
+	}
+	return BytesToString(data), nil
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClearDataDefinition removes every datum from a definition in the sim
+// the client's bookkeeping is left alone; use ReRegisterDataDefinition to
+// rebuild a struct definition
+func (s *SimConnect) ClearDataDefinition(defineID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_ClearDataDefinition(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_DEFINITION_ID DefineID
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_ClearDataDefinition.Call(
+		uintptr(s.handle),
+		uintptr(defineID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_ClearDataDefinition for defineID %d error: %d %s", defineID, r1, err)
+	}
+	return nil
+}
+
+// ReRegisterDataDefinition clears the struct's definition and registers it
+// again, so a struct whose fields changed at runtime gets its new layout
+// the define ID is kept, so periodic requests and IsReport still match
+func (s *SimConnect) ReRegisterDataDefinition(a any) error {
+	defineID := s.GetDefineID(a)
+	s.mu.Lock()
+	_, registered := s.datums[defineID]
+	s.mu.Unlock()
+	if registered {
+		if err := s.ClearDataDefinition(defineID); err != nil {
+			return err
+		}
+	}
+	s.forgetDefinition(defineID)
+	return s.RegisterDataDefinition(a)
+}
+
+// forgetDefinition drops the client's record of a cleared definition
+func (s *SimConnect) forgetDefinition(defineID DWORD) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.datums, defineID)
+	delete(s.layouts, defineID)
+	delete(s.conversions, defineID)
+	delete(s.explicit, defineID)
+	delete(s.versions, defineID)
+	delete(s.latencies, defineID)
+	delete(s.lastUsed, defineID)
+}
+
+// MarkUsed records that a definition is in use, keeping it from Reclaim
+// requests and writes mark their definition, and the connector marks it
+// whenever a reply is handed to the receivers, so only code dispatching
+// by hand needs to call it
+func (s *SimConnect) MarkUsed(defineID DWORD) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastUsed[defineID] = time.Now()
+}
+
+// Reclaimed is what Reclaim released
+type Reclaimed struct {
+	Definitions   []Definition
+	Subscriptions []Subscription
+}
+
+// Reclaim releases definitions and periodic requests not used for idle
+// a periodic request is unused when none of its replies reached a receiver
+// for idle; requests with DATA_REQUEST_FLAG_CHANGED are never reclaimed,
+// as a value that holds still sends nothing however much it is watched
+// a definition is unused when it has also not been requested or written
+// only struct definitions and the one-shot datum cache are reclaimed, as they are registered again on next use; definitions built
+// by hand under GetDefineIDByName are left alone
+func (s *SimConnect) Reclaim(idle time.Duration) (Reclaimed, error) {
+	var out Reclaimed
+	var errs []error
+	cutoff := time.Now().Add(-idle)
+
+	s.mu.Lock()
+	var subs []Subscription
+	for _, sub := range s.subscriptions {
+		if sub.Flags&DATA_REQUEST_FLAG_CHANGED == 0 && s.lastUsed[sub.DefineID].Before(cutoff) {
+			subs = append(subs, sub)
+		}
+	}
+	s.mu.Unlock()
+	for _, sub := range subs {
+		if err := s.RequestDataOnSimObject(sub.RequestID, sub.DefineID, sub.ObjectID, PERIOD_NEVER, DATA_REQUEST_FLAG_DEFAULT, 0, 0, 0); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		out.Subscriptions = append(out.Subscriptions, sub)
+	}
+
+	s.mu.Lock()
+	names := make(map[DWORD]string, len(s.defineMap))
+	for name, id := range s.defineMap {
+		if name != "_last" {
+			names[id] = name
+		}
+	}
+	active := map[DWORD]bool{}
+	for _, sub := range s.subscriptions {
+		active[sub.DefineID] = true
+	}
+	var defs []Definition
+	for id, datums := range s.datums {
+		name := names[id]
+		_, isStruct := s.layouts[id]
+		if !isStruct && !isCacheName(name) {
+			continue
+		}
+		if active[id] || !s.lastUsed[id].Before(cutoff) {
+			continue
+		}
+		defs = append(defs, Definition{ID: id, Name: name, Datums: append([]Datum(nil), datums...)})
+	}
+	s.mu.Unlock()
+
+	for _, d := range defs {
+		if err := s.ClearDataDefinition(d.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.forgetDefinition(d.ID)
+		s.mu.Lock()
+		// caches are keyed by name; dropping the key gets a fresh ID on next
+		// use, while a struct keeps its ID so IsReport still matches
+		if isCacheName(d.Name) {
+			delete(s.defineMap, d.Name)
+		}
+		s.mu.Unlock()
+		out.Definitions = append(out.Definitions, d)
+	}
+	return out, errors.Join(errs...)
+}
+
+func isCacheName(name string) bool {
+	return strings.HasPrefix(name, "datum:")
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"unsafe"
+)
+
+// RecvReservedKey is SIMCONNECT_RECV_RESERVED_KEY
+type RecvReservedKey struct {
+	Recv
+	ChoiceReserved [30]byte
+	ReservedKey    [50]byte
+}
+
+// ReservedKey is the key the sim gave to a RequestReservedKey
+type ReservedKey struct {
+	// Choice is the choice that was free, eg "q"
+	Choice string
+	// Key is the full key definition, eg "VK_LSHIFT+VK_Q"
+	Key string
+}
+
+func (s *SimConnect) RequestReservedKey(eventID DWORD, keyChoice1, keyChoice2, keyChoice3 string) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_RequestReservedKey(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_CLIENT_EVENT_ID EventID,
+	//   const char * szKeyChoice1 = "",
+	//   const char * szKeyChoice2 = "",
+	//   const char * szKeyChoice3 = ""
+	// );
+
+	_keyChoice1 := []byte(keyChoice1 + "\x00")
+	_keyChoice2 := []byte(keyChoice2 + "\x00")
+	_keyChoice3 := []byte(keyChoice3 + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_RequestReservedKey.Call(
+		uintptr(s.handle),
+		uintptr(eventID),
+		uintptr(unsafe.Pointer(&_keyChoice1[0])),
+		uintptr(unsafe.Pointer(&_keyChoice2[0])),
+		uintptr(unsafe.Pointer(&_keyChoice3[0])),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_RequestReservedKey for eventID %d error: %d %s", eventID, r1, err)
+	}
+	return nil
+}
+
+// ReserveKey asks the sim for the first free key of up to three choices,
+// eg "q", and waits for the key it reserved
+// the client event fires when the key is pressed; map it with
+// MapClientEventToSimEvent and add it to a notification group first
+// the reply does not name the event, so concurrent calls are answered in order
+// it is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) ReserveKey(ctx context.Context, eventID DWORD, choices ...string) (ReservedKey, error) {
+	if len(choices) == 0 || len(choices) > 3 {
+		return ReservedKey{}, fmt.Errorf("reserve key for eventID %d: want 1 to 3 choices, got %d", eventID, len(choices))
+	}
+	c := make([]string, 3)
+	copy(c, choices)
+
+	ch := make(chan RecvReservedKey, 1)
+	s.mu.Lock()
+	s.reservedKeys = append(s.reservedKeys, ch)
+	s.mu.Unlock()
+	cancel := func() {
+		s.mu.Lock()
+		for i, w := range s.reservedKeys {
+			if w == ch {
+				s.reservedKeys = append(s.reservedKeys[:i], s.reservedKeys[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	if err := s.RequestReservedKey(eventID, c[0], c[1], c[2]); err != nil {
+		cancel()
+		return ReservedKey{}, err
+	}
+	select {
+	case <-ctx.Done():
+		cancel()
+		return ReservedKey{}, fmt.Errorf("reserve key for eventID %d: %w", eventID, ctx.Err())
+	case x := <-ch:
+		return ReservedKey{
+			Choice: BytesToString(x.ChoiceReserved[:]),
+			Key:    BytesToString(x.ReservedKey[:]),
+		}, nil
+	}
+}
+
+// DeliverReservedKey hands a reserved key reply to the oldest waiting ReserveKey
+// it returns true if the reply was consumed; the connector calls this
+// for RESERVED_KEY messages
+func (s *SimConnect) DeliverReservedKey(x *RecvReservedKey) bool {
+	s.mu.Lock()
+	if len(s.reservedKeys) == 0 {
+		s.mu.Unlock()
+		return false
+	}
+	ch := s.reservedKeys[0]
+	s.reservedKeys = s.reservedKeys[1:]
+	s.mu.Unlock()
+	ch <- *x
+	return true
+}
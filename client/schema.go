@@ -0,0 +1,163 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var dataTypeNames = map[DWORD]string{
+	DATATYPE_INT32:        "INT32",
+	DATATYPE_INT64:        "INT64",
+	DATATYPE_FLOAT32:      "FLOAT32",
+	DATATYPE_FLOAT64:      "FLOAT64",
+	DATATYPE_STRING8:      "STRING8",
+	DATATYPE_STRING32:     "STRING32",
+	DATATYPE_STRING64:     "STRING64",
+	DATATYPE_STRING128:    "STRING128",
+	DATATYPE_STRING256:    "STRING256",
+	DATATYPE_STRING260:    "STRING260",
+	DATATYPE_STRINGV:      "STRINGV",
+	DATATYPE_INITPOSITION: "INITPOSITION",
+	DATATYPE_MARKERSTATE:  "MARKERSTATE",
+	DATATYPE_WAYPOINT:     "WAYPOINT",
+	DATATYPE_LATLONALT:    "LATLONALT",
+	DATATYPE_XYZ:          "XYZ",
+}
+
+// dataTypeSizes are the packed sizes of the datum types; STRINGV varies
+var dataTypeSizes = map[DWORD]int{
+	DATATYPE_INT32:        4,
+	DATATYPE_INT64:        8,
+	DATATYPE_FLOAT32:      4,
+	DATATYPE_FLOAT64:      8,
+	DATATYPE_STRING8:      8,
+	DATATYPE_STRING32:     32,
+	DATATYPE_STRING64:     64,
+	DATATYPE_STRING128:    128,
+	DATATYPE_STRING256:    256,
+	DATATYPE_STRING260:    260,
+	DATATYPE_INITPOSITION: 56,
+	DATATYPE_MARKERSTATE:  68,
+	DATATYPE_WAYPOINT:     44,
+	DATATYPE_LATLONALT:    24,
+	DATATYPE_XYZ:          24,
+}
+
+// DataTypeName returns the name of a DATATYPE, without the DATATYPE_ prefix
+func DataTypeName(t DWORD) string {
+	if name, ok := dataTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("DATATYPE(%d)", t)
+}
+
+// SchemaVersion is the version of the ExportDefinitions format
+// it changes only when fields are removed or change meaning
+const SchemaVersion = 1
+
+// Schema describes what a connection has registered
+type Schema struct {
+	Version       int                `json:"version"`
+	Definitions   []SchemaDefinition `json:"definitions"`
+	Events        []SchemaEvent      `json:"events"`
+	Subscriptions []Subscription     `json:"subscriptions"`
+}
+
+// SchemaDefinition is a data definition in a Schema
+type SchemaDefinition struct {
+	ID     DWORD  `json:"id"`
+	Name   string `json:"name"`
+	Struct bool   `json:"struct"` // registered from a Go struct
+	Size   int    `json:"size"`   // bytes per record; -1 if variable
+	// Version is the application release that registered the definition, see WithDefinitionVersion
+	Version string        `json:"version,omitempty"`
+	Fields  []SchemaField `json:"fields"`
+}
+
+// SchemaField is a datum of a SchemaDefinition
+type SchemaField struct {
+	Name    string  `json:"name"`
+	Unit    string  `json:"unit,omitempty"`
+	Type    string  `json:"type"`
+	Offset  int     `json:"offset"` // -1 after a variable-length field
+	Size    int     `json:"size"`   // -1 if variable
+	Epsilon float32 `json:"epsilon,omitempty"`
+	// Converted is true if the field is requested in Unit and converted
+	// locally to the unit of the struct field, see WithCanonicalUnits
+	Converted bool `json:"converted,omitempty"`
+}
+
+// SchemaEvent is a mapped client event or subscribed system event
+type SchemaEvent struct {
+	ID     DWORD  `json:"id"`
+	Name   string `json:"name"`
+	System bool   `json:"system"`
+}
+
+// Schema describes the definitions, events and subscriptions registered on
+// this connection
+func (s *SimConnect) Schema() Schema {
+	out := Schema{Version: SchemaVersion, Subscriptions: s.Subscriptions()}
+	if out.Subscriptions == nil {
+		out.Subscriptions = []Subscription{}
+	}
+	for _, d := range s.Definitions() {
+		s.mu.Lock()
+		_, isStruct := s.layouts[d.ID]
+		convs := s.conversions[d.ID]
+		s.mu.Unlock()
+		canonical := map[int]bool{}
+		for _, c := range convs {
+			canonical[c.index] = true
+		}
+
+		sd := SchemaDefinition{ID: d.ID, Name: d.Name, Struct: isStruct, Version: s.DefinitionVersion(d.ID), Fields: []SchemaField{}}
+		offset := 0
+		for i, datum := range d.Datums {
+			size, ok := dataTypeSizes[datum.DataType]
+			if !ok {
+				size = -1
+			}
+			f := SchemaField{
+				Name:    datum.Name,
+				Unit:    datum.Unit,
+				Type:    DataTypeName(datum.DataType),
+				Offset:  offset,
+				Size:    size,
+				Epsilon: datum.Epsilon,
+			}
+			f.Converted = canonical[i]
+			if offset >= 0 && size >= 0 {
+				offset += size
+			} else {
+				offset = -1
+			}
+			sd.Fields = append(sd.Fields, f)
+		}
+		sd.Size = offset
+		out.Definitions = append(out.Definitions, sd)
+	}
+	if out.Definitions == nil {
+		out.Definitions = []SchemaDefinition{}
+	}
+	for id, name := range s.EventNames() {
+		ev := SchemaEvent{ID: id, Name: name}
+		if n, ok := strings.CutPrefix(name, "system:"); ok {
+			ev.Name, ev.System = n, true
+		}
+		out.Events = append(out.Events, ev)
+	}
+	sort.Slice(out.Events, func(i, j int) bool { return out.Events[i].ID < out.Events[j].ID })
+	if out.Events == nil {
+		out.Events = []SchemaEvent{}
+	}
+	return out
+}
+
+// ExportDefinitions returns the connection's Schema as JSON, so external
+// tools can see what a running bridge exposes
+func (s *SimConnect) ExportDefinitions() ([]byte, error) {
+	return json.MarshalIndent(s.Schema(), "", "  ")
+}
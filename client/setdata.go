@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"unsafe"
+)
+
+// data set flags, see SIMCONNECT_DATA_SET_FLAG
+const (
+	DATA_SET_FLAG_DEFAULT DWORD = 0x00000000
+	DATA_SET_FLAG_TAGGED  DWORD = 0x00000001 // data is in tagged format
+)
+
+// SetDataOn sets a struct on any object, eg an AI aircraft or a spawned
+// simobject; a slice of structs is sent as a data array of that many elements
+// the struct is registered on first use, like RequestData
+func (s *SimConnect) SetDataOn(objectID DWORD, a any) error {
+	return s.SetDataOnFlags(objectID, DATA_SET_FLAG_DEFAULT, a)
+}
+
+// SetDataOnFlags is SetDataOn with data set flags
+// struct definitions carry no datum IDs, so DATA_SET_FLAG_TAGGED only
+// suits definitions built by hand with SetDataOnSimObject
+func (s *SimConnect) SetDataOnFlags(objectID, flags DWORD, a any) error {
+	return s.SetDataOnContext(context.Background(), objectID, flags, a)
+}
+
+// SetDataOnContext is SetDataOnFlags with ctx bounding the wait for the
+// command lane, eg to drop a stale write rather than queue it
+func (s *SimConnect) SetDataOnContext(ctx context.Context, objectID, flags DWORD, a any) error {
+	val := reflect.ValueOf(a)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Slice {
+		return s.setData(ctx, objectID, flags, a, val)
+	}
+	if val.Len() == 0 {
+		return fmt.Errorf("no data to set on object %d", objectID)
+	}
+	// the first element stands for the definition, without allocating one
+	return s.setData(ctx, objectID, flags, reflect.Indirect(val.Index(0)).Addr().Interface(), val)
+}
+
+// setData encodes val, a struct or a slice of structs of the type of def,
+// and sends it as one unit, or as an array of units for a slice
+func (s *SimConnect) setData(ctx context.Context, objectID, flags DWORD, def any, val reflect.Value) error {
+	if err := s.RegisterDataDefinition(def); err != nil {
+		return err
+	}
+	defineId := s.GetDefineID(def)
+
+	if val.Kind() != reflect.Slice {
+		enc, err := encoderFor(val.Type())
+		if err != nil {
+			return err
+		}
+		buf := enc.encode(val)
+		defer enc.put(buf)
+		s.unconvert(defineId, *buf)
+
+		cnt := len(*buf)
+		size := DWORD(cnt * 8)
+		slog.Debug("Setting data", "defineid", defineId, "objectID", objectID, "count", cnt, "size", size)
+		return s.SetDataOnSimObjectContext(ctx, defineId, objectID, flags, 0, size, unsafe.Pointer(&(*buf)[0]))
+	}
+
+	enc, err := encoderFor(reflect.Indirect(val.Index(0)).Type())
+	if err != nil {
+		return err
+	}
+	n, count := len(enc.fields), val.Len()
+	buf := enc.array(n * count)
+	defer enc.putArray(buf)
+	for i := 0; i < count; i++ {
+		unit := (*buf)[i*n : (i+1)*n]
+		enc.encodeInto(unit, reflect.Indirect(val.Index(i)))
+		s.unconvert(defineId, unit)
+	}
+	size := DWORD(n * 8)
+	slog.Debug("Setting data array", "defineid", defineId, "objectID", objectID, "count", count, "size", size)
+	return s.SetDataOnSimObjectContext(ctx, defineId, objectID, flags, DWORD(count), size, unsafe.Pointer(&(*buf)[0]))
+}
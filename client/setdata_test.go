@@ -0,0 +1,33 @@
+package client_test
+
+import "testing"
+
+func BenchmarkSetData(b *testing.B) {
+	dll, sc := connect(b)
+	dll.SetRecording(false)
+	report := &setReport{Latitude: 47.5, Longitude: -122.25}
+	items := make([]setReport, 16)
+	// register outside the timed loop
+	if err := sc.SetData(report); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("single", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			report.Latitude = float64(i)
+			if err := sc.SetData(report); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("array", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			items[0].Latitude = float64(i)
+			if err := sc.SetDataOn(42, items); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
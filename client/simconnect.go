@@ -4,22 +4,87 @@ package client
 // MSFS-SDK/SimConnect\ SDK/lib/SimConnect.dll
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"math"
 	"reflect"
+	"strconv"
+	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
 // SimConnect is the main struct for connecting to SimConnect
 type SimConnect struct {
 	handle      unsafe.Pointer
+	mu          sync.Mutex
 	defineMap   map[string]DWORD
+	building    map[string]chan struct{}
+	layouts     map[DWORD]string
 	lastEventID DWORD
 
-	dllPath string
-	dll     *dll
-	log     *slog.Logger
+	clientEvents map[string]DWORD
+	systemEvents map[string]DWORD
+
+	lastRequestID DWORD
+	pending       map[DWORD]chan []byte
+	facilities    map[DWORD]*facilityRequest
+	facilityLists map[DWORD]func(FacilityList)
+	clientData    map[DWORD]func(*RecvClientData, []byte)
+	assigned      map[DWORD]chan DWORD
+	systemStates  map[DWORD]chan RecvSystemState
+	sends         map[DWORD]chan RecvException
+	outstanding   map[DWORD]*Outstanding
+	reservedKeys  []chan RecvReservedKey
+	jetways       []*jetwayWaiter
+	// jetwayRequests are the tracked jetway requests, oldest first
+	jetwayRequests []DWORD
+	controllers    []*controllerWaiter
+
+	inputEvents       map[DWORD]*inputEventRequest
+	inputEventHashes  map[string]uint64
+	inputEventParams  map[uint64][]chan string
+	inputEventHandler func(InputEventValue)
+
+	customActionHandler func(CustomAction)
+
+	liveries map[DWORD]*liveryRequest
+	actions  map[DWORD]chan ActionResult
+	texts    map[DWORD]TextResultFunc
+	weather  map[DWORD]chan []byte
+
+	definitionVersion string
+	versions          map[DWORD]string
+	definitionSends   map[DWORD]DWORD
+	sentCalls         map[DWORD]string
+	requestTimes      map[DWORD]requestTime
+	latencies         map[DWORD]*latencyRing
+	slots             chan struct{}
+
+	datums        map[DWORD][]Datum
+	subscriptions map[DWORD]Subscription
+	eventNames    map[DWORD]string
+	lastUsed      map[DWORD]time.Time
+
+	dllPath      string
+	dll          *dll
+	log          *slog.Logger
+	textEncoding TextEncoding
+	lanes        *lanes
+	callTimeout  time.Duration
+	configIndex  DWORD
+
+	hWnd           uintptr
+	userEventWin32 DWORD
+	eventHandle    syscall.Handle
+
+	canonicalUnits bool
+	conversions    map[DWORD][]conversion
+	explicit       map[DWORD]*explicitLayout
+	dryRun         bool
+	guards         []WriteGuard
 }
 
 // SimConnectOption is a function that sets options on the SimConnect
@@ -39,29 +104,115 @@ func WithDLLPath(path string) SimConnectOption {
 	}
 }
 
+// WithConfigIndex selects the SimConnect.cfg entry to connect with
+// index n uses the [SimConnect.n] section of the SimConnect.cfg next to
+// the application, eg Protocol=IPv4, Address and Port of a sim on another
+// PC; use it to reach a second sim listening on another pipe or port
+func WithConfigIndex(index int) SimConnectOption {
+	return func(s *SimConnect) {
+		s.configIndex = DWORD(index)
+	}
+}
+
+// WithWindowHandle has SimConnect post the WithWin32Event message to the
+// window when a message is ready, for apps that run their own message loop
+func WithWindowHandle(hWnd uintptr) SimConnectOption {
+	return func(s *SimConnect) {
+		s.hWnd = hWnd
+	}
+}
+
+// WithWin32Event sets the user message, eg WM_USER+1, posted to the window
+// given with WithWindowHandle
+func WithWin32Event(msg DWORD) SimConnectOption {
+	return func(s *SimConnect) {
+		s.userEventWin32 = msg
+	}
+}
+
+// WithEventHandle has SimConnect signal the Win32 event when a message is
+// ready, so dispatch can wait on it instead of polling
+// the handle is owned by the caller and must outlive the connection
+func WithEventHandle(h syscall.Handle) SimConnectOption {
+	return func(s *SimConnect) {
+		s.eventHandle = h
+	}
+}
+
+// WithProcs connects through the procs find returns instead of a dll, so
+// tests can record calls and return canned results; see clienttest
+func WithProcs(find func(name string) Proc) SimConnectOption {
+	return func(s *SimConnect) {
+		s.dll = loadProcs(find)
+	}
+}
+
 // New creates a new SimConnect connection
 func New(name string, opts ...SimConnectOption) (*SimConnect, error) {
 	s := &SimConnect{
 		defineMap:   map[string]DWORD{"_last": 0},
+		building:    map[string]chan struct{}{},
+		layouts:     map[DWORD]string{},
 		lastEventID: 0,
-		log:         slog.With("name", name, "module", "simconnect"),
+
+		clientEvents:  map[string]DWORD{},
+		systemEvents:  map[string]DWORD{},
+		pending:       map[DWORD]chan []byte{},
+		facilities:    map[DWORD]*facilityRequest{},
+		facilityLists: map[DWORD]func(FacilityList){},
+		clientData:    map[DWORD]func(*RecvClientData, []byte){},
+		assigned:      map[DWORD]chan DWORD{},
+		systemStates:  map[DWORD]chan RecvSystemState{},
+		sends:         map[DWORD]chan RecvException{},
+		outstanding:   map[DWORD]*Outstanding{},
+
+		datums:           map[DWORD][]Datum{},
+		subscriptions:    map[DWORD]Subscription{},
+		eventNames:       map[DWORD]string{},
+		lastUsed:         map[DWORD]time.Time{},
+		conversions:      map[DWORD][]conversion{},
+		explicit:         map[DWORD]*explicitLayout{},
+		versions:         map[DWORD]string{},
+		definitionSends:  map[DWORD]DWORD{},
+		sentCalls:        map[DWORD]string{},
+		requestTimes:     map[DWORD]requestTime{},
+		latencies:        map[DWORD]*latencyRing{},
+		inputEvents:      map[DWORD]*inputEventRequest{},
+		inputEventHashes: map[string]uint64{},
+		inputEventParams: map[uint64][]chan string{},
+		liveries:         map[DWORD]*liveryRequest{},
+		actions:          map[DWORD]chan ActionResult{},
+		texts:            map[DWORD]TextResultFunc{},
+		weather:          map[DWORD]chan []byte{},
+		log:              slog.With("name", name, "module", "simconnect"),
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
-	if s.dllPath != "" {
+	switch {
+	case s.dll != nil:
+		// set by WithProcs
+	case s.dllPath != "":
 		d, err := newDLL(s.dllPath)
 		if err != nil {
 			return nil, err
 		}
 		s.dll = d
-	} else if defaultDll == nil {
+	case defaultDll == nil:
 		return nil, fmt.Errorf("no default DLL")
-	} else {
+	default:
 		s.dll = defaultDll
 	}
 
+	if err := s.open(name); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open opens the connection through the loaded dll
+func (s *SimConnect) open(name string) error {
 	// SimConnect_Open(
 	//   HANDLE * phSimConnect,
 	//   LPCSTR szName,
@@ -73,21 +224,23 @@ func New(name string, opts ...SimConnectOption) (*SimConnect, error) {
 	args := []uintptr{
 		uintptr(unsafe.Pointer(&s.handle)),
 		uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(name))),
-		0,
-		0,
-		0,
-		0,
+		s.hWnd,
+		uintptr(s.userEventWin32),
+		uintptr(s.eventHandle),
+		uintptr(s.configIndex),
 	}
 
 	r1, _, err := s.dll.proc_SimConnect_Open.Call(args...)
 	if int32(r1) < 0 {
-		return nil, fmt.Errorf("SimConnect_Open error: %s", err)
+		return fmt.Errorf("SimConnect_Open error: %s", err)
 	}
-	return s, nil
+	return nil
 }
 
 // GetEventID returns a new event ID
 func (s *SimConnect) GetEventID() DWORD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	id := s.lastEventID
 	s.lastEventID += 1
 	return id
@@ -99,12 +252,18 @@ func (s *SimConnect) GetDefineID(a interface{}) DWORD {
 	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
 		t = t.Elem()
 	}
-	structName := t.Name()
+	return s.GetDefineIDByName(t.Name())
+}
 
-	id, ok := s.defineMap[structName]
+// GetDefineIDByName returns the define ID for a name
+// use this for definitions that are built without a struct
+func (s *SimConnect) GetDefineIDByName(name string) DWORD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.defineMap[name]
 	if !ok {
 		id = s.defineMap["_last"]
-		s.defineMap[structName] = id
+		s.defineMap[name] = id
 		s.defineMap["_last"] = id + 1
 	}
 
@@ -112,13 +271,38 @@ func (s *SimConnect) GetDefineID(a interface{}) DWORD {
 }
 
 // RegisterDataDefinition registers a struct for data definition
+// registering the same struct again is a no-op; registering a different
+// layout under the same define ID returns ErrDefinitionMismatch; use
+// ReRegisterDataDefinition to replace it
 func (s *SimConnect) RegisterDataDefinition(a interface{}) error {
-	defineID := s.GetDefineID(a)
+	return s.RegisterDataDefinitionWithEpsilon(a, nil)
+}
+
+// RegisterDataDefinitionWithEpsilon registers a struct for data definition
+// with per-field epsilons keyed by simvar name, overriding any epsilon tags
+// with DATA_REQUEST_FLAG_CHANGED, a field only counts as changed when it
+// moves by more than its epsilon
+func (s *SimConnect) RegisterDataDefinitionWithEpsilon(a interface{}, epsilon map[string]float32) error {
+	defineID, err := s.CheckDefinition(a)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	_, registered := s.layouts[defineID]
+	s.mu.Unlock()
+	if registered {
+		return nil
+	}
 	v := reflect.ValueOf(a)
 	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
 		v = v.Elem()
 	}
+	explicit, err := explicitLayoutFor(v.Type())
+	if err != nil {
+		return err
+	}
 
+	var convs []conversion
 	for j := 1; j < v.NumField(); j++ {
 		fieldName := v.Type().Field(j).Name
 		nameTag, _ := v.Type().Field(j).Tag.Lookup("name")
@@ -138,9 +322,42 @@ func (s *SimConnect) RegisterDataDefinition(a interface{}) error {
 			return err
 		}
 
-		s.AddToDataDefinition(defineID, nameTag, unitTag, dataType)
+		eps, ok := epsilon[nameTag]
+		if !ok {
+			if epsTag, found := v.Type().Field(j).Tag.Lookup("epsilon"); found {
+				e, err := strconv.ParseFloat(epsTag, 32)
+				if err != nil {
+					return fmt.Errorf("%s invalid epsilon tag: %w", fieldName, err)
+				}
+				eps = float32(e)
+			}
+		}
+
+		if s.canonicalUnits && dataType == DATATYPE_FLOAT64 {
+			if canon, conv, ok := canonicalFor(nameTag, unitTag); ok {
+				conv.index = j - 1
+				conv.offset = v.Type().Field(j).Offset
+				if explicit != nil {
+					conv.offset, _ = explicit.wireOffset(j)
+				}
+				convs = append(convs, conv)
+				unitTag = canon
+				eps = float32(math.Abs(float64(eps) / conv.factor))
+			}
+		}
+
+		s.AddToDataDefinitionEpsilon(defineID, nameTag, unitTag, dataType, eps)
 	}
 
+	s.mu.Lock()
+	s.layouts[defineID] = fingerprint(v.Type())
+	if len(convs) > 0 {
+		s.conversions[defineID] = convs
+	}
+	if explicit != nil {
+		s.explicit[defineID] = explicit
+	}
+	s.mu.Unlock()
 	return nil
 }
 
@@ -156,8 +373,18 @@ func (s *SimConnect) Close() error {
 	return nil
 }
 
-// derefDataType returns the SimConnect data type for a Go type
+// AddToDataDefinition adds a datum to a data definition
 func (s *SimConnect) AddToDataDefinition(defineID DWORD, name, unit string, dataType DWORD) error {
+	return s.AddToDataDefinitionEpsilon(defineID, name, unit, dataType, 0)
+}
+
+// AddToDataDefinitionEpsilon adds a datum to a data definition with a change epsilon
+func (s *SimConnect) AddToDataDefinitionEpsilon(defineID DWORD, name, unit string, dataType DWORD, epsilon float32) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
 	// SimConnect_AddToDataDefinition(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_DATA_DEFINITION_ID DefineID,
@@ -177,7 +404,7 @@ func (s *SimConnect) AddToDataDefinition(defineID DWORD, name, unit string, data
 		uintptr(unsafe.Pointer(&_name[0])),
 		uintptr(0),
 		uintptr(dataType),
-		uintptr(float32(0)),
+		uintptr(math.Float32bits(epsilon)),
 		uintptr(UNUSED),
 	}
 	if unit != "" {
@@ -188,17 +415,27 @@ func (s *SimConnect) AddToDataDefinition(defineID DWORD, name, unit string, data
 	if int32(r1) < 0 {
 		return fmt.Errorf("SimConnect_AddToDataDefinition for %s error: %d %s", name, r1, err)
 	}
+	s.recordDatum(defineID, Datum{Name: name, Unit: unit, DataType: dataType, Epsilon: epsilon})
+	s.recordDefinitionSend("AddToDataDefinition", defineID)
 
 	return nil
 }
 
 func (s *SimConnect) SubscribeToSystemEvent(eventID DWORD, eventName string) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
 	// SimConnect_SubscribeToSystemEvent(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_CLIENT_EVENT_ID EventID,
 	//   const char * SystemEventName
 	// );
 
+	if err := s.claimEventID(eventID, "system:"+eventName); err != nil {
+		return err
+	}
 	_eventName := []byte(eventName + "\x00")
 
 	args := []uintptr{
@@ -211,11 +448,31 @@ func (s *SimConnect) SubscribeToSystemEvent(eventID DWORD, eventName string) err
 	if int32(r1) < 0 {
 		return fmt.Errorf("SimConnect_SubscribeToSystemEvent for %s error: %d %s", eventName, r1, err)
 	}
+	s.recordEvent(eventID, "system:"+eventName)
+	s.recordSend("SubscribeToSystemEvent")
 
 	return nil
 }
 
 func (s *SimConnect) RequestDataOnSimObjectType(requestID, defineID, radius, simobjectType DWORD) error {
+	return s.RequestDataOnSimObjectTypeContext(context.Background(), requestID, defineID, radius, simobjectType)
+}
+
+// RequestDataOnSimObjectTypeContext is RequestDataOnSimObjectType with ctx bounding the wait for its lane
+// the request counts towards WithMaxOutstanding until its last reply
+func (s *SimConnect) RequestDataOnSimObjectTypeContext(ctx context.Context, requestID, defineID, radius, simobjectType DWORD) (err error) {
+	sent, err := s.oneShot(ctx, requestID, "data by type")
+	if err != nil {
+		return err
+	}
+	defer func() { sent(err == nil) }()
+	leave, err := s.enterContext(ctx, LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	s.MarkUsed(defineID)
+
 	// SimConnect_RequestDataOnSimObjectType(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
@@ -238,11 +495,33 @@ func (s *SimConnect) RequestDataOnSimObjectType(requestID, defineID, radius, sim
 			requestID, defineID, r1, err,
 		)
 	}
+	s.recordDefinitionSend("RequestDataOnSimObjectType", defineID)
+	s.recordRequestSent(requestID, defineID)
 
 	return nil
 }
 
 func (s *SimConnect) RequestDataOnSimObject(requestID, defineID, objectID, period, flags, origin, interval, limit DWORD) error {
+	return s.RequestDataOnSimObjectContext(context.Background(), requestID, defineID, objectID, period, flags, origin, interval, limit)
+}
+
+// RequestDataOnSimObjectContext is RequestDataOnSimObject with ctx bounding the wait for its lane
+// with PERIOD_ONCE it counts towards WithMaxOutstanding until its reply
+func (s *SimConnect) RequestDataOnSimObjectContext(ctx context.Context, requestID, defineID, objectID, period, flags, origin, interval, limit DWORD) (err error) {
+	if period == PERIOD_ONCE {
+		var sent func(bool)
+		if sent, err = s.oneShot(ctx, requestID, "data once"); err != nil {
+			return err
+		}
+		defer func() { sent(err == nil) }()
+	}
+	leave, err := s.enterContext(ctx, LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	s.MarkUsed(defineID)
+
 	// SimConnect_RequestDataOnSimObject(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
@@ -274,11 +553,38 @@ func (s *SimConnect) RequestDataOnSimObject(requestID, defineID, objectID, perio
 			requestID, defineID, r1, err,
 		)
 	}
+	s.recordDefinitionSend("RequestDataOnSimObject", defineID)
+	if period == PERIOD_NEVER {
+		// stopping a subscription gets no reply
+		s.forgetRequestSent(requestID)
+	} else {
+		s.recordRequestSent(requestID, defineID)
+	}
+	s.recordSubscription(Subscription{
+		RequestID: requestID,
+		DefineID:  defineID,
+		ObjectID:  objectID,
+		Period:    period,
+		Flags:     flags,
+		Interval:  interval,
+	})
 
 	return nil
 }
 
 func (s *SimConnect) SetDataOnSimObject(defineID, simobjectType, flags, arrayCount, size DWORD, buf unsafe.Pointer) error {
+	return s.SetDataOnSimObjectContext(context.Background(), defineID, simobjectType, flags, arrayCount, size, buf)
+}
+
+// SetDataOnSimObjectContext is SetDataOnSimObject with ctx bounding the wait for its lane
+func (s *SimConnect) SetDataOnSimObjectContext(ctx context.Context, defineID, simobjectType, flags, arrayCount, size DWORD, buf unsafe.Pointer) error {
+	leave, err := s.enterContext(ctx, LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	s.MarkUsed(defineID)
+
 	//s.SetDataOnSimObject(defineID, simconnect.OBJECT_ID_USER, 0, 0, size, buf)
 
 	// SimConnect_SetDataOnSimObject(
@@ -290,6 +596,13 @@ func (s *SimConnect) SetDataOnSimObject(defineID, simobjectType, flags, arrayCou
 	//   DWORD cbUnitSize,
 	//   void * pDataSet
 	// );
+	if err := s.guardWrite(defineID, simobjectType, size, buf); err != nil {
+		return err
+	}
+	if s.dryRun {
+		s.dryRunData(defineID, simobjectType, size, buf)
+		return nil
+	}
 	args := []uintptr{
 		uintptr(s.handle),
 		uintptr(defineID),
@@ -307,11 +620,17 @@ func (s *SimConnect) SetDataOnSimObject(defineID, simobjectType, flags, arrayCou
 			defineID, r1, err,
 		)
 	}
+	s.recordDefinitionSend("SetDataOnSimObject", defineID)
 
 	return nil
 }
 
 func (s *SimConnect) SubscribeToFacilities(facilityType, requestID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
 	// SimConnect_SubscribeToFacilities(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_FACILITY_LIST_TYPE type,
@@ -336,6 +655,11 @@ func (s *SimConnect) SubscribeToFacilities(facilityType, requestID DWORD) error
 }
 
 func (s *SimConnect) UnsubscribeToFacilities(facilityType DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
 	// SimConnect_UnsubscribeToFacilities(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_FACILITY_LIST_TYPE type
@@ -357,7 +681,20 @@ func (s *SimConnect) UnsubscribeToFacilities(facilityType DWORD) error {
 	return nil
 }
 
-func (s *SimConnect) RequestFacilitiesList(facilityType, requestID DWORD) error {
+// RequestFacilitiesList requests every facility of a type in the reality
+// bubble; it counts towards WithMaxOutstanding until the last list arrives
+func (s *SimConnect) RequestFacilitiesList(facilityType, requestID DWORD) (err error) {
+	sent, err := s.oneShot(context.Background(), requestID, "facilities list")
+	if err != nil {
+		return err
+	}
+	defer func() { sent(err == nil) }()
+	leave, err := s.enter(LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
 	// SimConnect_RequestFacilitiesList(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_FACILITY_LIST_TYPE type,
@@ -382,12 +719,20 @@ func (s *SimConnect) RequestFacilitiesList(facilityType, requestID DWORD) error
 }
 
 func (s *SimConnect) MapClientEventToSimEvent(eventID DWORD, eventName string) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
 	// SimConnect_MapClientEventToSimEvent(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_CLIENT_EVENT_ID EventID,
 	//   const char * EventName = ""
 	// );
 
+	if err := s.claimEventID(eventID, eventName); err != nil {
+		return err
+	}
 	_eventName := []byte(eventName + "\x00")
 
 	args := []uintptr{
@@ -403,11 +748,27 @@ func (s *SimConnect) MapClientEventToSimEvent(eventID DWORD, eventName string) e
 			eventID, r1, err,
 		)
 	}
+	s.recordEvent(eventID, eventName)
+	s.recordSend("MapClientEventToSimEvent")
 
 	return nil
 }
 
 func (s *SimConnect) TransmitClientEvent(objectID, eventID, dwData, groupID, flags DWORD) error {
+	return s.TransmitClientEventContext(context.Background(), objectID, eventID, dwData, groupID, flags)
+}
+
+// TransmitClientEventContext is TransmitClientEvent with ctx bounding the wait for its lane
+func (s *SimConnect) TransmitClientEventContext(ctx context.Context, objectID, eventID, dwData, groupID, flags DWORD) error {
+	leave, err := s.enterContext(ctx, LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	if s.dryRun {
+		s.dryRunEvent(objectID, eventID, dwData)
+		return nil
+	}
 
 	r1, _, err := s.dll.proc_SimConnect_TransmitClientEvent.Call(
 		uintptr(s.handle),
@@ -425,6 +786,11 @@ func (s *SimConnect) TransmitClientEvent(objectID, eventID, dwData, groupID, fla
 }
 
 func (s *SimConnect) MenuAddItem(menuItem string, menuEventID, Data DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
 	// SimConnect_MenuAddItem(
 	//   HANDLE hSimConnect,
 	//   const char * szMenuItem,
@@ -432,7 +798,7 @@ func (s *SimConnect) MenuAddItem(menuItem string, menuEventID, Data DWORD) error
 	//   DWORD dwData
 	// );
 
-	_menuItem := []byte(menuItem + "\x00")
+	_menuItem := s.encodeText(menuItem)
 
 	args := []uintptr{
 		uintptr(s.handle),
@@ -452,7 +818,12 @@ func (s *SimConnect) MenuAddItem(menuItem string, menuEventID, Data DWORD) error
 	return nil
 }
 
-func (s *SimConnect) MenuDeleteItem(menuItem string, menuEventID, Data DWORD) error {
+func (s *SimConnect) MenuDeleteItem(menuEventID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
 	// SimConnect_MenuDeleteItem(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_CLIENT_EVENT_ID MenuEventID
@@ -474,7 +845,83 @@ func (s *SimConnect) MenuDeleteItem(menuItem string, menuEventID, Data DWORD) er
 	return nil
 }
 
+func (s *SimConnect) MenuAddSubItem(menuEventID DWORD, menuItem string, subMenuEventID, Data DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_MenuAddSubItem(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_CLIENT_EVENT_ID MenuEventID,
+	//   const char * szMenuItem,
+	//   SIMCONNECT_CLIENT_EVENT_ID SubMenuEventID,
+	//   DWORD dwData
+	// );
+
+	_menuItem := s.encodeText(menuItem)
+
+	args := []uintptr{
+		uintptr(s.handle),
+		uintptr(menuEventID),
+		uintptr(unsafe.Pointer(&_menuItem[0])),
+		uintptr(subMenuEventID),
+		uintptr(Data),
+	}
+
+	r1, _, err := s.dll.proc_SimConnect_MenuAddSubItem.Call(args...)
+	if int32(r1) < 0 {
+		return fmt.Errorf(
+			"SimConnect_MenuAddSubItem for menuEventID %d subMenuEventID %d '%s' error: %d %s",
+			menuEventID, subMenuEventID, menuItem, r1, err,
+		)
+	}
+
+	return nil
+}
+
+func (s *SimConnect) MenuDeleteSubItem(menuEventID, subMenuEventID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_MenuDeleteSubItem(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_CLIENT_EVENT_ID MenuEventID,
+	//   const SIMCONNECT_CLIENT_EVENT_ID SubMenuEventID
+	// );
+
+	args := []uintptr{
+		uintptr(s.handle),
+		uintptr(menuEventID),
+		uintptr(subMenuEventID),
+	}
+
+	r1, _, err := s.dll.proc_SimConnect_MenuDeleteSubItem.Call(args...)
+	if int32(r1) < 0 {
+		return fmt.Errorf(
+			"SimConnect_MenuDeleteSubItem for menuEventID %d subMenuEventID %d error: %d %s",
+			menuEventID, subMenuEventID, r1, err,
+		)
+	}
+
+	return nil
+}
+
 func (s *SimConnect) AddClientEventToNotificationGroup(groupID, eventID DWORD) error {
+	return s.AddClientEventToNotificationGroupMaskable(groupID, eventID, false)
+}
+
+// AddClientEventToNotificationGroupMaskable adds the event to the group;
+// a maskable event is not passed on to lower priority groups, or the sim,
+// when the group priority is maskable
+func (s *SimConnect) AddClientEventToNotificationGroupMaskable(groupID, eventID DWORD, maskable bool) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
 	// SimConnect_AddClientEventToNotificationGroup(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_NOTIFICATION_GROUP_ID GroupID,
@@ -486,6 +933,7 @@ func (s *SimConnect) AddClientEventToNotificationGroup(groupID, eventID DWORD) e
 		uintptr(s.handle),
 		uintptr(groupID),
 		uintptr(eventID),
+		boolArg(maskable),
 	}
 
 	r1, _, err := s.dll.proc_SimConnect_AddClientEventToNotificationGroup.Call(args...)
@@ -495,11 +943,17 @@ func (s *SimConnect) AddClientEventToNotificationGroup(groupID, eventID DWORD) e
 			groupID, eventID, r1, err,
 		)
 	}
+	s.recordSend("AddClientEventToNotificationGroup")
 
 	return nil
 }
 
 func (s *SimConnect) SetNotificationGroupPriority(groupID, priority DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
 	// SimConnect_SetNotificationGroupPriority(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_NOTIFICATION_GROUP_ID GroupID,
@@ -523,7 +977,97 @@ func (s *SimConnect) SetNotificationGroupPriority(groupID, priority DWORD) error
 	return nil
 }
 
+func (s *SimConnect) RemoveClientEvent(groupID, eventID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_RemoveClientEvent(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_NOTIFICATION_GROUP_ID GroupID,
+	//   SIMCONNECT_CLIENT_EVENT_ID EventID
+	// );
+
+	args := []uintptr{
+		uintptr(s.handle),
+		uintptr(groupID),
+		uintptr(eventID),
+	}
+
+	r1, _, err := s.dll.proc_SimConnect_RemoveClientEvent.Call(args...)
+	if int32(r1) < 0 {
+		return fmt.Errorf(
+			"SimConnect_RemoveClientEvent for groupID %d eventID %d error: %d %s",
+			groupID, eventID, r1, err,
+		)
+	}
+
+	return nil
+}
+
+func (s *SimConnect) ClearNotificationGroup(groupID DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_ClearNotificationGroup(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_NOTIFICATION_GROUP_ID GroupID
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_ClearNotificationGroup.Call(
+		uintptr(s.handle),
+		uintptr(groupID),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_ClearNotificationGroup for groupID %d error: %d %s", groupID, r1, err)
+	}
+
+	return nil
+}
+
+// RequestNotificationGroup asks for the group's queued events to be sent
+// reserved and flags are unused by the sim and should be 0
+func (s *SimConnect) RequestNotificationGroup(groupID, reserved, flags DWORD) error {
+	leave, err := s.enter(LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_RequestNotificationGroup(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_NOTIFICATION_GROUP_ID GroupID,
+	//   DWORD dwReserved = 0,
+	//   DWORD Flags = 0
+	// );
+
+	r1, _, err := s.dll.proc_SimConnect_RequestNotificationGroup.Call(
+		uintptr(s.handle),
+		uintptr(groupID),
+		uintptr(reserved),
+		uintptr(flags),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_RequestNotificationGroup for groupID %d error: %d %s", groupID, r1, err)
+	}
+
+	return nil
+}
+
 func (s *SimConnect) ShowText(textType DWORD, duration float64, eventID DWORD, text string) error {
+	return s.ShowTextContext(context.Background(), textType, duration, eventID, text)
+}
+
+// ShowTextContext is ShowText with ctx bounding the wait for its lane
+func (s *SimConnect) ShowTextContext(ctx context.Context, textType DWORD, duration float64, eventID DWORD, text string) error {
+	leave, err := s.enterContext(ctx, LaneNormal)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
 	// SimConnect_Text(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_TEXT_TYPE type,
@@ -533,12 +1077,12 @@ func (s *SimConnect) ShowText(textType DWORD, duration float64, eventID DWORD, t
 	//   void * pDataSet
 	// );
 
-	_text := []byte(text + "\x00")
+	_text := s.encodeText(text)
 
 	args := []uintptr{
 		uintptr(s.handle),
 		uintptr(textType),
-		uintptr(duration),
+		floatArg(float32(duration)),
 		uintptr(eventID),
 		uintptr(DWORD(len(_text))),
 		uintptr(unsafe.Pointer(&_text[0])),
@@ -556,6 +1100,18 @@ func (s *SimConnect) ShowText(textType DWORD, duration float64, eventID DWORD, t
 }
 
 func (s *SimConnect) GetNextDispatch() (unsafe.Pointer, int32, error) {
+	return s.GetNextDispatchContext(context.Background())
+}
+
+// GetNextDispatchContext is GetNextDispatch with ctx bounding the wait for
+// its lane; a wait that ends returns a nil message and the context error
+func (s *SimConnect) GetNextDispatchContext(ctx context.Context) (unsafe.Pointer, int32, error) {
+	leave, err := s.enterContext(ctx, LaneLow)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer leave()
+
 	var ppData unsafe.Pointer
 	var ppDataLength DWORD
 
@@ -569,37 +1125,7 @@ func (s *SimConnect) GetNextDispatch() (unsafe.Pointer, int32, error) {
 }
 
 // SetData currently only supports float64 fields
+// the field layout and buffers are cached per type, so repeated calls don't allocate
 func (s *SimConnect) SetData(fr any) error {
-	defineId := s.GetDefineID(fr)
-
-	cnt := 0
-
-	val := reflect.ValueOf(fr)
-	if val.Kind() == reflect.Ptr {
-		val = val.Elem()
-	}
-
-	typ := val.Type()
-	if typ.Kind() != reflect.Struct {
-		return fmt.Errorf("not a struct: %s", typ.Kind().String())
-	}
-	buf := []float64{}
-
-	for i := 0; i < typ.NumField(); i++ {
-		field := typ.Field(i)
-		name := field.Tag.Get("name")
-		if name == "" {
-			continue
-		}
-		if field.Type.Kind() != reflect.Float64 {
-			return fmt.Errorf("not a float64: %s -- %s", field.Name, field.Type.Kind().String())
-		}
-		buf = append(buf, val.Field(i).Float())
-		cnt++
-	}
-
-	size := DWORD(cnt * 8)
-	slog.Debug("Setting data", "defineid", defineId, "count", cnt, "size", size)
-	return s.SetDataOnSimObject(defineId, OBJECT_ID_USER, 0, 0, size, unsafe.Pointer(&buf[0]))
-
+	return s.SetDataOn(OBJECT_ID_USER, fr)
 }
@@ -0,0 +1,312 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/client/clienttest"
+)
+
+type definitionReport struct {
+	client.RecvSimobjectDataByType
+	Altitude float64  `name:"PLANE ALTITUDE" unit:"Feet" epsilon:"0.5"`
+	OnGround int32    `name:"SIM ON GROUND" unit:"Bool"`
+	ATCID    [32]byte `name:"ATC ID"`
+}
+
+type setReport struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+}
+
+func connect(t testing.TB) (*clienttest.DLL, *client.SimConnect) {
+	t.Helper()
+	dll := clienttest.New()
+	sc, err := dll.Connect()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return dll, sc
+}
+
+type datum struct {
+	defineID client.DWORD
+	name     string
+	unit     string
+	dataType client.DWORD
+	epsilon  float32
+	datumID  client.DWORD
+}
+
+func TestRegisterDataDefinition(t *testing.T) {
+	dll, sc := connect(t)
+	var got []datum
+	dll.Handle("SimConnect_AddToDataDefinition", func(args []uintptr) uintptr {
+		got = append(got, datum{
+			defineID: client.DWORD(args[1]),
+			name:     clienttest.String(args[2]),
+			unit:     clienttest.String(args[3]),
+			dataType: client.DWORD(args[4]),
+			epsilon:  math.Float32frombits(uint32(args[5])),
+			datumID:  client.DWORD(args[6]),
+		})
+		return 0
+	})
+
+	if err := sc.RegisterDataDefinition(&definitionReport{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	id := sc.GetDefineID(&definitionReport{})
+	// the header at field 0 is not a datum
+	want := []datum{
+		{id, "PLANE ALTITUDE", "Feet", client.DATATYPE_FLOAT64, 0.5, client.UNUSED},
+		{id, "SIM ON GROUND", "Bool", client.DATATYPE_INT32, 0, client.UNUSED},
+		{id, "ATC ID", "", client.DATATYPE_STRING32, 0, client.UNUSED},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("AddToDataDefinition calls:\n got %+v\nwant %+v", got, want)
+	}
+
+	// registering again sends nothing
+	if err := sc.RegisterDataDefinition(&definitionReport{}); err != nil {
+		t.Fatalf("register again: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("registering again added %d datums", len(got)-len(want))
+	}
+}
+
+type setCall struct {
+	defineID, objectID, flags, count, size client.DWORD
+	data                                   []float64
+}
+
+// recordSetData records SetDataOnSimObject calls with their buffers, read
+// while the call is made as the buffers are reused afterwards
+func recordSetData(dll *clienttest.DLL) *[]setCall {
+	calls := &[]setCall{}
+	dll.Handle("SimConnect_SetDataOnSimObject", func(args []uintptr) uintptr {
+		c := setCall{
+			defineID: client.DWORD(args[1]),
+			objectID: client.DWORD(args[2]),
+			flags:    client.DWORD(args[3]),
+			count:    client.DWORD(args[4]),
+			size:     client.DWORD(args[5]),
+		}
+		units := int(c.count)
+		if units == 0 {
+			units = 1
+		}
+		c.data = clienttest.Float64s(args[6], units*int(c.size)/8)
+		*calls = append(*calls, c)
+		return 0
+	})
+	return calls
+}
+
+func TestSetData(t *testing.T) {
+	dll, sc := connect(t)
+	calls := recordSetData(dll)
+
+	if err := sc.SetData(&setReport{Latitude: 47.5, Longitude: -122.25}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	id := sc.GetDefineID(&setReport{})
+	want := []setCall{{id, client.OBJECT_ID_USER, client.DATA_SET_FLAG_DEFAULT, 0, 16, []float64{47.5, -122.25}}}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Fatalf("SetDataOnSimObject calls:\n got %+v\nwant %+v", *calls, want)
+	}
+}
+
+func TestSetDataArray(t *testing.T) {
+	dll, sc := connect(t)
+	calls := recordSetData(dll)
+
+	items := []setReport{
+		{Latitude: 1, Longitude: 2},
+		{Latitude: 3, Longitude: 4},
+		{Latitude: 5, Longitude: 6},
+	}
+	if err := sc.SetDataOn(42, items); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	id := sc.GetDefineID(&setReport{})
+	// the size is that of one unit, the count that of the array
+	want := []setCall{{id, 42, client.DATA_SET_FLAG_DEFAULT, 3, 16, []float64{1, 2, 3, 4, 5, 6}}}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Fatalf("SetDataOnSimObject calls:\n got %+v\nwant %+v", *calls, want)
+	}
+
+	if err := sc.SetDataOn(42, []setReport{}); err == nil {
+		t.Fatalf("empty array: no error")
+	}
+}
+
+func TestDatumDefinition(t *testing.T) {
+	dll, sc := connect(t)
+	calls := recordSetData(dll)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var added int
+	fail := true
+	release := make(chan struct{})
+	dll.Handle("SimConnect_AddToDataDefinition", func(args []uintptr) uintptr {
+		mu.Lock()
+		added++
+		failing := fail
+		mu.Unlock()
+		if failing {
+			return 0x80004005
+		}
+		<-release
+		return 0
+	})
+
+	// a failed definition is not kept, so the next write builds it again
+	if err := sc.WriteFloat(ctx, "PLANE ALTITUDE", "Feet", 1000); err == nil {
+		t.Fatalf("write with a failing definition: no error")
+	}
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+
+	// a second write waits for the definition the first is building
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() { errs <- sc.WriteFloat(ctx, "PLANE ALTITUDE", "Feet", 2000) }()
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := len(*calls); n != 0 {
+		t.Fatalf("%d writes before the definition was added", n)
+	}
+	close(release)
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if added != 2 || len(*calls) != 2 {
+		t.Fatalf("definition added %d times and %d writes, want 2 and 2", added, len(*calls))
+	}
+}
+
+func TestWriteRejected(t *testing.T) {
+	dll, sc := connect(t)
+	dll.Handle("SimConnect_GetLastSentPacketID", func(args []uintptr) uintptr {
+		clienttest.PutDWORD(args[1], 77)
+		return 0
+	})
+
+	// the sim rejects the write's packet while the write waits
+	done := make(chan struct{})
+	go func() {
+		ex := client.RecvException{Exception: client.SIMCONNECT_EXCEPTION_DATA_ERROR, SendID: 77, Index: client.UNKNOWN_INDEX}
+		for !sc.DeliverException(ex) {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}()
+	err := sc.WriteFloat(context.Background(), "PLANE ALTITUDE", "Feet", 1000)
+	close(done)
+	var ee client.ExceptionError
+	if !errors.Is(err, client.ErrDataError) || !errors.As(err, &ee) || ee.Call != "SetDataOnSimObject" {
+		t.Fatalf("rejected write returned %v", err)
+	}
+
+	// no exception within the wait is success
+	if err := sc.WriteFloat(context.Background(), "PLANE ALTITUDE", "Feet", 2000); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestCallLanesGateEveryCall(t *testing.T) {
+	dll := clienttest.New()
+	sc, err := dll.Connect(client.WithCallLanes(), client.WithCallTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	entered, release := make(chan struct{}), make(chan struct{})
+	dll.Handle("SimConnect_TransmitClientEvent", func(args []uintptr) uintptr {
+		close(entered)
+		<-release
+		return 0
+	})
+	go sc.TransmitClientEvent(client.OBJECT_ID_USER, 1, 0, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+	<-entered
+
+	// setup calls have no ctx, but still wait their turn, up to the call timeout
+	if err := sc.AddToDataDefinition(1, "PLANE ALTITUDE", "Feet", client.DATATYPE_FLOAT64); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("call while the lane is busy returned %v", err)
+	}
+	if n := len(dll.Calls("SimConnect_AddToDataDefinition")); n != 0 {
+		t.Fatalf("call reached the dll while the lane was busy")
+	}
+	close(release)
+	if err := sc.AddToDataDefinition(1, "PLANE ALTITUDE", "Feet", client.DATATYPE_FLOAT64); err != nil {
+		t.Fatalf("call once the lane is free: %v", err)
+	}
+}
+
+func TestLatencyForgetsUnanswered(t *testing.T) {
+	reply := func(sc *client.SimConnect, requestID client.DWORD) {
+		sc.ObserveReply(&client.RecvSimobjectData{RequestID: requestID, DefineID: 1, EntryNumber: 1, OutOf: 1})
+	}
+	sampled := func(sc *client.SimConnect) int {
+		n := 0
+		for _, l := range sc.Latencies() {
+			n += l.Count
+		}
+		return n
+	}
+
+	t.Run("exception", func(t *testing.T) {
+		dll, sc := connect(t)
+		dll.Handle("SimConnect_GetLastSentPacketID", func(args []uintptr) uintptr {
+			clienttest.PutDWORD(args[1], 5)
+			return 0
+		})
+		sc.RequestDataOnSimObject(100, 1, client.OBJECT_ID_USER, client.PERIOD_SECOND, 0, 0, 0, 0)
+		sc.DeliverException(client.RecvException{SendID: 5})
+		reply(sc, 100)
+		if n := sampled(sc); n != 0 {
+			t.Fatalf("%d samples after the request was rejected", n)
+		}
+	})
+
+	t.Run("period never", func(t *testing.T) {
+		_, sc := connect(t)
+		sc.RequestDataOnSimObject(100, 1, client.OBJECT_ID_USER, client.PERIOD_SECOND, 0, 0, 0, 0)
+		sc.RequestDataOnSimObject(100, 1, client.OBJECT_ID_USER, client.PERIOD_NEVER, 0, 0, 0, 0)
+		reply(sc, 100)
+		if n := sampled(sc); n != 0 {
+			t.Fatalf("%d samples after the request was stopped", n)
+		}
+	})
+
+	t.Run("cap", func(t *testing.T) {
+		_, sc := connect(t)
+		for id := client.DWORD(1); id <= 1025; id++ {
+			sc.RequestDataOnSimObject(id, 1, client.OBJECT_ID_USER, client.PERIOD_SECOND, 0, 0, 0, 0)
+		}
+		// the oldest made way for the last
+		reply(sc, 1)
+		if n := sampled(sc); n != 0 {
+			t.Fatalf("oldest request kept past the cap")
+		}
+		reply(sc, 1025)
+		if n := sampled(sc); n != 1 {
+			t.Fatalf("%d samples, want the newest request's", n)
+		}
+	})
+}
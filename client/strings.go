@@ -0,0 +1,73 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// InsertString and RetrieveString follow the SDK helpers of the same name,
+// used for STRINGV data and the other variable length strings of the
+// protocol: the string bytes followed by a null terminator, with the next
+// value starting right after it
+
+// ErrStringTerminator is returned by RetrieveString when the data ends
+// before the string's null terminator
+const ErrStringTerminator ClientError = "string has no null terminator"
+
+// ErrStringSpace is returned by InsertString when the buffer can't hold
+// the string and its terminator
+const ErrStringSpace ClientError = "no space for string"
+
+// cleanString makes s safe to send: invalid UTF-8 is replaced and the
+// string is cut at an embedded null, which would end it early on the sim side
+func cleanString(s string) string {
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
+
+// InsertString writes s and its null terminator at the start of dst and
+// returns the number of bytes written
+func InsertString(dst []byte, s string) (int, error) {
+	s = cleanString(s)
+	if len(s)+1 > len(dst) {
+		return 0, fmt.Errorf("insert %d byte string into %d bytes: %w", len(s)+1, len(dst), ErrStringSpace)
+	}
+	n := copy(dst, s)
+	dst[n] = 0
+	return n + 1, nil
+}
+
+// AppendString appends s and its null terminator to dst
+func AppendString(dst []byte, s string) []byte {
+	return append(append(dst, cleanString(s)...), 0)
+}
+
+// RetrieveString reads the null terminated string at the start of data and
+// returns it with the number of bytes it takes, terminator included, so the
+// next value starts at data[n:]
+// invalid UTF-8 is replaced; without a terminator the whole of data is
+// returned along with ErrStringTerminator
+func RetrieveString(data []byte) (string, int, error) {
+	i := bytes.IndexByte(data, 0)
+	if i < 0 {
+		return strings.ToValidUTF8(string(data), "\uFFFD"), len(data), ErrStringTerminator
+	}
+	return strings.ToValidUTF8(string(data[:i]), "\uFFFD"), i + 1, nil
+}
+
+// ReadStringV reads a single string simvar of any length, as STRINGV
+func (s *SimConnect) ReadStringV(ctx context.Context, name string) (string, error) {
+	data, err := s.readOnce(ctx, name, "", DATATYPE_STRINGV)
+	if err != nil {
+		return "", err
+	}
+	v, _, err := RetrieveString(data)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", name, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,106 @@
+package client
+
+import "strings"
+
+// View is the data of the View system event
+type View DWORD
+
+const (
+	VIEW_COCKPIT_2D      View = 0x00000001 // 2D panels in cockpit view
+	VIEW_COCKPIT_VIRTUAL View = 0x00000002 // virtual (3D) panels in cockpit view
+	VIEW_ORTHOGONAL      View = 0x00000004 // orthogonal (map) view
+)
+
+func (v View) String() string {
+	switch v {
+	case VIEW_COCKPIT_2D:
+		return "cockpit 2d"
+	case VIEW_COCKPIT_VIRTUAL:
+		return "cockpit virtual"
+	case VIEW_ORTHOGONAL:
+		return "orthogonal"
+	default:
+		return "other"
+	}
+}
+
+// pause flags of the Pause_EX1 system event
+const (
+	PAUSE_STATE_FLAG_OFF              DWORD = 0
+	PAUSE_STATE_FLAG_PAUSE            DWORD = 1 // full pause
+	PAUSE_STATE_FLAG_PAUSE_WITH_SOUND DWORD = 2 // legacy, unused in MSFS
+	PAUSE_STATE_FLAG_ACTIVE_PAUSE     DWORD = 4
+	PAUSE_STATE_FLAG_SIM_PAUSE        DWORD = 8
+)
+
+// SOUND_SYSTEM_EVENT_DATA_MASTER is set in the Sound event data when sound is on
+const SOUND_SYSTEM_EVENT_DATA_MASTER DWORD = 0x00000001
+
+// PauseEvent is the decoded Pause system event
+type PauseEvent struct {
+	Paused bool
+}
+
+// PauseExEvent is the decoded Pause_EX1 system event
+type PauseExEvent struct {
+	Flags DWORD
+}
+
+// Paused is true for any kind of pause
+func (p PauseExEvent) Paused() bool { return p.Flags != PAUSE_STATE_FLAG_OFF }
+
+// Full is true for a full pause
+func (p PauseExEvent) Full() bool { return p.Flags&PAUSE_STATE_FLAG_PAUSE != 0 }
+
+// Active is true for active pause
+func (p PauseExEvent) Active() bool { return p.Flags&PAUSE_STATE_FLAG_ACTIVE_PAUSE != 0 }
+
+// Sim is true when the sim is paused but the user can still move around, eg in the menus
+func (p PauseExEvent) Sim() bool { return p.Flags&PAUSE_STATE_FLAG_SIM_PAUSE != 0 }
+
+// SimEvent is the decoded Sim system event
+type SimEvent struct {
+	Running bool
+}
+
+// SoundEvent is the decoded Sound system event
+type SoundEvent struct {
+	On bool
+}
+
+// ViewEvent is the decoded View system event
+type ViewEvent struct {
+	View View
+}
+
+// NotifyEvent is a system event that carries no data, eg Crashed or Paused
+type NotifyEvent struct{}
+
+// DecodeSystemEvent decodes the data of a system event by its name
+// events without a known payload decode to NotifyEvent for the data-free
+// notifications, and to the raw DWORD otherwise
+func DecodeSystemEvent(name string, data DWORD) any {
+	switch name {
+	case "Pause":
+		return PauseEvent{Paused: data != 0}
+	case "Pause_EX1":
+		return PauseExEvent{Flags: data}
+	case "Sim":
+		return SimEvent{Running: data != 0}
+	case "Sound":
+		return SoundEvent{On: data&SOUND_SYSTEM_EVENT_DATA_MASTER != 0}
+	case "View":
+		return ViewEvent{View: View(data)}
+	case "Paused", "Unpaused", "SimStart", "SimStop", "Crashed", "CrashReset",
+		"PositionChanged", "1sec", "4sec", "6Hz":
+		return NotifyEvent{}
+	}
+	return data
+}
+
+// SystemEventName returns the name of a subscribed system event
+func (s *SimConnect) SystemEventName(eventID DWORD) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.CutPrefix(s.eventNames[eventID], "system:")
+}
@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// System states that can be requested with RequestSystemState
+const (
+	SYSTEM_STATE_AIRCRAFT_LOADED = "AircraftLoaded" // path of the loaded aircraft.cfg
+	SYSTEM_STATE_DIALOG_MODE     = "DialogMode"     // 1 while a dialog is open
+	SYSTEM_STATE_FLIGHT_LOADED   = "FlightLoaded"   // path of the loaded flight
+	SYSTEM_STATE_FLIGHT_PLAN     = "FlightPlan"     // path of the active flight plan
+	SYSTEM_STATE_SIM             = "Sim"            // 1 while the user is in control
+)
+
+// SystemStateKind is which field of a system state holds its value
+type SystemStateKind int
+
+const (
+	SystemStateInteger SystemStateKind = iota
+	SystemStateFloat
+	SystemStateString
+)
+
+var systemStateKinds = map[string]SystemStateKind{
+	SYSTEM_STATE_AIRCRAFT_LOADED: SystemStateString,
+	SYSTEM_STATE_DIALOG_MODE:     SystemStateInteger,
+	SYSTEM_STATE_FLIGHT_LOADED:   SystemStateString,
+	SYSTEM_STATE_FLIGHT_PLAN:     SystemStateString,
+	SYSTEM_STATE_SIM:             SystemStateInteger,
+}
+
+// RecvSystemState is SIMCONNECT_RECV_SYSTEM_STATE
+type RecvSystemState struct {
+	Recv
+	RequestID DWORD
+	Integer   DWORD
+	Float     float32
+	String    [260]byte
+}
+
+// SystemState is the typed result of RequestSystemState
+type SystemState struct {
+	Name    string
+	Kind    SystemStateKind
+	Integer int
+	Float   float32
+	String  string
+}
+
+// Bool returns true if an integer state is set, eg Sim or DialogMode
+func (s SystemState) Bool() bool {
+	return s.Integer != 0
+}
+
+func (s *SimConnect) requestSystemState(requestID DWORD, state string) error {
+	leave, err := s.enter(LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_RequestSystemState(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
+	//   const char * szState
+	// );
+
+	_state := []byte(state + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_RequestSystemState.Call(
+		uintptr(s.handle),
+		uintptr(requestID),
+		uintptr(unsafe.Pointer(&_state[0])),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_RequestSystemState for %s error: %d %s", state, r1, err)
+	}
+	return nil
+}
+
+// RequestSystemState requests a system state, eg SYSTEM_STATE_AIRCRAFT_LOADED,
+// and waits for the reply
+// the reply is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) RequestSystemState(ctx context.Context, state string) (SystemState, error) {
+	requestID, end, err := s.beginRequest(ctx, "system state "+state)
+	if err != nil {
+		return SystemState{}, err
+	}
+	defer end()
+
+	ch := make(chan RecvSystemState, 1)
+	s.mu.Lock()
+	s.systemStates[requestID] = ch
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		delete(s.systemStates, requestID)
+		s.mu.Unlock()
+	}
+	if err := s.requestSystemState(requestID, state); err != nil {
+		cancel()
+		return SystemState{}, err
+	}
+	exceptions := s.watchSend(requestID)
+	select {
+	case <-ctx.Done():
+		cancel()
+		return SystemState{}, fmt.Errorf("system state %s: %w", state, ctx.Err())
+	case ex := <-exceptions:
+		cancel()
+		return SystemState{}, fmt.Errorf("system state %s: %w", state, ex)
+	case x := <-ch:
+		return decodeSystemState(state, &x), nil
+	}
+}
+
+func decodeSystemState(name string, x *RecvSystemState) SystemState {
+	st := SystemState{
+		Name:    name,
+		Integer: int(int32(x.Integer)),
+		Float:   x.Float,
+		String:  BytesToString(x.String[:]),
+	}
+	kind, ok := systemStateKinds[name]
+	if !ok {
+		// states added by later sims; guess from what was filled in
+		switch {
+		case st.String != "":
+			kind = SystemStateString
+		case st.Float != 0:
+			kind = SystemStateFloat
+		}
+	}
+	st.Kind = kind
+	return st
+}
+
+// DeliverSystemState hands a system state reply to a pending request
+// it returns true if the message was consumed
+func (s *SimConnect) DeliverSystemState(x *RecvSystemState) bool {
+	s.mu.Lock()
+	ch, ok := s.systemStates[x.RequestID]
+	delete(s.systemStates, x.RequestID)
+	s.mu.Unlock()
+	if ok {
+		ch <- *x
+	}
+	return ok
+}
+
+// SetSystemState sets a system state from all three value fields
+// a state reads only the field it needs, so the typed variants are simpler
+func (s *SimConnect) SetSystemState(state string, integer DWORD, float float32, str string) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_SetSystemState(
+	//   HANDLE hSimConnect,
+	//   const char * szState,
+	//   DWORD dwInteger,
+	//   float fFloat,
+	//   const char * szString
+	// );
+
+	if s.dryRun {
+		s.log.Info("Dry run: not setting system state", "state", state, "integer", integer, "float", float, "string", str)
+		return nil
+	}
+	_state := []byte(state + "\x00")
+	_str := s.encodeText(str)
+
+	r1, _, err := s.dll.proc_SimConnect_SetSystemState.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&_state[0])),
+		uintptr(integer),
+		uintptr(math.Float32bits(float)),
+		uintptr(unsafe.Pointer(&_str[0])),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_SetSystemState for %s error: %d %s", state, r1, err)
+	}
+	return nil
+}
+
+// SetSystemStateString sets a string state, eg loading a flight plan with
+// SYSTEM_STATE_FLIGHT_PLAN or a flight with SYSTEM_STATE_FLIGHT_LOADED
+// the string is sent in the connection's text encoding
+func (s *SimConnect) SetSystemStateString(state, v string) error {
+	return s.SetSystemState(state, 0, 0, v)
+}
+
+// SetSystemStateInt sets an integer state
+func (s *SimConnect) SetSystemStateInt(state string, v int) error {
+	return s.SetSystemState(state, DWORD(int32(v)), 0, "")
+}
+
+// SetSystemStateFloat sets a float state
+func (s *SimConnect) SetSystemStateFloat(state string, v float32) error {
+	return s.SetSystemState(state, 0, v, "")
+}
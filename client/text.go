@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// TextEncoding is the encoding of strings sent to SimConnect for display
+type TextEncoding int
+
+const (
+	// TextUTF8 sends UTF-8, which MSFS expects; this is the default
+	TextUTF8 TextEncoding = iota
+	// TextANSI sends the system ANSI code page, which FSX and Prepar3D expect
+	// characters outside the code page are replaced with '?'
+	TextANSI
+)
+
+// WithTextEncoding sets the encoding of text and menu items
+func WithTextEncoding(e TextEncoding) SimConnectOption {
+	return func(s *SimConnect) {
+		s.textEncoding = e
+	}
+}
+
+var procWideCharToMultiByte = syscall.NewLazyDLL("kernel32.dll").NewProc("WideCharToMultiByte")
+
+// cpACP is the system default ANSI code page
+const cpACP = 0
+
+// encodeText returns the null terminated text in the connection's encoding
+// invalid UTF-8 is replaced, so the sim never gets a broken sequence;
+// embedded nulls are kept, as menus use them to separate items
+func (s *SimConnect) encodeText(text string) []byte {
+	text = strings.ToValidUTF8(text, "�")
+	if s.textEncoding == TextANSI {
+		if b, ok := toANSI(text); ok {
+			return append(b, 0)
+		}
+		s.log.Warn("Cannot convert text to the ANSI code page; sending UTF-8")
+	}
+	return []byte(text + "\x00")
+}
+
+// toANSI converts the text to the system ANSI code page
+func toANSI(text string) ([]byte, bool) {
+	if text == "" {
+		return []byte{}, true
+	}
+	wide := utf16.Encode([]rune(text))
+	n, _, _ := procWideCharToMultiByte.Call(cpACP, 0,
+		uintptr(unsafe.Pointer(&wide[0])), uintptr(len(wide)),
+		0, 0, 0, 0)
+	if n == 0 {
+		return nil, false
+	}
+	out := make([]byte, n)
+	n, _, _ = procWideCharToMultiByte.Call(cpACP, 0,
+		uintptr(unsafe.Pointer(&wide[0])), uintptr(len(wide)),
+		uintptr(unsafe.Pointer(&out[0])), n, 0, 0)
+	if n == 0 {
+		return nil, false
+	}
+	return out[:n], true
+}
+
+// text results, see SIMCONNECT_TEXT_RESULT
+// they arrive as events with the event ID the text was shown with
+const (
+	TEXT_RESULT_MENU_SELECT_1 DWORD = iota
+	TEXT_RESULT_MENU_SELECT_2
+	TEXT_RESULT_MENU_SELECT_3
+	TEXT_RESULT_MENU_SELECT_4
+	TEXT_RESULT_MENU_SELECT_5
+	TEXT_RESULT_MENU_SELECT_6
+	TEXT_RESULT_MENU_SELECT_7
+	TEXT_RESULT_MENU_SELECT_8
+	TEXT_RESULT_MENU_SELECT_9
+	TEXT_RESULT_MENU_SELECT_10
+)
+
+const (
+	TEXT_RESULT_DISPLAYED DWORD = iota + 0x00010000
+	TEXT_RESULT_QUEUED
+	TEXT_RESULT_REMOVED
+	TEXT_RESULT_REPLACED
+	TEXT_RESULT_TIMEOUT
+)
+
+// maxMenuItems is the number of items a menu can show
+const maxMenuItems = 10
+
+// ErrMenuDismissed is returned by Menu when the menu closes without a selection
+const ErrMenuDismissed ClientError = "menu dismissed"
+
+// TextResultFunc is called with the TEXT_RESULT_* results of a text or menu
+// it runs on the dispatch loop, so it must not block
+type TextResultFunc func(result DWORD)
+
+// textDone reports whether no more results follow the result
+func textDone(result DWORD) bool {
+	switch result {
+	case TEXT_RESULT_DISPLAYED, TEXT_RESULT_QUEUED:
+		return false
+	}
+	return true
+}
+
+// ShowTextFunc shows text like ShowText, eg TEXT_TYPE_SCROLL_WHITE, and
+// calls fn with its results until it times out, is removed or replaced,
+// or a menu item is selected
+// it returns the event ID of the text, for RemoveText; results are only
+// delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) ShowTextFunc(ctx context.Context, textType DWORD, duration float64, text string, fn TextResultFunc) (DWORD, error) {
+	eventID := s.GetEventID()
+	s.mu.Lock()
+	s.texts[eventID] = fn
+	s.mu.Unlock()
+	if err := s.ShowTextContext(ctx, textType, duration, eventID, text); err != nil {
+		s.mu.Lock()
+		delete(s.texts, eventID)
+		s.mu.Unlock()
+		return 0, err
+	}
+	return eventID, nil
+}
+
+// ShowMenu shows a menu of up to ten items; a selection is reported to fn
+// as TEXT_RESULT_MENU_SELECT_1 for the first item, and so on
+// a duration of 0 leaves the menu up until it is answered or removed
+func (s *SimConnect) ShowMenu(ctx context.Context, duration float64, title, prompt string, items []string, fn TextResultFunc) (DWORD, error) {
+	if len(items) == 0 || len(items) > maxMenuItems {
+		return 0, fmt.Errorf("menu %q: %d items, want 1 to %d", title, len(items), maxMenuItems)
+	}
+	// the title, prompt and items are separated by nulls
+	text := title + "\x00" + prompt + "\x00" + strings.Join(items, "\x00")
+	return s.ShowTextFunc(ctx, TEXT_TYPE_MENU, duration, text, fn)
+}
+
+// Menu shows a menu and waits for a selection, returning the index of the
+// selected item; a menu that times out or is removed returns ErrMenuDismissed
+// the menu is removed if ctx ends first
+func (s *SimConnect) Menu(ctx context.Context, duration float64, title, prompt string, items ...string) (int, error) {
+	ch := make(chan DWORD, 1)
+	eventID, err := s.ShowMenu(ctx, duration, title, prompt, items, func(result DWORD) {
+		if textDone(result) {
+			ch <- result
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	select {
+	case <-ctx.Done():
+		if err := s.RemoveText(TEXT_TYPE_MENU, eventID); err != nil {
+			s.log.Warn("Cannot remove menu", "title", title, "error", err)
+		}
+		return 0, fmt.Errorf("menu %q: %w", title, ctx.Err())
+	case result := <-ch:
+		if result <= TEXT_RESULT_MENU_SELECT_10 {
+			return int(result - TEXT_RESULT_MENU_SELECT_1), nil
+		}
+		return 0, fmt.Errorf("menu %q: %w", title, ErrMenuDismissed)
+	}
+}
+
+// RemoveText removes a text or menu shown with ShowTextFunc by sending an
+// empty text with its event ID; its callback gets TEXT_RESULT_REMOVED
+func (s *SimConnect) RemoveText(textType, eventID DWORD) error {
+	return s.ShowText(textType, 0, eventID, "")
+}
+
+// DeliverText hands a text result to the callback of its text
+// it returns true if the event was consumed; the connector calls this
+// for EVENT messages before the receivers see them
+func (s *SimConnect) DeliverText(ev *RecvEvent) bool {
+	s.mu.Lock()
+	fn, ok := s.texts[ev.EventID]
+	if ok && textDone(ev.Data) {
+		delete(s.texts, ev.EventID)
+	}
+	s.mu.Unlock()
+	if ok && fn != nil {
+		fn(ev.Data)
+	}
+	return ok
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"math"
+	"strings"
+	"unsafe"
+)
+
+// CanonicalUnits are the units the SDK documents for common simvars
+// with WithCanonicalUnits, fields asking for another unit of the same kind
+// are registered in the canonical unit and converted locally; add entries
+// before connecting
+var CanonicalUnits = map[string]string{
+	"PLANE ALTITUDE":                       "Feet",
+	"PLANE ALT ABOVE GROUND":               "Feet",
+	"GROUND ALTITUDE":                      "Meters",
+	"INDICATED ALTITUDE":                   "Feet",
+	"PLANE LATITUDE":                       "Radians",
+	"PLANE LONGITUDE":                      "Radians",
+	"PLANE HEADING DEGREES TRUE":           "Radians",
+	"PLANE HEADING DEGREES MAGNETIC":       "Radians",
+	"PLANE PITCH DEGREES":                  "Radians",
+	"PLANE BANK DEGREES":                   "Radians",
+	"GPS GROUND TRUE TRACK":                "Radians",
+	"MAGVAR":                               "Degrees",
+	"AIRSPEED INDICATED":                   "Knots",
+	"AIRSPEED TRUE":                        "Knots",
+	"GROUND VELOCITY":                      "Knots",
+	"VERTICAL SPEED":                       "Feet per second",
+	"AMBIENT TEMPERATURE":                  "Celsius",
+	"AMBIENT WIND VELOCITY":                "Knots",
+	"BAROMETER PRESSURE":                   "Millibars",
+	"TOTAL WEIGHT":                         "Pounds",
+	"FUEL TOTAL QUANTITY":                  "Gallons",
+	"FUEL TANK LEFT MAIN QUANTITY":         "Gallons",
+	"FUEL TANK RIGHT MAIN QUANTITY":        "Gallons",
+	"FUEL TANK CENTER QUANTITY":            "Gallons",
+	"PRESSURIZATION CABIN ALTITUDE":        "Feet",
+	"PRESSURIZATION PRESSURE DIFFERENTIAL": "Pounds per square foot",
+}
+
+// unit is a unit as a linear map to its SI base: si = v*scale + offset
+type unit struct {
+	kind   string
+	scale  float64
+	offset float64
+}
+
+var units = map[string]unit{
+	"meters":                 {"length", 1, 0},
+	"meter":                  {"length", 1, 0},
+	"feet":                   {"length", 0.3048, 0},
+	"foot":                   {"length", 0.3048, 0},
+	"kilometers":             {"length", 1000, 0},
+	"nautical miles":         {"length", 1852, 0},
+	"miles":                  {"length", 1609.344, 0},
+	"radians":                {"angle", 1, 0},
+	"degrees":                {"angle", math.Pi / 180, 0},
+	"meters per second":      {"speed", 1, 0},
+	"knots":                  {"speed", 1852.0 / 3600, 0},
+	"feet per second":        {"speed", 0.3048, 0},
+	"feet per minute":        {"speed", 0.3048 / 60, 0},
+	"kilometers per hour":    {"speed", 1000.0 / 3600, 0},
+	"miles per hour":         {"speed", 1609.344 / 3600, 0},
+	"kelvin":                 {"temperature", 1, 0},
+	"celsius":                {"temperature", 1, 273.15},
+	"fahrenheit":             {"temperature", 5.0 / 9, 273.15 - 32*5.0/9},
+	"rankine":                {"temperature", 5.0 / 9, 0},
+	"pascals":                {"pressure", 1, 0},
+	"millibars":              {"pressure", 100, 0},
+	"hectopascals":           {"pressure", 100, 0},
+	"inches of mercury":      {"pressure", 3386.389, 0},
+	"psi":                    {"pressure", 6894.757, 0},
+	"pounds per square foot": {"pressure", 47.880259, 0},
+	"kilograms":              {"mass", 1, 0},
+	"pounds":                 {"mass", 0.45359237, 0},
+	"liters":                 {"volume", 1, 0},
+	"gallons":                {"volume", 3.785411784, 0},
+	"percent over 100":       {"ratio", 1, 0},
+	"percent":                {"ratio", 0.01, 0},
+}
+
+// WithCanonicalUnits registers catalogued simvars in their canonical unit
+// and converts to the requested unit locally, with factors worked out at
+// registration, rather than relying on the sim's unit names
+func WithCanonicalUnits() SimConnectOption {
+	return func(s *SimConnect) {
+		s.canonicalUnits = true
+	}
+}
+
+// conversion converts a float64 datum from the registered unit to the
+// requested one: requested = registered*factor + add
+type conversion struct {
+	index  int     // datum index, as used by the encoder
+	offset uintptr // byte offset of the datum in the message
+	factor float64
+	add    float64
+}
+
+// canonicalFor returns the unit to register a field with, and the
+// conversion to apply; ok is false if the field is registered as asked
+func canonicalFor(name, requested string) (string, conversion, bool) {
+	base, _, _ := strings.Cut(name, ":")
+	canon, ok := CanonicalUnits[base]
+	if !ok || strings.EqualFold(canon, requested) {
+		return "", conversion{}, false
+	}
+	from, ok1 := units[strings.ToLower(canon)]
+	to, ok2 := units[strings.ToLower(requested)]
+	if !ok1 || !ok2 || from.kind != to.kind {
+		return "", conversion{}, false
+	}
+	return canon, conversion{
+		factor: from.scale / to.scale,
+		add:    (from.offset - to.offset) / to.scale,
+	}, true
+}
+
+// Convert converts a data message to the requested units in place
+// the connector calls this before data reaches the receivers
+func (s *SimConnect) Convert(p *RecvSimobjectData) {
+	s.mu.Lock()
+	convs := s.conversions[p.DefineID]
+	s.mu.Unlock()
+	for _, c := range convs {
+		if uintptr(p.Size) < c.offset+8 {
+			continue
+		}
+		v := (*float64)(unsafe.Add(unsafe.Pointer(p), c.offset))
+		*v = *v*c.factor + c.add
+	}
+}
+
+// unconvert converts encoded values back to the registered units for SetData
+func (s *SimConnect) unconvert(defineID DWORD, buf []float64) {
+	s.mu.Lock()
+	convs := s.conversions[defineID]
+	s.mu.Unlock()
+	for _, c := range convs {
+		if c.index < len(buf) {
+			buf[c.index] = (buf[c.index] - c.add) / c.factor
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+// maxDefinitionSends is the number of recent definition packets kept for
+// tying exceptions back to their definition
+const maxDefinitionSends = 1024
+
+// WithDefinitionVersion records the application release, eg "1.4.2", with
+// every data definition registered on the connection
+// it appears in the schema export and in exception diagnostics, so a user
+// report of garbage values can be traced to the release that registered the layout
+func WithDefinitionVersion(v string) SimConnectOption {
+	return func(s *SimConnect) {
+		s.definitionVersion = v
+	}
+}
+
+// DefinitionVersion returns the version recorded when the definition was registered
+func (s *SimConnect) DefinitionVersion(defineID DWORD) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versions[defineID]
+}
+
+// recordDefinitionSend notes that the last packet sent by call was about a
+// definition, and records the version on its first use
+func (s *SimConnect) recordDefinitionSend(call string, defineID DWORD) {
+	sendID, err := s.GetLastSentPacketID()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.versions[defineID]; !ok {
+		s.versions[defineID] = s.definitionVersion
+	}
+	if err != nil {
+		return
+	}
+	if len(s.definitionSends) >= maxDefinitionSends {
+		// packet IDs increase, so the oldest are the smallest
+		for id := range s.definitionSends {
+			if id+maxDefinitionSends/2 < sendID {
+				delete(s.definitionSends, id)
+			}
+		}
+	}
+	s.definitionSends[sendID] = defineID
+	s.recordSendLocked(sendID, call)
+}
+
+// ExceptionDefinition returns the definition an exception was raised for,
+// and the version that registered it
+// ok is false if the exception was not raised by a definition or request
+// for one, or the packet is too old to be remembered
+func (s *SimConnect) ExceptionDefinition(ex RecvException) (defineID DWORD, version string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defineID, ok = s.definitionSends[ex.SendID]
+	if !ok {
+		return 0, "", false
+	}
+	return defineID, s.versions[defineID], true
+}
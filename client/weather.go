@@ -0,0 +1,288 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"unsafe"
+)
+
+// The legacy weather calls are for FSX and Prepar3D, and are still
+// exported by the MSFS 2020 dll, though MSFS ignores most of them. With a
+// dll that does not export them, see WithDLLPath, they return ErrUnsupported.
+
+// CLOUD_STATE_ARRAY_WIDTH is the width and height of a cloud state grid
+const CLOUD_STATE_ARRAY_WIDTH = 64
+
+// CLOUD_STATE_ARRAY_SIZE is the number of cells in a cloud state grid
+const CLOUD_STATE_ARRAY_SIZE = CLOUD_STATE_ARRAY_WIDTH * CLOUD_STATE_ARRAY_WIDTH
+
+type RecvWeatherObservation struct {
+	Recv
+	RequestID DWORD
+	Metar     [1]byte // variable length, null terminated
+}
+
+type RecvCloudState struct {
+	Recv
+	RequestID DWORD
+	ArraySize DWORD
+	Data      [1]byte // ArraySize bytes of cloud density
+}
+
+func (s *SimConnect) WeatherRequestObservationAtStation(requestID DWORD, icao string) error {
+	leave, err := s.enter(LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_WeatherRequestObservationAtStation(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
+	//   const char * szICAO
+	// );
+
+	if err := available(s.dll.proc_SimConnect_WeatherRequestObservationAtStation); err != nil {
+		return fmt.Errorf("SimConnect_WeatherRequestObservationAtStation: %w", err)
+	}
+	_icao := []byte(icao + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_WeatherRequestObservationAtStation.Call(
+		uintptr(s.handle),
+		uintptr(requestID),
+		uintptr(unsafe.Pointer(&_icao[0])),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_WeatherRequestObservationAtStation for %s error: %d %s", icao, r1, err)
+	}
+	return nil
+}
+
+// WeatherSetObservation sets the weather from a METAR, blending into it
+// over seconds
+func (s *SimConnect) WeatherSetObservation(seconds DWORD, metar string) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_WeatherSetObservation(
+	//   HANDLE hSimConnect,
+	//   DWORD Seconds,
+	//   const char * szMETAR
+	// );
+
+	if err := available(s.dll.proc_SimConnect_WeatherSetObservation); err != nil {
+		return fmt.Errorf("SimConnect_WeatherSetObservation: %w", err)
+	}
+	if s.dryRun {
+		s.log.Info("Dry run: set weather observation", "metar", metar, "seconds", seconds)
+		return nil
+	}
+	_metar := []byte(metar + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_WeatherSetObservation.Call(
+		uintptr(s.handle),
+		uintptr(seconds),
+		uintptr(unsafe.Pointer(&_metar[0])),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_WeatherSetObservation for %q error: %d %s", metar, r1, err)
+	}
+	return nil
+}
+
+// WeatherSetModeCustom puts the weather in custom mode, which
+// WeatherSetObservation needs
+func (s *SimConnect) WeatherSetModeCustom() error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_WeatherSetModeCustom(
+	//   HANDLE hSimConnect
+	// );
+
+	if err := available(s.dll.proc_SimConnect_WeatherSetModeCustom); err != nil {
+		return fmt.Errorf("SimConnect_WeatherSetModeCustom: %w", err)
+	}
+	if s.dryRun {
+		s.log.Info("Dry run: set custom weather mode")
+		return nil
+	}
+	r1, _, err := s.dll.proc_SimConnect_WeatherSetModeCustom.Call(
+		uintptr(s.handle),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_WeatherSetModeCustom error: %d %s", r1, err)
+	}
+	return nil
+}
+
+// WeatherSetModeTheme loads a weather theme by name, eg "StormyWeather"
+func (s *SimConnect) WeatherSetModeTheme(theme string) error {
+	leave, err := s.enter(LaneHigh)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_WeatherSetModeTheme(
+	//   HANDLE hSimConnect,
+	//   const char * szThemeName
+	// );
+
+	if err := available(s.dll.proc_SimConnect_WeatherSetModeTheme); err != nil {
+		return fmt.Errorf("SimConnect_WeatherSetModeTheme: %w", err)
+	}
+	if s.dryRun {
+		s.log.Info("Dry run: set weather theme", "theme", theme)
+		return nil
+	}
+	_theme := []byte(theme + "\x00")
+
+	r1, _, err := s.dll.proc_SimConnect_WeatherSetModeTheme.Call(
+		uintptr(s.handle),
+		uintptr(unsafe.Pointer(&_theme[0])),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_WeatherSetModeTheme for %s error: %d %s", theme, r1, err)
+	}
+	return nil
+}
+
+func (s *SimConnect) WeatherRequestCloudState(requestID DWORD, minLat, minLon, minAlt, maxLat, maxLon, maxAlt float32, flags DWORD) error {
+	leave, err := s.enter(LaneLow)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	// SimConnect_WeatherRequestCloudState(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
+	//   float minLat,
+	//   float minLon,
+	//   float minAlt,
+	//   float maxLat,
+	//   float maxLon,
+	//   float maxAlt,
+	//   DWORD dwFlags = 0
+	// );
+
+	if err := available(s.dll.proc_SimConnect_WeatherRequestCloudState); err != nil {
+		return fmt.Errorf("SimConnect_WeatherRequestCloudState: %w", err)
+	}
+	r1, _, err := s.dll.proc_SimConnect_WeatherRequestCloudState.Call(
+		uintptr(s.handle),
+		uintptr(requestID),
+		floatArg(minLat),
+		floatArg(minLon),
+		floatArg(minAlt),
+		floatArg(maxLat),
+		floatArg(maxLon),
+		floatArg(maxAlt),
+		uintptr(flags),
+	)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_WeatherRequestCloudState for requestID %d error: %d %s", requestID, r1, err)
+	}
+	return nil
+}
+
+// WeatherObservation requests the METAR of a weather station and waits for it
+// the reply is only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) WeatherObservation(ctx context.Context, icao string) (string, error) {
+	data, err := s.requestWeather(ctx, "weather at "+icao, func(requestID DWORD) error {
+		return s.WeatherRequestObservationAtStation(requestID, icao)
+	})
+	if err != nil {
+		return "", err
+	}
+	metar, _, err := RetrieveString(data)
+	if err != nil {
+		return "", fmt.Errorf("weather at %s: %w", icao, err)
+	}
+	return metar, nil
+}
+
+// CloudState requests the cloud density in a box, altitudes in feet, and
+// waits for it; the reply is a CLOUD_STATE_ARRAY_WIDTH square grid, one
+// byte of density per cell, rows running from minLat to maxLat
+func (s *SimConnect) CloudState(ctx context.Context, minLat, minLon, minAlt, maxLat, maxLon, maxAlt float32) ([]byte, error) {
+	return s.requestWeather(ctx, "cloud state", func(requestID DWORD) error {
+		return s.WeatherRequestCloudState(requestID, minLat, minLon, minAlt, maxLat, maxLon, maxAlt, 0)
+	})
+}
+
+// requestWeather makes a weather request with send and waits for its reply
+// an exception raised for the request, eg WEATHER_UNABLE_TO_GET_OBSERVATION,
+// ends the wait
+func (s *SimConnect) requestWeather(ctx context.Context, what string, send func(requestID DWORD) error) ([]byte, error) {
+	requestID, end, err := s.beginRequest(ctx, what)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+
+	ch := make(chan []byte, 1)
+	s.mu.Lock()
+	s.weather[requestID] = ch
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		delete(s.weather, requestID)
+		s.mu.Unlock()
+	}
+	if err := send(requestID); err != nil {
+		cancel()
+		return nil, err
+	}
+	exceptions := s.watchSend(requestID)
+	select {
+	case <-ctx.Done():
+		cancel()
+		return nil, fmt.Errorf("%s: %w", what, ctx.Err())
+	case ex := <-exceptions:
+		cancel()
+		return nil, fmt.Errorf("%s: %w", what, s.ExplainException(ex))
+	case data := <-ch:
+		return data, nil
+	}
+}
+
+// DeliverWeather hands a weather observation or cloud state to a pending request
+// it returns true if the message was consumed; the connector calls this
+// for WEATHER_OBSERVATION and CLOUD_STATE messages
+func (s *SimConnect) DeliverWeather(ppData unsafe.Pointer) bool {
+	recv := (*Recv)(ppData)
+	var requestID DWORD
+	var data []byte
+	switch recv.ID {
+	case RECV_ID_WEATHER_OBSERVATION:
+		x := (*RecvWeatherObservation)(ppData)
+		requestID = x.RequestID
+		header := DWORD(unsafe.Offsetof(x.Metar))
+		if x.Size > header {
+			data = make([]byte, x.Size-header)
+			copy(data, unsafe.Slice((*byte)(unsafe.Pointer(&x.Metar)), len(data)))
+		}
+	case RECV_ID_CLOUD_STATE:
+		x := (*RecvCloudState)(ppData)
+		requestID = x.RequestID
+		header := DWORD(unsafe.Offsetof(x.Data))
+		if n := x.ArraySize; n > 0 && x.Size >= header+n {
+			data = make([]byte, n)
+			copy(data, unsafe.Slice((*byte)(unsafe.Pointer(&x.Data)), n))
+		}
+	default:
+		return false
+	}
+	s.mu.Lock()
+	ch, ok := s.weather[requestID]
+	delete(s.weather, requestID)
+	s.mu.Unlock()
+	if ok {
+		ch <- data
+	}
+	return ok
+}
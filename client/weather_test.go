@@ -0,0 +1,23 @@
+package client_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+func TestWeatherUnsupported(t *testing.T) {
+	dll, sc := connect(t)
+	dll.Remove("SimConnect_WeatherSetModeCustom")
+
+	if err := sc.WeatherSetModeCustom(); !errors.Is(err, client.ErrUnsupported) {
+		t.Fatalf("missing proc: error %v, want ErrUnsupported", err)
+	}
+	if n := len(dll.Calls("SimConnect_WeatherSetModeCustom")); n != 0 {
+		t.Fatalf("missing proc called %d times", n)
+	}
+	if err := sc.WeatherSetModeTheme("stormy"); err != nil {
+		t.Fatalf("exported proc: %v", err)
+	}
+}
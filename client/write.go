@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// writeCheck is how long a write waits for the sim to reject it
+const writeCheck = 100 * time.Millisecond
+
+// writeOnce sets a single datum on the user aircraft and waits up to
+// writeCheck for an exception naming the write's packet, returned as an
+// ExceptionError; like checkSend this is best effort, and the exception is
+// only delivered while a dispatch loop (eg the Connector) is running
+func (s *SimConnect) writeOnce(ctx context.Context, name, unit string, dataType DWORD, size DWORD, buf unsafe.Pointer) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	defineID, err := s.datumDefinition(name, unit, dataType)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	err = s.checkSend(ctx, writeCheck, func() error {
+		if err := s.SetDataOnSimObjectContext(ctx, defineID, OBJECT_ID_USER, 0, 0, size, buf); err != nil {
+			return err
+		}
+		s.recordSend("SetDataOnSimObject")
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// WriteFloat sets a single simvar from a float64
+// a write the sim rejects returns an error matching its exception, eg
+// ErrDataError with errors.Is
+func (s *SimConnect) WriteFloat(ctx context.Context, name, unit string, v float64) error {
+	return s.writeOnce(ctx, name, unit, DATATYPE_FLOAT64, 8, unsafe.Pointer(&v))
+}
+
+// WriteInt sets a single simvar from an int64
+func (s *SimConnect) WriteInt(ctx context.Context, name, unit string, v int64) error {
+	return s.writeOnce(ctx, name, unit, DATATYPE_INT64, 8, unsafe.Pointer(&v))
+}
+
+// WriteString sets a single string simvar, eg ATC ID; v is truncated to 255 bytes
+func (s *SimConnect) WriteString(ctx context.Context, name string, v string) error {
+	var buf [256]byte
+	copy(buf[:255], v)
+	return s.writeOnce(ctx, name, "", DATATYPE_STRING256, DWORD(len(buf)), unsafe.Pointer(&buf[0]))
+}
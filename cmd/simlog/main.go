@@ -0,0 +1,164 @@
+// Command simlog records simvar changes to a journal and extracts time
+// ranges from it
+//
+//	simlog record -journal flight.jsonl [-vars vars.json]
+//	simlog query -journal flight.jsonl -from 14:30 -to 14:35 [-name "AUTOPILOT MASTER"]
+//
+// Times are RFC 3339, or a time of day (15:04 or 15:04:05) on the day of
+// the query. The vars file is a JSON list of {"name", "unit"} objects.
+//
+// simlog keeps its journal in a file only, so it builds without a
+// database/sql driver; a program with one can journal to SQLite through
+// journal.NewSQLite.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/journal"
+)
+
+// defaultVars are journaled when no vars file is given
+var defaultVars = []journal.Var{
+	{Name: "AUTOPILOT MASTER", Unit: "Bool"},
+	{Name: "AUTOPILOT HEADING LOCK", Unit: "Bool"},
+	{Name: "AUTOPILOT HEADING LOCK DIR", Unit: "Degrees"},
+	{Name: "AUTOPILOT ALTITUDE LOCK", Unit: "Bool"},
+	{Name: "AUTOPILOT ALTITUDE LOCK VAR", Unit: "Feet"},
+	{Name: "AUTOPILOT VERTICAL HOLD VAR", Unit: "Feet per minute"},
+	{Name: "AUTOPILOT NAV1 LOCK", Unit: "Bool"},
+	{Name: "AUTOPILOT APPROACH HOLD", Unit: "Bool"},
+	{Name: "PLANE ALTITUDE", Unit: "Feet"},
+	{Name: "PLANE HEADING DEGREES MAGNETIC", Unit: "Degrees"},
+	{Name: "AIRSPEED INDICATED", Unit: "Knots"},
+	{Name: "VERTICAL SPEED", Unit: "Feet per minute"},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	h := slog.NewTextHandler(os.Stderr, nil)
+	slog.SetDefault(slog.New(h))
+
+	switch os.Args[1] {
+	case "record":
+		record(os.Args[2:])
+	case "query":
+		query(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: simlog record|query [flags]")
+	os.Exit(2)
+}
+
+func record(args []string) {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	path := fs.String("journal", "simlog.jsonl", "The journal file to append to")
+	varsPath := fs.String("vars", "", "An optional JSON file listing the vars to journal")
+	epsilon := fs.Float64("epsilon", 0, "How much a value must change to be journaled")
+	fs.Parse(args)
+
+	vars := defaultVars
+	if *varsPath != "" {
+		data, err := os.ReadFile(*varsPath)
+		if err != nil {
+			slog.Error("Cannot read vars", "error", err)
+			os.Exit(1)
+		}
+		if err := json.Unmarshal(data, &vars); err != nil {
+			slog.Error("Cannot parse vars", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	store, err := journal.OpenFile(*path)
+	if err != nil {
+		slog.Error("Cannot open journal", "error", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	defer cancel()
+
+	j := journal.New(store, vars, journal.WithEpsilon(*epsilon))
+	con := simconnect.NewConnector("simlog", simconnect.WithReceiver(j))
+	con.StartReconnect(ctx)
+}
+
+func query(args []string) {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	path := fs.String("journal", "simlog.jsonl", "The journal file to read")
+	from := fs.String("from", "", "The start of the range")
+	to := fs.String("to", "", "The end of the range")
+	names := fs.String("name", "", "A comma separated list of vars to extract")
+	asJSON := fs.Bool("json", false, "Write JSON lines instead of a table")
+	fs.Parse(args)
+
+	var q journal.Query
+	var err error
+	if q.From, err = parseTime(*from); err != nil {
+		slog.Error("Cannot parse -from", "error", err)
+		os.Exit(2)
+	}
+	if q.To, err = parseTime(*to); err != nil {
+		slog.Error("Cannot parse -to", "error", err)
+		os.Exit(2)
+	}
+	if *names != "" {
+		for _, n := range strings.Split(*names, ",") {
+			q.Names = append(q.Names, strings.TrimSpace(n))
+		}
+	}
+
+	entries, err := journal.QueryFile(*path, q)
+	if err != nil {
+		slog.Error("Cannot query journal", "error", err)
+		os.Exit(1)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			enc.Encode(e)
+		}
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tSIM TIME\tNAME\tVALUE")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%.1f\t%s\t%g\n", e.At.Local().Format("2006-01-02 15:04:05.000"), e.SimTime, e.Name, e.Value)
+	}
+	w.Flush()
+}
+
+// parseTime parses RFC 3339 or a time of day today; empty is the zero time
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			now := time.Now()
+			return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not RFC 3339 or a time of day", s)
+}
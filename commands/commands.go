@@ -0,0 +1,230 @@
+// Package commands sets percent-based and detented controls, eg flaps and
+// spoilers, with the event values the loaded aircraft expects
+//
+// Aircraft disagree on what a control position means: FLAPS_SET 8192 is
+// flaps 2 on one type and flaps 15 on another, and some arm the spoilers
+// with an LVAR-backed event rather than SPOILERS_ARM_SET. A calibration
+// store maps logical positions to event values per aircraft TITLE, and the
+// Commander swaps calibrations as the aircraft detector reports changes.
+//
+//	[
+//	  {"title": "A320", "controls": {
+//	    "flaps": [{"name": "0", "percent": 0, "event": "FLAPS_SET", "value": 0},
+//	              {"name": "1", "percent": 25, "event": "FLAPS_SET", "value": 4096},
+//	              {"name": "full", "percent": 100, "event": "FLAPS_SET", "value": 16383}],
+//	    "spoiler_arm": [{"name": "armed", "percent": 100, "event": "SPOILERS_ARM_ON"}]}}
+//	]
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bmurray/simconnect-go/aircraft"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// CommandError is the error type for the package
+type CommandError string
+
+func (e CommandError) Error() string { return string(e) }
+
+const (
+	// ErrNotStarted is returned before the detector has reported an aircraft
+	ErrNotStarted CommandError = "no aircraft detected"
+	// ErrUnknownPosition is returned for a detent the calibration doesn't have
+	ErrUnknownPosition CommandError = "unknown position"
+)
+
+// Detent is a position of a control and the event that sets it
+type Detent struct {
+	Name string `json:"name"`
+	// Percent places the detent for SetPercent, from 0 to 100
+	Percent float64 `json:"percent"`
+	Event   string  `json:"event"`
+	Value   int32   `json:"value,omitempty"`
+}
+
+// Calibration maps the controls of the aircraft whose TITLE contains Title
+type Calibration struct {
+	Title    string              `json:"title"`
+	Controls map[string][]Detent `json:"controls"`
+}
+
+func (c Calibration) matches(title string) bool {
+	return c.Title != "" && strings.Contains(strings.ToLower(title), strings.ToLower(c.Title))
+}
+
+// Store is a list of calibrations, matched in order
+type Store []Calibration
+
+// LoadStore reads a calibration store from a JSON file
+func LoadStore(path string) (Store, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var st Store
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	return st, nil
+}
+
+// Save writes the store to a JSON file, replacing it in one step
+func (st Store) Save(path string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Lookup returns the calibration for an aircraft title
+func (st Store) Lookup(title string) (Calibration, bool) {
+	for _, c := range st {
+		if c.matches(title) {
+			return c, true
+		}
+	}
+	return Calibration{}, false
+}
+
+// DefaultRange is the range of the default percent events
+const DefaultRange = 16383
+
+// Defaults are the events used for controls an aircraft has no calibration
+// for; the percent is scaled to 0..DefaultRange
+var Defaults = map[string]string{
+	"flaps":    "FLAPS_SET",
+	"spoilers": "SPOILERS_SET",
+	"throttle": "THROTTLE_SET",
+	"mixture":  "MIXTURE_SET",
+	"prop":     "PROP_PITCH_SET",
+}
+
+// Commander sets controls using the calibration of the loaded aircraft
+type Commander struct {
+	mu     sync.Mutex
+	store  Store
+	sc     *client.SimConnect
+	title  string
+	active *Calibration
+}
+
+// New creates a commander and attaches it to the detector, so the
+// calibration follows the loaded aircraft
+func New(d *aircraft.Detector, store Store) *Commander {
+	c := &Commander{store: store}
+	d.Listen(c.aircraftChanged)
+	return c
+}
+
+func (c *Commander) aircraftChanged(ctx context.Context, sc *client.SimConnect, info aircraft.Info) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sc = sc
+	c.title = info.Title
+	c.apply()
+}
+
+// apply selects the calibration for the current aircraft; it must be called with the lock held
+func (c *Commander) apply() {
+	c.active = nil
+	if cal, ok := c.store.Lookup(c.title); ok {
+		c.active = &cal
+		slog.Info("Control calibration loaded", "calibration", cal.Title, "title", c.title)
+	}
+}
+
+// SetStore replaces the calibrations, eg after the user edits them
+func (c *Commander) SetStore(st Store) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store = st
+	c.apply()
+}
+
+// Calibration returns the calibration in use; false if the aircraft has none
+func (c *Commander) Calibration() (Calibration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active == nil {
+		return Calibration{}, false
+	}
+	return *c.active, true
+}
+
+// Set moves a control to a named detent, eg Set("flaps", "full")
+func (c *Commander) Set(control, position string) error {
+	c.mu.Lock()
+	sc, active := c.sc, c.active
+	c.mu.Unlock()
+	if sc == nil {
+		return ErrNotStarted
+	}
+	if active != nil {
+		for _, d := range active.Controls[control] {
+			if strings.EqualFold(d.Name, position) {
+				return send(sc, d.Event, d.Value)
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s %s", ErrUnknownPosition, control, position)
+}
+
+// SetPercent moves a control to a position from 0 to 100
+// a calibrated control goes to the nearest detent; otherwise the default
+// event is sent with the percent scaled to its range
+func (c *Commander) SetPercent(control string, percent float64) error {
+	c.mu.Lock()
+	sc, active := c.sc, c.active
+	c.mu.Unlock()
+	if sc == nil {
+		return ErrNotStarted
+	}
+	percent = math.Max(0, math.Min(100, percent))
+	if active != nil {
+		if detents := active.Controls[control]; len(detents) > 0 {
+			best := detents[0]
+			for _, d := range detents[1:] {
+				if math.Abs(d.Percent-percent) < math.Abs(best.Percent-percent) {
+					best = d
+				}
+			}
+			return send(sc, best.Event, best.Value)
+		}
+	}
+	event, ok := Defaults[control]
+	if !ok {
+		return fmt.Errorf("%w: %s has no calibration or default event", ErrUnknownPosition, control)
+	}
+	return send(sc, event, int32(math.Round(percent/100*DefaultRange)))
+}
+
+func send(sc *client.SimConnect, event string, value int32) error {
+	id, err := sc.MapClientEventByName(event)
+	if err != nil {
+		return err
+	}
+	return sc.TransmitClientEvent(client.OBJECT_ID_USER, id, client.DWORD(value), client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
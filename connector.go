@@ -27,14 +27,47 @@ type Receiver interface {
 	Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType)
 }
 
+// EventReceiver is an optional interface for receivers that handle events
+// Event is called for client events, system events and filename events
+// (eg AircraftLoaded); check ev.ID and cast to the larger type as needed
+type EventReceiver interface {
+	Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent)
+}
+
+// SystemEventReceiver is an optional interface for receivers that want
+// system events decoded; value is one of the client event types, eg
+// client.PauseEvent, see client.DecodeSystemEvent
+type SystemEventReceiver interface {
+	SystemEvent(ctx context.Context, sc *client.SimConnect, name string, value any)
+}
+
 // Connector is the main struct for connecting to SimConnect
 type Connector struct {
 	// simconnect *simconnect.SimConnect
 	name      string
 	receivers []Receiver
 	cycle     time.Duration
+	adaptive  *adaptiveCycle
+
+	dllPath     string
+	callLanes   bool
+	callTimeout time.Duration
+	configIndex int
+
+	canonicalUnits bool
+	dryRun         bool
+	maxOutstanding int
+	version        string
+
+	middleware []Middleware
 
-	dllPath string
+	definitions   []any
+	clientEvents  []string
+	systemEvents  []string
+	subscriptions []subscription
+
+	stats    stats
+	watchdog *watchdog
 
 	log *slog.Logger
 }
@@ -51,7 +84,7 @@ func WithReceiver(r Receiver) ConnectorOption {
 }
 
 // WithCycle sets the cycle time for the connector
-// the connector will dispatch data every cycle
+// the connector will dispatch data every cycle; see WithAdaptiveCycle
 func WithCycle(cycle time.Duration) ConnectorOption {
 	return func(c *Connector) {
 		c.cycle = cycle
@@ -73,6 +106,106 @@ func WithDLLPath(path string) ConnectorOption {
 	}
 }
 
+// WithConfigIndex selects the SimConnect.cfg entry to connect with
+// together with WithDLLPath this points a connector at a particular sim
+func WithConfigIndex(index int) ConnectorOption {
+	return func(c *Connector) {
+		c.configIndex = index
+	}
+}
+
+// WithCanonicalUnits registers catalogued simvars in their canonical unit
+// and converts locally; see client.WithCanonicalUnits
+func WithCanonicalUnits() ConnectorOption {
+	return func(c *Connector) {
+		c.canonicalUnits = true
+	}
+}
+
+// WithDryRun logs writes to the sim instead of sending them, while reads
+// work as normal; see client.WithDryRun
+func WithDryRun() ConnectorOption {
+	return func(c *Connector) {
+		c.dryRun = true
+	}
+}
+
+// WithMaxOutstanding caps the one-shot requests waiting for a reply; see
+// client.WithMaxOutstanding
+func WithMaxOutstanding(n int) ConnectorOption {
+	return func(c *Connector) {
+		c.maxOutstanding = n
+	}
+}
+
+// WithDefinitionVersion records the application release with every data
+// definition; see client.WithDefinitionVersion
+func WithDefinitionVersion(v string) ConnectorOption {
+	return func(c *Connector) {
+		c.version = v
+	}
+}
+
+// WithCallLanes gives commands priority over data requests and dispatch
+// when calls into SimConnect queue up; see client.WithCallLanes
+func WithCallLanes() ConnectorOption {
+	return func(c *Connector) {
+		c.callLanes = true
+	}
+}
+
+// WithCallTimeout bounds how long any call waits in its lane; see
+// client.WithCallTimeout
+func WithCallTimeout(d time.Duration) ConnectorOption {
+	return func(c *Connector) {
+		c.callTimeout = d
+	}
+}
+
+// WithDefinition registers data definitions on every (re)connect
+// definitions are registered before event maps, subscriptions and receivers,
+// each after the definitions it depends on (see Dependent)
+func WithDefinition(defs ...any) ConnectorOption {
+	return func(c *Connector) {
+		c.definitions = append(c.definitions, defs...)
+	}
+}
+
+// WithClientEvent maps sim events on every (re)connect
+// look up the IDs with sc.ClientEventID; they are assigned in order, so
+// they are stable across reconnects
+func WithClientEvent(names ...string) ConnectorOption {
+	return func(c *Connector) {
+		c.clientEvents = append(c.clientEvents, names...)
+	}
+}
+
+// WithSystemEvent subscribes to system events on every (re)connect
+// look up the IDs with sc.SystemEventID
+func WithSystemEvent(names ...string) ConnectorOption {
+	return func(c *Connector) {
+		c.systemEvents = append(c.systemEvents, names...)
+	}
+}
+
+// subscription is a periodic request made on every (re)connect
+type subscription struct {
+	report                            any
+	objectID, period, flags, interval client.DWORD
+}
+
+// WithSubscription registers T and everything it depends on, and requests
+// them from objectID every period on every (re)connect; the replies reach
+// Update as usual, with the define ID as the request ID so IsReport matches
+// flags and interval are as for client.RequestDataOnSimObject, eg
+// client.DATA_REQUEST_FLAG_CHANGED to be sent only what changed
+func WithSubscription[T any](objectID, period, flags, interval client.DWORD) ConnectorOption {
+	return func(c *Connector) {
+		var report *T
+		c.subscriptions = append(c.subscriptions, subscription{report, objectID, period, flags, interval})
+	}
+}
+
 // NewConnector creates a new connector
 // you can pass options to the connector
 func NewConnector(name string, opts ...ConnectorOption) *Connector {
@@ -139,22 +272,69 @@ func (c *Connector) connect(ctx context.Context) error {
 	if c.dllPath != "" {
 		opts = append(opts, client.WithDLLPath(c.dllPath))
 	}
-	sc, err := client.New(c.name)
+	if c.callLanes {
+		opts = append(opts, client.WithCallLanes())
+	}
+	if c.callTimeout > 0 {
+		opts = append(opts, client.WithCallTimeout(c.callTimeout))
+	}
+	if c.configIndex != 0 {
+		opts = append(opts, client.WithConfigIndex(c.configIndex))
+	}
+	if c.canonicalUnits {
+		opts = append(opts, client.WithCanonicalUnits())
+	}
+	if c.dryRun {
+		opts = append(opts, client.WithDryRun())
+	}
+	if c.maxOutstanding > 0 {
+		opts = append(opts, client.WithMaxOutstanding(c.maxOutstanding))
+	}
+	if c.version != "" {
+		opts = append(opts, client.WithDefinitionVersion(c.version))
+	}
+	sc, err := client.New(c.name, opts...)
 	if err != nil && errors.Is(err, syscall.Errno(0)) {
 		return nil
 	} else if err != nil {
 		return fmt.Errorf("cannot connect to SimConnect: %w", err)
 	}
+	c.stats.connected(sc)
+	subs := newSubscriptions(ctx2, sc)
+	groups := make([]*Group, len(c.receivers))
+	rctxs := make([]context.Context, len(c.receivers))
 	defer func() {
+		// stop the receivers and wait for their goroutines before closing
+		cancel()
+		for i, g := range groups {
+			if g == nil {
+				continue
+			}
+			c.log.Debug("Waiting for receiver", "receiver", fmt.Sprintf("%T", c.receivers[i]))
+			g.Wait()
+		}
+		subs.g.Wait()
+		c.stats.disconnected()
 		if err := sc.Close(); err != nil {
 			c.log.Error("Cannot close SimConnect", "error", err)
 		}
 	}()
 
-	for _, r := range c.receivers {
-		r.Start(ctx2, sc)
+	if err := c.register(sc); err != nil {
+		return err
+	}
+
+	sctx := context.WithValue(ctx2, subscriptionsKey{}, subs)
+	for i, r := range c.receivers {
+		g, rctx := newGroup(sctx)
+		groups[i], rctxs[i] = g, rctx
+		r.Start(rctx, sc)
+	}
+	interval := c.cycle
+	if c.adaptive != nil {
+		interval = c.adaptive.reset()
 	}
-	dispatcher := time.NewTicker(c.cycle)
+	dispatcher := time.NewTicker(interval)
 	defer dispatcher.Stop()
 
 	for {
@@ -163,13 +343,47 @@ func (c *Connector) connect(ctx context.Context) error {
 			return nil
 		case <-dispatcher.C:
 			// Dispatch
-			err := dispatchFn(ctx2, sc, func(x *client.RecvSimobjectDataByType) error {
-				for _, r := range c.receivers {
-					r.Update(ctx2, sc, x)
-				}
-				return nil
+			got := false
+			err := dispatchFn(ctx2, sc, c.middleware, dispatchHandlers{
+				data: func(x *client.RecvSimobjectDataByType) error {
+					for i, r := range c.receivers {
+						r.Update(rctxs[i], sc, x)
+					}
+					return nil
+				},
+				event: func(x *client.RecvEvent) error {
+					for i, r := range c.receivers {
+						if er, ok := r.(EventReceiver); ok {
+							er.Event(rctxs[i], sc, x)
+						}
+					}
+					if name, ok := sc.SystemEventName(x.EventID); ok {
+						value := client.DecodeSystemEvent(name, x.Data)
+						for i, r := range c.receivers {
+							if sr, ok := r.(SystemEventReceiver); ok {
+								sr.SystemEvent(rctxs[i], sc, name, value)
+							}
+						}
+					}
+					return nil
+				},
+				seen: func(r *client.Recv) {
+					got = true
+					c.stats.seen(r)
+				},
 			})
+			if c.adaptive != nil {
+				if d := c.adaptive.next(got); d != interval {
+					interval = d
+					dispatcher.Reset(d)
+				}
+			}
 			if err != nil {
+				var ex client.RecvException
+				if errors.As(err, &ex) {
+					c.stats.exception(ex)
+					c.watchdog.exception(c.log, sc, ex)
+				}
 				if errors.Is(err, ErrGetNextDispatch) {
 					return fmt.Errorf("cannot dispatch: %w", err)
 				} else if !errors.Is(err, syscall.Errno(0)) {
@@ -180,6 +394,42 @@ func (c *Connector) connect(ctx context.Context) error {
 	}
 }
 
+// register applies the pre-registered definitions, event maps and
+// subscriptions in a deterministic order
+func (c *Connector) register(sc *client.SimConnect) error {
+	for _, d := range c.definitions {
+		if err := RegisterWithDependencies(sc, d); err != nil {
+			return fmt.Errorf("cannot register definition %T: %w", d, err)
+		}
+	}
+	for _, name := range c.clientEvents {
+		if _, err := sc.MapClientEventByName(name); err != nil {
+			return fmt.Errorf("cannot map event %s: %w", name, err)
+		}
+	}
+	for _, name := range c.systemEvents {
+		if _, err := sc.SubscribeToSystemEventByName(name); err != nil {
+			return fmt.Errorf("cannot subscribe to %s: %w", name, err)
+		}
+	}
+	for _, sub := range c.subscriptions {
+		if err := RegisterWithDependencies(sc, sub.report); err != nil {
+			return fmt.Errorf("cannot register subscription %T: %w", sub.report, err)
+		}
+		reports, _ := Dependencies(sub.report)
+		for _, r := range reports {
+			if !hasSimvars(r) {
+				continue
+			}
+			defineID := sc.GetDefineID(r)
+			if err := sc.RequestDataOnSimObject(defineID, defineID, sub.objectID, sub.period, sub.flags, 0, sub.interval, 0); err != nil {
+				return fmt.Errorf("cannot subscribe to %T: %w", r, err)
+			}
+		}
+	}
+	return nil
+}
+
 // ConnectorError is the error type for the connector
 type ConnectorError string
 
@@ -192,8 +442,20 @@ const (
 	ErrGetNextDispatch ConnectorError = "GetNextDispatch"
 )
 
-func dispatchFn(ctx context.Context, s *client.SimConnect, fn func(*client.RecvSimobjectDataByType) error) error {
-	ppData, r1, err := s.GetNextDispatch()
+// dispatchHandlers are the callbacks dispatchFn routes messages to
+type dispatchHandlers struct {
+	data  func(*client.RecvSimobjectDataByType) error
+	event func(*client.RecvEvent) error
+	// seen is called with every message before it is routed; optional
+	seen func(*client.Recv)
+}
+
+func dispatchFn(ctx context.Context, s *client.SimConnect, mw []Middleware, h dispatchHandlers) error {
+	ppData, r1, err := s.GetNextDispatchContext(ctx)
+	if ppData == nil && r1 == 0 && err != nil {
+		// the wait for the lane ended, on shutdown or by the call timeout
+		return nil
+	}
 	if r1 < 0 {
 		if uint32(r1) == client.E_FAIL {
 			return fmt.Errorf("GetNextDispatch error E_FAIL: %d %w %T", r1, err, err)
@@ -202,10 +464,28 @@ func dispatchFn(ctx context.Context, s *client.SimConnect, fn func(*client.RecvS
 		}
 	}
 	recvInfo := *(*client.Recv)(ppData)
+	if h.seen != nil {
+		h.seen(&recvInfo)
+	}
+	var next DispatchHandler = func(ctx context.Context, s *client.SimConnect, m Message) error {
+		return route(s, m, h)
+	}
+	for i := len(mw) - 1; i >= 0; i-- {
+		next = mw[i](next)
+	}
+	return next(ctx, s, Message{Recv: &recvInfo, Data: ppData})
+}
+
+// route hands a message to the connector's handlers
+func route(s *client.SimConnect, m Message, h dispatchHandlers) error {
+	ppData, recvInfo := m.Data, *m.Recv
+	var err error
 	switch recvInfo.ID {
 	case client.RECV_ID_EXCEPTION:
 		recvErr := *(*client.RecvException)(ppData)
-		err = client.RecvException(recvErr)
+		// a waiting call gets the exception too; it is still reported
+		s.DeliverException(recvErr)
+		err = s.ExplainException(recvErr)
 		return fmt.Errorf("SIMCONNECT_RECV_ID_EXCEPTION: %w", err)
 	case client.RECV_ID_OPEN:
 		recvOpen := *(*client.RecvOpen)(ppData)
@@ -213,13 +493,76 @@ func dispatchFn(ctx context.Context, s *client.SimConnect, fn func(*client.RecvS
 		// Ignore open message
 		// return fmt.Errorf("SIMCONNECT_RECV_ID_OPEN %w", err)
 		return nil
-	case client.RECV_ID_EVENT:
-		recvEvent := *(*client.RecvEvent)(ppData)
-		err = client.RecvEventError(recvEvent)
-		return fmt.Errorf("SIMCONNECT_RECV_ID_EVENT %w", err)
-	case client.RECV_ID_SIMOBJECT_DATA_BYTYPE:
+	case client.RECV_ID_EVENT, client.RECV_ID_EVENT_FILENAME:
+		x := (*client.RecvEvent)(ppData)
+		if s.DeliverText(x) {
+			return nil
+		}
+		return h.event(x)
+	case client.RECV_ID_SIMOBJECT_DATA, client.RECV_ID_SIMOBJECT_DATA_BYTYPE:
+		// both messages share a layout, so periodic data reaches Update too
 		x := (*client.RecvSimobjectDataByType)(ppData)
-		return fn(x)
+		s.ObserveReply(&x.RecvSimobjectData)
+		if s.Deliver(&x.RecvSimobjectData) {
+			return nil
+		}
+		s.Convert(&x.RecvSimobjectData)
+		// a reply reaching the receivers is what keeps it from Reclaim
+		s.MarkUsed(x.DefineID)
+		return h.data(x)
+	case client.RECV_ID_FACILITY_DATA, client.RECV_ID_FACILITY_DATA_END:
+		// replies to RequestFacility; nothing else asks for facility data
+		s.DeliverFacility(ppData)
+		return nil
+	case client.RECV_ID_ASSIGNED_OBJECT_ID:
+		// replies to CreateSimulatedObject
+		s.DeliverAssignedObject((*client.RecvAssignedObjectID)(ppData))
+		return nil
+	case client.RECV_ID_AIRPORT_LIST, client.RECV_ID_WAYPOINT_LIST, client.RECV_ID_NDB_LIST, client.RECV_ID_VOR_LIST:
+		// replies to RequestFacilitiesList and SubscribeToFacilities
+		s.DeliverFacilityList(ppData)
+		return nil
+	case client.RECV_ID_CLIENT_DATA:
+		// replies to RequestClientData
+		s.DeliverClientData((*client.RecvClientData)(ppData))
+		return nil
+	case client.RECV_ID_SYSTEM_STATE:
+		// replies to RequestSystemState
+		s.DeliverSystemState((*client.RecvSystemState)(ppData))
+		return nil
+	case client.RECV_ID_JETWAY_DATA:
+		// replies to Jetways
+		s.DeliverJetways(ppData)
+		return nil
+	case client.RECV_ID_ENUMERATE_INPUT_EVENTS, client.RECV_ID_GET_INPUT_EVENT,
+		client.RECV_ID_SUBSCRIBE_INPUT_EVENT, client.RECV_ID_ENUMERATE_INPUT_EVENT_PARAMS:
+		// replies to the input event requests, and subscribed values
+		s.DeliverInputEvent(ppData)
+		return nil
+	case client.RECV_ID_CONTROLLERS_LIST:
+		// replies to Controllers
+		s.DeliverControllers(ppData)
+		return nil
+	case client.RECV_ID_ENUMERATE_SIMOBJECT_AND_LIVERY_LIST:
+		// replies to Liveries
+		s.DeliverLiveries(ppData)
+		return nil
+	case client.RECV_ID_ACTION_CALLBACK:
+		// replies to Action
+		s.DeliverAction(ppData)
+		return nil
+	case client.RECV_ID_WEATHER_OBSERVATION, client.RECV_ID_CLOUD_STATE:
+		// replies to WeatherObservation and CloudState
+		s.DeliverWeather(ppData)
+		return nil
+	case client.RECV_ID_CUSTOM_ACTION:
+		// custom actions triggered by missions, see HandleCustomActions
+		s.DeliverCustomAction(ppData)
+		return nil
+	case client.RECV_ID_RESERVED_KEY:
+		// replies to ReserveKey
+		s.DeliverReservedKey((*client.RecvReservedKey)(ppData))
+		return nil
 	default:
 		return fmt.Errorf("recvInfo.dwID unknown: %d", recvInfo.ID)
 	}
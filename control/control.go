@@ -0,0 +1,153 @@
+// Package control provides the pieces of a control loop that drives axis
+// events from simvar feedback: a PID controller with anti-windup, a rate
+// limiter, a deadband and the mapping to the axis event range
+//
+// A typical loop samples a simvar with simconnect.Subscribe, runs the error
+// through Deadband and the PID, limits how fast the output moves and sends
+// it with an AXIS_*_SET event:
+//
+//	out := limiter.Limit(pid.Update(control.Deadband(target-ias, 0.5), dt), dt)
+//	throttle.Transmit(sc, control.Axis(out, 0, 1))
+//
+// None of the types are safe for concurrent use; receivers that run the
+// loop from Update should hold their own lock.
+package control
+
+import (
+	"math"
+	"time"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// PID is a PID controller with its output clamped to a range
+// the integral stops growing while the output is clamped, so it does not
+// wind up while an axis is against a stop
+type PID struct {
+	kp, ki, kd float64
+	min, max   float64
+
+	integral float64
+	prev     float64
+	primed   bool
+}
+
+// Option is a function that sets options on the PID
+type Option func(*PID)
+
+// WithLimits clamps the output to min..max; the default is -1..1
+func WithLimits(min, max float64) Option {
+	return func(p *PID) {
+		p.min, p.max = min, max
+	}
+}
+
+// NewPID creates a controller with the gains, in output units per unit of
+// error, per unit of error second and per unit of error per second
+func NewPID(kp, ki, kd float64, opts ...Option) *PID {
+	p := &PID{kp: kp, ki: ki, kd: kd, min: -1, max: 1}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Reset restarts the controller so its output starts at out, for a
+// bumpless handover from the current position of the axis
+func (p *PID) Reset(out float64) {
+	p.primed = false
+	p.integral = 0
+	if p.ki != 0 {
+		p.integral = clamp(out, p.min, p.max) / p.ki
+	}
+}
+
+// Update returns the output for the error e, dt after the last update
+// the derivative term starts on the second update, so the first does not
+// kick on a stale error
+func (p *PID) Update(e float64, dt time.Duration) float64 {
+	secs := dt.Seconds()
+	var d float64
+	if p.primed && secs > 0 {
+		d = (e - p.prev) / secs
+	}
+	p.prev, p.primed = e, true
+
+	integral := p.integral + e*secs
+	out := p.kp*e + p.ki*integral + p.kd*d
+	switch {
+	case out > p.max:
+		out = p.max
+		if e < 0 {
+			p.integral = integral
+		}
+	case out < p.min:
+		out = p.min
+		if e > 0 {
+			p.integral = integral
+		}
+	default:
+		p.integral = integral
+	}
+	return out
+}
+
+// RateLimiter limits how fast a value may change, so an axis moves at a
+// believable speed rather than jumping
+type RateLimiter struct {
+	rate   float64
+	last   float64
+	primed bool
+}
+
+// NewRateLimiter creates a limiter that moves at most rate units a second
+func NewRateLimiter(rate float64) *RateLimiter {
+	return &RateLimiter{rate: rate}
+}
+
+// Reset makes v the current value, as after a handover
+func (r *RateLimiter) Reset(v float64) {
+	r.last, r.primed = v, true
+}
+
+// Limit returns v moved no further than the rate allows from the last
+// value, dt after it; the first value passes as is
+func (r *RateLimiter) Limit(v float64, dt time.Duration) float64 {
+	if !r.primed {
+		r.Reset(v)
+		return v
+	}
+	step := r.rate * dt.Seconds()
+	r.last = clamp(v, r.last-step, r.last+step)
+	return r.last
+}
+
+// Deadband returns 0 while v is within width of 0, and v moved width
+// towards 0 otherwise, so the output is continuous at the edges
+func Deadband(v, width float64) float64 {
+	switch {
+	case v > width:
+		return v - width
+	case v < -width:
+		return v + width
+	}
+	return 0
+}
+
+// AxisMax is the full deflection of the AXIS_*_SET events, which run from
+// -AxisMax to AxisMax
+const AxisMax = 16383
+
+// Axis maps v in min..max onto the axis event range, clamping it, eg
+// Axis(out, 0, 1) for a throttle or Axis(out, -1, 1) for a control surface
+func Axis(v, min, max float64) client.DWORD {
+	if max == min {
+		return 0
+	}
+	f := (clamp(v, min, max) - min) / (max - min)
+	return client.DWORD(int32(math.Round(-AxisMax + f*2*AxisMax)))
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
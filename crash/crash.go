@@ -0,0 +1,266 @@
+// Package crash handles the Crashed and CrashReset system events and can
+// restore the last saved position and fuel after a crash reset
+package crash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/geo"
+	"github.com/bmurray/simconnect-go/store"
+)
+
+// Snapshot is the state saved while flying and restored after a crash reset
+// every field is settable, so it is also the restore request
+type Snapshot struct {
+	client.RecvSimobjectDataByType
+	Latitude   float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude  float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude   float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	Heading    float64 `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees"`
+	Pitch      float64 `name:"PLANE PITCH DEGREES" unit:"Degrees"`
+	Bank       float64 `name:"PLANE BANK DEGREES" unit:"Degrees"`
+	VelocityZ  float64 `name:"VELOCITY BODY Z" unit:"Feet per second"`
+	FuelLeft   float64 `name:"FUEL TANK LEFT MAIN QUANTITY" unit:"Gallons"`
+	FuelRight  float64 `name:"FUEL TANK RIGHT MAIN QUANTITY" unit:"Gallons"`
+	FuelCenter float64 `name:"FUEL TANK CENTER QUANTITY" unit:"Gallons"`
+}
+
+// Position returns the position of the snapshot
+func (s Snapshot) Position() geo.Position {
+	return geo.Position{Latitude: s.Latitude, Longitude: s.Longitude, Altitude: s.Altitude}
+}
+
+// Watcher is a receiver that reports crashes and optionally restores state
+type Watcher struct {
+	interval    time.Duration
+	autoRestore bool
+	onCrash     func(last Snapshot, ok bool)
+	onReset     func()
+	store       store.Store
+	storeKey    string
+
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	crashID  client.DWORD
+	resetID  client.DWORD
+	crashed  bool
+	last     Snapshot
+	haveLast bool
+	dirty    bool
+}
+
+// Option is a function that sets options on the Watcher
+type Option func(*Watcher)
+
+// WithInterval sets how often a snapshot is saved while flying
+func WithInterval(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.interval = d
+	}
+}
+
+// WithAutoRestore restores the last snapshot after a crash reset
+func WithAutoRestore() Option {
+	return func(w *Watcher) {
+		w.autoRestore = true
+	}
+}
+
+// WithOnCrash sets a callback that is called when the aircraft crashes
+// it receives the last snapshot saved before the crash, if any
+func WithOnCrash(fn func(last Snapshot, ok bool)) Option {
+	return func(w *Watcher) {
+		w.onCrash = fn
+	}
+}
+
+// WithOnReset sets a callback that is called after a crash reset
+func WithOnReset(fn func()) Option {
+	return func(w *Watcher) {
+		w.onReset = fn
+	}
+}
+
+// WithStore saves the snapshot to a key of st and loads it on start, so
+// the last state survives a restart of the application
+func WithStore(st store.Store, key string) Option {
+	return func(w *Watcher) {
+		w.store = st
+		w.storeKey = key
+	}
+}
+
+// New creates a new Watcher
+func New(opts ...Option) *Watcher {
+	w := &Watcher{interval: 5 * time.Second}
+	for _, o := range opts {
+		o(w)
+	}
+	return w
+}
+
+// Start subscribes to the crash events and starts saving snapshots
+func (w *Watcher) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := sc.RegisterDataDefinition(&Snapshot{}); err != nil {
+		slog.Error("Cannot register crash snapshot", "error", err)
+		return
+	}
+	crashID, err := sc.SubscribeToSystemEventByName("Crashed")
+	if err != nil {
+		slog.Error("Cannot subscribe to Crashed", "error", err)
+		return
+	}
+	resetID, err := sc.SubscribeToSystemEventByName("CrashReset")
+	if err != nil {
+		slog.Error("Cannot subscribe to CrashReset", "error", err)
+		return
+	}
+	w.mu.Lock()
+	w.sc = sc
+	w.crashID = crashID
+	w.resetID = resetID
+	w.crashed = false
+	w.mu.Unlock()
+	w.load()
+
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.interval):
+				w.save()
+				w.mu.Lock()
+				crashed := w.crashed
+				w.mu.Unlock()
+				if crashed {
+					continue
+				}
+				if err := simconnect.RequestData[Snapshot](sc); err != nil {
+					slog.Error("Cannot request crash snapshot", "error", err)
+				}
+			}
+		}
+	})
+}
+
+// Update saves the snapshot unless the aircraft has crashed
+func (w *Watcher) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[Snapshot](sc, ppData)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// a snapshot requested just before the crash may arrive after it
+	if w.crashed {
+		return
+	}
+	w.last = *r
+	w.haveLast = true
+	w.dirty = true
+}
+
+// load reads the saved snapshot if there is none yet
+func (w *Watcher) load() {
+	if w.store == nil {
+		return
+	}
+	w.mu.Lock()
+	have := w.haveLast
+	w.mu.Unlock()
+	if have {
+		return
+	}
+	var last Snapshot
+	if err := store.GetJSON(w.store, w.storeKey, &last); err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			slog.Error("Cannot load crash snapshot", "error", err)
+		}
+		return
+	}
+	w.mu.Lock()
+	if !w.haveLast {
+		w.last, w.haveLast = last, true
+	}
+	w.mu.Unlock()
+}
+
+// save writes the snapshot if it changed since the last save
+// it runs on the sampling goroutine, so the dispatch loop never waits on the store
+func (w *Watcher) save() {
+	if w.store == nil {
+		return
+	}
+	w.mu.Lock()
+	last, dirty := w.last, w.dirty
+	w.dirty = false
+	w.mu.Unlock()
+	if !dirty {
+		return
+	}
+	if err := store.PutJSON(w.store, w.storeKey, last); err != nil {
+		slog.Error("Cannot save crash snapshot", "error", err)
+	}
+}
+
+// Event handles the crash events
+func (w *Watcher) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	w.mu.Lock()
+	crashID, resetID := w.crashID, w.resetID
+	w.mu.Unlock()
+
+	switch ev.EventID {
+	case crashID:
+		w.mu.Lock()
+		w.crashed = true
+		last, ok := w.last, w.haveLast
+		w.mu.Unlock()
+		slog.Info("Aircraft crashed")
+		if w.onCrash != nil {
+			w.onCrash(last, ok)
+		}
+	case resetID:
+		w.mu.Lock()
+		w.crashed = false
+		last, ok := w.last, w.haveLast
+		w.mu.Unlock()
+		slog.Info("Crash reset")
+		if w.autoRestore && ok {
+			if err := sc.SetData(&last); err != nil {
+				slog.Error("Cannot restore snapshot", "error", err)
+			}
+		}
+		if w.onReset != nil {
+			w.onReset()
+		}
+	}
+}
+
+// Last returns the last saved snapshot; false if none has been saved
+func (w *Watcher) Last() (Snapshot, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.last, w.haveLast
+}
+
+// Restore writes the last saved snapshot back to the sim
+func (w *Watcher) Restore() error {
+	w.mu.Lock()
+	sc, last, ok := w.sc, w.last, w.haveLast
+	w.mu.Unlock()
+	if sc == nil {
+		return fmt.Errorf("crash watcher not started")
+	}
+	if !ok {
+		return fmt.Errorf("no snapshot to restore")
+	}
+	return sc.SetData(&last)
+}
@@ -0,0 +1,51 @@
+package simconnect
+
+import "time"
+
+// WithAdaptiveCycle polls faster while messages are flowing and backs off
+// when the queue stays empty, keeping the interval between min and max
+// it replaces the fixed WithCycle interval, balancing latency and CPU for
+// apps that poll rather than wait on an event handle
+func WithAdaptiveCycle(min, max time.Duration) ConnectorOption {
+	return func(c *Connector) {
+		if min <= 0 || max < min {
+			c.log.Warn("Ignoring adaptive cycle", "min", min, "max", max)
+			return
+		}
+		c.adaptive = &adaptiveCycle{min: min, max: max, idleAfter: 3}
+	}
+}
+
+// adaptiveCycle picks the next poll interval from whether the last poll
+// got a message; it is only used by the dispatch loop
+type adaptiveCycle struct {
+	min, max time.Duration
+	// idleAfter is the number of empty polls in a row before backing off
+	idleAfter int
+
+	cur   time.Duration
+	empty int
+}
+
+// reset starts a connection at the fastest interval, as registration
+// replies arrive in a burst
+func (a *adaptiveCycle) reset() time.Duration {
+	a.cur, a.empty = a.min, 0
+	return a.cur
+}
+
+// next returns the interval after a poll
+// a message halves the interval; idleAfter empty polls double it
+func (a *adaptiveCycle) next(got bool) time.Duration {
+	if got {
+		a.empty = 0
+		a.cur = max(a.cur/2, a.min)
+		return a.cur
+	}
+	a.empty++
+	if a.empty >= a.idleAfter {
+		a.empty = 0
+		a.cur = min(a.cur*2, a.max)
+	}
+	return a.cur
+}
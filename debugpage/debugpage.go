@@ -0,0 +1,133 @@
+// Package debugpage serves a page describing a running Connector, in the
+// spirit of net/http/pprof, to help support end users remotely
+//
+//	mux.Handle("/debug/simconnect/", debugpage.Handler(con))
+//
+// The page lists the registered definitions with their field layouts, the
+// active subscriptions, the event maps, recent exceptions and the dispatch
+// rates. Append ?format=json for a machine-readable snapshot.
+package debugpage
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Snapshot is everything shown on the page
+type Snapshot struct {
+	Stats         simconnect.Stats
+	Definitions   []client.Definition
+	Subscriptions []client.Subscription
+	Events        []Event
+}
+
+// Event is a mapped event
+type Event struct {
+	ID   client.DWORD
+	Name string
+}
+
+// Take captures a snapshot of the connector
+func Take(c *simconnect.Connector) Snapshot {
+	snap := Snapshot{Stats: c.Stats()}
+	sc := c.SimConnect()
+	if sc == nil {
+		return snap
+	}
+	snap.Definitions = sc.Definitions()
+	snap.Subscriptions = sc.Subscriptions()
+	for id, name := range sc.EventNames() {
+		snap.Events = append(snap.Events, Event{ID: id, Name: name})
+	}
+	sort.Slice(snap.Events, func(i, j int) bool { return snap.Events[i].ID < snap.Events[j].ID })
+	return snap
+}
+
+// Handler returns a handler serving the debug page for the connector
+func Handler(c *simconnect.Connector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snap := Take(c)
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			enc.Encode(snap)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		page.Execute(w, snap)
+	})
+}
+
+var page = template.Must(template.New("page").Funcs(template.FuncMap{
+	"datatype": dataTypeName,
+	"recv":     client.RecvIDName,
+}).Parse(`<!DOCTYPE html>
+<html><head><title>SimConnect debug</title>
+<style>body{font-family:monospace} table{border-collapse:collapse;margin-bottom:1em} td,th{border:1px solid #ccc;padding:2px 6px;text-align:left}</style>
+</head><body>
+<h1>SimConnect</h1>
+{{if .Stats.Connected}}<p>Connected since {{.Stats.ConnectedAt.Format "2006-01-02 15:04:05"}}</p>{{else}}<p>Not connected</p>{{end}}
+
+<h2>Dispatch</h2>
+<table><tr><th>Message</th><th>Total</th><th>Rate/s</th></tr>
+{{range $k, $v := .Stats.Messages}}<tr><td>{{$k}}</td><td>{{$v}}</td><td>{{printf "%.1f" (index $.Stats.Rates $k)}}</td></tr>
+{{end}}</table>
+
+<h2>Definitions</h2>
+{{range .Definitions}}<table><tr><th colspan="4">{{.ID}} {{.Name}}</th></tr>
+<tr><th>Name</th><th>Unit</th><th>Type</th><th>Epsilon</th></tr>
+{{range .Datums}}<tr><td>{{.Name}}</td><td>{{.Unit}}</td><td>{{datatype .DataType}}</td><td>{{.Epsilon}}</td></tr>
+{{end}}</table>
+{{end}}
+
+<h2>Subscriptions</h2>
+<table><tr><th>Request</th><th>Define</th><th>Object</th><th>Period</th><th>Flags</th><th>Interval</th></tr>
+{{range .Subscriptions}}<tr><td>{{.RequestID}}</td><td>{{.DefineID}}</td><td>{{.ObjectID}}</td><td>{{.Period}}</td><td>{{.Flags}}</td><td>{{.Interval}}</td></tr>
+{{end}}</table>
+
+<h2>Events</h2>
+<table><tr><th>ID</th><th>Name</th></tr>
+{{range .Events}}<tr><td>{{.ID}}</td><td>{{.Name}}</td></tr>
+{{end}}</table>
+
+<h2>Recent exceptions</h2>
+<table><tr><th>Time</th><th>Exception</th><th>Send ID</th><th>Index</th></tr>
+{{range .Stats.Exceptions}}<tr><td>{{.At.Format "15:04:05.000"}}</td><td>{{.Exception.Exception}}</td><td>{{.Exception.SendID}}</td><td>{{.Exception.Index}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+func dataTypeName(t client.DWORD) string {
+	switch t {
+	case client.DATATYPE_INT32:
+		return "INT32"
+	case client.DATATYPE_INT64:
+		return "INT64"
+	case client.DATATYPE_FLOAT32:
+		return "FLOAT32"
+	case client.DATATYPE_FLOAT64:
+		return "FLOAT64"
+	case client.DATATYPE_STRING8:
+		return "STRING8"
+	case client.DATATYPE_STRING32:
+		return "STRING32"
+	case client.DATATYPE_STRING64:
+		return "STRING64"
+	case client.DATATYPE_STRING128:
+		return "STRING128"
+	case client.DATATYPE_STRING256:
+		return "STRING256"
+	case client.DATATYPE_STRING260:
+		return "STRING260"
+	case client.DATATYPE_STRINGV:
+		return "STRINGV"
+	default:
+		return "UNKNOWN"
+	}
+}
@@ -0,0 +1,231 @@
+package simconnect
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Dependent is implemented by report types that need other reports
+// eg a TrafficPicture computed from UserPosition returns []any{UserPosition{}}
+// a dependent type with no simvar fields of its own is computed: only its
+// dependencies are registered and requested
+type Dependent interface {
+	DependsOn() []any
+}
+
+// ErrDependencyCycle is returned when report types depend on each other
+const ErrDependencyCycle ConnectorError = "dependency cycle"
+
+// Dependencies returns the report and everything it depends on, with each
+// type after its dependencies and listed once
+func Dependencies(report any) ([]any, error) {
+	var out []any
+	done := map[reflect.Type]bool{}
+	visiting := map[reflect.Type]bool{}
+	var visit func(r any) error
+	visit = func(r any) error {
+		t := reportType(r)
+		if done[t] {
+			return nil
+		}
+		if visiting[t] {
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, t)
+		}
+		visiting[t] = true
+		if d, ok := reflect.New(t).Interface().(Dependent); ok {
+			for _, dep := range d.DependsOn() {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		visiting[t] = false
+		done[t] = true
+		out = append(out, reflect.New(t).Interface())
+		return nil
+	}
+	if err := visit(report); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RegisterWithDependencies registers the report's dependencies, then the report
+func RegisterWithDependencies(sc *client.SimConnect, report any) error {
+	reports, err := Dependencies(report)
+	if err != nil {
+		return err
+	}
+	for _, r := range reports {
+		if !hasSimvars(r) {
+			continue
+		}
+		if err := sc.RegisterDataDefinition(r); err != nil {
+			return fmt.Errorf("cannot register %T: %w", r, err)
+		}
+	}
+	return nil
+}
+
+// reportType is the struct type of a report value or pointer
+func reportType(r any) reflect.Type {
+	t := reflect.TypeOf(r)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// hasSimvars reports whether the report has any simvar fields to request
+func hasSimvars(r any) bool {
+	t := reportType(r)
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("name") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// requestReport requests a report for the user aircraft
+// it registers the report if needed, which also brings back a definition
+// the quota watchdog reclaimed
+func requestReport(s *client.SimConnect, report any) error {
+	if err := s.RegisterDataDefinition(report); err != nil {
+		return err
+	}
+	defineId := s.GetDefineID(report)
+	return s.RequestDataOnSimObjectType(defineId, defineId, 0, client.SIMOBJECT_TYPE_USER)
+}
+
+// Subscribe requests T, and everything it depends on, every interval until
+// ctx is done; the replies reach Update as usual
+// receivers subscribing to the same type share one request, made at the
+// shortest interval any of them asked for
+// outside a Connector each call polls on its own
+func Subscribe[T any](ctx context.Context, sc *client.SimConnect, interval time.Duration) error {
+	var report *T
+	if err := RegisterWithDependencies(sc, report); err != nil {
+		return err
+	}
+	reports, _ := Dependencies(report)
+	subs, _ := ctx.Value(subscriptionsKey{}).(*subscriptions)
+	for _, r := range reports {
+		if !hasSimvars(r) {
+			continue
+		}
+		if subs == nil {
+			r := r
+			Go(ctx, func(ctx context.Context) {
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(interval):
+						if err := requestReport(sc, r); err != nil {
+							slog.Error("Cannot request report", "report", fmt.Sprintf("%T", r), "error", err)
+						}
+					}
+				}
+			})
+			continue
+		}
+		subs.add(ctx, r, interval)
+	}
+	return nil
+}
+
+type subscriptionsKey struct{}
+
+// subscriptions shares periodic requests between the receivers of a connection
+type subscriptions struct {
+	sc *client.SimConnect
+	g  *Group
+
+	mu     sync.Mutex
+	shared map[reflect.Type]*shared
+}
+
+type shared struct {
+	report    any
+	intervals map[*int]time.Duration
+	wake      chan struct{}
+}
+
+func newSubscriptions(ctx context.Context, sc *client.SimConnect) *subscriptions {
+	g, _ := newGroup(ctx)
+	return &subscriptions{sc: sc, g: g, shared: map[reflect.Type]*shared{}}
+}
+
+// interval is the shortest interval asked for, or zero once there are no
+// subscribers; s.mu must be held
+func (sh *shared) interval() time.Duration {
+	var min time.Duration
+	for _, d := range sh.intervals {
+		if min == 0 || d < min {
+			min = d
+		}
+	}
+	return min
+}
+
+func (sh *shared) poke() {
+	select {
+	case sh.wake <- struct{}{}:
+	default:
+	}
+}
+
+// add subscribes to the report until ctx is done
+func (s *subscriptions) add(ctx context.Context, report any, interval time.Duration) {
+	t := reportType(report)
+	key := new(int)
+	s.mu.Lock()
+	sh, ok := s.shared[t]
+	if !ok {
+		sh = &shared{report: report, intervals: map[*int]time.Duration{}, wake: make(chan struct{}, 1)}
+		s.shared[t] = sh
+		s.g.Go(func(gctx context.Context) { s.poll(gctx, t, sh) })
+	}
+	sh.intervals[key] = interval
+	sh.poke()
+	s.mu.Unlock()
+
+	Go(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(sh.intervals, key)
+		if len(sh.intervals) == 0 {
+			delete(s.shared, t)
+		}
+		sh.poke()
+		s.mu.Unlock()
+	})
+}
+
+func (s *subscriptions) poll(ctx context.Context, t reflect.Type, sh *shared) {
+	for {
+		s.mu.Lock()
+		d := sh.interval()
+		s.mu.Unlock()
+		if d == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-sh.wake:
+			// the interval changed
+		case <-time.After(d):
+			if err := requestReport(s.sc, sh.report); err != nil {
+				slog.Error("Cannot request report", "report", t.String(), "error", err)
+			}
+		}
+	}
+}
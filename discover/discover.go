@@ -0,0 +1,213 @@
+// Package discover finds SimConnect servers for remote connections
+//
+// A sim accepts network clients when its SimConnect.xml has a global
+// scope IPv4 or IPv6 entry. Servers are found by reading that file on the
+// sim's host, or by probing hosts for listening ports; the endpoints found
+// are written as SimConnect.cfg entries next to the client, and selected
+// with client.WithConfigIndex.
+package discover
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoint is a SimConnect server address
+type Endpoint struct {
+	Protocol string // IPv4 or IPv6
+	Address  string
+	Port     int
+	// Source is where the endpoint was found, eg a file path or "probe"
+	Source string
+}
+
+func (e Endpoint) String() string {
+	return net.JoinHostPort(e.Address, strconv.Itoa(e.Port))
+}
+
+// CommonPorts are ports SimConnect.xml examples and installers commonly use
+// there is no fixed SimConnect port, so a probe only finds servers set up
+// on one of the ports it is given
+var CommonPorts = []int{500, 501, 502, 4500, 4504, 4506}
+
+type serverXML struct {
+	Comms []struct {
+		Protocol string `xml:"Protocol"`
+		Scope    string `xml:"Scope"`
+		Address  string `xml:"Address"`
+		Port     string `xml:"Port"`
+		Disabled string `xml:"Disabled"`
+	} `xml:"SimConnect.Comm"`
+}
+
+// ParseServerXML reads the network endpoints from a SimConnect.xml
+// pipe entries, local scope entries and disabled entries are skipped; a
+// wildcard address is returned as-is, to be replaced with the host's address
+func ParseServerXML(r io.Reader, source string) ([]Endpoint, error) {
+	var doc serverXML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", source, err)
+	}
+	var out []Endpoint
+	for _, c := range doc.Comms {
+		proto := strings.TrimSpace(c.Protocol)
+		if proto != "IPv4" && proto != "IPv6" {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(c.Disabled), "true") {
+			continue
+		}
+		if scope := strings.TrimSpace(c.Scope); scope != "" && !strings.EqualFold(scope, "global") {
+			continue
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(c.Port))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid port %q", source, c.Port)
+		}
+		out = append(out, Endpoint{
+			Protocol: proto,
+			Address:  strings.TrimSpace(c.Address),
+			Port:     port,
+			Source:   source,
+		})
+	}
+	return out, nil
+}
+
+// ServerXMLPaths returns where the installed sims keep SimConnect.xml on
+// this host
+func ServerXMLPaths() []string {
+	appData, localAppData := os.Getenv("APPDATA"), os.Getenv("LOCALAPPDATA")
+	var paths []string
+	if appData != "" {
+		paths = append(paths,
+			filepath.Join(appData, "Microsoft Flight Simulator", "SimConnect.xml"),
+			filepath.Join(appData, "Microsoft Flight Simulator 2024", "SimConnect.xml"),
+			filepath.Join(appData, "Microsoft", "FSX", "SimConnect.xml"),
+			filepath.Join(appData, "Lockheed Martin", "Prepar3D v5", "SimConnect.xml"),
+		)
+	}
+	if localAppData != "" {
+		paths = append(paths,
+			filepath.Join(localAppData, "Packages", "Microsoft.FlightSimulator_8wekyb3d8bbwe", "LocalCache", "SimConnect.xml"),
+			filepath.Join(localAppData, "Packages", "Microsoft.Limitless_8wekyb3d8bbwe", "LocalCache", "SimConnect.xml"),
+		)
+	}
+	return paths
+}
+
+// Local returns the endpoints of the sims installed on this host
+// run it on the sim's host, eg to print the entries for a client's
+// SimConnect.cfg; files that don't exist are skipped
+func Local() ([]Endpoint, error) {
+	var out []Endpoint
+	for _, p := range ServerXMLPaths() {
+		f, err := os.Open(p)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return out, err
+		}
+		eps, err := ParseServerXML(f, p)
+		f.Close()
+		if err != nil {
+			return out, err
+		}
+		out = append(out, eps...)
+	}
+	return out, nil
+}
+
+// Probe tries each host on each port and returns the ones that accept a
+// connection; an open port is a candidate, not proof of a SimConnect server
+func Probe(ctx context.Context, hosts []string, ports []int, timeout time.Duration) []Endpoint {
+	const workers = 64
+	type target struct {
+		host string
+		port int
+	}
+	targets := make(chan target)
+	var mu sync.Mutex
+	var out []Endpoint
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d := net.Dialer{Timeout: timeout}
+			for t := range targets {
+				addr := net.JoinHostPort(t.host, strconv.Itoa(t.port))
+				conn, err := d.DialContext(ctx, "tcp", addr)
+				if err != nil {
+					continue
+				}
+				conn.Close()
+				proto := "IPv4"
+				if ip, err := netip.ParseAddr(t.host); err == nil && ip.Is6() {
+					proto = "IPv6"
+				}
+				mu.Lock()
+				out = append(out, Endpoint{Protocol: proto, Address: t.host, Port: t.port, Source: "probe"})
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, h := range hosts {
+		for _, p := range ports {
+			select {
+			case <-ctx.Done():
+				break feed
+			case targets <- target{h, p}:
+			}
+		}
+	}
+	close(targets)
+	wg.Wait()
+	return out
+}
+
+// Scan probes every address in an IPv4 prefix, eg "192.168.1.0/24"
+// prefixes larger than a /16 are refused
+func Scan(ctx context.Context, prefix string, ports []int, timeout time.Duration) ([]Endpoint, error) {
+	p, err := netip.ParsePrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Addr().Is4() || p.Bits() < 16 {
+		return nil, fmt.Errorf("scan %s: only IPv4 prefixes of /16 or smaller are supported", prefix)
+	}
+	p = p.Masked()
+	var hosts []string
+	for a := p.Addr(); p.Contains(a); a = a.Next() {
+		hosts = append(hosts, a.String())
+	}
+	// skip the network and broadcast addresses
+	if len(hosts) > 2 {
+		hosts = hosts[1 : len(hosts)-1]
+	}
+	return Probe(ctx, hosts, ports, timeout), nil
+}
+
+// WriteConfig writes the endpoints as SimConnect.cfg entries, numbered from
+// first; connect to one with client.WithConfigIndex
+func WriteConfig(w io.Writer, first int, endpoints []Endpoint) error {
+	for i, e := range endpoints {
+		_, err := fmt.Fprintf(w, "[SimConnect.%d]\nProtocol=%s\nAddress=%s\nPort=%d\nMaxReceiveSize=41088\nDisableNagle=0\n\n",
+			first+i, e.Protocol, e.Address, e.Port)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
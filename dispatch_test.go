@@ -0,0 +1,296 @@
+package simconnect
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/client/clienttest"
+)
+
+// routed records which handler a message reached
+type routed struct {
+	data   []*client.RecvSimobjectDataByType
+	events []*client.RecvEvent
+	seen   []client.DWORD
+}
+
+func (r *routed) handlers() dispatchHandlers {
+	return dispatchHandlers{
+		data: func(x *client.RecvSimobjectDataByType) error {
+			r.data = append(r.data, x)
+			return nil
+		},
+		event: func(x *client.RecvEvent) error {
+			r.events = append(r.events, x)
+			return nil
+		},
+		seen: func(x *client.Recv) {
+			r.seen = append(r.seen, x.ID)
+		},
+	}
+}
+
+func connectFake(t *testing.T) (*clienttest.DLL, *client.SimConnect) {
+	t.Helper()
+	dll := clienttest.New()
+	sc, err := dll.Connect()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return dll, sc
+}
+
+func TestDispatchRoutesByID(t *testing.T) {
+	event := func(id client.DWORD) []byte {
+		return clienttest.Message(&client.RecvEvent{Recv: client.Recv{ID: id}, EventID: 7, Data: 3})
+	}
+	data := func(id client.DWORD) []byte {
+		x := client.RecvSimobjectDataByType{}
+		x.ID, x.RequestID, x.DefineID = id, 1, 2
+		return clienttest.Message(&x, make([]byte, 8)...)
+	}
+	tests := []struct {
+		name   string
+		msg    []byte
+		data   int
+		events int
+		err    bool
+	}{
+		{"event", event(client.RECV_ID_EVENT), 0, 1, false},
+		{"event filename", event(client.RECV_ID_EVENT_FILENAME), 0, 1, false},
+		{"data by type", data(client.RECV_ID_SIMOBJECT_DATA_BYTYPE), 1, 0, false},
+		{"periodic data", data(client.RECV_ID_SIMOBJECT_DATA), 1, 0, false},
+		{"open", clienttest.Message(&client.RecvOpen{Recv: client.Recv{ID: client.RECV_ID_OPEN}}), 0, 0, false},
+		{"exception", clienttest.Message(&client.RecvException{Recv: client.Recv{ID: client.RECV_ID_EXCEPTION}, Exception: 7, Index: client.UNKNOWN_INDEX}), 0, 0, true},
+		{"unhandled client data", clienttest.Message(&client.RecvClientData{RecvSimobjectData: client.RecvSimobjectData{Recv: client.Recv{ID: client.RECV_ID_CLIENT_DATA}}}), 0, 0, false},
+		{"unknown", clienttest.Message(&client.Recv{ID: 999}), 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dll, sc := connectFake(t)
+			dll.Queue(tt.msg)
+			var r routed
+			err := dispatchFn(context.Background(), sc, nil, r.handlers())
+			if (err != nil) != tt.err {
+				t.Fatalf("error = %v, want error %v", err, tt.err)
+			}
+			if len(r.data) != tt.data || len(r.events) != tt.events {
+				t.Fatalf("routed to %d data and %d event handlers, want %d and %d", len(r.data), len(r.events), tt.data, tt.events)
+			}
+			if len(r.seen) != 1 {
+				t.Fatalf("seen %d messages, want 1", len(r.seen))
+			}
+		})
+	}
+}
+
+func TestDispatchDecodes(t *testing.T) {
+	dll, sc := connectFake(t)
+
+	ev := clienttest.Message(&client.RecvEvent{Recv: client.Recv{ID: client.RECV_ID_EVENT}, GroupID: 1, EventID: 7, Data: 3})
+	by := client.RecvSimobjectDataByType{}
+	by.ID, by.RequestID, by.ObjectID, by.DefineID = client.RECV_ID_SIMOBJECT_DATA_BYTYPE, 4, 5, 6
+	by.EntryNumber, by.OutOf = 2, 3
+	dll.Queue(ev, clienttest.Message(&by, binary.LittleEndian.AppendUint64(nil, math.Float64bits(1.5))...))
+
+	var r routed
+	for i := 0; i < 2; i++ {
+		if err := dispatchFn(context.Background(), sc, nil, r.handlers()); err != nil {
+			t.Fatalf("dispatch %d: %v", i, err)
+		}
+	}
+	if len(r.events) != 1 || r.events[0].GroupID != 1 || r.events[0].EventID != 7 || r.events[0].Data != 3 {
+		t.Fatalf("event decoded as %+v", r.events)
+	}
+	if len(r.data) != 1 {
+		t.Fatalf("%d data messages, want 1", len(r.data))
+	}
+	d := r.data[0]
+	if d.RequestID != 4 || d.ObjectID != 5 || d.DefineID != 6 || d.EntryNumber != 2 || d.OutOf != 3 {
+		t.Fatalf("data header decoded as %+v", d.RecvSimobjectData)
+	}
+	if v := *(*float64)(d.DataPointer()); v != 1.5 {
+		t.Fatalf("data decoded as %v, want 1.5", v)
+	}
+}
+
+func TestDispatchException(t *testing.T) {
+	dll, sc := connectFake(t)
+	dll.Queue(clienttest.Message(&client.RecvException{Recv: client.Recv{ID: client.RECV_ID_EXCEPTION}, Exception: 7, SendID: 9, Index: 2}))
+	var r routed
+	err := dispatchFn(context.Background(), sc, nil, r.handlers())
+	var ex client.RecvException
+	if !errors.As(err, &ex) {
+		t.Fatalf("error %v does not carry the exception", err)
+	}
+	if ex.Exception != 7 || ex.SendID != 9 || ex.Index != 2 {
+		t.Fatalf("exception decoded as %+v", ex)
+	}
+}
+
+func TestDispatchEmpty(t *testing.T) {
+	_, sc := connectFake(t)
+	var r routed
+	if err := dispatchFn(context.Background(), sc, nil, r.handlers()); err == nil {
+		t.Fatalf("empty queue: no error")
+	}
+	if len(r.seen) != 0 {
+		t.Fatalf("empty queue: seen %d messages", len(r.seen))
+	}
+}
+
+// replies to the client's own requests are decoded for them and never
+// reach the receivers
+func TestDispatchClientReplies(t *testing.T) {
+	t.Run("one-shot read", func(t *testing.T) {
+		dll, sc := connectFake(t)
+		queued := make(chan struct{})
+		dll.Handle("SimConnect_RequestDataOnSimObjectType", func(args []uintptr) uintptr {
+			x := client.RecvSimobjectData{Recv: client.Recv{ID: client.RECV_ID_SIMOBJECT_DATA_BYTYPE}}
+			x.RequestID, x.DefineID = client.DWORD(args[1]), client.DWORD(args[2])
+			dll.Queue(clienttest.Message(&x, binary.LittleEndian.AppendUint64(nil, math.Float64bits(2500))...))
+			close(queued)
+			return 0
+		})
+		type result struct {
+			v   float64
+			err error
+		}
+		done := make(chan result, 1)
+		go func() {
+			v, err := sc.ReadFloat(context.Background(), "PLANE ALTITUDE", "Feet")
+			done <- result{v, err}
+		}()
+		<-queued
+		var r routed
+		if err := dispatchFn(context.Background(), sc, nil, r.handlers()); err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+		if len(r.data) != 0 {
+			t.Fatalf("reply reached the data handler")
+		}
+		if res := <-done; res.err != nil || res.v != 2500 {
+			t.Fatalf("read %v, %v; want 2500", res.v, res.err)
+		}
+	})
+
+	t.Run("facility list", func(t *testing.T) {
+		dll, sc := connectFake(t)
+		var got []client.FacilityList
+		sc.HandleFacilityLists(11, func(l client.FacilityList) { got = append(got, l) })
+		entry := append([]byte("KSEA\x00\x00\x00\x00\x00"), binary.LittleEndian.AppendUint64(nil, math.Float64bits(47.45))...)
+		entry = binary.LittleEndian.AppendUint64(entry, math.Float64bits(-122.31))
+		entry = binary.LittleEndian.AppendUint64(entry, math.Float64bits(131))
+		dll.Queue(clienttest.Message(&client.RecvFacilityList{
+			Recv: client.Recv{ID: client.RECV_ID_AIRPORT_LIST}, RequestID: 11, ArraySize: 1, OutOf: 1,
+		}, entry...))
+		var r routed
+		if err := dispatchFn(context.Background(), sc, nil, r.handlers()); err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+		if len(got) != 1 || len(got[0].Items) != 1 {
+			t.Fatalf("facility lists %+v", got)
+		}
+		it := got[0].Items[0]
+		if it.Type != client.FACILITY_LIST_TYPE_AIRPORT || it.ICAO != "KSEA" || it.Latitude != 47.45 || it.Longitude != -122.31 || it.Altitude != 131 {
+			t.Fatalf("airport decoded as %+v", it)
+		}
+	})
+
+	t.Run("client data", func(t *testing.T) {
+		dll, sc := connectFake(t)
+		var got []byte
+		sc.HandleClientData(12, func(x *client.RecvClientData, data []byte) { got = append(got, data...) })
+		x := client.RecvClientData{}
+		x.ID, x.RequestID = client.RECV_ID_CLIENT_DATA, 12
+		dll.Queue(clienttest.Message(&x, 1, 2, 3, 4))
+		var r routed
+		if err := dispatchFn(context.Background(), sc, nil, r.handlers()); err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+		if string(got) != "\x01\x02\x03\x04" || len(r.data) != 0 {
+			t.Fatalf("client data %v, %d data messages", got, len(r.data))
+		}
+	})
+
+	t.Run("custom action", func(t *testing.T) {
+		dll, sc := connectFake(t)
+		var got []client.CustomAction
+		sc.HandleCustomActions(func(a client.CustomAction) { got = append(got, a) })
+		x := client.RecvCustomAction{WaitForCompletion: 1}
+		x.ID, x.EventID = client.RECV_ID_CUSTOM_ACTION, 21
+		// the payload starts at PayLoad, replacing its placeholder byte
+		msg := append(clienttest.Message(&x)[:unsafe.Offsetof(x.PayLoad)], "go\x00"...)
+		binary.LittleEndian.PutUint32(msg, uint32(len(msg)))
+		dll.Queue(msg)
+		var r routed
+		if err := dispatchFn(context.Background(), sc, nil, r.handlers()); err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+		if len(got) != 1 || got[0].EventID != 21 || !got[0].Wait || got[0].PayLoad != "go" || len(r.events) != 0 {
+			t.Fatalf("custom actions %+v, %d events", got, len(r.events))
+		}
+	})
+}
+
+// a subscription whose replies reach the receivers is in use, without the
+// receiver marking it
+func TestDispatchKeepsSubscriptionsInUse(t *testing.T) {
+	dll, sc := connectFake(t)
+	live, idle := sc.GetDefineIDByName("live"), sc.GetDefineIDByName("idle")
+	for _, id := range []client.DWORD{live, idle} {
+		if err := sc.RequestDataOnSimObject(id, id, client.OBJECT_ID_USER, client.PERIOD_SECOND, client.DATA_REQUEST_FLAG_DEFAULT, 0, 0, 0); err != nil {
+			t.Fatalf("request: %v", err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	x := client.RecvSimobjectDataByType{}
+	x.ID, x.RequestID, x.DefineID = client.RECV_ID_SIMOBJECT_DATA, live, live
+	dll.Queue(clienttest.Message(&x, make([]byte, 8)...))
+	var r routed
+	if err := dispatchFn(context.Background(), sc, nil, r.handlers()); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	out, err := sc.Reclaim(10 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(out.Subscriptions) != 1 || out.Subscriptions[0].DefineID != idle {
+		t.Fatalf("reclaimed %+v, want only the idle subscription", out.Subscriptions)
+	}
+}
+
+type subscribedReport struct {
+	client.RecvSimobjectDataByType
+	Altitude float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+}
+
+func TestRegisterSubscriptions(t *testing.T) {
+	c := NewConnector("test", WithSubscription[subscribedReport](client.OBJECT_ID_USER, client.PERIOD_SECOND, client.DATA_REQUEST_FLAG_CHANGED, 2))
+	// each connection gets the request again
+	for range 2 {
+		dll, sc := connectFake(t)
+		if err := c.register(sc); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+		id := sc.GetDefineID(&subscribedReport{})
+		calls := dll.Calls("SimConnect_RequestDataOnSimObject")
+		if len(calls) != 1 {
+			t.Fatalf("%d requests, want 1", len(calls))
+		}
+		got := calls[0].Args[1:]
+		want := []uintptr{uintptr(id), uintptr(id), uintptr(client.OBJECT_ID_USER), uintptr(client.PERIOD_SECOND), uintptr(client.DATA_REQUEST_FLAG_CHANGED), 0, 2, 0}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("request %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// airports lists the airports nearest the user with their runways and
+// frequencies
+//
+// The airport list comes from the facilities cache, which reassembles the
+// list from however many messages the sim splits it over and merges the
+// airports the sim adds as the aircraft moves; the details of each airport
+// are read with the facility data API. With -watch it keeps running and
+// prints the list again whenever it changes, which makes it a manual test
+// for list pagination and delta updates.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/facilities"
+	"github.com/bmurray/simconnect-go/geo"
+)
+
+var programLevel = new(slog.LevelVar)
+
+func main() {
+	n := flag.Int("n", 5, "The number of airports to list")
+	watch := flag.Bool("watch", false, "Keep running and list the airports again when the list changes")
+	debug := flag.Bool("debug", false, "debug")
+	flag.Parse()
+
+	h := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: programLevel})
+	slog.SetDefault(slog.New(h))
+
+	if *debug {
+		programLevel.Set(slog.LevelDebug)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	defer cancel()
+
+	b := &browser{n: *n, watch: *watch, stop: cancel, changed: make(chan int, 1)}
+	b.cache = facilities.New(facilities.WithOnUpdate(b.updated))
+	con := simconnect.NewConnector("airports",
+		simconnect.WithReceiver(b.cache),
+		simconnect.WithReceiver(b),
+	)
+	con.StartReconnect(ctx)
+}
+
+// Airport is the facility data read for each airport
+type Airport struct {
+	Name        [32]byte    `facility:"NAME"`
+	Latitude    float64     `facility:"LATITUDE"`
+	Longitude   float64     `facility:"LONGITUDE"`
+	Altitude    float64     `facility:"ALTITUDE"`
+	Runways     []Runway    `facility:"RUNWAY"`
+	Frequencies []Frequency `facility:"FREQUENCY"`
+}
+
+// Runway is a runway of an Airport
+type Runway struct {
+	PrimaryNumber       int32   `facility:"PRIMARY_NUMBER"`
+	PrimaryDesignator   int32   `facility:"PRIMARY_DESIGNATOR"`
+	SecondaryNumber     int32   `facility:"SECONDARY_NUMBER"`
+	SecondaryDesignator int32   `facility:"SECONDARY_DESIGNATOR"`
+	Heading             float32 `facility:"HEADING"`
+	Length              float32 `facility:"LENGTH"`
+	Width               float32 `facility:"WIDTH"`
+}
+
+// Frequency is a com frequency of an Airport
+type Frequency struct {
+	Type      int32    `facility:"TYPE"`
+	Frequency int32    `facility:"FREQUENCY"`
+	Name      [64]byte `facility:"NAME"`
+}
+
+var frequencyTypes = []string{"", "ATIS", "MULTICOM", "UNICOM", "CTAF", "GROUND", "TOWER", "CLEARANCE",
+	"APPROACH", "DEPARTURE", "CENTER", "FSS", "AWOS", "ASOS", "CPT", "GCO"}
+
+var designators = []string{"", "L", "R", "C", "W", "A", "B"}
+
+// runwayName names a runway end, eg 09L; numbers past 36 are compass points
+func runwayName(number, designator int32) string {
+	name := fmt.Sprintf("%02d", number)
+	if number > 36 {
+		points := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+		if i := int(number - 37); i < len(points) {
+			name = points[i]
+		}
+	}
+	if designator > 0 && int(designator) < len(designators) {
+		name += designators[designator]
+	}
+	return name
+}
+
+type browser struct {
+	n       int
+	watch   bool
+	stop    context.CancelFunc
+	cache   *facilities.Cache
+	changed chan int
+}
+
+// updated is called by the cache on the dispatch goroutine, so it only
+// signals the lister
+func (b *browser) updated(typ client.DWORD, items []facilities.Item) {
+	if typ != client.FACILITY_LIST_TYPE_AIRPORT {
+		return
+	}
+	select {
+	case b.changed <- len(items):
+	default:
+	}
+}
+
+func (b *browser) Start(ctx context.Context, sc *client.SimConnect) {
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case count := <-b.changed:
+				slog.Debug("Airport list changed", "airports", count)
+			}
+			if err := b.list(ctx, sc); err != nil {
+				slog.Error("Cannot list airports", "error", err)
+			}
+			if !b.watch {
+				b.stop()
+				return
+			}
+		}
+	})
+}
+
+func (b *browser) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+func (b *browser) list(ctx context.Context, sc *client.SimConnect) error {
+	var user geo.Position
+	var err error
+	if user.Latitude, err = sc.ReadFloat(ctx, "PLANE LATITUDE", "Degrees"); err != nil {
+		return err
+	}
+	if user.Longitude, err = sc.ReadFloat(ctx, "PLANE LONGITUDE", "Degrees"); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%d airports in the sim's cache\n", time.Now().Format(time.TimeOnly), len(b.cache.Get(client.FACILITY_LIST_TYPE_AIRPORT)))
+	for _, it := range b.cache.Nearest(client.FACILITY_LIST_TYPE_AIRPORT, user, b.n) {
+		rctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		a, err := simconnect.ReadFacility[Airport](rctx, sc, "AIRPORT", it.ICAO, "")
+		cancel()
+		if err != nil {
+			slog.Error("Cannot read airport", "icao", it.ICAO, "error", err)
+			continue
+		}
+		dist := geo.Distance(user, facilities.Position(it))
+		fmt.Fprintf(w, "\n%s\t%s\t%.1f nm\t%.0f ft\n", it.ICAO, client.BytesToString(a.Name[:]), dist, a.Altitude*3.28084)
+		for _, r := range a.Runways {
+			fmt.Fprintf(w, "\trunway %s/%s\t%.0f x %.0f ft\t%03.0f°\n",
+				runwayName(r.PrimaryNumber, r.PrimaryDesignator),
+				runwayName(r.SecondaryNumber, r.SecondaryDesignator),
+				r.Length*3.28084, r.Width*3.28084, r.Heading)
+		}
+		for _, f := range a.Frequencies {
+			typ := ""
+			if int(f.Type) < len(frequencyTypes) {
+				typ = frequencyTypes[f.Type]
+			}
+			fmt.Fprintf(w, "\t%s\t%.3f\t%s\n", typ, float64(f.Frequency)/1e6, strings.TrimSpace(client.BytesToString(f.Name[:])))
+		}
+	}
+	return w.Flush()
+}
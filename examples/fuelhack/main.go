@@ -81,6 +81,7 @@ func (r *refuel) Start(ctx context.Context, sc *client.SimConnect) {
 	// The most convenient way to do this is to register them in the Start method
 	// as the start method is called after the connection is established
 	// and whenever a reconnection happens
+	// Alternatively, pass them to the connector with simconnect.WithDefinition
 	if err := sc.RegisterDataDefinition(&FuelReport{}); err != nil {
 		slog.Error("Cannot register report", "error", err)
 		return
@@ -91,8 +92,9 @@ func (r *refuel) Start(ctx context.Context, sc *client.SimConnect) {
 	}
 
 	// Start a goroutine to request fuel levels every 5 seconds
+	// simconnect.Go lets the connector wait for it before closing the connection
 	// This ensures we always have the latest fuel levels
-	go func(ctx context.Context) {
+	simconnect.Go(ctx, func(ctx context.Context) {
 		for {
 			select {
 			case <-ctx.Done():
@@ -107,7 +109,7 @@ func (r *refuel) Start(ctx context.Context, sc *client.SimConnect) {
 
 			}
 		}
-	}(ctx)
+	})
 }
 
 // Update is called whenever a new data packet is received
@@ -119,7 +121,8 @@ func (r *refuel) Update(ctx context.Context, sc *client.SimConnect, ppData *clie
 		// Print the data; this is just for us, and not required
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
-		if err := enc.Encode(fr); err != nil {
+		// simconnect.JSON keys the fields by simvar, eg fuel_tank_left_main_quantity
+		if err := enc.Encode(simconnect.JSON(fr)); err != nil {
 			slog.Error("Error encoding report", "error", err)
 		}
 
@@ -0,0 +1,312 @@
+// instructor is an instructor station server: it composes pause, weather
+// presets, failures, repositioning and fuel into commands on the gateway
+//
+// A station connects to ws://addr/socket and gets the aircraft state as
+// it changes while sending commands over the same connection, eg
+// {"type": "command", "id": "1", "name": "pause", "args": {"paused": true}}.
+// Simpler clients can use the event stream at /events and POST
+// /commands/{name}.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/failures"
+	"github.com/bmurray/simconnect-go/gateway"
+)
+
+var programLevel = new(slog.LevelVar)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "The address to serve the instructor station on")
+	scenarioPath := flag.String("scenario", "", "An optional failure scenario to run")
+	presetsPath := flag.String("presets", "", "An optional JSON file mapping weather preset names to lists of events")
+	configPath := flag.String("config", "", "An optional gateway config file, reloaded on change or SIGHUP")
+	debug := flag.Bool("debug", false, "debug")
+	flag.Parse()
+
+	h := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: programLevel})
+	slog.SetDefault(slog.New(h))
+
+	if *debug {
+		programLevel.Set(slog.LevelDebug)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	defer cancel()
+
+	scenario := &failures.Scenario{}
+	if *scenarioPath != "" {
+		f, err := os.Open(*scenarioPath)
+		if err != nil {
+			slog.Error("Cannot open scenario", "error", err)
+			return
+		}
+		scenario, err = failures.ParseScenario(f)
+		f.Close()
+		if err != nil {
+			slog.Error("Cannot parse scenario", "error", err)
+			return
+		}
+	}
+
+	if *presetsPath != "" {
+		data, err := os.ReadFile(*presetsPath)
+		if err != nil {
+			slog.Error("Cannot read weather presets", "error", err)
+			return
+		}
+		if err := json.Unmarshal(data, &weatherPresets); err != nil {
+			slog.Error("Cannot parse weather presets", "error", err)
+			return
+		}
+	}
+
+	var gwOpts []gateway.Option
+	if *configPath != "" {
+		cfg, err := gateway.LoadConfig(*configPath)
+		if err != nil {
+			slog.Error("Cannot load gateway config", "error", err)
+			return
+		}
+		gwOpts = append(gwOpts, gateway.WithConfig(cfg))
+	}
+	gw := gateway.New(gwOpts...)
+	if *configPath != "" {
+		go gw.WatchConfig(ctx, *configPath, 2*time.Second)
+	}
+	sched := failures.NewScheduler(scenario, failures.WithOnFailure(func(f failures.Failure, st failures.FlightState) {
+		gw.Publish("failure", map[string]any{"failure": f.Name, "flight_time": st.FlightTime.String()})
+	}))
+	inst := &instructor{gw: gw, failures: sched}
+	inst.register()
+
+	go func() {
+		if err := gw.ListenAndServe(ctx, *addr); err != nil {
+			slog.Error("Gateway stopped", "error", err)
+			cancel()
+		}
+	}()
+
+	con := simconnect.NewConnector("instructor",
+		simconnect.WithReceiver(sched),
+		simconnect.WithReceiver(inst),
+	)
+	con.StartReconnect(ctx)
+}
+
+// AircraftReport is the data structure published to the instructor station
+type AircraftReport struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude  float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	Heading   float64 `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees"`
+	Airspeed  float64 `name:"AIRSPEED INDICATED" unit:"Knots"`
+	FuelLeft  float64 `name:"FUEL TANK LEFT MAIN QUANTITY" unit:"Gallons"`
+	FuelRight float64 `name:"FUEL TANK RIGHT MAIN QUANTITY" unit:"Gallons"`
+	Paused    float64 `name:"SIM DISABLED" unit:"Bool"`
+}
+
+// RepositionRequest is the data structure to move the aircraft
+type RepositionRequest struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees" json:"latitude"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees" json:"longitude"`
+	Altitude  float64 `name:"PLANE ALTITUDE" unit:"Feet" json:"altitude"`
+	Heading   float64 `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees" json:"heading"`
+}
+
+// InstructorFuelRequest is the data structure to set fuel in the main tanks
+type InstructorFuelRequest struct {
+	client.RecvSimobjectDataByType
+	Left  float64 `name:"FUEL TANK LEFT MAIN QUANTITY" unit:"Gallons" json:"left"`
+	Right float64 `name:"FUEL TANK RIGHT MAIN QUANTITY" unit:"Gallons" json:"right"`
+}
+
+// weatherPresets map a preset name to the events fired to apply it
+// the available weather events depend on the sim and addons, so they are loaded with -presets
+var weatherPresets = map[string][]string{}
+
+type instructor struct {
+	gw       *gateway.Server
+	failures *failures.Scheduler
+
+	mu     sync.Mutex
+	sc     *client.SimConnect
+	events map[string]client.DWORD
+}
+
+// Start is called when the instructor receiver is started
+// it gets called after the connection is established
+// and whenever a reconnection happens
+func (i *instructor) Start(ctx context.Context, sc *client.SimConnect) {
+	for _, def := range []any{&AircraftReport{}, &RepositionRequest{}, &InstructorFuelRequest{}} {
+		if err := sc.RegisterDataDefinition(def); err != nil {
+			slog.Error("Cannot register definition", "error", err)
+			return
+		}
+	}
+	names := []string{"PAUSE_ON", "PAUSE_OFF"}
+	for _, evs := range weatherPresets {
+		names = append(names, evs...)
+	}
+	events := map[string]client.DWORD{}
+	for _, name := range names {
+		id := sc.GetEventID()
+		if err := sc.MapClientEventToSimEvent(id, name); err != nil {
+			slog.Error("Cannot map event", "event", name, "error", err)
+			continue
+		}
+		events[name] = id
+	}
+
+	i.mu.Lock()
+	i.sc = sc
+	i.events = events
+	i.mu.Unlock()
+
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				i.mu.Lock()
+				i.sc = nil
+				i.mu.Unlock()
+				return
+			case <-time.After(time.Second):
+				if err := simconnect.RequestData[AircraftReport](sc); err != nil {
+					slog.Error("Cannot request aircraft", "error", err)
+				}
+			}
+		}
+	})
+}
+
+// Update publishes the aircraft state to the gateway
+func (i *instructor) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	if r, ok := simconnect.IsReport[AircraftReport](sc, ppData); ok {
+		i.gw.Publish("aircraft", map[string]any{
+			"latitude":   r.Latitude,
+			"longitude":  r.Longitude,
+			"altitude":   r.Altitude,
+			"heading":    r.Heading,
+			"airspeed":   r.Airspeed,
+			"fuel_left":  r.FuelLeft,
+			"fuel_right": r.FuelRight,
+			"paused":     r.Paused != 0,
+		})
+	}
+}
+
+func (i *instructor) client() (*client.SimConnect, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.sc == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	return i.sc, nil
+}
+
+func (i *instructor) fire(name string) error {
+	sc, err := i.client()
+	if err != nil {
+		return err
+	}
+	i.mu.Lock()
+	id, ok := i.events[name]
+	i.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("event %s not mapped", name)
+	}
+	return sc.TransmitClientEvent(client.OBJECT_ID_USER, id, 0, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
+
+// register adds the instructor commands to the gateway
+func (i *instructor) register() {
+	i.gw.HandlePermission("pause", gateway.PermEvents, func(ctx context.Context, args json.RawMessage) (any, error) {
+		var req struct {
+			Paused bool `json:"paused"`
+		}
+		if err := json.Unmarshal(args, &req); err != nil {
+			return nil, err
+		}
+		if req.Paused {
+			return nil, i.fire("PAUSE_ON")
+		}
+		return nil, i.fire("PAUSE_OFF")
+	})
+	i.gw.HandlePermission("weather", gateway.PermEvents, func(ctx context.Context, args json.RawMessage) (any, error) {
+		var req struct {
+			Preset string `json:"preset"`
+		}
+		if err := json.Unmarshal(args, &req); err != nil {
+			return nil, err
+		}
+		evs, ok := weatherPresets[req.Preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown weather preset %s", req.Preset)
+		}
+		for _, ev := range evs {
+			if err := i.fire(ev); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	i.gw.HandlePermission("schema", gateway.PermRead, func(ctx context.Context, args json.RawMessage) (any, error) {
+		sc, err := i.client()
+		if err != nil {
+			return nil, err
+		}
+		return sc.Schema(), nil
+	})
+	i.gw.HandlePermission("failures", gateway.PermRead, func(ctx context.Context, args json.RawMessage) (any, error) {
+		return failures.Names(), nil
+	})
+	i.gw.HandlePermission("fail", gateway.PermEvents, func(ctx context.Context, args json.RawMessage) (any, error) {
+		var req struct {
+			Failure string `json:"failure"`
+		}
+		if err := json.Unmarshal(args, &req); err != nil {
+			return nil, err
+		}
+		f, ok := failures.Catalog[req.Failure]
+		if !ok {
+			return nil, fmt.Errorf("unknown failure %s", req.Failure)
+		}
+		return nil, i.failures.Inject(f)
+	})
+	i.gw.Handle("reposition", func(ctx context.Context, args json.RawMessage) (any, error) {
+		req := &RepositionRequest{}
+		if err := json.Unmarshal(args, req); err != nil {
+			return nil, err
+		}
+		sc, err := i.client()
+		if err != nil {
+			return nil, err
+		}
+		return nil, sc.SetData(req)
+	})
+	i.gw.Handle("fuel", func(ctx context.Context, args json.RawMessage) (any, error) {
+		req := &InstructorFuelRequest{}
+		if err := json.Unmarshal(args, req); err != nil {
+			return nil, err
+		}
+		sc, err := i.client()
+		if err != nil {
+			return nil, err
+		}
+		return nil, sc.SetData(req)
+	})
+}
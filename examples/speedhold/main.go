@@ -0,0 +1,217 @@
+// speedhold is an autothrottle demo: it holds indicated airspeed by
+// driving the throttle axis from a PID controller of the control package
+//
+// It subscribes to the airspeed, sends AXIS_THROTTLE_SET events, and is
+// engaged and disengaged by the sim's AUTO_THROTTLE_ARM key event, so it
+// exercises the subscription, event and system event paths together. With
+// -smoke it engages straight away, holds for the duration and exits
+// non-zero if the airspeed did not settle, as a smoke test for control loops.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"math"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/control"
+)
+
+var programLevel = new(slog.LevelVar)
+
+// settleTime is how long after engaging the airspeed is taken as settled
+const settleTime = 30 * time.Second
+
+func main() {
+	target := flag.Float64("target", 120, "The indicated airspeed to hold, in knots")
+	kp := flag.Float64("kp", 0.02, "Proportional gain, throttle fraction per knot")
+	ki := flag.Float64("ki", 0.004, "Integral gain, throttle fraction per knot second")
+	kd := flag.Float64("kd", 0.01, "Derivative gain, throttle fraction per knot per second")
+	rate := flag.Float64("rate", 0.5, "The fastest the throttle may move, in fraction of travel per second")
+	deadband := flag.Float64("deadband", 0, "Airspeed error in knots the controller ignores")
+	interval := flag.Duration("interval", 100*time.Millisecond, "How often the airspeed is sampled")
+	smoke := flag.Duration("smoke", 0, "Engage at once, hold for this long, then report and exit")
+	tolerance := flag.Float64("tolerance", 3, "The mean airspeed error in knots -smoke accepts once settled")
+	debug := flag.Bool("debug", false, "debug")
+	flag.Parse()
+
+	h := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: programLevel})
+	slog.SetDefault(slog.New(h))
+
+	if *debug {
+		programLevel.Set(slog.LevelDebug)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	defer cancel()
+
+	hold := &speedHold{
+		target:   *target,
+		interval: *interval,
+		deadband: *deadband,
+		ctl:      control.NewPID(*kp, *ki, *kd, control.WithLimits(0, 1)),
+		limit:    control.NewRateLimiter(*rate),
+		throttle: client.NewEvent("AXIS_THROTTLE_SET"),
+		arm:      client.NewEvent("AUTO_THROTTLE_ARM"),
+		engaged:  *smoke > 0,
+	}
+	if *smoke > 0 {
+		var stop context.CancelFunc
+		ctx, stop = context.WithTimeout(ctx, *smoke)
+		defer stop()
+	}
+
+	con := simconnect.NewConnector("speedhold",
+		simconnect.WithReceiver(hold),
+		simconnect.WithSystemEvent("Pause"),
+	)
+	con.StartReconnect(ctx)
+
+	if *smoke > 0 {
+		mean, n := hold.settledError()
+		slog.Info("Smoke test done", "samples", n, "mean_error_kts", mean, "tolerance_kts", *tolerance)
+		if n == 0 || mean > *tolerance {
+			slog.Error("Airspeed did not settle")
+			os.Exit(1)
+		}
+	}
+}
+
+// SpeedReport is the data the controller samples
+type SpeedReport struct {
+	client.RecvSimobjectDataByType
+	IAS      float64 `name:"AIRSPEED INDICATED" unit:"Knots"`
+	Throttle float64 `name:"GENERAL ENG THROTTLE LEVER POSITION:1" unit:"Percent"`
+	OnGround float64 `name:"SIM ON GROUND" unit:"Bool"`
+}
+
+type speedHold struct {
+	target   float64
+	interval time.Duration
+	deadband float64
+	throttle *client.Event
+	arm      *client.Event
+
+	mu      sync.Mutex
+	ctl     *control.PID
+	limit   *control.RateLimiter
+	engaged bool
+	paused  bool
+	last    time.Time
+	lever   float64
+	since   time.Time
+	errors  []float64
+}
+
+func (s *speedHold) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := simconnect.Subscribe[SpeedReport](ctx, sc, s.interval); err != nil {
+		slog.Error("Cannot subscribe to airspeed", "error", err)
+		return
+	}
+	// AUTO_THROTTLE_ARM is the trigger; the sim still sees it, so its own
+	// autothrottle may arm as well
+	id, err := s.arm.ID(sc)
+	if err != nil {
+		slog.Error("Cannot map arm event", "error", err)
+		return
+	}
+	group := sc.GetEventID()
+	if err := sc.AddClientEventToNotificationGroup(group, id); err != nil {
+		slog.Error("Cannot listen for arm event", "error", err)
+		return
+	}
+	if err := sc.SetNotificationGroupPriority(group, client.GROUP_PRIORITY_HIGHEST); err != nil {
+		slog.Error("Cannot set arm group priority", "error", err)
+	}
+	s.mu.Lock()
+	s.last = time.Time{}
+	s.mu.Unlock()
+}
+
+// Update runs the controller on each sample
+func (s *speedHold) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[SpeedReport](sc, ppData)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	s.mu.Lock()
+	s.lever = r.Throttle / 100
+	if !s.engaged || s.paused {
+		s.last = time.Time{}
+		s.mu.Unlock()
+		return
+	}
+	if r.OnGround != 0 {
+		s.engaged = false
+		s.mu.Unlock()
+		slog.Info("Speed hold disengaged on the ground")
+		return
+	}
+	if s.last.IsZero() {
+		// first sample since engaging or unpausing
+		s.ctl.Reset(s.lever)
+		s.limit.Reset(s.lever)
+		s.since = now
+		s.last = now
+		s.mu.Unlock()
+		return
+	}
+	dt := now.Sub(s.last)
+	s.last = now
+	e := s.target - r.IAS
+	out := s.limit.Limit(s.ctl.Update(control.Deadband(e, s.deadband), dt), dt)
+	if now.Sub(s.since) > settleTime {
+		s.errors = append(s.errors, math.Abs(e))
+	}
+	s.mu.Unlock()
+
+	slog.Debug("Speed hold", "ias", r.IAS, "error", e, "throttle", out)
+	if err := s.throttle.Transmit(sc, control.Axis(out, 0, 1)); err != nil {
+		slog.Error("Cannot set throttle", "error", err)
+	}
+}
+
+// Event toggles the hold on the arm trigger
+func (s *speedHold) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	if !s.arm.Is(sc, ev) {
+		return
+	}
+	s.mu.Lock()
+	s.engaged = !s.engaged
+	s.last = time.Time{}
+	engaged := s.engaged
+	s.mu.Unlock()
+	slog.Info("Speed hold", "engaged", engaged, "target_kts", s.target)
+}
+
+// SystemEvent holds the controller still while the sim is paused
+func (s *speedHold) SystemEvent(ctx context.Context, sc *client.SimConnect, name string, value any) {
+	if p, ok := value.(client.PauseEvent); ok {
+		s.mu.Lock()
+		s.paused = p.Paused
+		s.last = time.Time{}
+		s.mu.Unlock()
+	}
+}
+
+// settledError returns the mean absolute error once settled, and the
+// number of samples it covers
+func (s *speedHold) settledError() (float64, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.errors) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, e := range s.errors {
+		sum += e
+	}
+	return sum / float64(len(s.errors)), len(s.errors)
+}
@@ -0,0 +1,221 @@
+// traffic spawns a ring of AI aircraft around the user, with the aircraft
+// and liveries picked by the model matching package
+//
+// The installed liveries are listed with client.Liveries on MSFS 2024;
+// with the MSFS 2020 dll the -titles are used instead. Each spawned
+// aircraft reports its position and speed every few seconds, and the
+// traffic is removed again on exit.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/geo"
+	"github.com/bmurray/simconnect-go/modelmatch"
+)
+
+var programLevel = new(slog.LevelVar)
+
+func main() {
+	count := flag.Int("count", 8, "The number of aircraft in the ring")
+	radius := flag.Float64("radius", 2, "The radius of the ring in nautical miles")
+	altOffset := flag.Float64("alt", 0, "The altitude of the ring relative to the user, in feet")
+	types := flag.String("types", "B738,A320", "Comma separated ICAO types, used in turn around the ring")
+	operator := flag.String("operator", "", "An optional airline to prefer, eg Delta")
+	rulesPath := flag.String("rules", "", "An optional JSON file of model matching rules, added to the defaults")
+	titles := flag.String("titles", "Boeing 737-800 Asobo,Airbus A320 Neo Asobo", "Comma separated titles to match against when liveries cannot be listed")
+	debug := flag.Bool("debug", false, "debug")
+	flag.Parse()
+
+	h := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: programLevel})
+	slog.SetDefault(slog.New(h))
+
+	if *debug {
+		programLevel.Set(slog.LevelDebug)
+	}
+
+	rules := modelmatch.DefaultRules()
+	if *rulesPath != "" {
+		extra, err := modelmatch.LoadRules(*rulesPath)
+		if err != nil {
+			slog.Error("Cannot load rules", "error", err)
+			return
+		}
+		rules = append(rules, extra...)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	defer cancel()
+
+	t := &traffic{
+		count:     *count,
+		radius:    *radius,
+		altOffset: *altOffset,
+		types:     strings.Split(*types, ","),
+		operator:  *operator,
+		rules:     rules,
+		titles:    strings.Split(*titles, ","),
+	}
+	con := simconnect.NewConnector("traffic", simconnect.WithReceiver(t))
+	con.StartReconnect(ctx)
+}
+
+// TrafficReport is what each spawned aircraft reports
+type TrafficReport struct {
+	client.RecvSimobjectDataByType
+	Title    [256]byte `name:"TITLE"`
+	Altitude float64   `name:"PLANE ALTITUDE" unit:"Feet"`
+	Airspeed float64   `name:"AIRSPEED INDICATED" unit:"Knots"`
+	Heading  float64   `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees"`
+}
+
+type traffic struct {
+	count     int
+	radius    float64
+	altOffset float64
+	types     []string
+	operator  string
+	rules     []modelmatch.Rule
+	titles    []string
+
+	mu      sync.Mutex
+	objects []client.DWORD
+}
+
+func (t *traffic) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := sc.RegisterDataDefinition(&TrafficReport{}); err != nil {
+		slog.Error("Cannot register report", "error", err)
+		return
+	}
+	simconnect.Go(ctx, func(ctx context.Context) {
+		t.spawn(ctx, sc)
+		<-ctx.Done()
+		t.remove(sc)
+	})
+}
+
+// candidates lists the installed aircraft liveries, or the titles when
+// the dll cannot list them
+func (t *traffic) candidates(ctx context.Context, sc *client.SimConnect) ([]client.Livery, error) {
+	liveries, err := sc.Liveries(ctx, client.SIMOBJECT_TYPE_AIRCRAFT)
+	if errors.Is(err, client.ErrUnsupported) {
+		slog.Info("Cannot list liveries, matching the titles instead")
+		return modelmatch.Titles(t.titles...), nil
+	}
+	return liveries, err
+}
+
+func (t *traffic) spawn(ctx context.Context, sc *client.SimConnect) {
+	candidates, err := t.candidates(ctx, sc)
+	if err != nil {
+		slog.Error("Cannot list liveries", "error", err)
+		return
+	}
+	slog.Debug("Matching traffic", "candidates", len(candidates))
+	matcher := modelmatch.New(candidates, t.rules)
+
+	user, err := readUser(ctx, sc)
+	if err != nil {
+		slog.Error("Cannot read user position", "error", err)
+		return
+	}
+
+	for i := 0; i < t.count; i++ {
+		typ := strings.TrimSpace(t.types[i%len(t.types)])
+		l, ok := matcher.Match(modelmatch.Want{Type: typ, Operator: t.operator})
+		if !ok {
+			slog.Warn("No aircraft matches", "type", typ)
+			continue
+		}
+		bearing := user.heading + float64(i)*360/float64(t.count)
+		p := geo.Destination(user.pos, bearing, t.radius)
+		pos := client.InitPosition{
+			Latitude:  p.Latitude,
+			Longitude: p.Longitude,
+			Altitude:  user.pos.Altitude + t.altOffset,
+			Heading:   user.heading,
+			Airspeed:  client.DWORD(user.speed),
+		}
+		tail := fmt.Sprintf("TR%02d", i+1)
+		id, err := sc.CreateNonATCAircraftLivery(ctx, l, tail, pos)
+		if err != nil {
+			slog.Error("Cannot spawn aircraft", "title", l.Title, "livery", l.Livery, "error", err)
+			continue
+		}
+		slog.Info("Spawned aircraft", "object", id, "type", typ, "title", l.Title, "livery", l.Livery, "bearing", geo.Normalize(bearing))
+		t.mu.Lock()
+		t.objects = append(t.objects, id)
+		t.mu.Unlock()
+
+		// every fifth second
+		if _, err := simconnect.RequestDataPeriodic[TrafficReport](sc, id, client.PERIOD_SECOND, client.DATA_REQUEST_FLAG_DEFAULT, 4); err != nil {
+			slog.Error("Cannot request traffic data", "object", id, "error", err)
+		}
+	}
+}
+
+// remove removes the spawned aircraft; the connection is still open, as
+// the connector waits for this goroutine before closing it
+func (t *traffic) remove(sc *client.SimConnect) {
+	t.mu.Lock()
+	objects := t.objects
+	t.objects = nil
+	t.mu.Unlock()
+	for _, id := range objects {
+		if err := sc.RemoveObject(id); err != nil {
+			slog.Error("Cannot remove aircraft", "object", id, "error", err)
+		}
+	}
+}
+
+// Update logs the reports of the spawned aircraft
+func (t *traffic) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	if r, ok := simconnect.IsReport[TrafficReport](sc, ppData); ok {
+		slog.Info("Traffic",
+			"object", ppData.ObjectID,
+			"title", client.BytesToString(r.Title[:]),
+			"altitude", int(r.Altitude),
+			"airspeed", int(r.Airspeed),
+			"heading", int(r.Heading),
+		)
+	}
+}
+
+type userState struct {
+	pos     geo.Position
+	heading float64
+	speed   float64
+}
+
+// readUser reads where the ring is centred
+func readUser(ctx context.Context, sc *client.SimConnect) (userState, error) {
+	var u userState
+	reads := []struct {
+		name, unit string
+		v          *float64
+	}{
+		{"PLANE LATITUDE", "Degrees", &u.pos.Latitude},
+		{"PLANE LONGITUDE", "Degrees", &u.pos.Longitude},
+		{"PLANE ALTITUDE", "Feet", &u.pos.Altitude},
+		{"PLANE HEADING DEGREES TRUE", "Degrees", &u.heading},
+		{"AIRSPEED INDICATED", "Knots", &u.speed},
+	}
+	for _, r := range reads {
+		v, err := sc.ReadFloat(ctx, r.name, r.unit)
+		if err != nil {
+			return u, err
+		}
+		*r.v = v
+	}
+	return u, nil
+}
@@ -0,0 +1,328 @@
+// Package facilities keeps the sim's facility lists up to date
+//
+// The sim's facilities cache holds the airports, waypoints, NDBs and VORs
+// around the aircraft and changes as it flies. The Cache subscribes to
+// additions and requests the full lists again whenever the sim signals they
+// may have changed, such as a flight loading or the aircraft being moved,
+// so long sessions keep consistent data without manual re-requests.
+//
+// With WithStore the lists are saved after each full refresh and loaded
+// again on start, so they are available before the sim's first reply.
+package facilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/geo"
+	"github.com/bmurray/simconnect-go/store"
+)
+
+// Item is a facility list entry
+type Item = client.FacilityListItem
+
+// Position returns the position of a facility, with the altitude in feet
+func Position(it Item) geo.Position {
+	return geo.Position{Latitude: it.Latitude, Longitude: it.Longitude, Altitude: it.Altitude * 3.28084}
+}
+
+// UpdateFunc is called with the full list of a type after it changes
+type UpdateFunc func(typ client.DWORD, items []Item)
+
+// Cache is a receiver that mirrors the sim's facility lists
+type Cache struct {
+	types     []client.DWORD
+	refreshOn []string
+	interval  time.Duration
+	onUpdate  []UpdateFunc
+	store     store.Store
+
+	mu        sync.Mutex
+	sc        *client.SimConnect
+	lists     map[client.DWORD]map[string]Item
+	refreshes map[client.DWORD]*refresh // by request ID
+	refreshID map[client.DWORD]client.DWORD
+	events    map[client.DWORD]string
+}
+
+// refresh reassembles a full list sent over several messages
+type refresh struct {
+	typ   client.DWORD
+	seen  map[client.DWORD]bool
+	items map[string]Item
+}
+
+// Option is a function that sets options on the Cache
+type Option func(*Cache)
+
+// WithTypes sets the facility list types to keep; the default is airports
+func WithTypes(types ...client.DWORD) Option {
+	return func(c *Cache) {
+		c.types = types
+	}
+}
+
+// WithRefreshOn sets the system events that refresh the lists
+// the default is FlightLoaded and PositionChanged
+func WithRefreshOn(events ...string) Option {
+	return func(c *Cache) {
+		c.refreshOn = events
+	}
+}
+
+// WithRefreshInterval also refreshes the lists periodically
+func WithRefreshInterval(d time.Duration) Option {
+	return func(c *Cache) {
+		c.interval = d
+	}
+}
+
+// WithOnUpdate adds a callback that is called when a list changes
+// it is called on the dispatch goroutine, so it must not block
+func WithOnUpdate(fn UpdateFunc) Option {
+	return func(c *Cache) {
+		c.onUpdate = append(c.onUpdate, fn)
+	}
+}
+
+// WithStore saves the lists to st under "facilities/<type>" and loads them on start
+func WithStore(st store.Store) Option {
+	return func(c *Cache) {
+		c.store = st
+	}
+}
+
+// New creates a new Cache
+func New(opts ...Option) *Cache {
+	c := &Cache{
+		types:     []client.DWORD{client.FACILITY_LIST_TYPE_AIRPORT},
+		refreshOn: []string{"FlightLoaded", "PositionChanged"},
+		lists:     map[client.DWORD]map[string]Item{},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Start subscribes to the facility lists and the refresh events, and
+// requests the full lists
+func (c *Cache) Start(ctx context.Context, sc *client.SimConnect) {
+	events := map[client.DWORD]string{}
+	for _, name := range c.refreshOn {
+		id, err := sc.SubscribeToSystemEventByName(name)
+		if err != nil {
+			slog.Error("Cannot subscribe to facility refresh event", "event", name, "error", err)
+			continue
+		}
+		events[id] = name
+	}
+	c.mu.Lock()
+	c.sc = sc
+	c.events = events
+	c.refreshes = map[client.DWORD]*refresh{}
+	c.refreshID = map[client.DWORD]client.DWORD{}
+	c.mu.Unlock()
+	c.load()
+
+	for _, typ := range c.types {
+		subID := sc.NewRequestID()
+		sc.HandleFacilityLists(subID, func(l client.FacilityList) { c.added(typ, l) })
+		if err := sc.SubscribeToFacilities(typ, subID); err != nil {
+			slog.Error("Cannot subscribe to facilities", "type", typ, "error", err)
+		}
+		refreshID := sc.NewRequestID()
+		sc.HandleFacilityLists(refreshID, c.refreshed)
+		c.mu.Lock()
+		c.refreshID[typ] = refreshID
+		c.mu.Unlock()
+	}
+	c.Refresh()
+
+	if c.interval > 0 {
+		simconnect.Go(ctx, func(ctx context.Context) {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(c.interval):
+					c.Refresh()
+				}
+			}
+		})
+	}
+}
+
+// Update does nothing; facility lists arrive as their own messages
+func (c *Cache) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// Event refreshes the lists on the refresh events
+func (c *Cache) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	c.mu.Lock()
+	name, ok := c.events[ev.EventID]
+	c.mu.Unlock()
+	if ok {
+		slog.Debug("Refreshing facilities", "event", name)
+		c.Refresh()
+	}
+}
+
+// Refresh requests the full lists again
+// the current lists are kept until the new ones are complete
+func (c *Cache) Refresh() {
+	c.mu.Lock()
+	sc := c.sc
+	ids := make(map[client.DWORD]client.DWORD, len(c.refreshID))
+	for typ, id := range c.refreshID {
+		ids[typ] = id
+		c.refreshes[id] = &refresh{typ: typ, seen: map[client.DWORD]bool{}, items: map[string]Item{}}
+	}
+	c.mu.Unlock()
+	if sc == nil {
+		return
+	}
+	for typ, id := range ids {
+		if err := sc.RequestFacilitiesList(typ, id); err != nil {
+			slog.Error("Cannot request facilities", "type", typ, "error", err)
+		}
+	}
+}
+
+// refreshed collects a full list and replaces the cached one once every
+// message has arrived
+func (c *Cache) refreshed(l client.FacilityList) {
+	c.mu.Lock()
+	r, ok := c.refreshes[l.RequestID]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	r.seen[l.Entry] = true
+	for _, it := range l.Items {
+		r.items[it.ICAO] = it
+	}
+	if client.DWORD(len(r.seen)) < l.OutOf {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.refreshes, l.RequestID)
+	c.lists[r.typ] = r.items
+	c.mu.Unlock()
+	c.save(r.typ)
+	c.notify(r.typ)
+}
+
+func storeKey(typ client.DWORD) string {
+	return fmt.Sprintf("facilities/%d", typ)
+}
+
+// load fills the lists not yet known from the store
+func (c *Cache) load() {
+	if c.store == nil {
+		return
+	}
+	for _, typ := range c.types {
+		c.mu.Lock()
+		_, known := c.lists[typ]
+		c.mu.Unlock()
+		if known {
+			continue
+		}
+		var items []Item
+		if err := store.GetJSON(c.store, storeKey(typ), &items); err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				slog.Error("Cannot load facilities", "type", typ, "error", err)
+			}
+			continue
+		}
+		list := make(map[string]Item, len(items))
+		for _, it := range items {
+			list[it.ICAO] = it
+		}
+		c.mu.Lock()
+		if _, known := c.lists[typ]; !known {
+			c.lists[typ] = list
+		}
+		c.mu.Unlock()
+		c.notify(typ)
+	}
+}
+
+// save writes a full list to the store
+// only refreshes are saved, as additions alone are a partial picture
+func (c *Cache) save(typ client.DWORD) {
+	if c.store == nil {
+		return
+	}
+	if err := store.PutJSON(c.store, storeKey(typ), c.Get(typ)); err != nil {
+		slog.Error("Cannot save facilities", "type", typ, "error", err)
+	}
+}
+
+// added merges facilities that entered the sim's cache
+func (c *Cache) added(typ client.DWORD, l client.FacilityList) {
+	if len(l.Items) == 0 {
+		return
+	}
+	c.mu.Lock()
+	list := c.lists[typ]
+	if list == nil {
+		list = map[string]Item{}
+		c.lists[typ] = list
+	}
+	for _, it := range l.Items {
+		list[it.ICAO] = it
+	}
+	c.mu.Unlock()
+	c.notify(typ)
+}
+
+func (c *Cache) notify(typ client.DWORD) {
+	if len(c.onUpdate) == 0 {
+		return
+	}
+	items := c.Get(typ)
+	for _, fn := range c.onUpdate {
+		fn(typ, items)
+	}
+}
+
+// Get returns the cached list of a type, sorted by ICAO
+func (c *Cache) Get(typ client.DWORD) []Item {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	items := make([]Item, 0, len(c.lists[typ]))
+	for _, it := range c.lists[typ] {
+		items = append(items, it)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ICAO < items[j].ICAO })
+	return items
+}
+
+// Lookup returns a cached facility by ICAO
+func (c *Cache) Lookup(typ client.DWORD, icao string) (Item, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	it, ok := c.lists[typ][icao]
+	return it, ok
+}
+
+// Nearest returns up to n cached facilities closest to p, nearest first
+func (c *Cache) Nearest(typ client.DWORD, p geo.Position, n int) []Item {
+	items := c.Get(typ)
+	sort.SliceStable(items, func(i, j int) bool {
+		return geo.Distance(p, Position(items[i])) < geo.Distance(p, Position(items[j]))
+	})
+	if len(items) > n {
+		items = items[:n]
+	}
+	return items
+}
@@ -0,0 +1,38 @@
+// Package failures injects system failures through the sim's failure events
+package failures
+
+import "sort"
+
+// Failure is a failure that can be injected into the sim
+type Failure struct {
+	// Name is the short name used in scenarios, eg "engine1"
+	Name string
+	// Event is the sim event that toggles the failure
+	Event string
+}
+
+// Catalog is the set of known failures, keyed by name
+var Catalog = map[string]Failure{
+	"engine1":     {Name: "engine1", Event: "TOGGLE_ENGINE1_FAILURE"},
+	"engine2":     {Name: "engine2", Event: "TOGGLE_ENGINE2_FAILURE"},
+	"engine3":     {Name: "engine3", Event: "TOGGLE_ENGINE3_FAILURE"},
+	"engine4":     {Name: "engine4", Event: "TOGGLE_ENGINE4_FAILURE"},
+	"electrical":  {Name: "electrical", Event: "TOGGLE_ELECTRICAL_FAILURE"},
+	"vacuum":      {Name: "vacuum", Event: "TOGGLE_VACUUM_FAILURE"},
+	"hydraulic":   {Name: "hydraulic", Event: "TOGGLE_HYDRAULIC_FAILURE"},
+	"pitot":       {Name: "pitot", Event: "TOGGLE_PITOT_BLOCKAGE"},
+	"static":      {Name: "static", Event: "TOGGLE_STATIC_PORT_BLOCKAGE"},
+	"brakes":      {Name: "brakes", Event: "TOGGLE_TOTAL_BRAKE_FAILURE"},
+	"brake_left":  {Name: "brake_left", Event: "TOGGLE_LEFT_BRAKE_FAILURE"},
+	"brake_right": {Name: "brake_right", Event: "TOGGLE_RIGHT_BRAKE_FAILURE"},
+}
+
+// Names returns the sorted names of all known failures
+func Names() []string {
+	names := make([]string, 0, len(Catalog))
+	for n := range Catalog {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
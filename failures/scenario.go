@@ -0,0 +1,171 @@
+package failures
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scenario is a reproducible set of scheduled failures
+//
+// The text format is one directive per line; blank lines and lines
+// starting with # are ignored:
+//
+//	seed 1234
+//	engine1 per_hour=0.5 above=FL100
+//	vacuum per_hour=2 after=10m below=8000
+//	pitot at=15m
+//
+// per_hour is the probability rate per flight hour while armed,
+// at fires the failure once the flight time is reached,
+// after, above, below and speed_above are arming conditions; altitudes in
+// feet are indicated, flight levels are pressure altitude
+type Scenario struct {
+	Seed  int64
+	Rules []Rule
+}
+
+// Rule schedules a single failure
+type Rule struct {
+	Failure Failure
+
+	// PerHour is the failure rate per flight hour while the rule is armed
+	PerHour float64
+	// At fires the failure once this much flight time has elapsed; zero disables it
+	At time.Duration
+
+	// After arms the rule once this much flight time has elapsed
+	After time.Duration
+	// Above arms the rule above this altitude in feet; nil if unset
+	Above *float64
+	// Below arms the rule below this altitude in feet; nil if unset
+	Below *float64
+	// AboveFL and BelowFL compare Above and Below against the pressure
+	// altitude rather than the indicated, as for flight levels
+	AboveFL bool
+	BelowFL bool
+	// SpeedAbove arms the rule above this indicated airspeed in knots; nil if unset
+	SpeedAbove *float64
+}
+
+// Armed returns true if all the rule's conditions hold
+func (r Rule) Armed(s FlightState) bool {
+	if s.FlightTime < r.After {
+		return false
+	}
+	if r.Above != nil && s.altitude(r.AboveFL) <= *r.Above {
+		return false
+	}
+	if r.Below != nil && s.altitude(r.BelowFL) >= *r.Below {
+		return false
+	}
+	if r.SpeedAbove != nil && s.Airspeed <= *r.SpeedAbove {
+		return false
+	}
+	return true
+}
+
+// altitude is the pressure altitude for flight levels, else the indicated
+func (s FlightState) altitude(flightLevel bool) float64 {
+	if flightLevel {
+		return s.PressureAltitude
+	}
+	return s.Altitude
+}
+
+// ParseScenario parses a scenario from its text format
+func ParseScenario(r io.Reader) (*Scenario, error) {
+	sc := &Scenario{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if fields[0] == "seed" {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: seed takes one value", line)
+			}
+			seed, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid seed: %w", line, err)
+			}
+			sc.Seed = seed
+			continue
+		}
+		rule, err := parseRule(fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		sc.Rules = append(sc.Rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+func parseRule(fields []string) (Rule, error) {
+	f, ok := Catalog[fields[0]]
+	if !ok {
+		return Rule{}, fmt.Errorf("unknown failure %q", fields[0])
+	}
+	rule := Rule{Failure: f}
+	for _, kv := range fields[1:] {
+		key, val, ok := strings.Cut(kv, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("expected key=value, got %q", kv)
+		}
+		var err error
+		switch key {
+		case "per_hour":
+			rule.PerHour, err = strconv.ParseFloat(val, 64)
+		case "at":
+			rule.At, err = time.ParseDuration(val)
+		case "after":
+			rule.After, err = time.ParseDuration(val)
+		case "above":
+			rule.Above, rule.AboveFL, err = parseAltitude(val)
+		case "below":
+			rule.Below, rule.BelowFL, err = parseAltitude(val)
+		case "speed_above":
+			var v float64
+			v, err = strconv.ParseFloat(val, 64)
+			rule.SpeedAbove = &v
+		default:
+			return Rule{}, fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	if rule.PerHour <= 0 && rule.At <= 0 {
+		return Rule{}, fmt.Errorf("%s needs per_hour or at", f.Name)
+	}
+	return rule, nil
+}
+
+// parseAltitude accepts feet ("8000", "8000ft") or flight levels ("FL100"),
+// and reports which it was
+func parseAltitude(val string) (*float64, bool, error) {
+	upper := strings.ToUpper(val)
+	if strings.HasPrefix(upper, "FL") {
+		fl, err := strconv.ParseFloat(upper[2:], 64)
+		if err != nil {
+			return nil, false, err
+		}
+		ft := fl * 100
+		return &ft, true, nil
+	}
+	ft, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(val), "ft"), 64)
+	if err != nil {
+		return nil, false, err
+	}
+	return &ft, false, nil
+}
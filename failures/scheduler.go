@@ -0,0 +1,254 @@
+package failures
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// FlightStateReport is the data structure used to evaluate arming conditions
+type FlightStateReport struct {
+	client.RecvSimobjectDataByType
+	Altitude         float64 `name:"INDICATED ALTITUDE" unit:"Feet"`
+	PressureAltitude float64 `name:"PRESSURE ALTITUDE" unit:"Feet"`
+	Airspeed         float64 `name:"AIRSPEED INDICATED" unit:"Knots"`
+	OnGround         float64 `name:"SIM ON GROUND" unit:"Bool"`
+	SimTime          float64 `name:"SIMULATION TIME" unit:"Seconds"`
+}
+
+// FlightState is the state used to evaluate a rule
+type FlightState struct {
+	Altitude         float64
+	PressureAltitude float64
+	Airspeed         float64
+	OnGround         bool
+	FlightTime       time.Duration
+}
+
+// Scheduler is a receiver that runs a scenario against the sim
+// flight time is simulation time, so it stops while paused, and only
+// accumulates while airborne; each rule fires at most once
+// the scenario is evaluated in fixed steps of flight time, so a seeded
+// scenario makes the same draws however the samples happen to be spaced
+type Scheduler struct {
+	scenario *Scenario
+	interval time.Duration
+	step     time.Duration
+	onFail   func(Failure, FlightState)
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	fired    []bool
+	state    FlightState
+	ticked   bool
+	simTime  float64
+	pending  time.Duration
+	eventIDs map[string]client.DWORD
+	sc       *client.SimConnect
+}
+
+// SchedulerOption is a function that sets options on the Scheduler
+type SchedulerOption func(*Scheduler)
+
+// WithInterval sets how often the flight state is sampled
+func WithInterval(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.interval = d
+	}
+}
+
+// WithStep sets the step of simulation time the scenario is evaluated in
+func WithStep(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.step = d
+	}
+}
+
+// WithOnFailure sets a callback that is called when a failure is injected
+func WithOnFailure(fn func(Failure, FlightState)) SchedulerOption {
+	return func(s *Scheduler) {
+		s.onFail = fn
+	}
+}
+
+// NewScheduler creates a scheduler for the scenario
+// the random source is seeded from the scenario so runs are repeatable
+func NewScheduler(scenario *Scenario, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		scenario: scenario,
+		interval: time.Second,
+		step:     time.Second,
+		rng:      rand.New(rand.NewSource(scenario.Seed)),
+		fired:    make([]bool, len(scenario.Rules)),
+		eventIDs: map[string]client.DWORD{},
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Start registers the flight state definition, maps the failure events
+// and starts sampling the flight state
+func (s *Scheduler) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := sc.RegisterDataDefinition(&FlightStateReport{}); err != nil {
+		slog.Error("Cannot register flight state", "error", err)
+		return
+	}
+	ids := map[string]client.DWORD{}
+	for _, r := range s.scenario.Rules {
+		if _, ok := ids[r.Failure.Event]; ok {
+			continue
+		}
+		id := sc.GetEventID()
+		if err := sc.MapClientEventToSimEvent(id, r.Failure.Event); err != nil {
+			slog.Error("Cannot map failure event", "event", r.Failure.Event, "error", err)
+			return
+		}
+		ids[r.Failure.Event] = id
+	}
+
+	s.mu.Lock()
+	s.sc = sc
+	s.eventIDs = ids
+	s.ticked = false
+	s.mu.Unlock()
+
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.interval):
+				if err := simconnect.RequestData[FlightStateReport](sc); err != nil {
+					slog.Error("Cannot request flight state", "error", err)
+				}
+			}
+		}
+	})
+}
+
+// Update evaluates the scenario against the latest flight state
+func (s *Scheduler) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[FlightStateReport](sc, ppData)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	// simulation time going backwards is a reloaded flight: start over
+	// from there rather than count the jump
+	if s.ticked && r.SimTime > s.simTime {
+		s.pending += time.Duration((r.SimTime - s.simTime) * float64(time.Second))
+	}
+	s.ticked, s.simTime = true, r.SimTime
+	s.state.Altitude = r.Altitude
+	s.state.PressureAltitude = r.PressureAltitude
+	s.state.Airspeed = r.Airspeed
+	s.state.OnGround = r.OnGround != 0
+	var due []Failure
+	for s.step > 0 && s.pending >= s.step {
+		s.pending -= s.step
+		if !s.state.OnGround {
+			s.state.FlightTime += s.step
+		}
+		due = append(due, s.evaluate(s.state, s.step)...)
+	}
+	state := s.state
+	s.mu.Unlock()
+
+	for _, f := range due {
+		if err := s.inject(sc, f); err != nil {
+			slog.Error("Cannot inject failure", "failure", f.Name, "error", err)
+			continue
+		}
+		slog.Info("Failure injected", "failure", f.Name, "flight_time", state.FlightTime)
+		if s.onFail != nil {
+			s.onFail(f, state)
+		}
+	}
+}
+
+// evaluate returns the failures due this tick; it must be called with the lock held
+// every armed rate rule draws from the random source so the sequence is repeatable
+func (s *Scheduler) evaluate(state FlightState, dt time.Duration) []Failure {
+	var due []Failure
+	for i, r := range s.scenario.Rules {
+		if s.fired[i] || state.OnGround || !r.Armed(state) {
+			continue
+		}
+		fire := r.At > 0 && state.FlightTime >= r.At
+		if r.PerHour > 0 && dt > 0 {
+			p := 1 - math.Exp(-r.PerHour*dt.Hours())
+			if s.rng.Float64() < p {
+				fire = true
+			}
+		}
+		if fire {
+			s.fired[i] = true
+			due = append(due, r.Failure)
+		}
+	}
+	return due
+}
+
+// Inject injects a failure immediately, regardless of the scenario
+func (s *Scheduler) Inject(f Failure) error {
+	s.mu.Lock()
+	sc := s.sc
+	s.mu.Unlock()
+	if sc == nil {
+		return ErrNotStarted
+	}
+	return s.inject(sc, f)
+}
+
+func (s *Scheduler) inject(sc *client.SimConnect, f Failure) error {
+	s.mu.Lock()
+	id, ok := s.eventIDs[f.Event]
+	s.mu.Unlock()
+	if !ok {
+		id = sc.GetEventID()
+		if err := sc.MapClientEventToSimEvent(id, f.Event); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.eventIDs[f.Event] = id
+		s.mu.Unlock()
+	}
+	return sc.TransmitClientEvent(client.OBJECT_ID_USER, id, 0, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
+
+// State returns the latest flight state
+func (s *Scheduler) State() FlightState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Reset clears the fired rules, flight time and random source
+func (s *Scheduler) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rng = rand.New(rand.NewSource(s.scenario.Seed))
+	s.fired = make([]bool, len(s.scenario.Rules))
+	s.state = FlightState{}
+	s.ticked = false
+	s.pending = 0
+}
+
+// FailuresError is the error type for the failures package
+type FailuresError string
+
+func (e FailuresError) Error() string { return string(e) }
+
+const (
+	// ErrNotStarted is returned when the scheduler has no connection
+	ErrNotStarted FailuresError = "failure scheduler not started"
+)
@@ -0,0 +1,383 @@
+// Package flightmodel runs controlled parameter sweeps against the user
+// aircraft, for validating flight models from Go test harnesses
+//
+// The aircraft is put in slew mode with the slew axes at rest and frozen in
+// position, altitude and attitude; the swept parameter, airspeed, angle of
+// attack or pitch, is written directly, and after a settle time the
+// aerodynamic simvars are sampled. Lift and drag are resolved from the
+// body accelerations about the angle of attack, and made coefficients with
+// the dynamic pressure and wing area.
+package flightmodel
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// SampleReport is the data structure sampled at each sweep step
+type SampleReport struct {
+	client.RecvSimobjectDataByType
+	IndicatedAirspeed float64 `name:"AIRSPEED INDICATED" unit:"Knots"`
+	TrueAirspeed      float64 `name:"AIRSPEED TRUE" unit:"Knots"`
+	Alpha             float64 `name:"INCIDENCE ALPHA" unit:"Degrees"`
+	Pitch             float64 `name:"PLANE PITCH DEGREES" unit:"Degrees"`
+	GForce            float64 `name:"G FORCE" unit:"GForce"`
+	VerticalSpeed     float64 `name:"VERTICAL SPEED" unit:"Feet per minute"`
+	AccelerationZ     float64 `name:"ACCELERATION BODY Z" unit:"Feet per second squared"`
+	ElevatorTrim      float64 `name:"ELEVATOR TRIM POSITION" unit:"Degrees"`
+	StallWarning      float64 `name:"STALL WARNING" unit:"Bool"`
+	AccelerationY     float64 `name:"ACCELERATION BODY Y" unit:"Feet per second squared"`
+	TotalWeight       float64 `name:"TOTAL WEIGHT" unit:"Pounds"`
+	DynamicPressure   float64 `name:"DYNAMIC PRESSURE" unit:"Pounds per square foot"`
+	WingArea          float64 `name:"WING AREA" unit:"Square feet"`
+}
+
+// AirspeedRequest is the data structure to set the longitudinal body velocity
+type AirspeedRequest struct {
+	client.RecvSimobjectDataByType
+	Velocity float64 `name:"VELOCITY BODY Z" unit:"Knots"`
+}
+
+// AlphaRequest is the data structure to set the angle of attack, as the
+// body velocity split between the longitudinal and vertical axes
+type AlphaRequest struct {
+	client.RecvSimobjectDataByType
+	VelocityZ float64 `name:"VELOCITY BODY Z" unit:"Knots"`
+	VelocityY float64 `name:"VELOCITY BODY Y" unit:"Knots"`
+}
+
+// PitchRequest is the data structure to set the pitch attitude
+// note the sim reports pitch as positive nose down
+type PitchRequest struct {
+	client.RecvSimobjectDataByType
+	Pitch float64 `name:"PLANE PITCH DEGREES" unit:"Degrees"`
+}
+
+// Param is the parameter a sweep varies
+type Param int
+
+const (
+	// ParamAirspeed sweeps the body velocity in knots
+	ParamAirspeed Param = iota
+	// ParamPitch sweeps the pitch attitude in degrees, positive nose up
+	ParamPitch
+	// ParamAlpha sweeps the angle of attack in degrees at the sweep's
+	// Airspeed
+	ParamAlpha
+)
+
+func (p Param) String() string {
+	switch p {
+	case ParamAirspeed:
+		return "airspeed"
+	case ParamPitch:
+		return "pitch"
+	case ParamAlpha:
+		return "alpha"
+	default:
+		return fmt.Sprintf("Param(%d)", int(p))
+	}
+}
+
+// Sweep describes a parameter sweep
+type Sweep struct {
+	Param Param
+	From  float64
+	To    float64
+	Step  float64
+	// Airspeed is the body velocity in knots an alpha sweep holds
+	Airspeed float64
+	// Settle is how long to wait after setting the parameter before sampling
+	Settle time.Duration
+}
+
+// Sample is a decoded SampleReport
+type Sample struct {
+	IndicatedAirspeed float64
+	TrueAirspeed      float64
+	Alpha             float64
+	Pitch             float64
+	GForce            float64
+	VerticalSpeed     float64
+	AccelerationZ     float64
+	ElevatorTrim      float64
+	StallWarning      bool
+	// Lift and Drag are the forces in pounds normal to and along the
+	// airflow, with thrust included in Drag, so sweep at idle
+	Lift float64
+	Drag float64
+	// CL and CD are the lift and drag coefficients, zero when the sim
+	// reports no dynamic pressure or wing area
+	CL float64
+	CD float64
+}
+
+// Point is a single step of a sweep
+type Point struct {
+	Setpoint float64
+	Sample   Sample
+}
+
+var freezeEvents = []string{
+	"FREEZE_LATITUDE_LONGITUDE_SET",
+	"FREEZE_ALTITUDE_SET",
+	"FREEZE_ATTITUDE_SET",
+}
+
+// slewAxes are set to zero on entering slew, so the aircraft holds still
+var slewAxes = []string{
+	"AXIS_SLEW_AHEAD_SET",
+	"AXIS_SLEW_SIDEWAYS_SET",
+	"AXIS_SLEW_HEADING_SET",
+	"AXIS_SLEW_ALT_SET",
+	"AXIS_SLEW_BANK_SET",
+	"AXIS_SLEW_PITCH_SET",
+}
+
+// probeEvents are the events the probe maps
+var probeEvents = append(append([]string{"SLEW_ON", "SLEW_OFF"}, slewAxes...), freezeEvents...)
+
+// gravity is standard gravity in feet per second squared
+const gravity = 32.174
+
+// Probe is a receiver that runs sweeps
+// add it to a connector, then call Run from another goroutine
+type Probe struct {
+	mu      sync.Mutex
+	sc      *client.SimConnect
+	events  map[string]client.DWORD
+	samples chan Sample
+}
+
+// NewProbe creates a new probe
+func NewProbe() *Probe {
+	return &Probe{samples: make(chan Sample, 1)}
+}
+
+// Start registers the definitions and maps the slew and freeze events
+func (p *Probe) Start(ctx context.Context, sc *client.SimConnect) {
+	for _, def := range []any{&SampleReport{}, &AirspeedRequest{}, &AlphaRequest{}, &PitchRequest{}} {
+		if err := sc.RegisterDataDefinition(def); err != nil {
+			slog.Error("Cannot register probe definition", "error", err)
+			return
+		}
+	}
+	events := map[string]client.DWORD{}
+	for _, name := range probeEvents {
+		id := sc.GetEventID()
+		if err := sc.MapClientEventToSimEvent(id, name); err != nil {
+			slog.Error("Cannot map probe event", "event", name, "error", err)
+			return
+		}
+		events[name] = id
+	}
+	p.mu.Lock()
+	p.sc = sc
+	p.events = events
+	p.mu.Unlock()
+}
+
+// Update delivers samples to a running sweep
+func (p *Probe) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[SampleReport](sc, ppData)
+	if !ok {
+		return
+	}
+	s := Sample{
+		IndicatedAirspeed: r.IndicatedAirspeed,
+		TrueAirspeed:      r.TrueAirspeed,
+		Alpha:             r.Alpha,
+		Pitch:             -r.Pitch,
+		GForce:            r.GForce,
+		VerticalSpeed:     r.VerticalSpeed,
+		AccelerationZ:     r.AccelerationZ,
+		ElevatorTrim:      r.ElevatorTrim,
+		StallWarning:      r.StallWarning != 0,
+	}
+	s.Lift, s.Drag = aeroForces(r.TotalWeight, r.AccelerationZ, r.AccelerationY, s.Alpha, s.Pitch)
+	if qs := r.DynamicPressure * r.WingArea; qs > 0 {
+		s.CL, s.CD = s.Lift/qs, s.Drag/qs
+	}
+	select {
+	case p.samples <- s:
+	default:
+	}
+}
+
+// aeroForces resolves the force on the aircraft, less gravity, into lift
+// and drag about the angle of attack; the body axes are Z forward and Y up,
+// and alpha and pitch are in degrees, positive nose up
+func aeroForces(weight, accelZ, accelY, alpha, pitch float64) (lift, drag float64) {
+	theta, a := pitch*math.Pi/180, alpha*math.Pi/180
+	// weight in pounds over g is the mass in slugs
+	fz := weight / gravity * (accelZ + gravity*math.Sin(theta))
+	fy := weight / gravity * (accelY + gravity*math.Cos(theta))
+	lift = fz*math.Sin(a) + fy*math.Cos(a)
+	drag = fy*math.Sin(a) - fz*math.Cos(a)
+	return lift, drag
+}
+
+// Run executes the sweep and returns one point per step
+// the aircraft is slewed and frozen for the duration and released afterwards
+func (p *Probe) Run(ctx context.Context, sw Sweep) ([]Point, error) {
+	if sw.Step == 0 || (sw.To-sw.From)/sw.Step < 0 {
+		return nil, fmt.Errorf("invalid sweep from %v to %v step %v", sw.From, sw.To, sw.Step)
+	}
+	if sw.Param == ParamAlpha && sw.Airspeed <= 0 {
+		return nil, fmt.Errorf("alpha sweep needs an airspeed")
+	}
+	p.mu.Lock()
+	sc := p.sc
+	p.mu.Unlock()
+	if sc == nil {
+		return nil, fmt.Errorf("probe not started")
+	}
+
+	if err := p.slew(sc, true); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := p.slew(sc, false); err != nil {
+			slog.Error("Cannot leave slew", "error", err)
+		}
+	}()
+	if err := p.freeze(sc, true); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := p.freeze(sc, false); err != nil {
+			slog.Error("Cannot release freeze", "error", err)
+		}
+	}()
+
+	var points []Point
+	n := int((sw.To-sw.From)/sw.Step) + 1
+	for i := 0; i < n; i++ {
+		v := sw.From + float64(i)*sw.Step
+		if err := p.set(sc, sw, v); err != nil {
+			return points, err
+		}
+		select {
+		case <-ctx.Done():
+			return points, ctx.Err()
+		case <-time.After(sw.Settle):
+		}
+		s, err := p.sample(ctx, sc)
+		if err != nil {
+			return points, err
+		}
+		points = append(points, Point{Setpoint: v, Sample: s})
+	}
+	return points, nil
+}
+
+func (p *Probe) transmit(sc *client.SimConnect, name string, data client.DWORD) error {
+	p.mu.Lock()
+	id := p.events[name]
+	p.mu.Unlock()
+	return sc.TransmitClientEvent(client.OBJECT_ID_USER, id, data, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
+
+func (p *Probe) freeze(sc *client.SimConnect, on bool) error {
+	var data client.DWORD
+	if on {
+		data = 1
+	}
+	for _, name := range freezeEvents {
+		if err := p.transmit(sc, name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slew enters slew mode with the aircraft at rest, or leaves it
+func (p *Probe) slew(sc *client.SimConnect, on bool) error {
+	if !on {
+		return p.transmit(sc, "SLEW_OFF", 0)
+	}
+	if err := p.transmit(sc, "SLEW_ON", 0); err != nil {
+		return err
+	}
+	for _, name := range slewAxes {
+		if err := p.transmit(sc, name, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Probe) set(sc *client.SimConnect, sw Sweep, v float64) error {
+	switch sw.Param {
+	case ParamAirspeed:
+		return sc.SetData(&AirspeedRequest{Velocity: v})
+	case ParamPitch:
+		return sc.SetData(&PitchRequest{Pitch: -v})
+	case ParamAlpha:
+		// the air meets the wing from below, so the aircraft moves down
+		// its vertical axis
+		a := v * math.Pi / 180
+		return sc.SetData(&AlphaRequest{VelocityZ: sw.Airspeed * math.Cos(a), VelocityY: -sw.Airspeed * math.Sin(a)})
+	default:
+		return fmt.Errorf("unknown sweep parameter %s", sw.Param)
+	}
+}
+
+func (p *Probe) sample(ctx context.Context, sc *client.SimConnect) (Sample, error) {
+	// drop any stale sample from a previous step
+	select {
+	case <-p.samples:
+	default:
+	}
+	if err := simconnect.RequestData[SampleReport](sc); err != nil {
+		return Sample{}, err
+	}
+	select {
+	case <-ctx.Done():
+		return Sample{}, ctx.Err()
+	case s := <-p.samples:
+		return s, nil
+	}
+}
+
+// StallSpeed returns the lowest indicated airspeed without a stall warning
+// the points should come from an airspeed sweep; false if every point stalled
+func StallSpeed(points []Point) (float64, bool) {
+	best, found := 0.0, false
+	for _, pt := range points {
+		if pt.Sample.StallWarning {
+			continue
+		}
+		if !found || pt.Sample.IndicatedAirspeed < best {
+			best, found = pt.Sample.IndicatedAirspeed, true
+		}
+	}
+	return best, found
+}
+
+// WriteCSV writes the points as a CSV curve with a header row
+func WriteCSV(w io.Writer, points []Point) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"setpoint", "ias", "tas", "alpha", "pitch", "g", "vs", "accel_z", "elevator_trim", "stall_warning", "lift", "drag", "cl", "cd"})
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, pt := range points {
+		s := pt.Sample
+		cw.Write([]string{
+			f(pt.Setpoint), f(s.IndicatedAirspeed), f(s.TrueAirspeed), f(s.Alpha), f(s.Pitch),
+			f(s.GForce), f(s.VerticalSpeed), f(s.AccelerationZ), f(s.ElevatorTrim), strconv.FormatBool(s.StallWarning),
+			f(s.Lift), f(s.Drag), f(s.CL), f(s.CD),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
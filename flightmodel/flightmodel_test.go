@@ -0,0 +1,196 @@
+package flightmodel_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/client/clienttest"
+	"github.com/bmurray/simconnect-go/flightmodel"
+)
+
+// fakeAircraft answers the probe through the fake dll: the sampled
+// airspeed, alpha and pitch are the ones last set, the stall warning sounds
+// below stall knots, and the wing carries the weight whatever the alpha
+type fakeAircraft struct {
+	stall    float64
+	velocity float64
+	alpha    float64 // when swept, otherwise alpha falls with airspeed
+	swept    bool
+	pitch    float64 // as the sim has it, positive nose down
+	events   map[client.DWORD]string
+}
+
+func startProbe(t *testing.T, ac *fakeAircraft) (*clienttest.DLL, *flightmodel.Probe) {
+	t.Helper()
+	dll := clienttest.New()
+	sc, err := dll.Connect()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	ac.events = map[client.DWORD]string{}
+	dll.Handle("SimConnect_MapClientEventToSimEvent", func(args []uintptr) uintptr {
+		ac.events[client.DWORD(args[1])] = clienttest.String(args[2])
+		return 0
+	})
+	ctx := context.Background()
+	p := flightmodel.NewProbe()
+	p.Start(ctx, sc)
+
+	airspeedID := sc.GetDefineID(&flightmodel.AirspeedRequest{})
+	alphaID := sc.GetDefineID(&flightmodel.AlphaRequest{})
+	pitchID := sc.GetDefineID(&flightmodel.PitchRequest{})
+	sampleID := sc.GetDefineID(&flightmodel.SampleReport{})
+	dll.Handle("SimConnect_SetDataOnSimObject", func(args []uintptr) uintptr {
+		switch client.DWORD(args[1]) {
+		case airspeedID:
+			ac.velocity = clienttest.Float64s(args[6], 1)[0]
+		case alphaID:
+			v := clienttest.Float64s(args[6], 2)
+			ac.velocity = math.Hypot(v[0], v[1])
+			ac.alpha, ac.swept = math.Atan2(-v[1], v[0])*180/math.Pi, true
+		case pitchID:
+			ac.pitch = clienttest.Float64s(args[6], 1)[0]
+		}
+		return 0
+	})
+	// the sample is delivered while the request is made, as the sim would
+	// a moment later
+	dll.Handle("SimConnect_RequestDataOnSimObjectType", func(args []uintptr) uintptr {
+		if client.DWORD(args[2]) != sampleID {
+			return 0
+		}
+		r := &flightmodel.SampleReport{
+			IndicatedAirspeed: ac.velocity,
+			TrueAirspeed:      ac.velocity + 4,
+			Alpha:             600 / ac.velocity,
+			Pitch:             ac.pitch,
+			TotalWeight:       2000,
+			DynamicPressure:   50,
+			WingArea:          100,
+		}
+		if ac.swept {
+			r.Alpha = ac.alpha
+		}
+		if ac.velocity < ac.stall {
+			r.StallWarning = 1
+		}
+		r.DefineID = sampleID
+		p.Update(ctx, sc, &r.RecvSimobjectDataByType)
+		return 0
+	})
+	return dll, p
+}
+
+func TestAirspeedSweep(t *testing.T) {
+	ac := &fakeAircraft{stall: 55}
+	dll, p := startProbe(t, ac)
+	points, err := p.Run(context.Background(), flightmodel.Sweep{Param: flightmodel.ParamAirspeed, From: 40, To: 100, Step: 10})
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if len(points) != 7 {
+		t.Fatalf("%d points, want 7", len(points))
+	}
+	for i, pt := range points {
+		want := 40 + float64(i)*10
+		if pt.Setpoint != want || pt.Sample.IndicatedAirspeed != want || pt.Sample.Alpha != 600/want {
+			t.Fatalf("point %d is %+v, want airspeed %v", i, pt, want)
+		}
+		if pt.Sample.StallWarning != (want < 55) {
+			t.Fatalf("point %d stall warning %v", i, pt.Sample.StallWarning)
+		}
+	}
+	if v, ok := flightmodel.StallSpeed(points); !ok || v != 60 {
+		t.Fatalf("stall speed %v %v, want 60", v, ok)
+	}
+
+	// slewed at rest and frozen on all three axes for the sweep, and
+	// released after it
+	var events []string
+	for _, c := range dll.Calls("SimConnect_TransmitClientEvent") {
+		events = append(events, fmt.Sprintf("%s=%d", ac.events[client.DWORD(c.Args[2])], c.Args[3]))
+	}
+	want := []string{
+		"SLEW_ON=0",
+		"AXIS_SLEW_AHEAD_SET=0", "AXIS_SLEW_SIDEWAYS_SET=0", "AXIS_SLEW_HEADING_SET=0",
+		"AXIS_SLEW_ALT_SET=0", "AXIS_SLEW_BANK_SET=0", "AXIS_SLEW_PITCH_SET=0",
+		"FREEZE_LATITUDE_LONGITUDE_SET=1", "FREEZE_ALTITUDE_SET=1", "FREEZE_ATTITUDE_SET=1",
+		"FREEZE_LATITUDE_LONGITUDE_SET=0", "FREEZE_ALTITUDE_SET=0", "FREEZE_ATTITUDE_SET=0",
+		"SLEW_OFF=0",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events %v, want %v", events, want)
+	}
+
+	var csv bytes.Buffer
+	if err := flightmodel.WriteCSV(&csv, points); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 8 || !strings.HasPrefix(lines[1], "40,40,44,15,") || !strings.HasSuffix(lines[0], ",lift,drag,cl,cd") {
+		t.Fatalf("csv:\n%s", csv.String())
+	}
+}
+
+func TestAlphaSweep(t *testing.T) {
+	ac := &fakeAircraft{}
+	_, p := startProbe(t, ac)
+	points, err := p.Run(context.Background(), flightmodel.Sweep{Param: flightmodel.ParamAlpha, From: 0, To: 10, Step: 5, Airspeed: 100})
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if len(points) != 3 {
+		t.Fatalf("%d points, want 3", len(points))
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for i, pt := range points {
+		s := pt.Sample
+		if !near(s.Alpha, pt.Setpoint) || !near(s.IndicatedAirspeed, 100) {
+			t.Fatalf("point %d: alpha %v at %v knots, want %v at 100", i, s.Alpha, s.IndicatedAirspeed, pt.Setpoint)
+		}
+		// level and unaccelerated, the air holds up the whole weight, split
+		// between lift and drag by the alpha
+		a := pt.Setpoint * math.Pi / 180
+		if !near(s.Lift, 2000*math.Cos(a)) || !near(s.Drag, 2000*math.Sin(a)) {
+			t.Fatalf("point %d: lift %v drag %v", i, s.Lift, s.Drag)
+		}
+		if !near(s.CL, s.Lift/5000) || !near(s.CD, s.Drag/5000) {
+			t.Fatalf("point %d: cl %v cd %v", i, s.CL, s.CD)
+		}
+	}
+
+	if _, err := p.Run(context.Background(), flightmodel.Sweep{Param: flightmodel.ParamAlpha, From: 0, To: 10, Step: 5}); err == nil {
+		t.Fatalf("alpha sweep without an airspeed: no error")
+	}
+}
+
+func TestPitchSweep(t *testing.T) {
+	ac := &fakeAircraft{velocity: 120}
+	_, p := startProbe(t, ac)
+	points, err := p.Run(context.Background(), flightmodel.Sweep{Param: flightmodel.ParamPitch, From: -5, To: 5, Step: 5})
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	for i, pt := range points {
+		// the sweep is positive nose up, the sim positive nose down
+		if pt.Sample.Pitch != pt.Setpoint {
+			t.Fatalf("point %d: pitch %v at setpoint %v", i, pt.Sample.Pitch, pt.Setpoint)
+		}
+	}
+	if ac.pitch != -5 {
+		t.Fatalf("sim pitch %v after sweeping to 5 nose up, want -5", ac.pitch)
+	}
+}
+
+func TestInvalidSweep(t *testing.T) {
+	_, p := startProbe(t, &fakeAircraft{})
+	if _, err := p.Run(context.Background(), flightmodel.Sweep{Param: flightmodel.ParamAirspeed, From: 100, To: 40, Step: 10}); err == nil {
+		t.Fatalf("sweep running backwards: no error")
+	}
+}
@@ -0,0 +1,335 @@
+// Package formation computes station keeping guidance from a lead AI or
+// multiplayer object, for formation and aerial refueling practice
+//
+// The Keeper samples the lead and the user aircraft, places the station at
+// an Offset from the lead and reports how far the user aircraft is from it
+// with heading, speed and vertical speed commands to fly it. With driving
+// on, the user aircraft is frozen and moved to the station every sample.
+package formation
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/geo"
+)
+
+// FormationError is an error returned by the keeper
+type FormationError string
+
+func (e FormationError) Error() string {
+	return string(e)
+}
+
+const (
+	// ErrNotStarted is returned before the connector has started the keeper
+	ErrNotStarted FormationError = "keeper not started"
+)
+
+// LeadReport is the state of the lead, requested on its object ID
+type LeadReport struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude  float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	Heading   float64 `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees"`
+	Pitch     float64 `name:"PLANE PITCH DEGREES" unit:"Degrees"`
+	Bank      float64 `name:"PLANE BANK DEGREES" unit:"Degrees"`
+	Speed     float64 `name:"GROUND VELOCITY" unit:"Knots"`
+}
+
+// OwnReport is the state of the user aircraft
+type OwnReport struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude  float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	Heading   float64 `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees"`
+	Pitch     float64 `name:"PLANE PITCH DEGREES" unit:"Degrees"`
+	Bank      float64 `name:"PLANE BANK DEGREES" unit:"Degrees"`
+	Speed     float64 `name:"GROUND VELOCITY" unit:"Knots"`
+}
+
+// PlacementRequest is the data structure to move the user aircraft to the station
+type PlacementRequest struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude  float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	Heading   float64 `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees"`
+	Pitch     float64 `name:"PLANE PITCH DEGREES" unit:"Degrees"`
+	Bank      float64 `name:"PLANE BANK DEGREES" unit:"Degrees"`
+}
+
+// state converts a report; the sim reports pitch positive nose down
+func state(lat, lon, alt, heading, pitch, bank, speed float64) State {
+	return State{
+		Position: geo.Position{Latitude: lat, Longitude: lon, Altitude: alt},
+		Heading:  heading,
+		Pitch:    -pitch,
+		Bank:     bank,
+		Speed:    speed,
+	}
+}
+
+// Guidance is the station keeping solution for one sample
+type Guidance struct {
+	Lead    State
+	Own     State
+	Station geo.Position
+	// Error is where the user aircraft is relative to the station, in the lead's frame
+	Error Offset
+	// Range is the distance to the lead in nautical miles
+	Range float64
+	// Closure is the rate the range shrinks in knots, negative when opening
+	Closure float64
+	// the commands to fly the station
+	HeadingCommand       float64 // true, degrees
+	SpeedCommand         float64 // ground speed, knots
+	VerticalSpeedCommand float64 // feet per minute
+	At                   time.Time
+}
+
+// the command gains: knots per foot ahead, degrees per foot right and
+// feet per minute per foot above, with their limits
+const (
+	speedGain    = 0.01
+	speedLimit   = 30
+	headingGain  = 0.02
+	headingLimit = 30
+	climbGain    = 5
+	climbLimit   = 2000
+)
+
+var freezeEvents = []string{
+	"FREEZE_LATITUDE_LONGITUDE_SET",
+	"FREEZE_ALTITUDE_SET",
+	"FREEZE_ATTITUDE_SET",
+}
+
+// Keeper is a receiver that computes station keeping guidance
+type Keeper struct {
+	interval   time.Duration
+	onGuidance func(Guidance)
+
+	mu        sync.Mutex
+	sc        *client.SimConnect
+	events    map[string]client.DWORD
+	requestID client.DWORD
+	lead      client.DWORD
+	hasLead   bool
+	offset    Offset
+	own       *State
+	guidance  *Guidance
+	drive     bool
+}
+
+// Option is a function that sets options on the Keeper
+type Option func(*Keeper)
+
+// WithInterval sets how often the lead and user aircraft are sampled; the default is 100ms
+func WithInterval(d time.Duration) Option {
+	return func(k *Keeper) {
+		k.interval = d
+	}
+}
+
+// WithOffset sets the station relative to the lead
+func WithOffset(o Offset) Option {
+	return func(k *Keeper) {
+		k.offset = o
+	}
+}
+
+// WithOnGuidance sets a callback that is called with every solution
+// it runs on the dispatch goroutine, so it must not block
+func WithOnGuidance(fn func(Guidance)) Option {
+	return func(k *Keeper) {
+		k.onGuidance = fn
+	}
+}
+
+// New creates a new Keeper
+func New(opts ...Option) *Keeper {
+	k := &Keeper{interval: 100 * time.Millisecond}
+	for _, o := range opts {
+		o(k)
+	}
+	return k
+}
+
+// SetLead selects the lead by object ID, eg from AICreate* or the traffic list
+func (k *Keeper) SetLead(objectID client.DWORD) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.lead = objectID
+	k.hasLead = true
+	k.guidance = nil
+}
+
+// SetOffset moves the station
+func (k *Keeper) SetOffset(o Offset) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.offset = o
+}
+
+// Guidance returns the latest solution; false before the first one
+func (k *Keeper) Guidance() (Guidance, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.guidance == nil {
+		return Guidance{}, false
+	}
+	return *k.guidance, true
+}
+
+// SetDrive freezes the user aircraft and places it on the station every
+// sample, or releases it
+func (k *Keeper) SetDrive(on bool) error {
+	k.mu.Lock()
+	sc, events := k.sc, k.events
+	k.drive = on
+	k.mu.Unlock()
+	if sc == nil {
+		return ErrNotStarted
+	}
+	var data client.DWORD
+	if on {
+		data = 1
+	}
+	for _, name := range freezeEvents {
+		if err := sc.TransmitClientEvent(client.OBJECT_ID_USER, events[name], data, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start registers the definitions and samples the lead and user aircraft
+func (k *Keeper) Start(ctx context.Context, sc *client.SimConnect) {
+	for _, def := range []any{&LeadReport{}, &OwnReport{}, &PlacementRequest{}} {
+		if err := sc.RegisterDataDefinition(def); err != nil {
+			slog.Error("Cannot register formation definition", "error", err)
+			return
+		}
+	}
+	events := map[string]client.DWORD{}
+	for _, name := range freezeEvents {
+		id := sc.GetEventID()
+		if err := sc.MapClientEventToSimEvent(id, name); err != nil {
+			slog.Error("Cannot map freeze event", "event", name, "error", err)
+			return
+		}
+		events[name] = id
+	}
+	leadID := sc.GetDefineID(&LeadReport{})
+
+	k.mu.Lock()
+	k.sc = sc
+	k.events = events
+	k.requestID = sc.NewRequestID()
+	k.own = nil
+	k.guidance = nil
+	k.drive = false
+	requestID := k.requestID
+	k.mu.Unlock()
+
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				k.mu.Lock()
+				k.sc = nil
+				k.mu.Unlock()
+				return
+			case <-time.After(k.interval):
+				if err := simconnect.RequestData[OwnReport](sc); err != nil {
+					slog.Error("Cannot request own state", "error", err)
+				}
+				k.mu.Lock()
+				lead, hasLead := k.lead, k.hasLead
+				k.mu.Unlock()
+				if !hasLead {
+					continue
+				}
+				if err := sc.RequestDataOnSimObject(requestID, leadID, lead, client.PERIOD_ONCE, client.DATA_REQUEST_FLAG_DEFAULT, 0, 0, 0); err != nil {
+					slog.Error("Cannot request lead state", "objectID", lead, "error", err)
+				}
+			}
+		}
+	})
+}
+
+// Update records the user aircraft and solves for the station on every lead sample
+func (k *Keeper) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	if r, ok := simconnect.IsReport[OwnReport](sc, ppData); ok {
+		s := state(r.Latitude, r.Longitude, r.Altitude, r.Heading, r.Pitch, r.Bank, r.Speed)
+		k.mu.Lock()
+		k.own = &s
+		k.mu.Unlock()
+		return
+	}
+	r, ok := simconnect.IsReport[LeadReport](sc, ppData)
+	if !ok {
+		return
+	}
+	lead := state(r.Latitude, r.Longitude, r.Altitude, r.Heading, r.Pitch, r.Bank, r.Speed)
+
+	k.mu.Lock()
+	if !k.hasLead || ppData.ObjectID != k.lead || k.own == nil {
+		k.mu.Unlock()
+		return
+	}
+	g := solve(lead, *k.own, k.offset, k.guidance)
+	k.guidance = &g
+	drive := k.drive
+	k.mu.Unlock()
+
+	if drive {
+		err := sc.SetData(&PlacementRequest{
+			Latitude:  g.Station.Latitude,
+			Longitude: g.Station.Longitude,
+			Altitude:  g.Station.Altitude,
+			Heading:   r.Heading,
+			Pitch:     r.Pitch,
+			Bank:      r.Bank,
+		})
+		if err != nil {
+			slog.Error("Cannot place aircraft on station", "error", err)
+		}
+	}
+	if k.onGuidance != nil {
+		k.onGuidance(g)
+	}
+}
+
+// solve computes the guidance; prev gives the closure rate
+func solve(lead, own State, offset Offset, prev *Guidance) Guidance {
+	g := Guidance{
+		Lead:    lead,
+		Own:     own,
+		Station: At(lead, offset),
+		Error:   Relative(lead, own.Position).Sub(offset),
+		Range:   geo.Distance(lead.Position, own.Position),
+		At:      time.Now(),
+	}
+	if prev != nil {
+		if dt := g.At.Sub(prev.At).Hours(); dt > 0 {
+			g.Closure = (prev.Range - g.Range) / dt
+		}
+	}
+	g.SpeedCommand = lead.Speed - clamp(g.Error.Forward*speedGain, speedLimit)
+	g.HeadingCommand = geo.Normalize(lead.Heading - clamp(g.Error.Right*headingGain, headingLimit))
+	g.VerticalSpeedCommand = -clamp(g.Error.Up*climbGain, climbLimit)
+	return g
+}
+
+func clamp(v, limit float64) float64 {
+	return math.Max(-limit, math.Min(limit, v))
+}
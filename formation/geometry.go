@@ -0,0 +1,57 @@
+package formation
+
+import (
+	"math"
+
+	"github.com/bmurray/simconnect-go/geo"
+)
+
+// feetPerNM converts the nautical miles of package geo to feet
+const feetPerNM = 6076.12
+
+// State is the position and attitude of an aircraft
+type State struct {
+	geo.Position
+	Heading float64 // true, degrees
+	Pitch   float64 // degrees, positive nose up
+	Bank    float64 // degrees, as PLANE BANK DEGREES reports it
+	Speed   float64 // ground speed, knots
+}
+
+// Offset is a position relative to an aircraft along its heading, in feet
+// the frame turns with the heading but ignores pitch and bank, which is
+// close enough for station keeping in level or gently banked flight
+type Offset struct {
+	Forward float64 // positive ahead
+	Right   float64 // positive right
+	Up      float64 // positive above
+}
+
+// Sub returns the offset from b to o
+func (o Offset) Sub(b Offset) Offset {
+	return Offset{Forward: o.Forward - b.Forward, Right: o.Right - b.Right, Up: o.Up - b.Up}
+}
+
+// Distance returns the straight line length of the offset in feet
+func (o Offset) Distance() float64 {
+	return math.Sqrt(o.Forward*o.Forward + o.Right*o.Right + o.Up*o.Up)
+}
+
+// Relative returns where p is relative to the reference aircraft
+func Relative(ref State, p geo.Position) Offset {
+	d := geo.Distance(ref.Position, p) * feetPerNM
+	a := (geo.Bearing(ref.Position, p) - ref.Heading) * math.Pi / 180
+	return Offset{
+		Forward: d * math.Cos(a),
+		Right:   d * math.Sin(a),
+		Up:      p.Altitude - ref.Altitude,
+	}
+}
+
+// At returns the position at the offset from the reference aircraft
+func At(ref State, o Offset) geo.Position {
+	p := geo.Destination(ref.Position, ref.Heading, o.Forward/feetPerNM)
+	p = geo.Destination(p, geo.Normalize(ref.Heading+90), o.Right/feetPerNM)
+	p.Altitude = ref.Altitude + o.Up
+	return p
+}
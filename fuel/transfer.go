@@ -0,0 +1,310 @@
+// Package fuel moves fuel between tanks for aircraft without their own
+// transfer logic
+//
+// A transfer runs as a series of small writes to the tank quantities, at
+// a rate in gallons per minute, so gauges and weight change the way they
+// would with a real pump. Transfers follow the sim rate and stop while the
+// sim is paused.
+package fuel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// FuelError is the error type for the package
+type FuelError string
+
+func (e FuelError) Error() string { return string(e) }
+
+const (
+	// ErrNotStarted is returned before the connector has started the scheduler
+	ErrNotStarted FuelError = "scheduler not started"
+	// ErrSameTank is returned for a transfer from a tank to itself
+	ErrSameTank FuelError = "transfer to the same tank"
+	// ErrCancelled is the error of a cancelled transfer
+	ErrCancelled FuelError = "transfer cancelled"
+)
+
+// Tank names a fuel tank as it appears in the FUEL TANK simvars
+type Tank string
+
+const (
+	Center    Tank = "CENTER"
+	Center2   Tank = "CENTER2"
+	Center3   Tank = "CENTER3"
+	LeftMain  Tank = "LEFT MAIN"
+	LeftAux   Tank = "LEFT AUX"
+	LeftTip   Tank = "LEFT TIP"
+	RightMain Tank = "RIGHT MAIN"
+	RightAux  Tank = "RIGHT AUX"
+	RightTip  Tank = "RIGHT TIP"
+	External1 Tank = "EXTERNAL1"
+	External2 Tank = "EXTERNAL2"
+)
+
+// Quantity returns the simvar holding the fuel in the tank
+func (t Tank) Quantity() string { return "FUEL TANK " + string(t) + " QUANTITY" }
+
+// Capacity returns the simvar holding the size of the tank
+func (t Tank) Capacity() string { return "FUEL TANK " + string(t) + " CAPACITY" }
+
+// Transfer describes fuel to move from one tank to another
+type Transfer struct {
+	From Tank
+	To   Tank
+	// Rate is the pump rate in gallons per minute
+	Rate float64
+	// Amount is the gallons to move; zero moves until From is empty or To is full
+	Amount float64
+}
+
+// Job is a running transfer
+type Job struct {
+	Transfer
+
+	mu    sync.Mutex
+	moved float64
+	err   error
+	done  chan struct{}
+}
+
+func (j *Job) finish(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	select {
+	case <-j.done:
+		return
+	default:
+	}
+	j.err = err
+	close(j.done)
+}
+
+// Cancel stops the transfer, leaving the fuel moved so far where it is
+func (j *Job) Cancel() { j.finish(ErrCancelled) }
+
+// Done is closed when the transfer finishes or is cancelled
+func (j *Job) Done() <-chan struct{} { return j.done }
+
+// Moved returns the gallons moved so far
+func (j *Job) Moved() float64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.moved
+}
+
+// Err returns nil once the transfer has completed, or why it stopped
+func (j *Job) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Scheduler is a receiver that runs fuel transfers
+type Scheduler struct {
+	interval   time.Duration
+	onComplete func(*Job)
+
+	mu      sync.Mutex
+	sc      *client.SimConnect
+	jobs    []*Job
+	paused  bool
+	pauseID client.DWORD
+}
+
+// Option is a function that sets options on the Scheduler
+type Option func(*Scheduler)
+
+// WithInterval sets how often the tanks are written; the default is 1s
+// shorter intervals give smoother gauges at the cost of more writes
+func WithInterval(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.interval = d
+	}
+}
+
+// WithOnComplete sets a function called when a transfer stops for any reason
+func WithOnComplete(fn func(*Job)) Option {
+	return func(s *Scheduler) {
+		s.onComplete = fn
+	}
+}
+
+// New creates a new Scheduler
+func New(opts ...Option) *Scheduler {
+	s := &Scheduler{
+		interval: time.Second,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Start subscribes to the Pause event and starts running transfers
+// transfers scheduled before a reconnect carry on afterwards
+func (s *Scheduler) Start(ctx context.Context, sc *client.SimConnect) {
+	pauseID, err := sc.SubscribeToSystemEventByName("Pause")
+	if err != nil {
+		slog.Error("Cannot subscribe to Pause", "error", err)
+		return
+	}
+	s.mu.Lock()
+	s.sc = sc
+	s.pauseID = pauseID
+	s.paused = false
+	s.mu.Unlock()
+
+	simconnect.Go(ctx, func(ctx context.Context) {
+		last := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				s.mu.Lock()
+				s.sc = nil
+				s.mu.Unlock()
+				return
+			case now := <-time.After(s.interval):
+				s.step(ctx, sc, now.Sub(last))
+				last = now
+			}
+		}
+	})
+}
+
+func (s *Scheduler) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// Event tracks the pause state
+func (s *Scheduler) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ev.EventID == s.pauseID {
+		s.paused = client.DecodeSystemEvent("Pause", ev.Data).(client.PauseEvent).Paused
+	}
+}
+
+// Schedule starts a transfer
+// transfers sharing a tank run together, each at its own rate
+func (s *Scheduler) Schedule(t Transfer) (*Job, error) {
+	if t.From == t.To {
+		return nil, ErrSameTank
+	}
+	if t.Rate <= 0 {
+		return nil, fmt.Errorf("transfer from %s to %s has no rate", t.From, t.To)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sc == nil {
+		return nil, ErrNotStarted
+	}
+	j := &Job{Transfer: t, done: make(chan struct{})}
+	s.jobs = append(s.jobs, j)
+	return j, nil
+}
+
+// Jobs returns the transfers that are still running
+func (s *Scheduler) Jobs() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Job(nil), s.jobs...)
+}
+
+// step moves the fuel for elapsed wall time
+func (s *Scheduler) step(ctx context.Context, sc *client.SimConnect, elapsed time.Duration) {
+	rate, err := sc.ReadFloat(ctx, "SIMULATION RATE", "Number")
+	if err != nil {
+		slog.Error("Cannot read simulation rate", "error", err)
+		return
+	}
+	s.mu.Lock()
+	paused := s.paused
+	jobs := append([]*Job(nil), s.jobs...)
+	s.mu.Unlock()
+	if paused {
+		return
+	}
+	minutes := elapsed.Minutes() * rate
+
+	for _, j := range jobs {
+		select {
+		case <-j.done:
+			s.remove(j)
+			continue
+		default:
+		}
+		if err := s.move(ctx, sc, j, j.Rate*minutes); err != nil {
+			slog.Error("Cannot transfer fuel", "from", j.From, "to", j.To, "error", err)
+		}
+	}
+}
+
+// move takes up to gallons from one tank to the other, less if the source
+// runs dry, the destination fills or the amount is reached
+func (s *Scheduler) move(ctx context.Context, sc *client.SimConnect, j *Job, gallons float64) error {
+	from, err := sc.ReadFloat(ctx, j.From.Quantity(), "Gallons")
+	if err != nil {
+		return err
+	}
+	to, err := sc.ReadFloat(ctx, j.To.Quantity(), "Gallons")
+	if err != nil {
+		return err
+	}
+	capacity, err := sc.ReadFloat(ctx, j.To.Capacity(), "Gallons")
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	moved := j.moved
+	j.mu.Unlock()
+	limit := math.Inf(1)
+	if j.Amount > 0 {
+		limit = j.Amount - moved
+	}
+	gallons = math.Min(gallons, math.Min(limit, math.Min(from, capacity-to)))
+
+	if gallons > 0 {
+		if err := sc.WriteFloat(ctx, j.From.Quantity(), "Gallons", from-gallons); err != nil {
+			return err
+		}
+		if err := sc.WriteFloat(ctx, j.To.Quantity(), "Gallons", to+gallons); err != nil {
+			return err
+		}
+		j.mu.Lock()
+		j.moved += gallons
+		j.mu.Unlock()
+	}
+
+	// tiny remainders would never finish through float rounding
+	const epsilon = 0.001
+	if from-gallons < epsilon || capacity-to-gallons < epsilon || (j.Amount > 0 && limit-gallons < epsilon) {
+		j.finish(nil)
+		s.remove(j)
+	}
+	return nil
+}
+
+func (s *Scheduler) remove(j *Job) {
+	s.mu.Lock()
+	found := false
+	for i, o := range s.jobs {
+		if o == j {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			found = true
+			break
+		}
+	}
+	s.mu.Unlock()
+	if found && s.onComplete != nil {
+		s.onComplete(j)
+	}
+}
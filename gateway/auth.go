@@ -0,0 +1,105 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Permission is what a client may do through the gateway
+type Permission uint8
+
+const (
+	// PermRead allows reading state, streaming events and listing commands
+	PermRead Permission = 1 << iota
+	// PermEvents allows commands that send events to the sim
+	PermEvents
+	// PermData allows commands that set data on the sim
+	PermData
+
+	// PermAll allows everything
+	PermAll = PermRead | PermEvents | PermData
+)
+
+var permissionNames = map[string]Permission{
+	"read":   PermRead,
+	"events": PermEvents,
+	"data":   PermData,
+	"all":    PermAll,
+}
+
+// ParsePermissions parses permission names: read, events, data or all
+func ParsePermissions(names []string) (Permission, error) {
+	var p Permission
+	for _, n := range names {
+		v, ok := permissionNames[strings.ToLower(n)]
+		if !ok {
+			return 0, fmt.Errorf("unknown permission %q", n)
+		}
+		p |= v
+	}
+	return p, nil
+}
+
+// Has returns true if p includes every permission in want
+func (p Permission) Has(want Permission) bool {
+	return p&want == want
+}
+
+// Client is a token and what it may do
+type Client struct {
+	Name        string   `json:"name"`
+	Token       string   `json:"token"`
+	Permissions []string `json:"permissions"`
+}
+
+type permissionKey struct{}
+
+// PermissionFromContext returns the permissions of the client making a
+// command request; commands can use it to check finer grained access
+func PermissionFromContext(ctx context.Context) Permission {
+	p, _ := ctx.Value(permissionKey{}).(Permission)
+	return p
+}
+
+// secured returns true if the config requires a token
+func (c Config) secured() bool {
+	return len(c.Tokens) > 0 || len(c.Clients) > 0
+}
+
+// authorize returns the permissions of the request's token
+// without tokens configured every request has full access; Tokens have full
+// access and Clients have their listed permissions
+// the token can also be passed as the token query parameter for clients
+// such as EventSource that cannot set headers
+func (c Config) authorize(r *http.Request) (string, Permission, bool) {
+	if !c.secured() {
+		return "", PermAll, true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		return "", 0, false
+	}
+	for _, t := range c.Tokens {
+		if tokenEqual(t, token) {
+			return "", PermAll, true
+		}
+	}
+	for _, cl := range c.Clients {
+		if tokenEqual(cl.Token, token) {
+			// permissions are checked when the config is loaded
+			p, _ := ParsePermissions(cl.Permissions)
+			return cl.Name, p, true
+		}
+	}
+	return "", 0, false
+}
+
+func tokenEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,199 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Config is the reloadable part of the server's setup
+// empty lists allow everything, so the zero Config is the default
+type Config struct {
+	// Topics are the topics exposed
+	Topics []string `json:"topics,omitempty"`
+	// Commands are the commands that may be invoked
+	Commands []string `json:"commands,omitempty"`
+	// Tokens are bearer tokens with full access
+	Tokens []string `json:"tokens,omitempty"`
+	// Clients are bearer tokens with limited permissions
+	Clients []Client `json:"clients,omitempty"`
+	// Subscriptions are publish intervals by topic, eg "aircraft": "500ms";
+	// the server does not use them, publishers read them in OnConfig
+	Subscriptions map[string]string `json:"subscriptions,omitempty"`
+}
+
+// Interval returns the publish interval for the topic
+func (c Config) Interval(topic string) (time.Duration, bool) {
+	s, ok := c.Subscriptions[topic]
+	if !ok {
+		return 0, false
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
+
+func (c Config) allowTopic(topic string) bool {
+	return len(c.Topics) == 0 || contains(c.Topics, topic)
+}
+
+func (c Config) allowCommand(name string) bool {
+	return len(c.Commands) == 0 || contains(c.Commands, name)
+}
+
+func (c Config) validate() error {
+	for _, cl := range c.Clients {
+		if cl.Token == "" {
+			return fmt.Errorf("client %s has no token", cl.Name)
+		}
+		if _, err := ParsePermissions(cl.Permissions); err != nil {
+			return fmt.Errorf("client %s: %w", cl.Name, err)
+		}
+	}
+	for topic := range c.Subscriptions {
+		if _, ok := c.Interval(topic); !ok {
+			return fmt.Errorf("invalid interval for topic %s: %q", topic, c.Subscriptions[topic])
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WithConfig sets the initial config
+func WithConfig(c Config) Option {
+	return func(s *Server) {
+		s.config = c
+	}
+}
+
+// OnConfig is called with the config whenever it is set or reloaded
+// use it to change what is published without reconnecting
+func OnConfig(f func(Config)) Option {
+	return func(s *Server) {
+		s.onConfig = append(s.onConfig, f)
+	}
+}
+
+// LoadConfig reads a JSON config file
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	return c, c.validate()
+}
+
+// Config returns the current config
+func (s *Server) Config() Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// SetConfig replaces the config; open event streams pick it up from their next message
+func (s *Server) SetConfig(c Config) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.config = c
+	hooks := s.onConfig
+	// open streams check their tokens against the new config
+	s.notify(message{reauth: true})
+	s.mu.Unlock()
+	for _, f := range hooks {
+		f(c)
+	}
+	return nil
+}
+
+// WatchConfig reloads the config file when it changes on disk, on SIGHUP,
+// and on POST /config/reload; Windows never delivers SIGHUP, so there the
+// endpoint is the way to reload at once
+// a config that fails to load is logged and the current one kept
+// it blocks until the context is cancelled
+func (s *Server) WatchConfig(ctx context.Context, path string, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	s.mu.Lock()
+	s.configPath = path
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.configPath = ""
+		s.mu.Unlock()
+	}()
+
+	var modTime time.Time
+	if st, err := os.Stat(path); err == nil {
+		modTime = st.ModTime()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			s.reloadConfig(path, "signal")
+		case <-time.After(interval):
+			st, err := os.Stat(path)
+			if err != nil || st.ModTime().Equal(modTime) {
+				continue
+			}
+			modTime = st.ModTime()
+			s.reloadConfig(path, "changed")
+		}
+	}
+}
+
+// reloadConfig loads and sets the config file, logging the outcome
+func (s *Server) reloadConfig(path, reason string) error {
+	c, err := LoadConfig(path)
+	if err == nil {
+		err = s.SetConfig(c)
+	}
+	if err != nil {
+		s.log.Error("Cannot reload config", "path", path, "reason", reason, "error", err)
+		return err
+	}
+	s.log.Info("Reloaded config", "path", path, "reason", reason)
+	return nil
+}
+
+// handleReload reloads the watched config file; it needs PermData, as the
+// file decides every client's access
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if !PermissionFromContext(r.Context()).Has(PermData) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("reload not permitted"))
+		return
+	}
+	s.mu.RLock()
+	path := s.configPath
+	s.mu.RUnlock()
+	if path == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no config file is watched"))
+		return
+	}
+	if err := s.reloadConfig(path, "request"); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,331 @@
+// Package gateway exposes sim state and commands over HTTP
+//
+// State is published to named topics and can be read as JSON or streamed
+// with server-sent events; commands are registered by name and invoked
+// with a JSON body. Both also go over a WebSocket, for clients such as an
+// instructor station that watch and command over one connection; see
+// /socket below for its messages. The server is independent of the connection, so it
+// keeps serving across reconnects. Topics, commands and auth tokens can be
+// reloaded from a config file without restarting; see WatchConfig.
+//
+// Once tokens are configured every request needs one. A token carries
+// permissions: read for state and events, and events or data for the
+// commands that need them, so a LAN display can watch without being able
+// to fly the aircraft.
+//
+//	GET  /state            latest value of every topic
+//	GET  /state/{topic}    latest value of a topic
+//	GET  /events           server-sent events; ?topic=a&topic=b filters
+//	GET  /commands         names of the registered commands
+//	POST /commands/{name}  invokes a command with the request body as arguments
+//	GET  /socket           WebSocket of events and commands; ?topic=a filters
+//	POST /config/reload    reloads the file WatchConfig watches; needs data
+//
+// On the WebSocket every message is a JSON object with a type. The server
+// sends the latest value of each topic on connect and every publish after,
+// as {"type": "event", "topic": ..., "data": ...}. A client sends
+// {"type": "command", "id": ..., "name": ..., "args": ...} and gets back
+// {"type": "result", "id": ..., "result": ...}, or "error" in its place,
+// and can narrow the topics with {"type": "subscribe", "topics": [...]}.
+// A token removed or stripped of read by a config reload closes its
+// event streams and WebSockets.
+//
+// Other handlers, eg an overlay page, can be served alongside with Mount.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Command is a remotely invokable action
+// args is the raw JSON request body, and may be empty
+// the result is encoded as JSON in the response
+type Command func(ctx context.Context, args json.RawMessage) (any, error)
+
+// Server is an HTTP gateway to the sim
+type Server struct {
+	mux *http.ServeMux
+	log *slog.Logger
+
+	mu          sync.RWMutex
+	commands    map[string]command
+	state       map[string]json.RawMessage
+	subscribers map[chan message]struct{}
+	config      Config
+	configPath  string
+	onConfig    []func(Config)
+}
+
+type command struct {
+	fn   Command
+	perm Permission
+}
+
+type message struct {
+	topic string
+	data  json.RawMessage
+	// reauth asks streams to check their token again, after a config change
+	reauth bool
+}
+
+// Option is a function that sets options on the Server
+type Option func(*Server)
+
+// WithLogger sets the logger for the server
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) {
+		s.log = l.With("module", "gateway")
+	}
+}
+
+// New creates a new gateway server
+func New(opts ...Option) *Server {
+	s := &Server{
+		mux:         http.NewServeMux(),
+		log:         slog.Default().With("module", "gateway"),
+		commands:    map[string]command{},
+		state:       map[string]json.RawMessage{},
+		subscribers: map[chan message]struct{}{},
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	s.mux.HandleFunc("GET /state", s.handleState)
+	s.mux.HandleFunc("GET /state/{topic}", s.handleTopic)
+	s.mux.HandleFunc("GET /events", s.handleEvents)
+	s.mux.HandleFunc("GET /commands", s.handleCommands)
+	s.mux.HandleFunc("POST /commands/{name}", s.handleCommand)
+	s.mux.HandleFunc("GET /socket", s.handleSocket)
+	s.mux.HandleFunc("POST /config/reload", s.handleReload)
+	return s
+}
+
+// Handle registers a command that needs PermData, the most trusted
+// permission; registering a name again replaces the command
+func (s *Server) Handle(name string, cmd Command) {
+	s.HandlePermission(name, PermData, cmd)
+}
+
+// HandlePermission registers a command that needs perm
+func (s *Server) HandlePermission(name string, perm Permission, cmd Command) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands[name] = command{fn: cmd, perm: perm}
+}
+
+// Mount serves another handler on the gateway, behind the same auth
+// GET requests need PermRead like the state endpoints
+func (s *Server) Mount(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// Publish sets the latest value of a topic and sends it to subscribers
+// slow subscribers miss updates rather than blocking the publisher
+func (s *Server) Publish(topic string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cannot encode topic %s: %w", topic, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state[topic] = data
+	s.notify(message{topic: topic, data: data})
+	return nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	client, perm, ok := s.Config().authorize(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	// every endpoint but invoking a command reads; commands check their own
+	if r.Method == http.MethodGet && !perm.Has(PermRead) {
+		s.log.Warn("Request denied", "client", client, "path", r.URL.Path)
+		writeError(w, http.StatusForbidden, fmt.Errorf("read not permitted"))
+		return
+	}
+	r = r.WithContext(context.WithValue(r.Context(), permissionKey{}, perm))
+	s.mux.ServeHTTP(w, r)
+}
+
+// mayRead returns true if the request's token still allows reading under
+// the current config
+func (s *Server) mayRead(r *http.Request) bool {
+	_, perm, ok := s.Config().authorize(r)
+	return ok && perm.Has(PermRead)
+}
+
+// notify sends m to every subscriber that has room for it
+// the caller holds s.mu
+func (s *Server) notify(m message) {
+	for ch := range s.subscribers {
+		select {
+		case ch <- m:
+		default:
+		}
+	}
+}
+
+// ListenAndServe serves the gateway on addr until the context is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	// requests, and so WebSockets, end with ctx too
+	srv := &http.Server{Addr: addr, Handler: s, BaseContext: func(net.Listener) context.Context { return ctx }}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	s.log.Info("Gateway listening", "addr", addr)
+	if !s.Config().secured() {
+		s.log.Warn("Gateway has no tokens configured; anyone who can reach it has full access", "addr", addr)
+	}
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	out := make(map[string]json.RawMessage, len(s.state))
+	for k, v := range s.state {
+		if s.config.allowTopic(k) {
+			out[k] = v
+		}
+	}
+	s.mu.RUnlock()
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleTopic(w http.ResponseWriter, r *http.Request) {
+	topic := r.PathValue("topic")
+	s.mu.RLock()
+	data, ok := s.state[topic]
+	ok = ok && s.config.allowTopic(topic)
+	s.mu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown topic %s", topic))
+		return
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+	filter := map[string]bool{}
+	for _, t := range r.URL.Query()["topic"] {
+		filter[t] = true
+	}
+
+	ch := make(chan message, 64)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m := <-ch:
+			// the token may have been revoked or downgraded since the stream opened
+			if !s.mayRead(r) {
+				s.log.Info("Event stream closed, read no longer permitted", "path", r.URL.Path)
+				return
+			}
+			if m.reauth {
+				continue
+			}
+			if len(filter) > 0 && !filter[m.topic] || !s.Config().allowTopic(m.topic) {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", m.topic, m.data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) handleCommands(w http.ResponseWriter, r *http.Request) {
+	perm := PermissionFromContext(r.Context())
+	s.mu.RLock()
+	names := make([]string, 0, len(s.commands))
+	for n, cmd := range s.commands {
+		if s.config.allowCommand(n) && perm.Has(cmd.perm) {
+			names = append(names, n)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
+	var args json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid arguments: %w", err))
+		return
+	}
+	res, status, err := s.invoke(r.Context(), r.PathValue("name"), PermissionFromContext(r.Context()), args)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// invoke runs a command for a client with perm, returning the HTTP status
+// of the failure with its error
+func (s *Server) invoke(ctx context.Context, name string, perm Permission, args json.RawMessage) (any, int, error) {
+	s.mu.RLock()
+	cmd, ok := s.commands[name]
+	ok = ok && s.config.allowCommand(name)
+	s.mu.RUnlock()
+	if !ok {
+		return nil, http.StatusNotFound, fmt.Errorf("unknown command %s", name)
+	}
+	if !perm.Has(cmd.perm) {
+		s.log.Warn("Command denied", "command", name)
+		return nil, http.StatusForbidden, fmt.Errorf("command %s not permitted", name)
+	}
+	res, err := cmd.fn(context.WithValue(ctx, permissionKey{}, perm), args)
+	if err != nil {
+		s.log.Warn("Command failed", "command", name, "error", err)
+		return nil, http.StatusInternalServerError, err
+	}
+	return res, http.StatusOK, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
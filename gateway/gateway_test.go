@@ -0,0 +1,175 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openStream opens the event stream with a token and returns its lines
+// until the stream or ctx ends
+func openStream(ctx context.Context, t *testing.T, url, token string) <-chan string {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url+"/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("open stream: %s", resp.Status)
+	}
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer resp.Body.Close()
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			select {
+			case lines <- sc.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return lines
+}
+
+// next returns the next data line of the stream, or false once it ends
+func next(t *testing.T, lines <-chan string) (string, bool) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				return "", false
+			}
+			if data, ok := strings.CutPrefix(l, "data: "); ok {
+				return data, true
+			}
+		case <-timeout:
+			t.Fatalf("stream neither sent nor ended")
+		}
+	}
+}
+
+func TestEventsRevoked(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"token removed", Config{Tokens: []string{"other"}}},
+		{"read taken away", Config{Clients: []Client{{Name: "display", Token: "secret", Permissions: []string{"events"}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			if err := s.SetConfig(Config{Clients: []Client{{Name: "display", Token: "secret", Permissions: []string{"read"}}}}); err != nil {
+				t.Fatalf("config: %v", err)
+			}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			lines := openStream(ctx, t, srv.URL, "secret")
+			// the stream is registered before its headers are sent, so this
+			// reaches it
+			s.Publish("aircraft", 1)
+			if data, ok := next(t, lines); !ok || data != "1" {
+				t.Fatalf("first event %q %v, want 1", data, ok)
+			}
+
+			if err := s.SetConfig(tt.config); err != nil {
+				t.Fatalf("reload: %v", err)
+			}
+			if data, ok := next(t, lines); ok {
+				t.Fatalf("stream still open after revoking, sent %q", data)
+			}
+		})
+	}
+}
+
+func TestEventsKeptOnReload(t *testing.T) {
+	s := New()
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lines := openStream(ctx, t, srv.URL, "")
+	if err := s.SetConfig(Config{Topics: []string{"aircraft"}}); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	s.Publish("aircraft", 2)
+	if data, ok := next(t, lines); !ok || data != "2" {
+		t.Fatalf("event after reload %q %v, want 2", data, ok)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.json")
+	write := func(c string) {
+		if err := os.WriteFile(path, []byte(c), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"clients": [{"name": "admin", "token": "admin", "permissions": ["all"]}, {"name": "display", "token": "display", "permissions": ["read"]}]}`)
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	s := New(WithConfig(c))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	reload := func(token string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/config/reload", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("reload: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := reload("admin"); status != http.StatusNotFound {
+		t.Fatalf("reload with no file watched: %d", status)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.WatchConfig(ctx, path, time.Hour)
+	deadline := time.Now().Add(2 * time.Second)
+	for reload("admin") == http.StatusNotFound {
+		if time.Now().After(deadline) {
+			t.Fatalf("config never watched")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	write(`{"topics": ["aircraft"], "clients": [{"name": "admin", "token": "admin", "permissions": ["all"]}, {"name": "display", "token": "display", "permissions": ["read"]}]}`)
+	if status := reload("display"); status != http.StatusForbidden {
+		t.Fatalf("reload with read only: %d", status)
+	}
+	if status := reload("admin"); status != http.StatusNoContent {
+		t.Fatalf("reload: %d", status)
+	}
+	if topics := s.Config().Topics; len(topics) != 1 || topics[0] != "aircraft" {
+		t.Fatalf("topics after reload %v", topics)
+	}
+
+	write(`{"clients": [{"name": "broken"}]}`)
+	if status := reload("admin"); status != http.StatusInternalServerError {
+		t.Fatalf("reload of an invalid file: %d", status)
+	}
+	if topics := s.Config().Topics; len(topics) != 1 {
+		t.Fatalf("invalid file replaced the config")
+	}
+}
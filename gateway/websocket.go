@@ -0,0 +1,362 @@
+package gateway
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// socketMessage is a message on the WebSocket, in either direction
+//
+// clients send:
+//
+//	{"type": "subscribe", "topics": ["aircraft"]}          only these topics from now on; none is every topic
+//	{"type": "command", "id": "1", "name": "pause", "args": {...}}
+//
+// the server sends:
+//
+//	{"type": "event", "topic": "aircraft", "data": {...}}  the latest value of every topic on connect, then each publish
+//	{"type": "result", "id": "1", "result": {...}}         or "error" in place of "result"
+type socketMessage struct {
+	Type   string          `json:"type"`
+	ID     string          `json:"id,omitempty"`
+	Topic  string          `json:"topic,omitempty"`
+	Topics []string        `json:"topics,omitempty"`
+	Name   string          `json:"name,omitempty"`
+	Args   json.RawMessage `json:"args,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Result any             `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// maxSocketMessage bounds what a client may send in one message
+const maxSocketMessage = 1 << 20
+
+func (s *Server) handleSocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := acceptSocket(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer ws.conn.Close()
+
+	var fmu sync.Mutex
+	filter := map[string]bool{}
+	for _, t := range r.URL.Query()["topic"] {
+		filter[t] = true
+	}
+	wanted := func(topic string) bool {
+		fmu.Lock()
+		defer fmu.Unlock()
+		return (len(filter) == 0 || filter[topic]) && s.Config().allowTopic(topic)
+	}
+
+	ch := make(chan message, 64)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	snapshot := make(map[string]json.RawMessage, len(s.state))
+	for k, v := range s.state {
+		snapshot[k] = v
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}()
+
+	// commands are read and answered in order, on their own goroutine so
+	// events keep flowing while one runs
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			data, err := ws.read()
+			if err != nil {
+				return
+			}
+			var m socketMessage
+			if err := json.Unmarshal(data, &m); err != nil {
+				ws.writeJSON(socketMessage{Type: "result", Error: fmt.Sprintf("invalid message: %v", err)})
+				continue
+			}
+			switch m.Type {
+			case "subscribe":
+				fmu.Lock()
+				filter = map[string]bool{}
+				for _, t := range m.Topics {
+					filter[t] = true
+				}
+				fmu.Unlock()
+			case "command":
+				// the token's permissions are those of the current config
+				_, perm, ok := s.Config().authorize(r)
+				if !ok {
+					ws.close(closePolicy, "unauthorized")
+					return
+				}
+				res := socketMessage{Type: "result", ID: m.ID}
+				if out, _, err := s.invoke(r.Context(), m.Name, perm, m.Args); err != nil {
+					res.Error = err.Error()
+				} else {
+					res.Result = out
+				}
+				if err := ws.writeJSON(res); err != nil {
+					return
+				}
+			default:
+				ws.writeJSON(socketMessage{Type: "result", ID: m.ID, Error: fmt.Sprintf("unknown message type %q", m.Type)})
+			}
+		}
+	}()
+
+	for topic, data := range snapshot {
+		if wanted(topic) {
+			if err := ws.writeJSON(socketMessage{Type: "event", Topic: topic, Data: data}); err != nil {
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			ws.close(closeGoingAway, "")
+			return
+		case <-done:
+			return
+		case m := <-ch:
+			if !s.mayRead(r) {
+				s.log.Info("WebSocket closed, read no longer permitted", "path", r.URL.Path)
+				ws.close(closePolicy, "read not permitted")
+				return
+			}
+			if m.reauth || !wanted(m.topic) {
+				continue
+			}
+			if err := ws.writeJSON(socketMessage{Type: "event", Topic: m.topic, Data: m.data}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// WebSocket close codes, see RFC 6455 section 7.4.1
+const (
+	closeNormal    = 1000
+	closeGoingAway = 1001
+	closeProtocol  = 1002
+	closePolicy    = 1008
+	closeTooBig    = 1009
+)
+
+// WebSocket opcodes
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// socketGUID is appended to the client's key to accept the handshake
+const socketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsConn is the server side of a WebSocket, just enough of RFC 6455 for
+// JSON messages: no extensions or subprotocols
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+func headerHas(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptSocket completes the opening handshake and takes over the connection
+func acceptSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !headerHas(r.Header, "Connection", "upgrade") || !headerHas(r.Header, "Upgrade", "websocket") {
+		return nil, fmt.Errorf("not a WebSocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, fmt.Errorf("unsupported WebSocket version %q", r.Header.Get("Sec-WebSocket-Version"))
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, fmt.Errorf("missing Sec-WebSocket-Key")
+	}
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("cannot take over the connection: %w", err)
+	}
+	sum := sha1.Sum([]byte(key + socketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	rw.WriteString(base64.StdEncoding.EncodeToString(sum[:]))
+	rw.WriteString("\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return &wsConn{conn: conn, r: rw.Reader}, nil
+}
+
+// read returns the next text or binary message, answering pings on the way
+// a close from the client is echoed and returned as io.EOF
+func (c *wsConn) read() ([]byte, error) {
+	var msg []byte
+	started := false
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.write(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			c.close(closeNormal, "")
+			return nil, io.EOF
+		case opText, opBinary:
+			if started {
+				c.close(closeProtocol, "expected a continuation frame")
+				return nil, errors.New("websocket: new message before the last ended")
+			}
+			started = true
+		case opContinuation:
+			if !started {
+				c.close(closeProtocol, "unexpected continuation frame")
+				return nil, errors.New("websocket: continuation without a message")
+			}
+		default:
+			c.close(closeProtocol, "unknown opcode")
+			return nil, fmt.Errorf("websocket: unknown opcode %d", op)
+		}
+		if len(msg)+len(payload) > maxSocketMessage {
+			c.close(closeTooBig, "")
+			return nil, errors.New("websocket: message too big")
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var h [2]byte
+	if _, err = io.ReadFull(c.r, h[:]); err != nil {
+		return
+	}
+	fin, op = h[0]&0x80 != 0, h[0]&0x0f
+	if h[0]&0x70 != 0 {
+		c.close(closeProtocol, "no extensions")
+		return false, 0, nil, errors.New("websocket: reserved bits set")
+	}
+	if h[1]&0x80 == 0 {
+		// clients must mask every frame
+		c.close(closeProtocol, "unmasked frame")
+		return false, 0, nil, errors.New("websocket: unmasked client frame")
+	}
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		if _, err = io.ReadFull(c.r, b[:]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err = io.ReadFull(c.r, b[:]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(b[:])
+	}
+	if op >= opClose && (n > 125 || !fin) {
+		c.close(closeProtocol, "bad control frame")
+		return false, 0, nil, errors.New("websocket: bad control frame")
+	}
+	if n > maxSocketMessage {
+		c.close(closeTooBig, "")
+		return false, 0, nil, errors.New("websocket: frame too big")
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(c.r, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.r, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// write sends one unfragmented frame
+func (c *wsConn) write(op byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	h := make([]byte, 2, 10+len(payload))
+	h[0] = 0x80 | op
+	switch n := len(payload); {
+	case n < 126:
+		h[1] = byte(n)
+	case n <= 0xffff:
+		h[1] = 126
+		h = binary.BigEndian.AppendUint16(h, uint16(n))
+	default:
+		h[1] = 127
+		h = binary.BigEndian.AppendUint64(h, uint64(n))
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := c.conn.Write(append(h, payload...))
+	return err
+}
+
+func (c *wsConn) writeJSON(m socketMessage) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return c.write(opText, data)
+}
+
+// close sends a close frame; the connection is closed by the handler
+func (c *wsConn) close(code uint16, reason string) {
+	payload := binary.BigEndian.AppendUint16(nil, code)
+	c.write(opClose, append(payload, reason...))
+	c.wmu.Lock()
+	c.closed = true
+	c.wmu.Unlock()
+}
@@ -0,0 +1,184 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testSocket is a bare WebSocket client
+type testSocket struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialSocket(t *testing.T, url, token string) *testSocket {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	req, _ := http.NewRequest(http.MethodGet, url+"/socket", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	sum := sha1.Sum([]byte(key + socketGUID))
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		t.Fatalf("handshake: %s %v", resp.Status, resp.Header)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return &testSocket{t: t, conn: conn, r: r}
+}
+
+// send writes a masked text frame, as clients must
+func (s *testSocket) send(m socketMessage) {
+	s.t.Helper()
+	data, _ := json.Marshal(m)
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opText, 0x80 | 126}
+	frame = binary.BigEndian.AppendUint16(frame, uint16(len(data)))
+	frame = append(frame, mask[:]...)
+	for i, b := range data {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := s.conn.Write(frame); err != nil {
+		s.t.Fatalf("send: %v", err)
+	}
+}
+
+// recv reads a frame, returning its opcode and payload
+func (s *testSocket) recv() (byte, []byte) {
+	s.t.Helper()
+	var h [2]byte
+	if _, err := io.ReadFull(s.r, h[:]); err != nil {
+		s.t.Fatalf("recv: %v", err)
+	}
+	n := int(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		io.ReadFull(s.r, b[:])
+		n = int(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		io.ReadFull(s.r, b[:])
+		n = int(binary.BigEndian.Uint64(b[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(s.r, payload); err != nil {
+		s.t.Fatalf("recv: %v", err)
+	}
+	return h[0] & 0x0f, payload
+}
+
+func (s *testSocket) recvMessage() socketMessage {
+	s.t.Helper()
+	op, data := s.recv()
+	if op != opText {
+		s.t.Fatalf("frame %d %q, want a text message", op, data)
+	}
+	var m socketMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		s.t.Fatalf("message %q: %v", data, err)
+	}
+	return m
+}
+
+func TestSocket(t *testing.T) {
+	s := New()
+	s.SetConfig(Config{Clients: []Client{
+		{Name: "instructor", Token: "full", Permissions: []string{"all"}},
+		{Name: "display", Token: "view", Permissions: []string{"read"}},
+	}})
+	s.Publish("aircraft", map[string]int{"altitude": 3000})
+	var paused atomic.Bool
+	s.HandlePermission("pause", PermEvents, func(ctx context.Context, args json.RawMessage) (any, error) {
+		paused.Store(string(args) == "true")
+		return map[string]bool{"paused": paused.Load()}, nil
+	})
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	ws := dialSocket(t, srv.URL, "full")
+	if m := ws.recvMessage(); m.Type != "event" || m.Topic != "aircraft" || string(m.Data) != `{"altitude":3000}` {
+		t.Fatalf("snapshot %+v", m)
+	}
+	ws.send(socketMessage{Type: "command", ID: "1", Name: "pause", Args: json.RawMessage("true")})
+	if m := ws.recvMessage(); m.Type != "result" || m.ID != "1" || m.Error != "" || !paused.Load() {
+		t.Fatalf("command result %+v, paused %v", m, paused.Load())
+	}
+	ws.send(socketMessage{Type: "command", ID: "2", Name: "missing"})
+	if m := ws.recvMessage(); m.ID != "2" || m.Error == "" {
+		t.Fatalf("unknown command result %+v", m)
+	}
+	s.Publish("aircraft", map[string]int{"altitude": 3100})
+	if m := ws.recvMessage(); m.Type != "event" || string(m.Data) != `{"altitude":3100}` {
+		t.Fatalf("event %+v", m)
+	}
+
+	// a read-only client watches but cannot command
+	view := dialSocket(t, srv.URL, "view")
+	view.recvMessage()
+	view.send(socketMessage{Type: "command", ID: "3", Name: "pause", Args: json.RawMessage("false")})
+	if m := view.recvMessage(); m.ID != "3" || m.Error == "" || !paused.Load() {
+		t.Fatalf("read-only command result %+v, paused %v", m, paused.Load())
+	}
+
+	// narrowing the topics
+	view.send(socketMessage{Type: "subscribe", Topics: []string{"fuel"}})
+	// the subscribe is handled before the command answered after it
+	view.send(socketMessage{Type: "command", ID: "4", Name: "missing"})
+	view.recvMessage()
+	s.Publish("aircraft", 1)
+	s.Publish("fuel", 2)
+	if m := view.recvMessage(); m.Topic != "fuel" {
+		t.Fatalf("filtered event %+v", m)
+	}
+
+	// revoking the token closes the socket
+	s.SetConfig(Config{Clients: []Client{{Name: "instructor", Token: "full", Permissions: []string{"all"}}}})
+	if op, data := view.recv(); op != opClose || binary.BigEndian.Uint16(data) != closePolicy {
+		t.Fatalf("after revoking: frame %d %q, want a policy close", op, data)
+	}
+	s.Publish("aircraft", 2)
+	if m := ws.recvMessage(); m.Type != "event" {
+		t.Fatalf("kept client event %+v", m)
+	}
+}
+
+func TestSocketHandshake(t *testing.T) {
+	srv := httptest.NewServer(New())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/socket")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("plain GET: %s, want 400", resp.Status)
+	}
+}
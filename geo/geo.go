@@ -0,0 +1,109 @@
+// Package geo has great circle and magnetic variation helpers for sim positions
+//
+// Distances are in nautical miles and angles in degrees, matching the units
+// the sim reports; bearings are true unless the name says otherwise
+package geo
+
+import (
+	"math"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// EarthRadiusNM is the mean earth radius in nautical miles
+const EarthRadiusNM = 3440.065
+
+// Position is a point on the earth
+type Position struct {
+	Latitude  float64 // degrees
+	Longitude float64 // degrees
+	Altitude  float64 // feet
+}
+
+// PositionReport is the user aircraft position, for use with RequestData
+type PositionReport struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude  float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	Heading   float64 `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees"`
+	Track     float64 `name:"GPS GROUND TRUE TRACK" unit:"Degrees"`
+	Speed     float64 `name:"GROUND VELOCITY" unit:"Knots"`
+	MagVar    float64 `name:"MAGVAR" unit:"Degrees"`
+}
+
+// Position returns the position of the report
+func (r PositionReport) Position() Position {
+	return Position{Latitude: r.Latitude, Longitude: r.Longitude, Altitude: r.Altitude}
+}
+
+func rad(d float64) float64 { return d * math.Pi / 180 }
+func deg(r float64) float64 { return r * 180 / math.Pi }
+
+// Normalize wraps a heading into [0, 360)
+func Normalize(d float64) float64 {
+	d = math.Mod(d, 360)
+	if d < 0 {
+		d += 360
+	}
+	return d
+}
+
+// Distance is the great circle distance between two positions
+func Distance(a, b Position) float64 {
+	return EarthRadiusNM * angular(a, b)
+}
+
+// angular is the central angle between two positions, in radians
+func angular(a, b Position) float64 {
+	lat1, lat2 := rad(a.Latitude), rad(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := rad(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Bearing is the initial true bearing from a to b
+func Bearing(a, b Position) float64 {
+	lat1, lat2 := rad(a.Latitude), rad(b.Latitude)
+	dLon := rad(b.Longitude - a.Longitude)
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return Normalize(deg(math.Atan2(y, x)))
+}
+
+// Destination is the position reached from p on a true bearing after the distance
+// the altitude is carried over from p
+func Destination(p Position, bearing, distance float64) Position {
+	lat1, lon1 := rad(p.Latitude), rad(p.Longitude)
+	d := distance / EarthRadiusNM
+	b := rad(bearing)
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(b))
+	lon2 := lon1 + math.Atan2(math.Sin(b)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+	return Position{
+		Latitude:  deg(lat2),
+		Longitude: math.Mod(deg(lon2)+540, 360) - 180,
+		Altitude:  p.Altitude,
+	}
+}
+
+// CrossTrack is the distance of p from the great circle course from a to b
+// it is positive when p is right of course
+func CrossTrack(a, b, p Position) float64 {
+	d13 := angular(a, p)
+	t13 := rad(Bearing(a, p))
+	t12 := rad(Bearing(a, b))
+	return EarthRadiusNM * math.Asin(math.Sin(d13)*math.Sin(t13-t12))
+}
+
+// AlongTrack is the distance from a to the point on the course from a to b
+// abeam p; it is negative when p is behind a
+func AlongTrack(a, b, p Position) float64 {
+	d13 := angular(a, p)
+	dxt := math.Asin(math.Sin(d13) * math.Sin(rad(Bearing(a, p))-rad(Bearing(a, b))))
+	dat := math.Acos(math.Max(-1, math.Min(1, math.Cos(d13)/math.Cos(dxt))))
+	if math.Cos(rad(Bearing(a, p))-rad(Bearing(a, b))) < 0 {
+		dat = -dat
+	}
+	return EarthRadiusNM * dat
+}
@@ -0,0 +1,95 @@
+package geo
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Variation returns the magnetic variation at a position, in degrees,
+// positive east
+type Variation interface {
+	At(p Position) float64
+}
+
+// TrueToMagnetic converts a true heading to magnetic
+func TrueToMagnetic(heading, variation float64) float64 {
+	return Normalize(heading - variation)
+}
+
+// MagneticToTrue converts a magnetic heading to true
+func MagneticToTrue(heading, variation float64) float64 {
+	return Normalize(heading + variation)
+}
+
+// Fixed is the same variation everywhere
+type Fixed float64
+
+// At returns the fixed variation
+func (f Fixed) At(Position) float64 { return float64(f) }
+
+// ReadMagVar reads the MAGVAR simvar, the variation at the user aircraft
+// the reply is only delivered while the Connector is running
+func ReadMagVar(ctx context.Context, sc *client.SimConnect) (float64, error) {
+	return sc.ReadFloat(ctx, "MAGVAR", "Degrees")
+}
+
+// Table interpolates variation from known samples, such as the MAGVAR
+// simvar along the flight path or the variation of navdata fixes
+// the sim does not expose its magnetic model, so this stands in for the
+// WMM: the result is only as good as the sample coverage
+type Table struct {
+	mu      sync.RWMutex
+	samples []sample
+}
+
+type sample struct {
+	p Position
+	v float64
+}
+
+// Add records the variation at a position
+func (t *Table) Add(p Position, variation float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples = append(t.samples, sample{p: p, v: variation})
+}
+
+// Len returns the number of samples
+func (t *Table) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.samples)
+}
+
+// At returns the inverse distance weighted variation of the samples
+// within 300nm; the nearest sample is used if none are, and zero if the
+// table is empty
+func (t *Table) At(p Position) float64 {
+	const radius = 300
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var sum, weights float64
+	nearest, best := 0.0, math.Inf(1)
+	for _, s := range t.samples {
+		d := Distance(p, s.p)
+		if d < 0.01 {
+			return s.v
+		}
+		if d < best {
+			nearest, best = s.v, d
+		}
+		if d > radius {
+			continue
+		}
+		w := 1 / (d * d)
+		sum += w * s.v
+		weights += w
+	}
+	if weights == 0 {
+		return nearest
+	}
+	return sum / weights
+}
@@ -0,0 +1,230 @@
+// Package geofence raises triggers when the aircraft crosses circles,
+// lines and altitude gates, the basis of races, checkrides and tours
+//
+// A Watcher samples the user aircraft position and tests the track since
+// the last sample against every trigger, so a shape smaller than the
+// distance flown in one interval can be crossed without entering it.
+package geofence
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/geo"
+)
+
+// GeofenceError is an error returned by the watcher
+type GeofenceError string
+
+func (e GeofenceError) Error() string {
+	return string(e)
+}
+
+const (
+	// ErrDuplicate is returned when adding a trigger with a name already in use
+	ErrDuplicate GeofenceError = "duplicate trigger name"
+	// ErrNoShape is returned when adding a trigger without a shape
+	ErrNoShape GeofenceError = "trigger has no shape"
+)
+
+// Hit is a trigger firing
+type Hit struct {
+	Trigger   string
+	Direction Direction
+	Position  geo.Position
+	At        time.Time
+}
+
+// Trigger is a shape and what to do when it is crossed
+type Trigger struct {
+	Name  string
+	Shape Shape
+	// On limits the trigger to one direction; None fires on any crossing
+	On Direction
+	// Once removes the trigger after it fires
+	Once bool
+	// Event is an optional sim event transmitted with Value when it fires
+	Event string
+	Value client.DWORD
+	// Fn is an optional callback; it runs on the dispatch goroutine, so it must not block
+	Fn func(Hit)
+}
+
+// Watcher is a receiver that fires triggers as the aircraft moves
+type Watcher struct {
+	interval time.Duration
+	onHit    func(Hit)
+
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	triggers []Trigger
+	events   map[string]client.DWORD
+	prev     *geo.Position
+}
+
+// Option is a function that sets options on the Watcher
+type Option func(*Watcher)
+
+// WithInterval sets how often the position is sampled; the default is 250ms
+func WithInterval(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.interval = d
+	}
+}
+
+// WithOnHit sets a callback that is called for every trigger that fires
+func WithOnHit(fn func(Hit)) Option {
+	return func(w *Watcher) {
+		w.onHit = fn
+	}
+}
+
+// New creates a new Watcher
+func New(opts ...Option) *Watcher {
+	w := &Watcher{
+		interval: 250 * time.Millisecond,
+		events:   map[string]client.DWORD{},
+	}
+	for _, o := range opts {
+		o(w)
+	}
+	return w
+}
+
+// Add adds a trigger; triggers can be added before or after the connector starts
+func (w *Watcher) Add(t Trigger) error {
+	if t.Shape == nil {
+		return ErrNoShape
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, o := range w.triggers {
+		if o.Name == t.Name {
+			return ErrDuplicate
+		}
+	}
+	w.triggers = append(w.triggers, t)
+	if w.sc != nil {
+		w.mapEvent(w.sc, t.Event)
+	}
+	return nil
+}
+
+// Remove removes a trigger by name; it returns false if there is none
+func (w *Watcher) Remove(name string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, t := range w.triggers {
+		if t.Name == name {
+			w.triggers = append(w.triggers[:i], w.triggers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Triggers returns the armed triggers
+func (w *Watcher) Triggers() []Trigger {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Trigger(nil), w.triggers...)
+}
+
+// Start maps the trigger events and samples the aircraft position
+func (w *Watcher) Start(ctx context.Context, sc *client.SimConnect) {
+	w.mu.Lock()
+	w.sc = sc
+	w.prev = nil
+	w.events = map[string]client.DWORD{}
+	for _, t := range w.triggers {
+		w.mapEvent(sc, t.Event)
+	}
+	w.mu.Unlock()
+
+	if err := simconnect.Subscribe[geo.PositionReport](ctx, sc, w.interval); err != nil {
+		slog.Error("Cannot subscribe to position", "error", err)
+		return
+	}
+	simconnect.Go(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+		w.mu.Lock()
+		w.sc = nil
+		w.mu.Unlock()
+	})
+}
+
+// mapEvent maps a trigger event on first use; it must be called with the lock held
+func (w *Watcher) mapEvent(sc *client.SimConnect, name string) {
+	if name == "" {
+		return
+	}
+	if _, ok := w.events[name]; ok {
+		return
+	}
+	id := sc.GetEventID()
+	if err := sc.MapClientEventToSimEvent(id, name); err != nil {
+		slog.Error("Cannot map trigger event", "event", name, "error", err)
+		return
+	}
+	w.events[name] = id
+}
+
+// Update tests the track since the last position against the triggers
+func (w *Watcher) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[geo.PositionReport](sc, ppData)
+	if !ok {
+		return
+	}
+	cur := r.Position()
+
+	w.mu.Lock()
+	prev := w.prev
+	w.prev = &cur
+	if prev == nil {
+		w.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	var hits []Hit
+	var fired []Trigger
+	var eventIDs []*client.DWORD
+	kept := w.triggers[:0]
+	for _, t := range w.triggers {
+		d := t.Shape.Crossing(*prev, cur)
+		if d == None || (t.On != None && t.On != d) {
+			kept = append(kept, t)
+			continue
+		}
+		hits = append(hits, Hit{Trigger: t.Name, Direction: d, Position: cur, At: now})
+		fired = append(fired, t)
+		var id *client.DWORD
+		if v, ok := w.events[t.Event]; ok {
+			id = &v
+		}
+		eventIDs = append(eventIDs, id)
+		if !t.Once {
+			kept = append(kept, t)
+		}
+	}
+	w.triggers = kept
+	w.mu.Unlock()
+
+	for i, h := range hits {
+		t := fired[i]
+		if id := eventIDs[i]; id != nil {
+			if err := sc.TransmitClientEvent(client.OBJECT_ID_USER, *id, t.Value, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY); err != nil {
+				slog.Error("Cannot transmit trigger event", "trigger", t.Name, "event", t.Event, "error", err)
+			}
+		}
+		if t.Fn != nil {
+			t.Fn(h)
+		}
+		if w.onHit != nil {
+			w.onHit(h)
+		}
+	}
+}
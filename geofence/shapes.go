@@ -0,0 +1,126 @@
+package geofence
+
+import (
+	"math"
+
+	"github.com/bmurray/simconnect-go/geo"
+)
+
+// Direction is how the aircraft crossed a shape
+type Direction int
+
+const (
+	// None is no crossing; as a trigger filter it matches any crossing
+	None Direction = iota
+	// Enter is moving into an area
+	Enter
+	// Exit is moving out of an area
+	Exit
+	// Cross is crossing a line
+	Cross
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Enter:
+		return "enter"
+	case Exit:
+		return "exit"
+	case Cross:
+		return "cross"
+	default:
+		return "none"
+	}
+}
+
+// Shape is something the aircraft can cross
+type Shape interface {
+	// Crossing returns how moving from prev to cur crossed the shape
+	Crossing(prev, cur geo.Position) Direction
+}
+
+// Band is an altitude band in feet; a zero Ceiling is unbounded
+type Band struct {
+	Floor   float64
+	Ceiling float64
+}
+
+// Contains returns true if the altitude is in the band
+func (b Band) Contains(alt float64) bool {
+	return alt >= b.Floor && (b.Ceiling == 0 || alt <= b.Ceiling)
+}
+
+// Circle is an area within Radius nautical miles of Center
+type Circle struct {
+	Center geo.Position
+	Radius float64
+	Band
+}
+
+// Inside returns true if p is in the circle and its altitude band
+func (c Circle) Inside(p geo.Position) bool {
+	return geo.Distance(c.Center, p) <= c.Radius && c.Contains(p.Altitude)
+}
+
+// Crossing returns Enter or Exit when the inside state changes
+func (c Circle) Crossing(prev, cur geo.Position) Direction {
+	switch was, is := c.Inside(prev), c.Inside(cur); {
+	case !was && is:
+		return Enter
+	case was && !is:
+		return Exit
+	}
+	return None
+}
+
+// Polyline is a line through Points; crossing any segment within the
+// altitude band is a Cross
+type Polyline struct {
+	Points []geo.Position
+	Band
+}
+
+// Gate is a line between two points that must be crossed within the band,
+// eg a race gate or a checkride fix
+func Gate(a, b geo.Position, floor, ceiling float64) Polyline {
+	return Polyline{Points: []geo.Position{a, b}, Band: Band{Floor: floor, Ceiling: ceiling}}
+}
+
+// Crossing returns Cross if the track from prev to cur crosses the line
+func (l Polyline) Crossing(prev, cur geo.Position) Direction {
+	// the segments are short, so a flat projection around prev is close enough
+	cosLat := math.Cos(prev.Latitude * math.Pi / 180)
+	xy := func(p geo.Position) (float64, float64) {
+		dlon := math.Mod(p.Longitude-prev.Longitude+540, 360) - 180
+		return dlon * cosLat * 60, (p.Latitude - prev.Latitude) * 60
+	}
+	cx, cy := xy(cur)
+	for i := 1; i < len(l.Points); i++ {
+		ax, ay := xy(l.Points[i-1])
+		bx, by := xy(l.Points[i])
+		t, ok := intersect(0, 0, cx, cy, ax, ay, bx, by)
+		if !ok {
+			continue
+		}
+		if l.Contains(prev.Altitude + t*(cur.Altitude-prev.Altitude)) {
+			return Cross
+		}
+	}
+	return None
+}
+
+// intersect returns where along p1-p2 it crosses q1-q2, from 0 to 1
+func intersect(p1x, p1y, p2x, p2y, q1x, q1y, q2x, q2y float64) (float64, bool) {
+	rx, ry := p2x-p1x, p2y-p1y
+	sx, sy := q2x-q1x, q2y-q1y
+	den := rx*sy - ry*sx
+	if den == 0 {
+		return 0, false
+	}
+	t := ((q1x-p1x)*sy - (q1y-p1y)*sx) / den
+	u := ((q1x-p1x)*ry - (q1y-p1y)*rx) / den
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
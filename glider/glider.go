@@ -0,0 +1,181 @@
+// Package glider wraps the aerotow and winch events and the tow simvars
+package glider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// TowReport is the data structure to report the tow state
+type TowReport struct {
+	client.RecvSimobjectDataByType
+	Connected     float64 `name:"TOW CONNECTION" unit:"Bool"`
+	ReleaseHandle float64 `name:"TOW RELEASE HANDLE" unit:"Percent"`
+}
+
+// State is the decoded tow state
+type State struct {
+	Connected     bool
+	ReleaseHandle float64
+}
+
+// Tow is a receiver that requests and releases tows and reports the tow state
+type Tow struct {
+	interval     time.Duration
+	onChange     func(prev, next State)
+	winchRequest string
+	winchRelease string
+
+	mu     sync.Mutex
+	sc     *client.SimConnect
+	state  State
+	known  bool
+	events map[string]client.DWORD
+}
+
+// Option is a function that sets options on the Tow
+type Option func(*Tow)
+
+// WithInterval sets how often the tow state is requested
+func WithInterval(d time.Duration) Option {
+	return func(t *Tow) {
+		t.interval = d
+	}
+}
+
+// WithOnChange sets a callback that is called when the tow state changes
+// the first report after a connect is always delivered
+func WithOnChange(fn func(prev, next State)) Option {
+	return func(t *Tow) {
+		t.onChange = fn
+	}
+}
+
+// WithWinchEvents sets the events used for winch launches
+// winch events vary between sims and addons, so there is no default
+func WithWinchEvents(request, release string) Option {
+	return func(t *Tow) {
+		t.winchRequest = request
+		t.winchRelease = release
+	}
+}
+
+// New creates a new Tow
+func New(opts ...Option) *Tow {
+	t := &Tow{interval: time.Second}
+	for _, o := range opts {
+		o(t)
+	}
+	return t
+}
+
+// Start registers the tow report, maps the events and starts polling
+func (t *Tow) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := sc.RegisterDataDefinition(&TowReport{}); err != nil {
+		slog.Error("Cannot register tow report", "error", err)
+		return
+	}
+	names := []string{"TOW_PLANE_REQUEST", "TOW_PLANE_RELEASE"}
+	if t.winchRequest != "" {
+		names = append(names, t.winchRequest, t.winchRelease)
+	}
+	events := map[string]client.DWORD{}
+	for _, name := range names {
+		id, err := sc.MapClientEventByName(name)
+		if err != nil {
+			slog.Error("Cannot map tow event", "event", name, "error", err)
+			return
+		}
+		events[name] = id
+	}
+
+	t.mu.Lock()
+	t.sc = sc
+	t.events = events
+	t.known = false
+	t.mu.Unlock()
+
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(t.interval):
+				if err := simconnect.RequestData[TowReport](sc); err != nil {
+					slog.Error("Cannot request tow state", "error", err)
+				}
+			}
+		}
+	})
+}
+
+// Update decodes the tow report and calls the change callback
+func (t *Tow) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[TowReport](sc, ppData)
+	if !ok {
+		return
+	}
+	next := State{Connected: r.Connected != 0, ReleaseHandle: r.ReleaseHandle}
+
+	t.mu.Lock()
+	prev, known := t.state, t.known
+	t.state, t.known = next, true
+	t.mu.Unlock()
+
+	if (!known || prev != next) && t.onChange != nil {
+		t.onChange(prev, next)
+	}
+}
+
+// State returns the last known tow state; false if none has been received
+func (t *Tow) State() (State, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state, t.known
+}
+
+// RequestTow requests a tow plane
+func (t *Tow) RequestTow() error {
+	return t.fire("TOW_PLANE_REQUEST")
+}
+
+// Release releases the tow rope
+func (t *Tow) Release() error {
+	return t.fire("TOW_PLANE_RELEASE")
+}
+
+// RequestWinch requests a winch launch; requires WithWinchEvents
+func (t *Tow) RequestWinch() error {
+	if t.winchRequest == "" {
+		return fmt.Errorf("no winch events configured")
+	}
+	return t.fire(t.winchRequest)
+}
+
+// ReleaseWinch releases the winch cable; requires WithWinchEvents
+func (t *Tow) ReleaseWinch() error {
+	if t.winchRelease == "" {
+		return fmt.Errorf("no winch events configured")
+	}
+	return t.fire(t.winchRelease)
+}
+
+func (t *Tow) fire(name string) error {
+	t.mu.Lock()
+	sc := t.sc
+	id, ok := t.events[name]
+	t.mu.Unlock()
+	if sc == nil {
+		return fmt.Errorf("tow not started")
+	}
+	if !ok {
+		return fmt.Errorf("event %s not mapped", name)
+	}
+	return sc.TransmitClientEvent(client.OBJECT_ID_USER, id, 0, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
@@ -0,0 +1,325 @@
+// Package groundservices tracks and drives ground services such as doors,
+// ground power, jetways and pushback as a set of observable state machines
+//
+// Each service is driven by a key event or a writable variable (usually an
+// LVAR for addon aircraft), and its state is confirmed by reading back a
+// simvar or LVAR. Services with no state variable are tracked optimistically.
+package groundservices
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Service names a ground service
+type Service string
+
+const (
+	Doors    Service = "doors"
+	GPU      Service = "gpu"
+	Jetway   Service = "jetway"
+	Stairs   Service = "stairs"
+	Fuel     Service = "fuel"
+	Pushback Service = "pushback"
+	Chocks   Service = "chocks"
+	Catering Service = "catering"
+)
+
+// State is the state of a service
+type State int
+
+const (
+	StateUnknown State = iota
+	StateOff
+	StateRequestedOn
+	StateOn
+	StateRequestedOff
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOff:
+		return "off"
+	case StateRequestedOn:
+		return "requested_on"
+	case StateOn:
+		return "on"
+	case StateRequestedOff:
+		return "requested_off"
+	default:
+		return "unknown"
+	}
+}
+
+// Hook describes how a service is driven and observed
+type Hook struct {
+	// Event is the key event that toggles the service
+	Event string
+	// SetVar is a variable written with 1 or 0 instead of firing Event
+	SetVar  string
+	SetUnit string
+	// StateVar is read back to confirm the service state; optional
+	StateVar  string
+	StateUnit string
+	// IsOn interprets the state variable; defaults to v != 0
+	IsOn func(v float64) bool
+}
+
+// DefaultHooks are the hooks for the stock services
+// chocks and catering have no stock events and need aircraft specific hooks
+var DefaultHooks = map[Service]Hook{
+	Doors:    {Event: "TOGGLE_AIRCRAFT_EXIT", StateVar: "EXIT OPEN:0", StateUnit: "Percent", IsOn: func(v float64) bool { return v > 50 }},
+	GPU:      {Event: "TOGGLE_EXTERNAL_POWER", StateVar: "EXTERNAL POWER ON", StateUnit: "Bool"},
+	Jetway:   {Event: "TOGGLE_JETWAY"},
+	Stairs:   {Event: "TOGGLE_RAMPTRUCK"},
+	Fuel:     {Event: "REQUEST_FUEL_KEY"},
+	Pushback: {Event: "TOGGLE_PUSHBACK", StateVar: "PUSHBACK STATE", StateUnit: "Enum", IsOn: func(v float64) bool { return v != 3 }},
+}
+
+// Transition is a change of state of a service
+type Transition struct {
+	Service Service
+	From    State
+	To      State
+	At      time.Time
+}
+
+// Machine is a receiver that tracks the ground services
+type Machine struct {
+	hooks    map[Service]Hook
+	interval time.Duration
+	onChange []func(Transition)
+
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	states   map[Service]State
+	observed []Service
+	stateID  client.DWORD
+	setIDs   map[Service]client.DWORD
+	eventIDs map[Service]client.DWORD
+}
+
+// Option is a function that sets options on the Machine
+type Option func(*Machine)
+
+// WithHook adds or replaces the hook for a service
+func WithHook(s Service, h Hook) Option {
+	return func(m *Machine) {
+		m.hooks[s] = h
+	}
+}
+
+// WithInterval sets how often the state variables are read
+func WithInterval(d time.Duration) Option {
+	return func(m *Machine) {
+		m.interval = d
+	}
+}
+
+// WithOnTransition adds a callback that is called on every state change
+func WithOnTransition(fn func(Transition)) Option {
+	return func(m *Machine) {
+		m.onChange = append(m.onChange, fn)
+	}
+}
+
+// New creates a ground services machine with the default hooks
+func New(opts ...Option) *Machine {
+	m := &Machine{
+		hooks:    map[Service]Hook{},
+		interval: time.Second,
+		states:   map[Service]State{},
+	}
+	for s, h := range DefaultHooks {
+		m.hooks[s] = h
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+// Start registers the state definition, maps the events and starts polling
+func (m *Machine) Start(ctx context.Context, sc *client.SimConnect) {
+	services := make([]Service, 0, len(m.hooks))
+	for s := range m.hooks {
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i] < services[j] })
+
+	stateID := sc.GetDefineIDByName("groundservices:state")
+	setIDs := map[Service]client.DWORD{}
+	eventIDs := map[Service]client.DWORD{}
+	var observed []Service
+	for _, s := range services {
+		h := m.hooks[s]
+		if h.StateVar != "" {
+			if err := sc.AddToDataDefinition(stateID, h.StateVar, h.StateUnit, client.DATATYPE_FLOAT64); err != nil {
+				slog.Error("Cannot add ground service state", "service", s, "error", err)
+				return
+			}
+			observed = append(observed, s)
+		}
+		if h.SetVar != "" {
+			id := sc.GetDefineIDByName("groundservices:set:" + string(s))
+			if err := sc.AddToDataDefinition(id, h.SetVar, h.SetUnit, client.DATATYPE_FLOAT64); err != nil {
+				slog.Error("Cannot add ground service setter", "service", s, "error", err)
+				return
+			}
+			setIDs[s] = id
+		} else if h.Event != "" {
+			id := sc.GetEventID()
+			if err := sc.MapClientEventToSimEvent(id, h.Event); err != nil {
+				slog.Error("Cannot map ground service event", "service", s, "error", err)
+				return
+			}
+			eventIDs[s] = id
+		}
+	}
+
+	m.mu.Lock()
+	m.sc = sc
+	m.observed = observed
+	m.stateID = stateID
+	m.setIDs = setIDs
+	m.eventIDs = eventIDs
+	m.mu.Unlock()
+
+	if len(observed) == 0 {
+		return
+	}
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(m.interval):
+				if err := sc.RequestDataOnSimObjectType(stateID, stateID, 0, client.SIMOBJECT_TYPE_USER); err != nil {
+					slog.Error("Cannot request ground service state", "error", err)
+				}
+			}
+		}
+	})
+}
+
+// Update confirms the service states from the state variables
+func (m *Machine) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	m.mu.Lock()
+	if m.sc != sc || ppData.DefineID != m.stateID || len(m.observed) == 0 {
+		m.mu.Unlock()
+		return
+	}
+	data := unsafe.Slice((*float64)(ppData.DataPointer()), len(m.observed))
+	var changes []Transition
+	for i, s := range m.observed {
+		h := m.hooks[s]
+		on := data[i] != 0
+		if h.IsOn != nil {
+			on = h.IsOn(data[i])
+		}
+		to := StateOff
+		if on {
+			to = StateOn
+		}
+		from := m.states[s]
+		// a requested transition stays requested until it is observed
+		if (from == StateRequestedOn && !on) || (from == StateRequestedOff && on) {
+			continue
+		}
+		if from != to {
+			m.states[s] = to
+			changes = append(changes, Transition{Service: s, From: from, To: to, At: time.Now()})
+		}
+	}
+	m.mu.Unlock()
+	m.notify(changes...)
+}
+
+// State returns the current state of a service
+func (m *Machine) State(s Service) State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.states[s]
+}
+
+// States returns the current state of every service
+func (m *Machine) States() map[Service]State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[Service]State, len(m.hooks))
+	for s := range m.hooks {
+		out[s] = m.states[s]
+	}
+	return out
+}
+
+// Request drives a service on or off; it does nothing if already in that state
+func (m *Machine) Request(s Service, on bool) error {
+	m.mu.Lock()
+	sc := m.sc
+	h, ok := m.hooks[s]
+	from := m.states[s]
+	setID, hasSet := m.setIDs[s]
+	eventID, hasEvent := m.eventIDs[s]
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no hook for ground service %s", s)
+	}
+	if sc == nil {
+		return fmt.Errorf("ground services not started")
+	}
+	if (on && (from == StateOn || from == StateRequestedOn)) || (!on && (from == StateOff || from == StateRequestedOff)) {
+		return nil
+	}
+
+	switch {
+	case hasSet:
+		v := 0.0
+		if on {
+			v = 1
+		}
+		if err := sc.SetDataOnSimObject(setID, client.OBJECT_ID_USER, 0, 0, 8, unsafe.Pointer(&v)); err != nil {
+			return err
+		}
+	case hasEvent:
+		if err := sc.TransmitClientEvent(client.OBJECT_ID_USER, eventID, 0, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("ground service %s has no event or variable", s)
+	}
+
+	to := StateRequestedOff
+	if on {
+		to = StateRequestedOn
+	}
+	// without a state variable the request is assumed to succeed
+	if h.StateVar == "" {
+		to = StateOff
+		if on {
+			to = StateOn
+		}
+	}
+	m.mu.Lock()
+	m.states[s] = to
+	m.mu.Unlock()
+	m.notify(Transition{Service: s, From: from, To: to, At: time.Now()})
+	return nil
+}
+
+func (m *Machine) notify(changes ...Transition) {
+	for _, t := range changes {
+		for _, fn := range m.onChange {
+			fn(t)
+		}
+	}
+}
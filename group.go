@@ -0,0 +1,54 @@
+package simconnect
+
+import (
+	"context"
+	"sync"
+)
+
+// Group tracks the goroutines a receiver starts for a single connection
+// the Connector waits for every group to finish before it closes the
+// connection, so goroutines never use a closed SimConnect handle
+type Group struct {
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+type groupKey struct{}
+
+func newGroup(ctx context.Context) (*Group, context.Context) {
+	g := &Group{}
+	g.ctx = context.WithValue(ctx, groupKey{}, g)
+	return g, g.ctx
+}
+
+// GroupFromContext returns the group for the receiver context passed to Start
+// it returns nil if the context did not come from a Connector
+func GroupFromContext(ctx context.Context) *Group {
+	g, _ := ctx.Value(groupKey{}).(*Group)
+	return g
+}
+
+// Go runs fn in a goroutine tracked by the group
+// fn must return promptly once ctx is cancelled
+func (g *Group) Go(fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn(g.ctx)
+	}()
+}
+
+// Wait blocks until every goroutine in the group has returned
+func (g *Group) Wait() {
+	g.wg.Wait()
+}
+
+// Go runs fn in a goroutine tracked by the receiver's group in ctx
+// if ctx has no group, fn runs in a plain goroutine
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	if g := GroupFromContext(ctx); g != nil {
+		g.Go(fn)
+		return
+	}
+	go fn(ctx)
+}
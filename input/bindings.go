@@ -0,0 +1,294 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Binding maps a hardware input to sim events
+// a button sends Event on press and Release on release; an encoder, which
+// has Down set, sends Inc when Input fires and Dec when Down fires
+type Binding struct {
+	// Input is a raw input definition, eg "joystick:1:button:3"
+	Input string `json:"input"`
+
+	Event        string `json:"event,omitempty"`
+	Value        int32  `json:"value,omitempty"`
+	Release      string `json:"release,omitempty"`
+	ReleaseValue int32  `json:"release_value,omitempty"`
+
+	Down string `json:"down,omitempty"`
+	Inc  string `json:"inc,omitempty"`
+	Dec  string `json:"dec,omitempty"`
+	// Accel is the most events a detent sends when the encoder is turned
+	// quickly; each detent within FastMS of the last adds one
+	Accel  int `json:"accel,omitempty"`
+	FastMS int `json:"fast_ms,omitempty"`
+}
+
+func (b Binding) encoder() bool { return b.Down != "" }
+
+func (b Binding) validate() error {
+	if b.Input == "" {
+		return fmt.Errorf("binding has no input")
+	}
+	if b.encoder() {
+		if b.Inc == "" || b.Dec == "" {
+			return fmt.Errorf("encoder %s needs inc and dec events", b.Input)
+		}
+		return nil
+	}
+	if b.Event == "" {
+		return fmt.Errorf("button %s has no event", b.Input)
+	}
+	return nil
+}
+
+// LoadBindings reads bindings from a JSON file, a list of Binding:
+//
+//	[
+//	  {"input": "joystick:1:button:0", "event": "GEAR_TOGGLE"},
+//	  {"input": "joystick:1:button:1", "event": "FLAPS_INCR"},
+//	  {"input": "joystick:1:button:4", "down": "joystick:1:button:5",
+//	   "inc": "HEADING_BUG_INC", "dec": "HEADING_BUG_DEC", "accel": 10, "fast_ms": 60}
+//	]
+func LoadBindings(path string) ([]Binding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bindings []Binding
+	if err := json.Unmarshal(data, &bindings); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	for _, b := range bindings {
+		if err := b.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return bindings, nil
+}
+
+// action is what a private client event does when its input fires
+type action struct {
+	binding *Binding
+	event   string
+	value   int32
+	encoder bool
+
+	// the encoder's acceleration state, shared by both directions
+	accel *accelState
+}
+
+type accelState struct {
+	last  time.Time
+	count int
+}
+
+// Panel is a receiver that sends sim events for hardware inputs
+// its bindings can be replaced while connected, eg from a watched file
+type Panel struct {
+	priority client.DWORD
+
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	group    client.DWORD
+	bindings []Binding
+	actions  map[client.DWORD]*action
+}
+
+// PanelOption is a function that sets options on the Panel
+type PanelOption func(*Panel)
+
+// WithPanelPriority sets the input group priority; the default is GROUP_PRIORITY_HIGHEST
+func WithPanelPriority(priority client.DWORD) PanelOption {
+	return func(p *Panel) {
+		p.priority = priority
+	}
+}
+
+// NewPanel creates a panel with the bindings
+func NewPanel(bindings []Binding, opts ...PanelOption) *Panel {
+	p := &Panel{
+		priority: client.GROUP_PRIORITY_HIGHEST,
+		bindings: bindings,
+		actions:  map[client.DWORD]*action{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *Panel) Start(ctx context.Context, sc *client.SimConnect) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sc = sc
+	p.group = sc.GetEventID()
+	if err := sc.SetInputGroupPriority(p.group, p.priority); err != nil {
+		slog.Error("Cannot set input group priority", "error", err)
+	}
+	p.bind()
+}
+
+func (p *Panel) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// bind maps the bindings into the input group; it must be called with the lock held
+func (p *Panel) bind() {
+	sc := p.sc
+	actions := map[client.DWORD]*action{}
+	for i := range p.bindings {
+		b := &p.bindings[i]
+		var err error
+		if b.encoder() {
+			acc := &accelState{}
+			err = p.mapInput(actions, b.Input, &action{binding: b, event: b.Inc, value: b.Value, encoder: true, accel: acc}, nil)
+			if err == nil {
+				err = p.mapInput(actions, b.Down, &action{binding: b, event: b.Dec, value: b.Value, encoder: true, accel: acc}, nil)
+			}
+		} else {
+			var release *action
+			if b.Release != "" {
+				release = &action{binding: b, event: b.Release, value: b.ReleaseValue}
+			}
+			err = p.mapInput(actions, b.Input, &action{binding: b, event: b.Event, value: b.Value}, release)
+		}
+		if err != nil {
+			slog.Error("Cannot bind input", "input", b.Input, "error", err)
+		}
+	}
+	p.actions = actions
+	if err := sc.SetInputGroupState(p.group, client.STATE_ON); err != nil {
+		slog.Error("Cannot enable input group", "error", err)
+	}
+}
+
+// mapInput binds an input to private client events for press and, if set, release
+func (p *Panel) mapInput(actions map[client.DWORD]*action, input string, press, release *action) error {
+	sc := p.sc
+	for _, a := range []*action{press, release} {
+		if a == nil {
+			continue
+		}
+		if _, err := sc.MapClientEventByName(a.event); err != nil {
+			return err
+		}
+	}
+	down := sc.GetEventID()
+	if err := sc.MapClientEventToSimEvent(down, ""); err != nil {
+		return err
+	}
+	up := client.UNUSED
+	if release != nil {
+		up = sc.GetEventID()
+		if err := sc.MapClientEventToSimEvent(up, ""); err != nil {
+			return err
+		}
+	}
+	if err := sc.MapInputEventToClientEvent(p.group, input, down, 0, up, 0, false); err != nil {
+		return err
+	}
+	actions[down] = press
+	if release != nil {
+		actions[up] = release
+	}
+	return nil
+}
+
+// SetBindings replaces the bindings, remapping them now if connected
+func (p *Panel) SetBindings(bindings []Binding) error {
+	for _, b := range bindings {
+		if err := b.validate(); err != nil {
+			return err
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bindings = bindings
+	if p.sc == nil {
+		return nil
+	}
+	if err := p.sc.ClearInputGroup(p.group); err != nil {
+		return err
+	}
+	p.bind()
+	return nil
+}
+
+// Bindings returns the current bindings
+func (p *Panel) Bindings() []Binding {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Binding(nil), p.bindings...)
+}
+
+// WatchBindings applies the bindings file now and whenever it changes
+// a file that fails to load is logged and the current bindings kept
+// it blocks until the context is cancelled
+func (p *Panel) WatchBindings(ctx context.Context, path string, interval time.Duration) {
+	var modTime time.Time
+	for {
+		if st, err := os.Stat(path); err == nil && !st.ModTime().Equal(modTime) {
+			modTime = st.ModTime()
+			if bindings, err := LoadBindings(path); err != nil {
+				slog.Error("Cannot load bindings", "path", path, "error", err)
+			} else if err := p.SetBindings(bindings); err != nil {
+				slog.Error("Cannot apply bindings", "path", path, "error", err)
+			} else {
+				slog.Info("Applied bindings", "path", path, "bindings", len(bindings))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Event sends the events bound to a fired input
+func (p *Panel) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	p.mu.Lock()
+	a, ok := p.actions[ev.EventID]
+	n := 1
+	if ok && a.encoder {
+		n = a.accel.detent(*a.binding, time.Now())
+	}
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+	id, err := sc.MapClientEventByName(a.event)
+	if err != nil {
+		slog.Error("Cannot map bound event", "event", a.event, "error", err)
+		return
+	}
+	for i := 0; i < n; i++ {
+		err := sc.TransmitClientEvent(client.OBJECT_ID_USER, id, client.DWORD(a.value), client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+		if err != nil {
+			slog.Error("Cannot send bound event", "event", a.event, "error", err)
+			return
+		}
+	}
+}
+
+// detent returns how many events a detent sends, speeding up while the
+// encoder turns quickly
+func (s *accelState) detent(b Binding, now time.Time) int {
+	fast := time.Duration(b.FastMS) * time.Millisecond
+	if b.Accel > 1 && fast > 0 && now.Sub(s.last) < fast {
+		s.count = min(s.count+1, b.Accel)
+	} else {
+		s.count = 1
+	}
+	s.last = now
+	return s.count
+}
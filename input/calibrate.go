@@ -0,0 +1,252 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bmurray/simconnect-go/store"
+)
+
+// Calibration maps a physical axis onto the full range and shapes it
+// Min, Center and Max are the raw values, normalised by the axis Range, at
+// the stops and at rest; the zero Calibration is the uncalibrated axis
+type Calibration struct {
+	Min      float64      `json:"min"`
+	Center   float64      `json:"center"`
+	Max      float64      `json:"max"`
+	Deadzone float64      `json:"deadzone,omitempty"`
+	Expo     float64      `json:"expo,omitempty"`
+	Curve    [][2]float64 `json:"curve,omitempty"` // applied instead of Expo if set
+	Trim     float64      `json:"trim,omitempty"`
+	Invert   bool         `json:"invert,omitempty"`
+}
+
+// Calibrations are calibrations keyed by axis Input or Event
+type Calibrations map[string]Calibration
+
+// Normalize maps a raw value onto [-1, 1], each side of the centre
+// scaled separately so an off-centre rest position still reaches both stops
+func (c Calibration) Normalize(v float64) float64 {
+	if c.Min == 0 && c.Max == 0 {
+		return clamp(v)
+	}
+	if v >= c.Center {
+		if c.Max <= c.Center {
+			return 0
+		}
+		return clamp((v - c.Center) / (c.Max - c.Center))
+	}
+	if c.Min >= c.Center {
+		return 0
+	}
+	return clamp((v - c.Center) / (c.Center - c.Min))
+}
+
+// Shape returns the calibration as a shape
+func (c Calibration) Shape() Shape {
+	shapes := []Shape{c.Normalize}
+	if c.Deadzone > 0 {
+		shapes = append(shapes, Deadzone(c.Deadzone))
+	}
+	if len(c.Curve) > 0 {
+		shapes = append(shapes, Piecewise(c.Curve))
+	} else if c.Expo != 0 {
+		shapes = append(shapes, Expo(c.Expo))
+	}
+	if c.Trim != 0 {
+		shapes = append(shapes, Trim(c.Trim))
+	}
+	if c.Invert {
+		shapes = append(shapes, Invert)
+	}
+	return Chain(shapes...)
+}
+
+func (c Calibration) validate() error {
+	if c.Min > c.Center || c.Center > c.Max {
+		return fmt.Errorf("min %g, center %g and max %g are out of order", c.Min, c.Center, c.Max)
+	}
+	if !sort.SliceIsSorted(c.Curve, func(a, b int) bool { return c.Curve[a][0] < c.Curve[b][0] }) {
+		return fmt.Errorf("curve points are not sorted by input")
+	}
+	return nil
+}
+
+// LoadCalibrations reads calibrations from a JSON file
+func LoadCalibrations(path string) (Calibrations, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseCalibrations(path, data)
+}
+
+// LoadCalibrationsFrom reads calibrations from a key of a store, as
+// written by Put
+func LoadCalibrationsFrom(st store.Store, key string) (Calibrations, error) {
+	data, err := st.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return parseCalibrations(key, data)
+}
+
+func parseCalibrations(name string, data []byte) (Calibrations, error) {
+	cals := Calibrations{}
+	if err := json.Unmarshal(data, &cals); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", name, err)
+	}
+	for key, c := range cals {
+		if err := c.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return cals, nil
+}
+
+// Save writes the calibrations to a JSON file
+// the file is replaced in one step, so a watcher never reads half of it
+func (cals Calibrations) Save(path string) error {
+	data, err := json.MarshalIndent(cals, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Put writes the calibrations to a key of a store
+func (cals Calibrations) Put(st store.Store, key string) error {
+	return store.PutJSON(st, key, cals)
+}
+
+// Capture records an axis' range while it is moved through its stops
+type Capture struct {
+	mu     sync.Mutex
+	last   float64
+	cal    Calibration
+	seen   bool
+	center bool
+}
+
+func (c *Capture) observe(v float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = v
+	if !c.seen {
+		c.cal.Min, c.cal.Max, c.seen = v, v, true
+		return
+	}
+	c.cal.Min = math.Min(c.cal.Min, v)
+	c.cal.Max = math.Max(c.cal.Max, v)
+}
+
+// SetCenter takes the latest value as the rest position
+func (c *Capture) SetCenter() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cal.Center, c.center = c.last, true
+}
+
+// Calibration returns the captured range
+// without SetCenter the centre is midway between the stops
+func (c *Capture) Calibration() Calibration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cal := c.cal
+	if !c.center {
+		cal.Center = (cal.Min + cal.Max) / 2
+	}
+	return cal
+}
+
+// Calibrate starts capturing the range of an axis, keyed by its Input or
+// Event; values keep flowing through the current shape meanwhile
+// call StopCalibrate to finish
+func (i *Interceptor) Calibrate(key string) *Capture {
+	c := &Capture{}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.captures[key] = c
+	return c
+}
+
+// StopCalibrate stops capturing an axis and returns its calibration, with
+// the shaping of any existing calibration kept
+func (i *Interceptor) StopCalibrate(key string) (Calibration, bool) {
+	i.mu.Lock()
+	c, ok := i.captures[key]
+	delete(i.captures, key)
+	prev := i.calibrations[key]
+	i.mu.Unlock()
+	if !ok {
+		return Calibration{}, false
+	}
+	cal := c.Calibration()
+	cal.Deadzone, cal.Expo, cal.Curve, cal.Trim, cal.Invert = prev.Deadzone, prev.Expo, prev.Curve, prev.Trim, prev.Invert
+	return cal, true
+}
+
+// Calibrations returns the applied calibrations
+func (i *Interceptor) Calibrations() Calibrations {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	out := make(Calibrations, len(i.calibrations))
+	for k, c := range i.calibrations {
+		out[k] = c
+	}
+	return out
+}
+
+// ApplyCalibrations replaces the shapes of the calibrated axes
+// axes without a calibration keep their shape
+func (i *Interceptor) ApplyCalibrations(cals Calibrations) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for key, c := range cals {
+		i.calibrations[key] = c
+		i.shapes[key] = c.Shape()
+	}
+}
+
+// WatchCalibrations applies the calibration file now and whenever it changes
+// a file that fails to load is logged and the current calibrations kept
+// it blocks until the context is cancelled
+func (i *Interceptor) WatchCalibrations(ctx context.Context, path string, interval time.Duration) {
+	var modTime time.Time
+	for {
+		if st, err := os.Stat(path); err == nil && !st.ModTime().Equal(modTime) {
+			modTime = st.ModTime()
+			if cals, err := LoadCalibrations(path); err != nil {
+				slog.Error("Cannot load calibrations", "path", path, "error", err)
+			} else {
+				i.ApplyCalibrations(cals)
+				slog.Info("Applied calibrations", "path", path, "axes", len(cals))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package input shapes joystick axes inside Go
+//
+// The Interceptor takes raw input events, or intercepts sim axis events,
+// passes the value through a Shape (curves, deadzones, trims) and sends the
+// result on to the sim, so no external tools are needed for control shaping
+//
+// Axes can be calibrated by capturing their stops and centre; calibrations
+// are saved to a JSON file and applied live when the file changes.
+//
+// The Panel binds buttons and encoders to sim events from a bindings file
+// that is also reloaded when it changes, so cockpit builders can iterate on
+// mappings without restarting.
+package input
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// AxisRange is the range of the sim's axis events, eg AXIS_ELEVATOR_SET
+const AxisRange = 16383
+
+// Axis is an axis to intercept
+type Axis struct {
+	// Input is a raw input definition, eg "joystick:0:YAxis"
+	// the sim binding for the same input should be removed, or it masked by
+	// the input group priority
+	Input string
+	// Event is a sim event to intercept when Input is empty, eg "AXIS_ELEVATOR_SET"
+	Event string
+	// Target is the event the shaped value is sent to; it defaults to Event
+	Target string
+	// Range is the magnitude of the incoming values; it defaults to AxisRange
+	Range float64
+	Shape Shape
+}
+
+func (a Axis) key() string {
+	if a.Input != "" {
+		return a.Input
+	}
+	return a.Event
+}
+
+type axisState struct {
+	Axis
+	in  client.DWORD
+	out client.DWORD
+}
+
+// Interceptor is a receiver that shapes axis values
+type Interceptor struct {
+	priority client.DWORD
+
+	mu     sync.Mutex
+	sc     *client.SimConnect
+	axes   []Axis
+	byIn   map[client.DWORD]*axisState
+	shapes map[string]Shape
+
+	calibrations Calibrations
+	captures     map[string]*Capture
+}
+
+// Option is a function that sets options on the Interceptor
+type Option func(*Interceptor)
+
+// WithPriority sets the input and notification group priority
+// it must be maskable for the original value to be hidden from the sim;
+// the default is GROUP_PRIORITY_HIGHEST_MASKABLE
+func WithPriority(p client.DWORD) Option {
+	return func(i *Interceptor) {
+		i.priority = p
+	}
+}
+
+// New creates an interceptor for the axes
+func New(axes []Axis, opts ...Option) *Interceptor {
+	i := &Interceptor{
+		priority: client.GROUP_PRIORITY_HIGHEST_MASKABLE,
+		axes:     axes,
+		byIn:     map[client.DWORD]*axisState{},
+		shapes:   map[string]Shape{},
+
+		calibrations: Calibrations{},
+		captures:     map[string]*Capture{},
+	}
+	for _, a := range axes {
+		i.shapes[a.key()] = a.Shape
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// SetShape replaces the shape of an axis, keyed by its Input or Event
+// this takes effect on the next value, eg to adjust trim in flight
+func (i *Interceptor) SetShape(key string, s Shape) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.shapes[key] = s
+}
+
+func (i *Interceptor) Start(ctx context.Context, sc *client.SimConnect) {
+	inputGroup := sc.GetEventID()
+	notifyGroup := sc.GetEventID()
+	byIn := map[client.DWORD]*axisState{}
+	var inputs, events int
+	for _, a := range i.axes {
+		st := &axisState{Axis: a}
+		if st.Target == "" {
+			st.Target = st.Event
+		}
+		if st.Range == 0 {
+			st.Range = AxisRange
+		}
+		out, err := sc.MapClientEventByName(st.Target)
+		if err != nil {
+			slog.Error("Cannot map axis target", "event", st.Target, "error", err)
+			continue
+		}
+		st.out = out
+		if a.Input != "" {
+			// a private client event carries the raw value
+			st.in = sc.GetEventID()
+			if err := sc.MapClientEventToSimEvent(st.in, ""); err != nil {
+				slog.Error("Cannot map axis input event", "input", a.Input, "error", err)
+				continue
+			}
+			if err := sc.MapInputEventToClientEvent(inputGroup, a.Input, st.in, 0, client.UNUSED, 0, true); err != nil {
+				slog.Error("Cannot map axis input", "input", a.Input, "error", err)
+				continue
+			}
+			inputs++
+		} else {
+			in, err := sc.MapClientEventByName(a.Event)
+			if err != nil {
+				slog.Error("Cannot map axis event", "event", a.Event, "error", err)
+				continue
+			}
+			st.in = in
+			if err := sc.AddClientEventToNotificationGroupMaskable(notifyGroup, in, true); err != nil {
+				slog.Error("Cannot intercept axis event", "event", a.Event, "error", err)
+				continue
+			}
+			events++
+		}
+		byIn[st.in] = st
+	}
+	if inputs > 0 {
+		if err := sc.SetInputGroupPriority(inputGroup, i.priority); err != nil {
+			slog.Error("Cannot set input group priority", "error", err)
+		}
+		if err := sc.SetInputGroupState(inputGroup, client.STATE_ON); err != nil {
+			slog.Error("Cannot enable input group", "error", err)
+		}
+	}
+	if events > 0 {
+		if err := sc.SetNotificationGroupPriority(notifyGroup, i.priority); err != nil {
+			slog.Error("Cannot set notification group priority", "error", err)
+		}
+	}
+	i.mu.Lock()
+	i.sc = sc
+	i.byIn = byIn
+	i.mu.Unlock()
+}
+
+func (i *Interceptor) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// Event shapes an intercepted value and sends it on
+func (i *Interceptor) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	i.mu.Lock()
+	st, ok := i.byIn[ev.EventID]
+	var shape Shape
+	var capture *Capture
+	if ok {
+		shape = i.shapes[st.key()]
+		capture = i.captures[st.key()]
+	}
+	i.mu.Unlock()
+	if !ok {
+		return
+	}
+	v := clamp(float64(int32(ev.Data)) / st.Range)
+	if capture != nil {
+		capture.observe(v)
+	}
+	if shape != nil {
+		v = shape(v)
+	}
+	out := int32(math.Round(clamp(v) * AxisRange))
+	// sent just below our priority, so it goes on to the sim without
+	// coming back to us
+	err := sc.TransmitClientEvent(client.OBJECT_ID_USER, st.out, client.DWORD(out), i.priority+1, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+	if err != nil {
+		slog.Error("Cannot send shaped axis", "event", st.Target, "error", err)
+	}
+}
@@ -0,0 +1,83 @@
+package input
+
+import "math"
+
+// Shape transforms a normalised axis value in [-1, 1]
+type Shape func(v float64) float64
+
+// Linear passes the value through
+func Linear(v float64) float64 { return v }
+
+// Chain applies the shapes in order
+func Chain(shapes ...Shape) Shape {
+	return func(v float64) float64 {
+		for _, s := range shapes {
+			v = s(v)
+		}
+		return v
+	}
+}
+
+// Deadzone zeroes values within dz of the centre and rescales the rest,
+// so the output still reaches full deflection
+func Deadzone(dz float64) Shape {
+	return func(v float64) float64 {
+		a := math.Abs(v)
+		if a <= dz || dz >= 1 {
+			return 0
+		}
+		return math.Copysign((a-dz)/(1-dz), v)
+	}
+}
+
+// Expo blends in a cubic curve; 0 is linear and 1 is fully cubic, which
+// softens the response around the centre
+func Expo(k float64) Shape {
+	return func(v float64) float64 {
+		return (1-k)*v + k*v*v*v
+	}
+}
+
+// Trim offsets the value, clamped to the axis range
+func Trim(offset float64) Shape {
+	return func(v float64) float64 {
+		return clamp(v + offset)
+	}
+}
+
+// Scale multiplies the value, clamped to the axis range; use it to limit authority
+func Scale(f float64) Shape {
+	return func(v float64) float64 {
+		return clamp(v * f)
+	}
+}
+
+// Invert reverses the axis
+func Invert(v float64) float64 { return -v }
+
+func clamp(v float64) float64 {
+	return math.Max(-1, math.Min(1, v))
+}
+
+// Piecewise interpolates linearly between points, given as [in, out] pairs
+// sorted by input; values outside the points take the nearest end
+func Piecewise(points [][2]float64) Shape {
+	return func(v float64) float64 {
+		if len(points) == 0 {
+			return v
+		}
+		if v <= points[0][0] {
+			return points[0][1]
+		}
+		for j := 1; j < len(points); j++ {
+			a, b := points[j-1], points[j]
+			if v <= b[0] {
+				if b[0] == a[0] {
+					return b[1]
+				}
+				return a[1] + (v-a[0])*(b[1]-a[1])/(b[0]-a[0])
+			}
+		}
+		return points[len(points)-1][1]
+	}
+}
@@ -0,0 +1,256 @@
+// Package interlock enforces limits on writes to critical simvars
+//
+// Rules are checked in the client's write path, so every SetData and
+// one-shot write goes through them, whichever receiver makes it. A write
+// that breaks a rule is blocked, or clamped to the limit, and audited.
+// Rules can be loaded from a JSON file so end users can set their own:
+//
+//	[
+//	  {"name": "no takeoff power on the ground", "simvar": "GENERAL ENG THROTTLE LEVER POSITION",
+//	   "max": 30, "when": "ground", "clamp": true},
+//	  {"name": "keep fuel in flight", "simvar": "FUEL TANK LEFT MAIN QUANTITY", "min": 1, "when": "air"}
+//	]
+package interlock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Rule limits the values written to a simvar
+type Rule struct {
+	Name string `json:"name"`
+	// Simvar is the datum the rule applies to; without an index, eg
+	// "GENERAL ENG THROTTLE LEVER POSITION", it applies to every index
+	Simvar string   `json:"simvar"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	// When is "ground" or "air" to apply the rule only there; empty is always
+	When string `json:"when,omitempty"`
+	// Clamp writes the limit instead of blocking the write
+	Clamp bool `json:"clamp,omitempty"`
+}
+
+func (r Rule) matches(name string) bool {
+	if strings.EqualFold(r.Simvar, name) {
+		return true
+	}
+	base, _, indexed := strings.Cut(name, ":")
+	return indexed && !strings.Contains(r.Simvar, ":") && strings.EqualFold(r.Simvar, base)
+}
+
+func (r Rule) applies(onGround, known bool) bool {
+	switch r.When {
+	case "ground":
+		return known && onGround
+	case "air":
+		return known && !onGround
+	}
+	return true
+}
+
+// limit returns the allowed value nearest v, and whether v was allowed
+func (r Rule) limit(v float64) (float64, bool) {
+	if r.Min != nil && v < *r.Min {
+		return *r.Min, false
+	}
+	if r.Max != nil && v > *r.Max {
+		return *r.Max, false
+	}
+	return v, true
+}
+
+func (r Rule) validate() error {
+	if r.Simvar == "" {
+		return fmt.Errorf("rule %q has no simvar", r.Name)
+	}
+	if r.Min == nil && r.Max == nil {
+		return fmt.Errorf("rule %q has no min or max", r.Name)
+	}
+	if r.When != "" && r.When != "ground" && r.When != "air" {
+		return fmt.Errorf("rule %q: when must be ground or air, not %q", r.Name, r.When)
+	}
+	return nil
+}
+
+// LoadRules reads rules from a JSON file
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	for _, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// Violation is a write that broke a rule
+type Violation struct {
+	Time     time.Time `json:"time"`
+	Rule     string    `json:"rule"`
+	Simvar   string    `json:"simvar"`
+	ObjectID uint32    `json:"object_id"`
+	Value    float64   `json:"value"`
+	Written  *float64  `json:"written,omitempty"` // the clamped value; nil if blocked
+}
+
+// Error is returned by SetData for a blocked write
+type Error struct {
+	Violation
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("interlock %q blocked %s = %g", e.Rule, e.Simvar, e.Value)
+}
+
+// GroundReport is the data structure used for ground and air rules
+type GroundReport struct {
+	client.RecvSimobjectDataByType
+	OnGround float64 `name:"SIM ON GROUND" unit:"Bool"`
+}
+
+// Guard is a receiver that installs the rules on every connection
+type Guard struct {
+	interval    time.Duration
+	audit       io.Writer
+	onViolation func(Violation)
+
+	mu       sync.Mutex
+	rules    []Rule
+	onGround bool
+	known    bool
+}
+
+// Option is a function that sets options on the Guard
+type Option func(*Guard)
+
+// WithInterval sets how often the ground state is sampled
+func WithInterval(d time.Duration) Option {
+	return func(g *Guard) {
+		g.interval = d
+	}
+}
+
+// WithAudit writes every violation to w as a JSON line
+func WithAudit(w io.Writer) Option {
+	return func(g *Guard) {
+		g.audit = w
+	}
+}
+
+// WithOnViolation sets a callback that is called for every violation
+func WithOnViolation(fn func(Violation)) Option {
+	return func(g *Guard) {
+		g.onViolation = fn
+	}
+}
+
+// New creates a guard for the rules
+func New(rules []Rule, opts ...Option) *Guard {
+	g := &Guard{interval: time.Second, rules: rules}
+	for _, o := range opts {
+		o(g)
+	}
+	return g
+}
+
+// SetRules replaces the rules; it takes effect on the next write
+func (g *Guard) SetRules(rules []Rule) error {
+	for _, r := range rules {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rules = rules
+	return nil
+}
+
+// Start installs the guard on the connection and samples the ground state
+// until the ground state is known, ground and air rules do not apply
+func (g *Guard) Start(ctx context.Context, sc *client.SimConnect) {
+	g.mu.Lock()
+	g.known = false
+	g.mu.Unlock()
+	sc.AddWriteGuard(g.check)
+	if err := simconnect.Subscribe[GroundReport](ctx, sc, g.interval); err != nil {
+		slog.Error("Cannot subscribe to ground state", "error", err)
+	}
+}
+
+// Update tracks the ground state
+func (g *Guard) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[GroundReport](sc, ppData)
+	if !ok {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onGround, g.known = r.OnGround != 0, true
+}
+
+// check is the client write guard
+func (g *Guard) check(w *client.Write) error {
+	g.mu.Lock()
+	rules, onGround, known := g.rules, g.onGround, g.known
+	g.mu.Unlock()
+	for i, d := range w.Datums {
+		for _, r := range rules {
+			if !r.matches(d.Name) || !r.applies(onGround, known) {
+				continue
+			}
+			v := w.Values[i]
+			allowed, ok := r.limit(v)
+			if ok {
+				continue
+			}
+			vio := Violation{Time: time.Now(), Rule: r.Name, Simvar: d.Name, ObjectID: uint32(w.ObjectID), Value: v}
+			if !r.Clamp {
+				g.record(vio)
+				return &Error{Violation: vio}
+			}
+			w.Set(i, allowed)
+			vio.Written = &allowed
+			g.record(vio)
+		}
+	}
+	return nil
+}
+
+func (g *Guard) record(v Violation) {
+	if v.Written != nil {
+		slog.Warn("Interlock clamped write", "rule", v.Rule, "simvar", v.Simvar, "value", v.Value, "written", *v.Written)
+	} else {
+		slog.Warn("Interlock blocked write", "rule", v.Rule, "simvar", v.Simvar, "value", v.Value)
+	}
+	if g.audit != nil {
+		line, _ := json.Marshal(v)
+		g.mu.Lock()
+		_, err := g.audit.Write(append(line, '\n'))
+		g.mu.Unlock()
+		if err != nil {
+			slog.Error("Cannot write interlock audit", "error", err)
+		}
+	}
+	if g.onViolation != nil {
+		g.onViolation(v)
+	}
+}
@@ -0,0 +1,97 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileStore is a Store writing one JSON entry per line
+// queries scan the whole file, which is fine for a flight or two
+type FileStore struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+	w  *bufio.Writer
+}
+
+// OpenFile opens a journal file for appending, creating it if needed
+func OpenFile(path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{path: path, f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// Append writes the entries and flushes them to the file
+func (s *FileStore) Append(entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return fmt.Errorf("journal %s is closed", s.path)
+	}
+	enc := json.NewEncoder(s.w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return s.w.Flush()
+}
+
+// Query reads the file and returns the matching entries in the order they were written
+func (s *FileStore) Query(q Query) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w != nil {
+		if err := s.w.Flush(); err != nil {
+			return nil, err
+		}
+	}
+	return QueryFile(s.path, q)
+}
+
+// Close flushes and closes the file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.w.Flush()
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	s.f, s.w = nil, nil
+	return err
+}
+
+// QueryFile returns the matching entries of a journal file without opening it for writing
+func QueryFile(path string, q Query) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return out, fmt.Errorf("journal %s line %d: %w", path, line, err)
+		}
+		if q.Match(e) {
+			out = append(out, e)
+		}
+	}
+	return out, sc.Err()
+}
@@ -0,0 +1,190 @@
+// Package journal records every change of a set of simvars, with the sim
+// timestamp, so a flight can be examined afterwards
+//
+// The values are sampled every period and each value that changed is
+// appended to a Store. FileStore keeps the journal as JSON lines and
+// SQLite in a table indexed for time range queries; other backends plug
+// in by implementing Store.
+// cmd/simlog records and queries journals from the command line.
+package journal
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+	"unsafe"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Var is a simvar to journal
+type Var struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+}
+
+// Entry is one changed value
+type Entry struct {
+	At time.Time `json:"at"`
+	// SimTime is ABSOLUTE TIME, in seconds, when the value was sampled
+	SimTime float64 `json:"sim_time"`
+	Name    string  `json:"name"`
+	Value   float64 `json:"value"`
+}
+
+// Query selects entries; zero fields match everything
+type Query struct {
+	From  time.Time
+	To    time.Time
+	Names []string
+}
+
+// Match returns true if the entry is selected by the query
+func (q Query) Match(e Entry) bool {
+	if !q.From.IsZero() && e.At.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && e.At.After(q.To) {
+		return false
+	}
+	if len(q.Names) == 0 {
+		return true
+	}
+	for _, n := range q.Names {
+		if n == e.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// Store persists journal entries
+type Store interface {
+	Append(entries []Entry) error
+	Query(q Query) ([]Entry, error)
+	Close() error
+}
+
+// Journal is a receiver that writes changed simvar values to a Store
+type Journal struct {
+	store   Store
+	vars    []Var
+	period  client.DWORD
+	epsilon float64
+	onError func(error)
+
+	mu       sync.Mutex
+	started  bool
+	defineID client.DWORD
+	last     []float64
+	seen     []bool
+}
+
+// Option is a function that sets options on the Journal
+type Option func(*Journal)
+
+// WithPeriod sets how often the sim checks for changes, eg PERIOD_SIM_FRAME
+// the default is PERIOD_SECOND
+func WithPeriod(period client.DWORD) Option {
+	return func(j *Journal) {
+		j.period = period
+	}
+}
+
+// WithEpsilon sets how much a value must change to be journaled
+func WithEpsilon(epsilon float64) Option {
+	return func(j *Journal) {
+		j.epsilon = epsilon
+	}
+}
+
+// WithOnError sets a callback that is called when the store fails
+func WithOnError(fn func(error)) Option {
+	return func(j *Journal) {
+		j.onError = fn
+	}
+}
+
+// New creates a journal of the vars
+func New(store Store, vars []Var, opts ...Option) *Journal {
+	j := &Journal{
+		store:  store,
+		vars:   vars,
+		period: client.PERIOD_SECOND,
+	}
+	for _, o := range opts {
+		o(j)
+	}
+	return j
+}
+
+// Start registers the journaled vars and requests their changes
+func (j *Journal) Start(ctx context.Context, sc *client.SimConnect) {
+	defineID := sc.GetDefineIDByName("journal")
+	// the timestamp changes on every sample, so the sim sends every period
+	// and unchanged values are filtered in Update
+	if err := sc.AddToDataDefinition(defineID, "ABSOLUTE TIME", "Seconds", client.DATATYPE_FLOAT64); err != nil {
+		slog.Error("Cannot add journal timestamp", "error", err)
+		return
+	}
+	for _, v := range j.vars {
+		if err := sc.AddToDataDefinition(defineID, v.Name, v.Unit, client.DATATYPE_FLOAT64); err != nil {
+			slog.Error("Cannot add journal var", "name", v.Name, "error", err)
+			return
+		}
+	}
+
+	j.mu.Lock()
+	j.started = true
+	j.defineID = defineID
+	j.last = make([]float64, len(j.vars))
+	j.seen = make([]bool, len(j.vars))
+	j.mu.Unlock()
+
+	requestID := sc.NewRequestID()
+	if err := sc.RequestDataOnSimObject(requestID, defineID, client.OBJECT_ID_USER, j.period, client.DATA_REQUEST_FLAG_DEFAULT, 0, 0, 0); err != nil {
+		slog.Error("Cannot request journal vars", "error", err)
+		return
+	}
+	simconnect.Go(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+		j.mu.Lock()
+		j.started = false
+		j.mu.Unlock()
+	})
+}
+
+// Update appends the changed values to the store
+func (j *Journal) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	j.mu.Lock()
+	if !j.started || ppData.DefineID != j.defineID {
+		j.mu.Unlock()
+		return
+	}
+	data := unsafe.Slice((*float64)(ppData.DataPointer()), 1+len(j.vars))
+	now := time.Now()
+	var entries []Entry
+	for i, v := range j.vars {
+		value := data[1+i]
+		if j.seen[i] && math.Abs(value-j.last[i]) <= j.epsilon {
+			continue
+		}
+		j.seen[i] = true
+		j.last[i] = value
+		entries = append(entries, Entry{At: now, SimTime: data[0], Name: v.Name, Value: value})
+	}
+	j.mu.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+	if err := j.store.Append(entries); err != nil {
+		slog.Error("Cannot append to journal", "error", err)
+		if j.onError != nil {
+			j.onError(err)
+		}
+	}
+}
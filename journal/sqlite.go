@@ -0,0 +1,118 @@
+package journal
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLite is a Store keeping entries in a table of a SQLite database
+// the table is indexed by time and by name and time, so a time range of a
+// long journal is read without scanning it; like store.SQLite the database
+// is opened by the application with the driver it already uses, so this
+// package does not pull one in
+type SQLite struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLite creates a store in a table of db, creating the table and its
+// indexes if needed
+// the table name must be a plain identifier
+func NewSQLite(db *sql.DB, table string) (*SQLite, error) {
+	if !identifier(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (at INTEGER NOT NULL, sim_time REAL NOT NULL, name TEXT NOT NULL, value REAL NOT NULL)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_at ON ` + table + ` (at)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_name_at ON ` + table + ` (name, at)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("cannot create table %s: %w", table, err)
+		}
+	}
+	return &SQLite{db: db, table: table}, nil
+}
+
+func identifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Append inserts the entries in one transaction
+func (s *SQLite) Append(entries []Entry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT INTO ` + s.table + ` (at, sim_time, name, value) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, e := range entries {
+		if _, err := stmt.Exec(e.At.UnixNano(), e.SimTime, e.Name, e.Value); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Query returns the matching entries in the order they were sampled
+func (s *SQLite) Query(q Query) ([]Entry, error) {
+	var where []string
+	var args []any
+	if !q.From.IsZero() {
+		where = append(where, `at >= ?`)
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		where = append(where, `at <= ?`)
+		args = append(args, q.To.UnixNano())
+	}
+	if len(q.Names) > 0 {
+		where = append(where, `name IN (?`+strings.Repeat(`, ?`, len(q.Names)-1)+`)`)
+		for _, n := range q.Names {
+			args = append(args, n)
+		}
+	}
+	query := `SELECT at, sim_time, name, value FROM ` + s.table
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	rows, err := s.db.Query(query+` ORDER BY at, rowid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Entry
+	for rows.Next() {
+		var e Entry
+		var at int64
+		if err := rows.Scan(&at, &e.SimTime, &e.Name, &e.Value); err != nil {
+			return out, err
+		}
+		e.At = time.Unix(0, at)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Close does nothing: the database belongs to whoever opened it
+func (s *SQLite) Close() error {
+	return nil
+}
@@ -0,0 +1,155 @@
+package simconnect
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// JSONName returns the JSON key for a simvar, eg "PLANE ALTITUDE" is
+// "plane_altitude" and "GENERAL ENG RPM:1" is "general_eng_rpm_1"
+func JSONName(simvar string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range simvar {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			underscore = false
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		underscore = true
+	}
+	return b.String()
+}
+
+// jsonField is a report field and its key
+type jsonField struct {
+	index int
+	key   string
+}
+
+var jsonFields sync.Map // map[reflect.Type][]jsonField
+
+// reportFields returns the keyed fields of a report type
+// a json tag overrides the derived key, and "-" leaves the field out
+func reportFields(t reflect.Type) []jsonField {
+	if f, ok := jsonFields.Load(t); ok {
+		return f.([]jsonField)
+	}
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := f.Tag.Lookup("name")
+		if !ok || !f.IsExported() {
+			continue
+		}
+		key := JSONName(name)
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				key = tagName
+			}
+		}
+		fields = append(fields, jsonField{index: i, key: key})
+	}
+	jsonFields.Store(t, fields)
+	return fields
+}
+
+func reportValue(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("nil report")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("not a struct: %s", rv.Kind())
+	}
+	return rv, nil
+}
+
+// MarshalReport encodes the simvar fields of a report as a JSON object
+// keyed by JSONName, leaving out the message header; byte array strings
+// are encoded as text
+func MarshalReport(v any) ([]byte, error) {
+	rv, err := reportValue(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := reportFields(rv.Type())
+	out := make(map[string]any, len(fields))
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+		if fv.Kind() == reflect.Array && fv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, fv.Len())
+			reflect.Copy(reflect.ValueOf(b), fv)
+			out[f.key] = client.BytesToString(b)
+			continue
+		}
+		out[f.key] = fv.Interface()
+	}
+	// map keys are sorted, so the output is stable
+	return json.Marshal(out)
+}
+
+// UnmarshalReport decodes a JSON object written by MarshalReport into a
+// report; keys that aren't fields are ignored, missing fields are unchanged
+func UnmarshalReport(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("report must be a pointer to a struct")
+	}
+	rv = rv.Elem()
+	var in map[string]json.RawMessage
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	for _, f := range reportFields(rv.Type()) {
+		raw, ok := in[f.key]
+		if !ok {
+			continue
+		}
+		fv := rv.Field(f.index)
+		if fv.Kind() == reflect.Array && fv.Type().Elem().Kind() == reflect.Uint8 {
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return fmt.Errorf("%s: %w", f.key, err)
+			}
+			b := make([]byte, fv.Len())
+			copy(b[:len(b)-1], s)
+			reflect.Copy(fv, reflect.ValueOf(b))
+			continue
+		}
+		if err := json.Unmarshal(raw, fv.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s: %w", f.key, err)
+		}
+	}
+	return nil
+}
+
+// JSON wraps a report so encoding/json uses MarshalReport, eg
+// json.NewEncoder(w).Encode(simconnect.JSON(report))
+func JSON(report any) json.Marshaler {
+	return jsonReport{report}
+}
+
+type jsonReport struct {
+	v any
+}
+
+func (r jsonReport) MarshalJSON() ([]byte, error) {
+	return MarshalReport(r.v)
+}
@@ -0,0 +1,184 @@
+// Package macro plays sequences of events with delays and conditions, such
+// as taking an aircraft from cold and dark to ready to taxi
+//
+// The text format is one step per line; blank lines and lines starting
+// with # are ignored:
+//
+//	send TOGGLE_MASTER_BATTERY
+//	wait 2s
+//	send FUELSYSTEM_PUMP_TOGGLE data=1
+//	send ENGINE_AUTO_START
+//	until "GENERAL ENG RPM:1" rpm > 600 timeout=60s
+//
+// send transmits an event, wait pauses for a duration, and until waits
+// for a simvar to meet a condition. Events are sent no closer together than
+// the player's gap, so a long macro doesn't flood the sim.
+package macro
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Condition compares a simvar to a value
+type Condition struct {
+	Name  string
+	Unit  string
+	Op    string // one of > < >= <= == !=
+	Value float64
+}
+
+var operators = map[string]bool{">": true, "<": true, ">=": true, "<=": true, "==": true, "!=": true}
+
+// Holds returns true if v meets the condition
+func (c Condition) Holds(v float64) bool {
+	switch c.Op {
+	case ">":
+		return v > c.Value
+	case "<":
+		return v < c.Value
+	case ">=":
+		return v >= c.Value
+	case "<=":
+		return v <= c.Value
+	case "==":
+		return v == c.Value
+	case "!=":
+		return v != c.Value
+	}
+	return false
+}
+
+func (c Condition) String() string {
+	return fmt.Sprintf("%q %s %s %g", c.Name, c.Unit, c.Op, c.Value)
+}
+
+// Step is a single step of a macro
+// it waits for Delay, sends Event if set, then waits for Until if set
+type Step struct {
+	Delay time.Duration
+	Event string
+	Data  client.DWORD
+	Until *Condition
+	// Timeout limits the wait for Until; zero waits forever
+	Timeout time.Duration
+}
+
+// Macro is a named sequence of steps
+type Macro struct {
+	Name  string
+	Steps []Step
+}
+
+// Parse parses a macro from its text format
+func Parse(name string, r io.Reader) (*Macro, error) {
+	m := &Macro{Name: name}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields, err := splitFields(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		step, err := parseStep(fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		m.Steps = append(m.Steps, step)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func parseStep(fields []string) (Step, error) {
+	switch fields[0] {
+	case "send":
+		if len(fields) < 2 {
+			return Step{}, fmt.Errorf("send needs an event")
+		}
+		step := Step{Event: fields[1]}
+		for _, kv := range fields[2:] {
+			key, val, ok := strings.Cut(kv, "=")
+			if !ok || key != "data" {
+				return Step{}, fmt.Errorf("expected data=value, got %q", kv)
+			}
+			data, err := strconv.ParseInt(val, 0, 64)
+			if err != nil {
+				return Step{}, fmt.Errorf("invalid data: %w", err)
+			}
+			step.Data = client.DWORD(data)
+		}
+		return step, nil
+	case "wait":
+		if len(fields) != 2 {
+			return Step{}, fmt.Errorf("wait takes one duration")
+		}
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return Step{}, fmt.Errorf("invalid wait: %w", err)
+		}
+		return Step{Delay: d}, nil
+	case "until":
+		if len(fields) < 5 {
+			return Step{}, fmt.Errorf("until needs a simvar, unit, operator and value")
+		}
+		c := &Condition{Name: fields[1], Unit: fields[2], Op: fields[3]}
+		if !operators[c.Op] {
+			return Step{}, fmt.Errorf("unknown operator %q", c.Op)
+		}
+		v, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return Step{}, fmt.Errorf("invalid value: %w", err)
+		}
+		c.Value = v
+		step := Step{Until: c}
+		for _, kv := range fields[5:] {
+			key, val, ok := strings.Cut(kv, "=")
+			if !ok || key != "timeout" {
+				return Step{}, fmt.Errorf("expected timeout=duration, got %q", kv)
+			}
+			if step.Timeout, err = time.ParseDuration(val); err != nil {
+				return Step{}, fmt.Errorf("invalid timeout: %w", err)
+			}
+		}
+		return step, nil
+	}
+	return Step{}, fmt.Errorf("unknown step %q", fields[0])
+}
+
+// splitFields splits on spaces, keeping double quoted fields together
+// so simvar names with spaces can be written
+func splitFields(text string) ([]string, error) {
+	var fields []string
+	for text = strings.TrimSpace(text); text != ""; text = strings.TrimSpace(text) {
+		if text[0] == '"' {
+			end := strings.IndexByte(text[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			fields = append(fields, text[1:end+1])
+			text = text[end+2:]
+			continue
+		}
+		end := strings.IndexAny(text, " \t")
+		if end < 0 {
+			end = len(text)
+		}
+		fields = append(fields, text[:end])
+		text = text[end:]
+	}
+	return fields, nil
+}
@@ -0,0 +1,180 @@
+package macro
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Player is a receiver that plays macros on the connection
+type Player struct {
+	gap    time.Duration
+	poll   time.Duration
+	onStep func(m *Macro, i int)
+
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	lastSent time.Time
+}
+
+// Option is a function that sets options on the Player
+type Option func(*Player)
+
+// WithGap sets the minimum time between events, across every running macro
+func WithGap(d time.Duration) Option {
+	return func(p *Player) {
+		p.gap = d
+	}
+}
+
+// WithPollInterval sets how often an until condition is checked
+func WithPollInterval(d time.Duration) Option {
+	return func(p *Player) {
+		p.poll = d
+	}
+}
+
+// WithOnStep sets a callback that is called as each step starts
+func WithOnStep(fn func(m *Macro, i int)) Option {
+	return func(p *Player) {
+		p.onStep = fn
+	}
+}
+
+// New creates a new Player
+func New(opts ...Option) *Player {
+	p := &Player{
+		gap:  250 * time.Millisecond,
+		poll: 500 * time.Millisecond,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// Start keeps the connection used to play macros
+func (p *Player) Start(ctx context.Context, sc *client.SimConnect) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sc = sc
+}
+
+// Update does nothing; conditions are read on demand
+func (p *Player) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// Play starts playing a macro and returns its run
+// the run stops when it finishes, fails, is aborted, or ctx is cancelled
+func (p *Player) Play(ctx context.Context, m *Macro) (*Run, error) {
+	p.mu.Lock()
+	sc := p.sc
+	p.mu.Unlock()
+	if sc == nil {
+		return nil, ErrNotStarted
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	r := &Run{
+		Macro:    m,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		pauseCh:  make(chan struct{}),
+		resumeCh: make(chan struct{}),
+	}
+	go func() {
+		defer close(r.done)
+		err := p.run(ctx, sc, r)
+		if cause := context.Cause(ctx); cause == ErrAborted {
+			err = ErrAborted
+		}
+		cancel(nil)
+		r.mu.Lock()
+		r.err = err
+		r.mu.Unlock()
+		if err != nil {
+			slog.Warn("Macro stopped", "macro", m.Name, "step", r.Step(), "error", err)
+		}
+	}()
+	return r, nil
+}
+
+func (p *Player) run(ctx context.Context, sc *client.SimConnect, r *Run) error {
+	for i, st := range r.Macro.Steps {
+		r.mu.Lock()
+		r.step = i
+		r.mu.Unlock()
+		if p.onStep != nil {
+			p.onStep(r.Macro, i)
+		}
+		if err := r.sleep(ctx, st.Delay); err != nil {
+			return err
+		}
+		if st.Event != "" {
+			if err := p.send(ctx, sc, r, st); err != nil {
+				return err
+			}
+		}
+		if st.Until != nil {
+			if err := p.until(ctx, sc, r, st); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// send transmits the step's event once the gap since the last event has passed
+func (p *Player) send(ctx context.Context, sc *client.SimConnect, r *Run, st Step) error {
+	for {
+		p.mu.Lock()
+		wait := time.Until(p.lastSent.Add(p.gap))
+		if wait <= 0 {
+			p.lastSent = time.Now()
+		}
+		p.mu.Unlock()
+		if wait <= 0 {
+			break
+		}
+		if err := r.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+	if err := r.checkpoint(ctx); err != nil {
+		return err
+	}
+	id, err := sc.MapClientEventByName(st.Event)
+	if err != nil {
+		return err
+	}
+	return sc.TransmitClientEvent(client.OBJECT_ID_USER, id, st.Data, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
+
+// until polls the step's condition; time spent paused does not count
+// toward the timeout
+func (p *Player) until(ctx context.Context, sc *client.SimConnect, r *Run, st Step) error {
+	var waited time.Duration
+	for {
+		if err := r.checkpoint(ctx); err != nil {
+			return err
+		}
+		readCtx, cancel := context.WithTimeout(ctx, p.poll*4)
+		v, err := sc.ReadFloat(readCtx, st.Until.Name, st.Until.Unit)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if st.Until.Holds(v) {
+			return nil
+		}
+		if st.Timeout > 0 && waited >= st.Timeout {
+			return &TimeoutError{Condition: *st.Until, Last: v}
+		}
+		if err := r.sleep(ctx, p.poll); err != nil {
+			return err
+		}
+		waited += p.poll
+	}
+}
@@ -0,0 +1,149 @@
+package macro
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Run is a macro being played
+type Run struct {
+	Macro *Macro
+
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	step     int
+	err      error
+	paused   bool
+	pauseCh  chan struct{} // closed when paused
+	resumeCh chan struct{} // closed when resumed
+}
+
+// Pause holds the run before its next event or condition check
+// a delay in progress keeps its remaining time for Resume
+func (r *Run) Pause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.paused {
+		return
+	}
+	r.paused = true
+	close(r.pauseCh)
+	r.resumeCh = make(chan struct{})
+}
+
+// Resume continues a paused run
+func (r *Run) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.paused {
+		return
+	}
+	r.paused = false
+	close(r.resumeCh)
+	r.pauseCh = make(chan struct{})
+}
+
+// Abort stops the run; Err returns ErrAborted
+func (r *Run) Abort() {
+	r.cancel(ErrAborted)
+}
+
+// Paused returns true if the run is paused
+func (r *Run) Paused() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.paused
+}
+
+// Step returns the index of the current step
+func (r *Run) Step() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.step
+}
+
+// Done is closed when the run stops
+func (r *Run) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait blocks until the run stops and returns Err
+func (r *Run) Wait() error {
+	<-r.done
+	return r.Err()
+}
+
+// Err returns why the run stopped; nil if it finished or is still running
+func (r *Run) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// checkpoint blocks while the run is paused
+func (r *Run) checkpoint(ctx context.Context) error {
+	for {
+		r.mu.Lock()
+		paused, resume := r.paused, r.resumeCh
+		r.mu.Unlock()
+		if !paused {
+			return ctx.Err()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-resume:
+		}
+	}
+}
+
+// sleep waits for d of unpaused time
+func (r *Run) sleep(ctx context.Context, d time.Duration) error {
+	for d > 0 {
+		if err := r.checkpoint(ctx); err != nil {
+			return err
+		}
+		r.mu.Lock()
+		pause := r.pauseCh
+		r.mu.Unlock()
+		start := time.Now()
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+			return nil
+		case <-pause:
+			t.Stop()
+			d -= time.Since(start)
+		}
+	}
+	return nil
+}
+
+// MacroError is the error type for the macro package
+type MacroError string
+
+func (e MacroError) Error() string { return string(e) }
+
+const (
+	// ErrNotStarted is returned when the player has no connection
+	ErrNotStarted MacroError = "macro player not started"
+	// ErrAborted is returned by a run that was aborted
+	ErrAborted MacroError = "macro aborted"
+)
+
+// TimeoutError is returned when an until condition does not hold in time
+type TimeoutError struct {
+	Condition Condition
+	Last      float64
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out waiting for %s, last %g", e.Condition, e.Last)
+}
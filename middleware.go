@@ -0,0 +1,63 @@
+package simconnect
+
+import (
+	"context"
+	"log/slog"
+	"unsafe"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Message is a message from SimConnect; Data points at the whole message,
+// Recv header included, and is only valid until the handler returns
+type Message struct {
+	Recv *client.Recv
+	Data unsafe.Pointer
+}
+
+// Bytes copies the whole message
+func (m Message) Bytes() []byte {
+	return append([]byte(nil), unsafe.Slice((*byte)(m.Data), m.Recv.Size)...)
+}
+
+// DispatchHandler handles a message; the innermost handler routes it to the receivers
+type DispatchHandler func(ctx context.Context, sc *client.SimConnect, m Message) error
+
+// Middleware wraps the dispatch of every message, eg for metrics, tracing,
+// filtering or recording; return without calling next to drop a message
+type Middleware func(next DispatchHandler) DispatchHandler
+
+// WithMiddleware adds middleware around the dispatch of every message
+// the first middleware added is the outermost
+func WithMiddleware(mw ...Middleware) ConnectorOption {
+	return func(c *Connector) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// LogMessages logs every message at debug level
+func LogMessages(log *slog.Logger) Middleware {
+	return func(next DispatchHandler) DispatchHandler {
+		return func(ctx context.Context, sc *client.SimConnect, m Message) error {
+			err := next(ctx, sc, m)
+			log.Debug("Dispatch", "id", client.RecvIDName(m.Recv.ID), "size", m.Recv.Size, "error", err)
+			return err
+		}
+	}
+}
+
+// DropMessages drops messages with the given IDs before they reach the receivers
+func DropMessages(ids ...client.DWORD) Middleware {
+	drop := map[client.DWORD]bool{}
+	for _, id := range ids {
+		drop[id] = true
+	}
+	return func(next DispatchHandler) DispatchHandler {
+		return func(ctx context.Context, sc *client.SimConnect, m Message) error {
+			if drop[m.Recv.ID] {
+				return nil
+			}
+			return next(ctx, sc, m)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Package modelmatch picks installed aircraft for AI traffic by type and
+// operator, the way traffic and multiplayer tools map a flight's aircraft
+// to a local model
+//
+// Candidates come from client.Liveries on MSFS 2024, or from a list of
+// titles with the MSFS 2020 dll, where each livery has its own title. A Rule
+// maps an ICAO type designator to the words that identify it in titles,
+// and can be loaded from a JSON file:
+//
+//	[
+//	  {"type": "B738", "match": ["737-800", "737 800"]},
+//	  {"type": "A320", "match": ["A320"]}
+//	]
+//
+// A Want is matched on type first and operator second; ties are broken at
+// random, so a ring of the same type gets a mix of liveries.
+package modelmatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Rule identifies an aircraft type in titles
+type Rule struct {
+	// Type is the ICAO type designator, eg "B738"
+	Type string `json:"type"`
+	// Match are words any of which identify the type, eg "737-800"
+	// they are compared without case, spaces or punctuation
+	Match []string `json:"match"`
+}
+
+// Want is the aircraft to find
+type Want struct {
+	// Type is the ICAO type designator; types without a rule match titles
+	// containing the designator itself
+	Type string
+	// Operator is the airline, eg "Delta"; it is optional
+	Operator string
+}
+
+// DefaultRules covers common airliners and general aviation types
+func DefaultRules() []Rule {
+	return []Rule{
+		{Type: "A20N", Match: []string{"A320neo", "A320 neo"}},
+		{Type: "A320", Match: []string{"A320"}},
+		{Type: "A321", Match: []string{"A321"}},
+		{Type: "A339", Match: []string{"A330-900", "A339"}},
+		{Type: "A359", Match: []string{"A350-900", "A350"}},
+		{Type: "B738", Match: []string{"737-800", "737 MAX 8", "B738"}},
+		{Type: "B748", Match: []string{"747-8", "B748"}},
+		{Type: "B77W", Match: []string{"777-300ER", "777-300", "B77W"}},
+		{Type: "B789", Match: []string{"787-9", "B789"}},
+		{Type: "C172", Match: []string{"172", "Skyhawk"}},
+		{Type: "C208", Match: []string{"208", "Caravan"}},
+		{Type: "CRJ7", Match: []string{"CRJ700", "CRJ-700", "CRJ 700"}},
+		{Type: "E190", Match: []string{"E190", "E-190", "ERJ-190"}},
+		{Type: "DH8D", Match: []string{"Q400", "DHC-8-400", "Dash 8"}},
+	}
+}
+
+// LoadRules reads rules from a JSON file
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	for _, r := range rules {
+		if r.Type == "" || len(r.Match) == 0 {
+			return nil, fmt.Errorf("rule %q: needs a type and match words", r.Type)
+		}
+	}
+	return rules, nil
+}
+
+// Titles makes candidates from plain titles, for the MSFS 2020 dll
+func Titles(titles ...string) []client.Livery {
+	out := make([]client.Livery, 0, len(titles))
+	for _, t := range titles {
+		out = append(out, client.Livery{Title: t})
+	}
+	return out
+}
+
+// Matcher picks candidates for wants
+type Matcher struct {
+	candidates []client.Livery
+	keys       []string
+	rules      map[string][]string
+	fallback   *client.Livery
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// Option is a function that sets options on the Matcher
+type Option func(*Matcher)
+
+// WithFallback sets the candidate used when no candidate has the type
+func WithFallback(l client.Livery) Option {
+	return func(m *Matcher) {
+		m.fallback = &l
+	}
+}
+
+// WithRand sets the source of tie breaks, eg for repeatable traffic
+func WithRand(r *rand.Rand) Option {
+	return func(m *Matcher) {
+		m.rand = r
+	}
+}
+
+// New creates a matcher over the candidates; later rules for a type
+// replace earlier ones, so user rules can follow DefaultRules
+func New(candidates []client.Livery, rules []Rule, opts ...Option) *Matcher {
+	m := &Matcher{
+		candidates: candidates,
+		keys:       make([]string, len(candidates)),
+		rules:      map[string][]string{},
+		rand:       rand.New(rand.NewSource(rand.Int63())),
+	}
+	for i, c := range candidates {
+		m.keys[i] = normalize(c.Title + " " + c.Livery)
+	}
+	for _, r := range rules {
+		words := make([]string, 0, len(r.Match))
+		for _, w := range r.Match {
+			words = append(words, normalize(w))
+		}
+		m.rules[normalize(r.Type)] = words
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Match returns the best candidate for the want
+// ok is false if no candidate has the type and there is no fallback
+func (m *Matcher) Match(w Want) (client.Livery, bool) {
+	typ := normalize(w.Type)
+	words, ok := m.rules[typ]
+	if !ok {
+		words = []string{typ}
+	}
+	operator := normalize(w.Operator)
+
+	best, bestScore := []int(nil), 0
+	for i, key := range m.keys {
+		score := 0
+		for _, word := range words {
+			if word != "" && strings.Contains(key, word) {
+				score = 2
+				break
+			}
+		}
+		if score == 0 {
+			continue
+		}
+		if operator != "" && strings.Contains(key, operator) {
+			score++
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore = []int{i}, score
+		case score == bestScore:
+			best = append(best, i)
+		}
+	}
+	if len(best) == 0 {
+		if m.fallback != nil {
+			return *m.fallback, true
+		}
+		return client.Livery{}, false
+	}
+	m.mu.Lock()
+	pick := best[m.rand.Intn(len(best))]
+	m.mu.Unlock()
+	return m.candidates[pick], true
+}
+
+// normalize drops case, spaces and punctuation, so "737-800" matches
+// "Boeing 737 800"
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
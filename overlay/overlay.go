@@ -0,0 +1,122 @@
+// Package overlay publishes a small set of flight values for stream
+// overlays and serves an OBS browser-source friendly page that shows them
+//
+// The Feed publishes to a gateway topic, so the values can be read as JSON
+// from /state/overlay or streamed as server-sent events from /events;
+// Page renders them live from that stream:
+//
+//	gw.Mount("GET /overlay", overlay.Page(overlay.WithTheme(overlay.Theme{Foreground: "#0f0"})))
+//
+// The page takes theme overrides as query parameters (bg, fg, accent,
+// font, size) so one URL per scene can restyle it, and forwards the token
+// parameter to the event stream.
+package overlay
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/gateway"
+)
+
+// Report is the data structure sampled for the overlay
+type Report struct {
+	client.RecvSimobjectDataByType
+	Airspeed         float64  `name:"AIRSPEED INDICATED" unit:"Knots"`
+	GroundSpeed      float64  `name:"GROUND VELOCITY" unit:"Knots"`
+	Altitude         float64  `name:"INDICATED ALTITUDE" unit:"Feet"`
+	Heading          float64  `name:"PLANE HEADING DEGREES MAGNETIC" unit:"Degrees"`
+	VerticalSpeed    float64  `name:"VERTICAL SPEED" unit:"Feet per minute"`
+	WaypointDistance float64  `name:"GPS WP DISTANCE" unit:"Nautical miles"`
+	WaypointETE      float64  `name:"GPS WP ETE" unit:"Seconds"`
+	NextWaypoint     [32]byte `name:"GPS WP NEXT ID"`
+}
+
+// Values is what the feed publishes
+type Values struct {
+	Airspeed         float64 `json:"airspeed"`
+	GroundSpeed      float64 `json:"ground_speed"`
+	Altitude         float64 `json:"altitude"`
+	Heading          float64 `json:"heading"`
+	VerticalSpeed    float64 `json:"vertical_speed"`
+	NextWaypoint     string  `json:"next_waypoint"`
+	WaypointDistance float64 `json:"waypoint_distance"`
+	WaypointETE      float64 `json:"waypoint_ete"`
+}
+
+// Feed is a receiver that publishes the overlay values to a gateway
+type Feed struct {
+	gw       *gateway.Server
+	topic    string
+	interval time.Duration
+	extend   func(Values) any
+}
+
+// Option is a function that sets options on the Feed
+type Option func(*Feed)
+
+// WithTopic sets the gateway topic; the default is "overlay"
+func WithTopic(topic string) Option {
+	return func(f *Feed) {
+		f.topic = topic
+	}
+}
+
+// WithInterval sets how often the values are published; the default is 250ms
+func WithInterval(d time.Duration) Option {
+	return func(f *Feed) {
+		f.interval = d
+	}
+}
+
+// WithExtend replaces the published value, eg to add values from other
+// receivers; a page Field can show any key of the result
+func WithExtend(fn func(Values) any) Option {
+	return func(f *Feed) {
+		f.extend = fn
+	}
+}
+
+// NewFeed creates a feed publishing to the gateway
+func NewFeed(gw *gateway.Server, opts ...Option) *Feed {
+	f := &Feed{gw: gw, topic: "overlay", interval: 250 * time.Millisecond}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// Start subscribes to the overlay report
+func (f *Feed) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := simconnect.Subscribe[Report](ctx, sc, f.interval); err != nil {
+		slog.Error("Cannot subscribe to overlay report", "error", err)
+	}
+}
+
+// Update publishes the values
+func (f *Feed) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[Report](sc, ppData)
+	if !ok {
+		return
+	}
+	v := Values{
+		Airspeed:         r.Airspeed,
+		GroundSpeed:      r.GroundSpeed,
+		Altitude:         r.Altitude,
+		Heading:          r.Heading,
+		VerticalSpeed:    r.VerticalSpeed,
+		NextWaypoint:     client.BytesToString(r.NextWaypoint[:]),
+		WaypointDistance: r.WaypointDistance,
+		WaypointETE:      r.WaypointETE,
+	}
+	var out any = v
+	if f.extend != nil {
+		out = f.extend(v)
+	}
+	if err := f.gw.Publish(f.topic, out); err != nil {
+		slog.Error("Cannot publish overlay", "error", err)
+	}
+}
@@ -0,0 +1,142 @@
+package overlay
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// Theme styles the overlay page; empty fields keep the defaults
+type Theme struct {
+	Background string // CSS colour; transparent suits OBS
+	Foreground string
+	Accent     string // label colour
+	Font       string
+	Size       string // CSS font size
+	// CSS is appended to the page's style sheet
+	CSS template.CSS
+}
+
+// Field is a value shown on the page
+type Field struct {
+	Key   string // key of the published value, eg "airspeed"
+	Label string
+	Unit  string
+	// Decimals is the number of decimals for numbers
+	Decimals int
+}
+
+// DefaultFields are shown unless WithFields is used
+var DefaultFields = []Field{
+	{Key: "airspeed", Label: "IAS", Unit: "kt"},
+	{Key: "altitude", Label: "ALT", Unit: "ft"},
+	{Key: "heading", Label: "HDG", Unit: "°"},
+	{Key: "vertical_speed", Label: "VS", Unit: "fpm"},
+	{Key: "next_waypoint", Label: "NEXT"},
+	{Key: "waypoint_distance", Label: "DIST", Unit: "nm", Decimals: 1},
+}
+
+type page struct {
+	topic  string
+	events string
+	theme  Theme
+	fields []Field
+}
+
+// PageOption is a function that sets options on the page
+type PageOption func(*page)
+
+// WithTheme sets the page theme
+func WithTheme(t Theme) PageOption {
+	return func(p *page) {
+		p.theme = t
+	}
+}
+
+// WithFields sets the values shown, in order
+func WithFields(fields ...Field) PageOption {
+	return func(p *page) {
+		p.fields = fields
+	}
+}
+
+// WithPageTopic sets the topic the page shows; the default is "overlay"
+func WithPageTopic(topic string) PageOption {
+	return func(p *page) {
+		p.topic = topic
+	}
+}
+
+// WithEvents sets the path of the gateway event stream; the default is
+// "/events", for a page mounted on the gateway
+func WithEvents(path string) PageOption {
+	return func(p *page) {
+		p.events = path
+	}
+}
+
+// Page returns a handler serving the overlay page
+func Page(opts ...PageOption) http.Handler {
+	p := &page{
+		topic:  "overlay",
+		events: "/events",
+		theme: Theme{
+			Background: "transparent",
+			Foreground: "#ffffff",
+			Accent:     "#9ad0ff",
+			Font:       "sans-serif",
+			Size:       "28px",
+		},
+		fields: DefaultFields,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+func (p *page) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t := p.theme
+	q := r.URL.Query()
+	for key, dst := range map[string]*string{"bg": &t.Background, "fg": &t.Foreground, "accent": &t.Accent, "font": &t.Font, "size": &t.Size} {
+		if v := q.Get(key); v != "" {
+			*dst = v
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	pageTemplate.Execute(w, map[string]any{
+		"Theme":  t,
+		"Fields": p.fields,
+		"Topic":  p.topic,
+		"Events": p.events,
+	})
+}
+
+var pageTemplate = template.Must(template.New("overlay").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Overlay</title>
+<style>
+body{margin:0;background:{{.Theme.Background}};color:{{.Theme.Foreground}};font-family:{{.Theme.Font}};font-size:{{.Theme.Size}}}
+.overlay{display:flex;gap:1em;padding:.5em}
+.field .label{color:{{.Theme.Accent}};font-size:.6em;display:block}
+.field .unit{font-size:.6em}
+{{.Theme.CSS}}
+</style></head><body>
+<div class="overlay">
+{{range .Fields}}<div class="field" data-key="{{.Key}}" data-decimals="{{.Decimals}}"><span class="label">{{.Label}}</span><span class="value">-</span> <span class="unit">{{.Unit}}</span></div>
+{{end}}</div>
+<script>
+const params = new URLSearchParams(location.search);
+const url = new URL({{.Events}}, location.href);
+url.searchParams.set("topic", {{.Topic}});
+if (params.get("token")) url.searchParams.set("token", params.get("token"));
+const es = new EventSource(url);
+es.addEventListener({{.Topic}}, e => {
+	const v = JSON.parse(e.data);
+	for (const el of document.querySelectorAll(".field")) {
+		const x = v[el.dataset.key];
+		if (x === undefined) continue;
+		el.querySelector(".value").textContent = typeof x === "number" ? x.toFixed(+el.dataset.decimals) : x;
+	}
+});
+</script>
+</body></html>
+`))
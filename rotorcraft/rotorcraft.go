@@ -0,0 +1,162 @@
+// Package rotorcraft provides the helicopter simvars and control events,
+// which differ from the fixed-wing ones
+package rotorcraft
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// RotorReport is the data structure to report the rotor and engine state
+type RotorReport struct {
+	client.RecvSimobjectDataByType
+	Collective     float64 `name:"COLLECTIVE POSITION" unit:"Percent"`
+	RotorRPM       float64 `name:"ROTOR RPM PCT:1" unit:"Percent"`
+	EngineRPM      float64 `name:"ENG ROTOR RPM:1" unit:"Percent"`
+	Torque         float64 `name:"ENG TORQUE PERCENT:1" unit:"Percent"`
+	LateralTrim    float64 `name:"ROTOR LATERAL TRIM PCT" unit:"Percent"`
+	BrakeActive    float64 `name:"ROTOR BRAKE ACTIVE" unit:"Bool"`
+	ClutchActive   float64 `name:"ROTOR CLUTCH ACTIVE" unit:"Bool"`
+	GovActive      float64 `name:"ROTOR GOV ACTIVE" unit:"Bool"`
+	RotorTemp      float64 `name:"ROTOR TEMPERATURE" unit:"Rankine"`
+	DiskBank       float64 `name:"DISK BANK PCT:1" unit:"Percent"`
+	DiskPitch      float64 `name:"DISK PITCH PCT:1" unit:"Percent"`
+	DiskConing     float64 `name:"DISK CONING PCT:1" unit:"Percent"`
+	TailRotorPitch float64 `name:"TAIL ROTOR BLADE PITCH PCT" unit:"Percent"`
+}
+
+// Axis and switch events for helicopters
+const (
+	EventCollective      = "AXIS_COLLECTIVE_SET"
+	EventTailRotor       = "ROTOR_AXIS_TAIL_ROTOR_SET"
+	EventCyclicLateral   = "AXIS_CYCLIC_LATERAL_SET"
+	EventCyclicLongitude = "AXIS_CYCLIC_LONGITUDINAL_SET"
+	EventRotorBrake      = "ROTOR_BRAKE"
+	EventClutchSet       = "ROTOR_CLUTCH_SWITCH_SET"
+	EventGovernorSet     = "ROTOR_GOV_SWITCH_SET"
+	EventLateralTrimSet  = "ROTOR_LATERAL_TRIM_SET"
+	EventRotorTrimReset  = "ROTOR_TRIM_RESET"
+)
+
+// axisMax is the full deflection of an axis event
+const axisMax = 16383
+
+var controlEvents = []string{
+	EventCollective,
+	EventTailRotor,
+	EventCyclicLateral,
+	EventCyclicLongitude,
+	EventRotorBrake,
+	EventClutchSet,
+	EventGovernorSet,
+	EventLateralTrimSet,
+	EventRotorTrimReset,
+}
+
+// Controls is a receiver that drives the helicopter controls
+type Controls struct {
+	mu     sync.Mutex
+	sc     *client.SimConnect
+	events map[string]client.DWORD
+}
+
+// NewControls creates a new Controls
+func NewControls() *Controls {
+	return &Controls{}
+}
+
+// Start registers the rotor report and maps the control events
+func (c *Controls) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := sc.RegisterDataDefinition(&RotorReport{}); err != nil {
+		slog.Error("Cannot register rotor report", "error", err)
+		return
+	}
+	events := map[string]client.DWORD{}
+	for _, name := range controlEvents {
+		id, err := sc.MapClientEventByName(name)
+		if err != nil {
+			slog.Error("Cannot map rotorcraft event", "event", name, "error", err)
+			return
+		}
+		events[name] = id
+	}
+	c.mu.Lock()
+	c.sc = sc
+	c.events = events
+	c.mu.Unlock()
+}
+
+// Update does nothing; use simconnect.IsReport[RotorReport] in your own receiver
+func (c *Controls) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// SetCollective sets the collective from 0 (full down) to 1 (full up)
+func (c *Controls) SetCollective(v float64) error {
+	return c.send(EventCollective, axis(v*2-1))
+}
+
+// SetTailRotor sets the anti-torque pedals from -1 (left) to 1 (right)
+func (c *Controls) SetTailRotor(v float64) error {
+	return c.send(EventTailRotor, axis(v))
+}
+
+// SetCyclic sets the cyclic from -1 to 1 on each axis
+// positive lateral is right, positive longitudinal is forward
+func (c *Controls) SetCyclic(lateral, longitudinal float64) error {
+	if err := c.send(EventCyclicLateral, axis(lateral)); err != nil {
+		return err
+	}
+	return c.send(EventCyclicLongitude, axis(-longitudinal))
+}
+
+// SetClutch engages or disengages the rotor clutch
+func (c *Controls) SetClutch(on bool) error {
+	return c.send(EventClutchSet, boolData(on))
+}
+
+// SetGovernor engages or disengages the rotor governor
+func (c *Controls) SetGovernor(on bool) error {
+	return c.send(EventGovernorSet, boolData(on))
+}
+
+// ToggleRotorBrake toggles the rotor brake
+func (c *Controls) ToggleRotorBrake() error {
+	return c.send(EventRotorBrake, 0)
+}
+
+// ResetTrim resets the rotor trim
+func (c *Controls) ResetTrim() error {
+	return c.send(EventRotorTrimReset, 0)
+}
+
+func (c *Controls) send(name string, data client.DWORD) error {
+	c.mu.Lock()
+	sc := c.sc
+	id, ok := c.events[name]
+	c.mu.Unlock()
+	if sc == nil {
+		return fmt.Errorf("rotorcraft controls not started")
+	}
+	if !ok {
+		return fmt.Errorf("event %s not mapped", name)
+	}
+	return sc.TransmitClientEvent(client.OBJECT_ID_USER, id, data, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
+
+// axis converts -1..1 to the signed axis range, passed to the sim as a DWORD
+func axis(v float64) client.DWORD {
+	v = math.Max(-1, math.Min(1, v))
+	return client.DWORD(int32(math.Round(v * axisMax)))
+}
+
+func boolData(on bool) client.DWORD {
+	if on {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+package simconnect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Router runs connectors to several sims at once, eg MSFS 2020 and 2024
+// side by side during migration testing, and can mirror commands to all of
+// them; each connector keeps its own receivers
+//
+//	r := simconnect.NewRouter()
+//	r.Add("msfs2020", simconnect.NewConnector("test", simconnect.WithDLLPath(dll2020)))
+//	r.Add("msfs2024", simconnect.NewConnector("test", simconnect.WithDLLPath(dll2024), simconnect.WithConfigIndex(1)))
+//	go r.Start(ctx)
+//	err := r.SendEvent("GEAR_TOGGLE", 0)
+type Router struct {
+	mu    sync.Mutex
+	conns map[string]*Connector
+}
+
+// NewRouter creates an empty router
+func NewRouter() *Router {
+	return &Router{conns: map[string]*Connector{}}
+}
+
+// Add adds a connector under a name; add connectors before Start
+func (r *Router) Add(name string, c *Connector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conns[name] = c
+}
+
+// Names returns the connector names in order
+func (r *Router) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.conns))
+	for name := range r.conns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Connector returns a connector by name
+func (r *Router) Connector(name string) (*Connector, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.conns[name]
+	return c, ok
+}
+
+// Start runs every connector with reconnect until ctx is done
+// this is BLOCKING
+func (r *Router) Start(ctx context.Context) {
+	r.mu.Lock()
+	conns := make([]*Connector, 0, len(r.conns))
+	for _, c := range r.conns {
+		conns = append(conns, c)
+	}
+	r.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, c := range conns {
+		wg.Add(1)
+		go func(c *Connector) {
+			defer wg.Done()
+			c.StartReconnect(ctx)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// Mirror runs fn on every connected sim and joins the errors
+// sims that are not connected are skipped
+func (r *Router) Mirror(fn func(name string, sc *client.SimConnect) error) error {
+	var errs []error
+	for _, name := range r.Names() {
+		c, _ := r.Connector(name)
+		sc := c.SimConnect()
+		if sc == nil {
+			continue
+		}
+		if err := fn(name, sc); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendEvent maps the sim event, if needed, and sends it to the user aircraft on every sim
+func (r *Router) SendEvent(event string, data client.DWORD) error {
+	return r.Mirror(func(name string, sc *client.SimConnect) error {
+		id, err := sc.MapClientEventByName(event)
+		if err != nil {
+			return err
+		}
+		return sc.TransmitClientEvent(client.OBJECT_ID_USER, id, data, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+	})
+}
+
+// SetData writes the report to the user aircraft on every sim
+func (r *Router) SetData(report any) error {
+	return r.Mirror(func(name string, sc *client.SimConnect) error {
+		if err := sc.RegisterDataDefinition(report); err != nil {
+			return err
+		}
+		return sc.SetData(report)
+	})
+}
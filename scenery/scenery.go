@@ -0,0 +1,208 @@
+// Package scenery places simulated objects, such as ground vehicles, boats,
+// animals and windsocks, and keeps them placed across reconnects
+//
+// Objects are created with AICreateSimulatedObject at an init position. The
+// sim removes a client's objects when its connection closes, so the Placer
+// creates them again after every (re)connect and tracks their new IDs.
+package scenery
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/geo"
+)
+
+// SceneryError is the error type for the package
+type SceneryError string
+
+func (e SceneryError) Error() string { return string(e) }
+
+const (
+	// ErrNotPlaced is returned for an object that is not in the sim
+	ErrNotPlaced SceneryError = "object not placed"
+)
+
+// Object is an object managed by a Placer
+type Object struct {
+	Title string
+
+	// guarded by the Placer
+	pos    client.InitPosition
+	id     client.DWORD
+	placed bool
+}
+
+// objectPosition moves an object
+type objectPosition struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude  float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+	Heading   float64 `name:"PLANE HEADING DEGREES TRUE" unit:"Degrees"`
+}
+
+// Placer is a receiver that creates and removes objects
+type Placer struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	sc      *client.SimConnect
+	objects map[*Object]struct{}
+}
+
+// Option is a function that sets options on the Placer
+type Option func(*Placer)
+
+// WithTimeout sets how long to wait for the sim to create an object
+// the default is 10s
+func WithTimeout(d time.Duration) Option {
+	return func(p *Placer) {
+		p.timeout = d
+	}
+}
+
+// New creates a new Placer
+func New(opts ...Option) *Placer {
+	p := &Placer{
+		timeout: 10 * time.Second,
+		objects: map[*Object]struct{}{},
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// Start creates the objects again on the new connection
+func (p *Placer) Start(ctx context.Context, sc *client.SimConnect) {
+	p.mu.Lock()
+	p.sc = sc
+	objects := make([]*Object, 0, len(p.objects))
+	for o := range p.objects {
+		o.placed = false
+		objects = append(objects, o)
+	}
+	p.mu.Unlock()
+
+	// the assigned IDs arrive through the dispatch loop, which runs once
+	// every receiver has started
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for _, o := range objects {
+			if err := p.create(ctx, sc, o); err != nil {
+				slog.Error("Cannot place object again", "title", o.Title, "error", err)
+			}
+		}
+	})
+}
+
+// Update does nothing
+func (p *Placer) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// Place creates an object and keeps it placed until it is removed
+// if the sim is not connected, it is created on the next connect
+func (p *Placer) Place(ctx context.Context, title string, pos client.InitPosition) (*Object, error) {
+	o := &Object{Title: title, pos: pos}
+	p.mu.Lock()
+	p.objects[o] = struct{}{}
+	sc := p.sc
+	p.mu.Unlock()
+	if sc == nil {
+		return o, nil
+	}
+	if err := p.create(ctx, sc, o); err != nil {
+		p.mu.Lock()
+		delete(p.objects, o)
+		p.mu.Unlock()
+		return nil, err
+	}
+	return o, nil
+}
+
+func (p *Placer) create(ctx context.Context, sc *client.SimConnect, o *Object) error {
+	p.mu.Lock()
+	pos := o.pos
+	p.mu.Unlock()
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	id, err := sc.CreateSimulatedObject(ctx, o.Title, pos)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.objects[o]; !ok || p.sc != sc {
+		// removed, or the connection changed, while it was being created
+		if p.sc == sc {
+			return sc.RemoveObject(id)
+		}
+		return nil
+	}
+	o.id, o.placed = id, true
+	return nil
+}
+
+// ID returns the object's ID on the current connection
+func (p *Placer) ID(o *Object) (client.DWORD, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return o.id, o.placed
+}
+
+// Move moves a placed object; the new position is also used after a reconnect
+func (p *Placer) Move(o *Object, pos geo.Position, heading float64) error {
+	p.mu.Lock()
+	o.pos.Latitude, o.pos.Longitude, o.pos.Altitude, o.pos.Heading = pos.Latitude, pos.Longitude, pos.Altitude, heading
+	sc, id, placed := p.sc, o.id, o.placed
+	p.mu.Unlock()
+	if !placed {
+		return ErrNotPlaced
+	}
+	return sc.SetDataOn(id, &objectPosition{
+		Latitude:  pos.Latitude,
+		Longitude: pos.Longitude,
+		Altitude:  pos.Altitude,
+		Heading:   heading,
+	})
+}
+
+// Remove removes an object from the sim and stops placing it
+func (p *Placer) Remove(o *Object) error {
+	p.mu.Lock()
+	delete(p.objects, o)
+	sc, id, placed := p.sc, o.id, o.placed
+	o.placed = false
+	p.mu.Unlock()
+	if !placed {
+		return nil
+	}
+	return sc.RemoveObject(id)
+}
+
+// RemoveAll removes every object
+func (p *Placer) RemoveAll() error {
+	var errs []error
+	for _, o := range p.Objects() {
+		if err := p.Remove(o); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Objects returns the objects being placed
+func (p *Placer) Objects() []*Object {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]*Object, 0, len(p.objects))
+	for o := range p.objects {
+		out = append(out, o)
+	}
+	return out
+}
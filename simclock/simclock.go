@@ -0,0 +1,206 @@
+// Package simclock provides a clock and timers that run on simulation time,
+// so they respect the sim rate and pause
+//
+// The clock samples SIMULATION TIME, which stops while paused and runs
+// faster under time acceleration, so "in 10 sim minutes" behaves the same
+// at 1x and 4x. Timer resolution is the sampling interval.
+package simclock
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// SimTimeReport is the data structure used to drive the clock
+type SimTimeReport struct {
+	client.RecvSimobjectDataByType
+	SimTime float64 `name:"SIMULATION TIME" unit:"Seconds"`
+	Rate    float64 `name:"SIMULATION RATE" unit:"Number"`
+}
+
+// Clock is a receiver that tracks simulation time
+type Clock struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	now     time.Duration
+	last    float64
+	known   bool
+	rate    float64
+	paused  bool
+	pauseID client.DWORD
+	timers  map[*timer]struct{}
+}
+
+type timer struct {
+	remaining time.Duration
+	period    time.Duration
+	ch        chan time.Duration
+}
+
+// Option is a function that sets options on the Clock
+type Option func(*Clock)
+
+// WithInterval sets how often simulation time is sampled
+func WithInterval(d time.Duration) Option {
+	return func(c *Clock) {
+		c.interval = d
+	}
+}
+
+// New creates a new Clock
+func New(opts ...Option) *Clock {
+	c := &Clock{
+		interval: 250 * time.Millisecond,
+		rate:     1,
+		timers:   map[*timer]struct{}{},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Start subscribes to the Pause event and starts sampling simulation time
+func (c *Clock) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := sc.RegisterDataDefinition(&SimTimeReport{}); err != nil {
+		slog.Error("Cannot register sim time report", "error", err)
+		return
+	}
+	pauseID, err := sc.SubscribeToSystemEventByName("Pause")
+	if err != nil {
+		slog.Error("Cannot subscribe to Pause", "error", err)
+		return
+	}
+	c.mu.Lock()
+	c.pauseID = pauseID
+	// simulation time restarts with the sim, so don't measure across connections
+	c.known = false
+	c.mu.Unlock()
+
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.interval):
+				if err := simconnect.RequestData[SimTimeReport](sc); err != nil {
+					slog.Error("Cannot request sim time", "error", err)
+				}
+			}
+		}
+	})
+}
+
+// Update advances the clock and fires due timers
+func (c *Clock) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[SimTimeReport](sc, ppData)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rate = r.Rate
+	if !c.known {
+		c.last, c.known = r.SimTime, true
+		return
+	}
+	delta := r.SimTime - c.last
+	c.last = r.SimTime
+	// a new flight resets simulation time; skip the jump
+	if delta <= 0 {
+		return
+	}
+	c.advance(time.Duration(delta * float64(time.Second)))
+}
+
+// Event tracks the pause state
+func (c *Clock) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ev.EventID == c.pauseID {
+		c.paused = client.DecodeSystemEvent("Pause", ev.Data).(client.PauseEvent).Paused
+	}
+}
+
+// advance moves the clock forward; it must be called with the lock held
+func (c *Clock) advance(d time.Duration) {
+	c.now += d
+	for t := range c.timers {
+		t.remaining -= d
+		for t.remaining <= 0 {
+			select {
+			case t.ch <- c.now:
+			default:
+			}
+			if t.period <= 0 {
+				delete(c.timers, t)
+				break
+			}
+			t.remaining += t.period
+		}
+	}
+}
+
+// Now returns the simulation time elapsed since the clock started
+func (c *Clock) Now() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Rate returns the last sampled simulation rate
+func (c *Clock) Rate() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rate
+}
+
+// Paused returns true if the sim is paused
+func (c *Clock) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// AfterSimTime returns a channel that receives the clock time once d of
+// simulation time has passed
+func (c *Clock) AfterSimTime(d time.Duration) <-chan time.Duration {
+	t := &timer{remaining: d, ch: make(chan time.Duration, 1)}
+	c.mu.Lock()
+	c.timers[t] = struct{}{}
+	c.mu.Unlock()
+	return t.ch
+}
+
+// SimTicker delivers the clock time every period of simulation time
+// ticks are dropped if the receiver falls behind, like time.Ticker
+type SimTicker struct {
+	C     <-chan time.Duration
+	clock *Clock
+	t     *timer
+}
+
+// NewSimTicker returns a ticker that ticks every d of simulation time
+func (c *Clock) NewSimTicker(d time.Duration) *SimTicker {
+	if d <= 0 {
+		panic("non-positive interval for NewSimTicker")
+	}
+	t := &timer{remaining: d, period: d, ch: make(chan time.Duration, 1)}
+	c.mu.Lock()
+	c.timers[t] = struct{}{}
+	c.mu.Unlock()
+	return &SimTicker{C: t.ch, clock: c, t: t}
+}
+
+// Stop turns off the ticker
+func (t *SimTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	delete(t.clock.timers, t.t)
+}
@@ -0,0 +1,376 @@
+// Package statesync shares cockpit state between two sims for shared
+// cockpit experiments
+//
+// A Session samples a chosen set of simvars and watches a set of events,
+// sends local changes to a peer over a Transport the application supplies
+// (a socket, a relay, a message bus) and applies the peer's changes
+// locally. Each subsystem has a Role deciding which side wins: the master
+// of a subsystem sends and ignores the peer, a slave applies and does not
+// send, and a shared subsystem does both with the latest change winning.
+package statesync
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+	"unsafe"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Transport carries encoded changes to and from the peer
+// Receive blocks until a message arrives or the context is done
+type Transport interface {
+	Send(ctx context.Context, msg []byte) error
+	Receive(ctx context.Context) ([]byte, error)
+}
+
+// Role is which side owns a subsystem
+type Role int
+
+const (
+	// Shared sends and applies changes; the latest change wins
+	Shared Role = iota
+	// Master sends local changes and ignores the peer's
+	Master
+	// Slave applies the peer's changes and does not send its own
+	Slave
+)
+
+func (r Role) sends() bool   { return r != Slave }
+func (r Role) applies() bool { return r != Master }
+
+// Var is a simvar to share
+type Var struct {
+	Name      string
+	Unit      string
+	Subsystem string
+	// Epsilon is how much the value must change to be sent
+	Epsilon float64
+}
+
+// Event is a sim event to share, eg "GEAR_TOGGLE"
+type Event struct {
+	Name      string
+	Subsystem string
+}
+
+// Change is a message between peers
+type Change struct {
+	Name  string    `json:"name"`
+	Event bool      `json:"event,omitempty"`
+	Value float64   `json:"value,omitempty"`
+	Data  int32     `json:"data,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+type varState struct {
+	Var
+	setID client.DWORD
+	value float64
+	known bool
+	// changed is when the value last changed, locally or from the peer
+	changed time.Time
+}
+
+type eventState struct {
+	Event
+	id client.DWORD
+	// echoes counts applied peer events still to come back through the notification group
+	echoes int
+}
+
+// Session is a receiver that syncs state with a peer
+type Session struct {
+	transport Transport
+	vars      []Var
+	events    []Event
+	roles     map[string]Role
+	fallback  Role
+	interval  time.Duration
+
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	valuesID client.DWORD
+	byName   map[string]*varState
+	order    []*varState
+	byEvent  map[string]*eventState
+	byID     map[client.DWORD]*eventState
+	out      chan Change
+}
+
+// Option is a function that sets options on the Session
+type Option func(*Session)
+
+// WithVars adds simvars to share
+func WithVars(vars ...Var) Option {
+	return func(s *Session) {
+		s.vars = append(s.vars, vars...)
+	}
+}
+
+// WithEvents adds events to share
+func WithEvents(events ...Event) Option {
+	return func(s *Session) {
+		s.events = append(s.events, events...)
+	}
+}
+
+// WithRole sets the role for a subsystem
+func WithRole(subsystem string, r Role) Option {
+	return func(s *Session) {
+		s.roles[subsystem] = r
+	}
+}
+
+// WithDefaultRole sets the role of subsystems without one; the default is Shared
+func WithDefaultRole(r Role) Option {
+	return func(s *Session) {
+		s.fallback = r
+	}
+}
+
+// WithInterval sets how often the simvars are sampled; the default is 100ms
+func WithInterval(d time.Duration) Option {
+	return func(s *Session) {
+		s.interval = d
+	}
+}
+
+// New creates a session over the transport
+func New(t Transport, opts ...Option) *Session {
+	s := &Session{
+		transport: t,
+		roles:     map[string]Role{},
+		fallback:  Shared,
+		interval:  100 * time.Millisecond,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// SetRole changes the role of a subsystem, eg to hand over control
+func (s *Session) SetRole(subsystem string, r Role) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roles[subsystem] = r
+}
+
+// role returns the role of a subsystem; it must be called with the lock held
+func (s *Session) role(subsystem string) Role {
+	if r, ok := s.roles[subsystem]; ok {
+		return r
+	}
+	return s.fallback
+}
+
+// Start registers the shared vars and events, then exchanges changes with
+// the peer until the connection ends
+func (s *Session) Start(ctx context.Context, sc *client.SimConnect) {
+	valuesID := sc.GetDefineIDByName("statesync:values")
+	byName := map[string]*varState{}
+	var order []*varState
+	for _, v := range s.vars {
+		if err := sc.AddToDataDefinition(valuesID, v.Name, v.Unit, client.DATATYPE_FLOAT64); err != nil {
+			slog.Error("Cannot add shared var", "name", v.Name, "error", err)
+			return
+		}
+		setID := sc.GetDefineIDByName("statesync:" + v.Name)
+		if err := sc.AddToDataDefinition(setID, v.Name, v.Unit, client.DATATYPE_FLOAT64); err != nil {
+			slog.Error("Cannot add shared var", "name", v.Name, "error", err)
+			return
+		}
+		st := &varState{Var: v, setID: setID}
+		byName[v.Name] = st
+		order = append(order, st)
+	}
+
+	notifyGroup := sc.GetEventID()
+	byEvent := map[string]*eventState{}
+	byID := map[client.DWORD]*eventState{}
+	for _, e := range s.events {
+		id, err := sc.MapClientEventByName(e.Name)
+		if err != nil {
+			slog.Error("Cannot map shared event", "event", e.Name, "error", err)
+			continue
+		}
+		if err := sc.AddClientEventToNotificationGroup(notifyGroup, id); err != nil {
+			slog.Error("Cannot watch shared event", "event", e.Name, "error", err)
+			continue
+		}
+		st := &eventState{Event: e, id: id}
+		byEvent[e.Name] = st
+		byID[id] = st
+	}
+	if len(byEvent) > 0 {
+		if err := sc.SetNotificationGroupPriority(notifyGroup, client.GROUP_PRIORITY_STANDARD); err != nil {
+			slog.Error("Cannot set notification group priority", "error", err)
+		}
+	}
+
+	out := make(chan Change, 64)
+	s.mu.Lock()
+	s.sc = sc
+	s.valuesID = valuesID
+	s.byName = byName
+	s.order = order
+	s.byEvent = byEvent
+	s.byID = byID
+	s.out = out
+	s.mu.Unlock()
+
+	// sampling
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				s.mu.Lock()
+				s.sc = nil
+				s.mu.Unlock()
+				return
+			case <-time.After(s.interval):
+				if len(order) == 0 {
+					continue
+				}
+				if err := sc.RequestDataOnSimObjectType(valuesID, valuesID, 0, client.SIMOBJECT_TYPE_USER); err != nil {
+					slog.Error("Cannot request shared vars", "error", err)
+				}
+			}
+		}
+	})
+	// sending; the transport may block, so it is kept off the dispatch goroutine
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case c := <-out:
+				msg, err := json.Marshal(c)
+				if err != nil {
+					slog.Error("Cannot encode change", "name", c.Name, "error", err)
+					continue
+				}
+				if err := s.transport.Send(ctx, msg); err != nil {
+					slog.Error("Cannot send change", "name", c.Name, "error", err)
+				}
+			}
+		}
+	})
+	// receiving
+	simconnect.Go(ctx, func(ctx context.Context) {
+		for {
+			msg, err := s.transport.Receive(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.Error("Cannot receive change", "error", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+			var c Change
+			if err := json.Unmarshal(msg, &c); err != nil {
+				slog.Error("Cannot decode change", "error", err)
+				continue
+			}
+			s.apply(sc, c)
+		}
+	})
+}
+
+// apply applies a change from the peer if the subsystem's role allows it
+func (s *Session) apply(sc *client.SimConnect, c Change) {
+	s.mu.Lock()
+	if c.Event {
+		st, ok := s.byEvent[c.Name]
+		if !ok || !s.role(st.Subsystem).applies() {
+			s.mu.Unlock()
+			return
+		}
+		st.echoes++
+		id := st.id
+		s.mu.Unlock()
+		if err := sc.TransmitClientEvent(client.OBJECT_ID_USER, id, client.DWORD(c.Data), client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY); err != nil {
+			slog.Error("Cannot apply shared event", "event", c.Name, "error", err)
+		}
+		return
+	}
+
+	st, ok := s.byName[c.Name]
+	if !ok || !s.role(st.Subsystem).applies() || c.At.Before(st.changed) {
+		// an older change loses to a newer local one
+		s.mu.Unlock()
+		return
+	}
+	// record the value first so the next sample does not send it back
+	st.value, st.known, st.changed = c.Value, true, c.At
+	setID := st.setID
+	s.mu.Unlock()
+
+	v := c.Value
+	if err := sc.SetDataOnSimObject(setID, client.OBJECT_ID_USER, 0, 0, 8, unsafe.Pointer(&v)); err != nil {
+		slog.Error("Cannot apply shared var", "name", c.Name, "error", err)
+	}
+}
+
+// send queues a change for the peer; it must be called with the lock held
+// changes are dropped while the queue is full rather than stalling dispatch
+func (s *Session) send(c Change) {
+	select {
+	case s.out <- c:
+	default:
+		slog.Warn("Dropping shared change, the transport is behind", "name", c.Name)
+	}
+}
+
+// Update sends the vars that changed locally
+func (s *Session) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sc == nil || ppData.DefineID != s.valuesID || len(s.order) == 0 {
+		return
+	}
+	data := unsafe.Slice((*float64)(ppData.DataPointer()), len(s.order))
+	now := time.Now()
+	for i, st := range s.order {
+		v := data[i]
+		if st.known && math.Abs(v-st.value) <= st.Epsilon {
+			continue
+		}
+		first := !st.known
+		st.value, st.known, st.changed = v, true, now
+		// the master sends its starting state so the peer converges; shared
+		// subsystems only send changes, so neither side overwrites the other on join
+		role := s.role(st.Subsystem)
+		if role.sends() && (!first || role == Master) {
+			s.send(Change{Name: st.Name, Value: v, At: now})
+		}
+	}
+}
+
+// Event sends the events that fired locally
+func (s *Session) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.byID[ev.EventID]
+	if !ok {
+		return
+	}
+	if st.echoes > 0 {
+		st.echoes--
+		return
+	}
+	if s.role(st.Subsystem).sends() {
+		s.send(Change{Name: st.Name, Event: true, Data: int32(ev.Data), At: time.Now()})
+	}
+}
@@ -0,0 +1,130 @@
+package simconnect
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// statsWindow is the window dispatch rates are measured over
+const statsWindow = 5 * time.Second
+
+// maxExceptions is the number of recent exceptions kept
+const maxExceptions = 50
+
+// Stats is a snapshot of the connector state
+type Stats struct {
+	Connected   bool
+	ConnectedAt time.Time
+	// Messages counts the messages dispatched since connecting, by message type
+	Messages map[string]uint64
+	// Rates is messages per second over the last complete window, by message type
+	Rates map[string]float64
+	// Exceptions are the most recent exceptions, oldest first
+	Exceptions []ExceptionRecord
+	// Latency is the data request round trip time, by definition
+	Latency []client.LatencyStats
+}
+
+// ExceptionRecord is an exception received from SimConnect
+type ExceptionRecord struct {
+	At        time.Time
+	Exception client.RecvException
+	// DefineID and Version identify the definition the exception was
+	// raised for, when it was; see client.WithDefinitionVersion
+	DefineID      client.DWORD
+	Version       string
+	HasDefinition bool
+}
+
+type stats struct {
+	mu          sync.Mutex
+	sc          *client.SimConnect
+	connectedAt time.Time
+	messages    map[string]uint64
+	window      map[string]uint64
+	windowStart time.Time
+	rates       map[string]float64
+	exceptions  []ExceptionRecord
+}
+
+func (s *stats) connected(sc *client.SimConnect) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sc = sc
+	s.connectedAt = time.Now()
+	s.messages = map[string]uint64{}
+	s.window = map[string]uint64{}
+	s.windowStart = s.connectedAt
+	s.rates = map[string]float64{}
+}
+
+func (s *stats) disconnected() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sc = nil
+}
+
+func (s *stats) seen(r *client.Recv) {
+	name := client.RecvIDName(r.ID)
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.messages == nil {
+		return
+	}
+	s.messages[name]++
+	if d := now.Sub(s.windowStart); d >= statsWindow {
+		s.rates = make(map[string]float64, len(s.window))
+		for k, v := range s.window {
+			s.rates[k] = float64(v) / d.Seconds()
+		}
+		s.window = map[string]uint64{}
+		s.windowStart = now
+	}
+	s.window[name]++
+}
+
+func (s *stats) exception(ex client.RecvException) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := ExceptionRecord{At: time.Now(), Exception: ex}
+	if s.sc != nil {
+		rec.DefineID, rec.Version, rec.HasDefinition = s.sc.ExceptionDefinition(ex)
+	}
+	s.exceptions = append(s.exceptions, rec)
+	if len(s.exceptions) > maxExceptions {
+		s.exceptions = s.exceptions[len(s.exceptions)-maxExceptions:]
+	}
+}
+
+// Stats returns a snapshot of the connector state
+func (c *Connector) Stats() Stats {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	st := Stats{
+		Connected:   c.stats.sc != nil,
+		ConnectedAt: c.stats.connectedAt,
+		Messages:    make(map[string]uint64, len(c.stats.messages)),
+		Rates:       make(map[string]float64, len(c.stats.rates)),
+		Exceptions:  append([]ExceptionRecord(nil), c.stats.exceptions...),
+	}
+	for k, v := range c.stats.messages {
+		st.Messages[k] = v
+	}
+	for k, v := range c.stats.rates {
+		st.Rates[k] = v
+	}
+	if c.stats.sc != nil {
+		st.Latency = c.stats.sc.Latencies()
+	}
+	return st
+}
+
+// SimConnect returns the current connection, or nil if not connected
+func (c *Connector) SimConnect() *client.SimConnect {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	return c.stats.sc
+}
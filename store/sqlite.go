@@ -0,0 +1,85 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SQLite is a Store keeping keys in a table of a SQLite database
+// the database is opened by the application with the driver it already
+// uses, eg modernc.org/sqlite or github.com/mattn/go-sqlite3, so this
+// package does not pull one in; SQLite 3.24 or later is needed
+type SQLite struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLite creates a store in a table of db, creating the table if needed
+// the table name must be a plain identifier
+func NewSQLite(db *sql.DB, table string) (*SQLite, error) {
+	if !identifier(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + table + ` (key TEXT PRIMARY KEY, value BLOB NOT NULL)`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create table %s: %w", table, err)
+	}
+	return &SQLite{db: db, table: table}, nil
+}
+
+func identifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Get reads the row of a key
+func (s *SQLite) Get(key string) ([]byte, error) {
+	var value []byte
+	err := s.db.QueryRow(`SELECT value FROM `+s.table+` WHERE key = ?`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return value, err
+}
+
+// Put inserts or replaces the row of a key
+func (s *SQLite) Put(key string, value []byte) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	_, err := s.db.Exec(`INSERT INTO `+s.table+` (key, value) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value`, key, value)
+	return err
+}
+
+// List returns the keys starting with prefix, sorted
+func (s *SQLite) List(prefix string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT key FROM `+s.table+` WHERE substr(key, 1, length(?1)) = ?1 ORDER BY key`, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
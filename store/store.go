@@ -0,0 +1,119 @@
+// Package store is the persistence the other packages share, so an
+// embedding application can keep facility lists, calibrations, aircraft
+// profile matches and crash snapshots wherever it keeps its own data
+//
+// A Store maps slash separated keys, eg "facilities/airports", to values.
+// Dir keeps each key in a file, SQLite keeps them in a table of a database
+// the application opens with its SQLite driver, and Memory keeps them for
+// the life of the process. Anything else implementing Store can be passed
+// in their place.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned by Get for a key that was never Put
+var ErrNotFound = errors.New("not found")
+
+// Store keeps values by key
+// implementations must be safe for concurrent use
+type Store interface {
+	// Get returns the value of a key, or an error wrapping ErrNotFound
+	Get(key string) ([]byte, error)
+	// Put sets the value of a key, replacing any previous value in one step
+	Put(key string, value []byte) error
+	// List returns the keys starting with prefix, sorted
+	List(prefix string) ([]string, error)
+}
+
+// GetJSON reads a key and decodes its JSON value into v
+func GetJSON(s Store, key string, v any) error {
+	data, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", key, err)
+	}
+	return nil
+}
+
+// PutJSON encodes v as JSON and writes it to a key
+func PutJSON(s Store, key string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.Put(key, data)
+}
+
+// validKey checks a key is a list of non-empty slash separated parts
+// parts may not start with a dot, so they can't climb out of a Dir or
+// collide with its temporary files, nor hold a backslash or colon, which
+// Windows reads as a separator or a drive or stream, nor be anything
+// filepath.Clean would rewrite
+func validKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part[0] == '.' || strings.ContainsAny(part, `\:`) || filepath.Clean(part) != part {
+			return fmt.Errorf("invalid key %q", key)
+		}
+	}
+	return nil
+}
+
+// Memory is a Store that keeps values in memory
+type Memory struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+// NewMemory creates an empty Memory store
+func NewMemory() *Memory {
+	return &Memory{values: map[string][]byte{}}
+}
+
+// Get returns a copy of the value of a key
+func (m *Memory) Get(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.values[key]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return append([]byte(nil), v...), nil
+}
+
+// Put keeps a copy of the value
+func (m *Memory) Put(key string, value []byte) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// List returns the keys starting with prefix, sorted
+func (m *Memory) List(prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for k := range m.values {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,274 @@
+// Package tanker scores aerial refueling contacts from the formation
+// geometry between a receiver and a tanker object
+//
+// A Monitor is fed the formation guidance, with the Keeper's station set
+// to the envelope's contact point, and raises events as the receiver
+// moves through the pre-contact and contact zones behind the tanker:
+//
+//	mon := tanker.NewMonitor(tanker.Boom())
+//	keeper := formation.New(formation.WithOffset(mon.Envelope().Contact), formation.WithOnGuidance(mon.Observe))
+package tanker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bmurray/simconnect-go/formation"
+)
+
+// Envelope is the refueling geometry behind a tanker
+// offsets are from the tanker to the receiver in feet; the presets are
+// typical values and depend on the tanker and receiver models
+type Envelope struct {
+	Name string
+	// Contact is where the receiver sits while connected
+	Contact formation.Offset
+	// ContactRadius is how close to Contact counts as connected
+	ContactRadius float64
+	// PreContact is the distance from Contact where the receiver stabilises
+	PreContact float64
+	// MaxClosure is the fastest approach to Contact, in knots, that does not
+	// count as an overrun
+	MaxClosure float64
+}
+
+// Boom is a flying boom envelope, the receiver below and behind the tail
+func Boom() Envelope {
+	return Envelope{
+		Name:          "boom",
+		Contact:       formation.Offset{Forward: -110, Up: -30},
+		ContactRadius: 8,
+		PreContact:    50,
+		MaxClosure:    3,
+	}
+}
+
+// Drogue is a probe and drogue envelope for a centreline hose
+func Drogue() Envelope {
+	return Envelope{
+		Name:          "drogue",
+		Contact:       formation.Offset{Forward: -120, Up: -15},
+		ContactRadius: 3,
+		PreContact:    20,
+		MaxClosure:    5,
+	}
+}
+
+// Kind is the kind of event
+type Kind int
+
+const (
+	// PreContact is entering the pre-contact zone
+	PreContact Kind = iota
+	// Contact is connecting within the contact radius at a safe closure
+	Contact
+	// Disconnect is leaving the contact radius after a contact
+	Disconnect
+	// Overrun is closing faster than MaxClosure inside the pre-contact zone
+	Overrun
+	// Exit is leaving the pre-contact zone
+	Exit
+)
+
+func (k Kind) String() string {
+	switch k {
+	case PreContact:
+		return "pre-contact"
+	case Contact:
+		return "contact"
+	case Disconnect:
+		return "disconnect"
+	case Overrun:
+		return "overrun"
+	case Exit:
+		return "exit"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is raised as the receiver moves through the envelope
+type Event struct {
+	Kind Kind
+	// Distance is from the contact point, in feet
+	Distance float64
+	// Closure is the rate of approach to the contact point, in knots
+	Closure float64
+	// Held is the time connected, on Disconnect
+	Held time.Duration
+	At   time.Time
+}
+
+// Score sums up the contacts
+type Score struct {
+	Contacts    int
+	Disconnects int
+	Overruns    int
+	// Connected is the total time connected
+	Connected time.Duration
+	// Longest is the longest single contact
+	Longest time.Duration
+}
+
+// zone is where the receiver is in the envelope
+type zone int
+
+const (
+	outside zone = iota
+	preContact
+	contact
+)
+
+// Monitor turns formation guidance into refueling events
+type Monitor struct {
+	envelope Envelope
+	onEvent  func(Event)
+
+	mu        sync.Mutex
+	zone      zone
+	overrun   bool
+	last      *sample
+	connected time.Time
+	score     Score
+}
+
+type sample struct {
+	distance float64
+	at       time.Time
+}
+
+// Option is a function that sets options on the Monitor
+type Option func(*Monitor)
+
+// WithOnEvent sets a callback that is called with every event
+func WithOnEvent(fn func(Event)) Option {
+	return func(m *Monitor) {
+		m.onEvent = fn
+	}
+}
+
+// NewMonitor creates a monitor for the envelope
+func NewMonitor(e Envelope, opts ...Option) *Monitor {
+	m := &Monitor{envelope: e}
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+// Envelope returns the envelope being monitored
+func (m *Monitor) Envelope() Envelope {
+	return m.envelope
+}
+
+// Score returns the score so far; a contact in progress counts toward Connected
+func (m *Monitor) Score() Score {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.score
+	if m.zone == contact {
+		held := time.Since(m.connected)
+		s.Connected += held
+		if held > s.Longest {
+			s.Longest = held
+		}
+	}
+	return s
+}
+
+// Reset clears the score and the zone
+func (m *Monitor) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.zone = outside
+	m.overrun = false
+	m.last = nil
+	m.score = Score{}
+}
+
+// Observe updates the monitor with a guidance sample, eg as the
+// formation.WithOnGuidance callback
+func (m *Monitor) Observe(g formation.Guidance) {
+	rel := formation.Relative(g.Lead, g.Own.Position)
+	m.observe(rel.Sub(m.envelope.Contact).Distance(), g.At)
+}
+
+func (m *Monitor) observe(distance float64, at time.Time) {
+	m.mu.Lock()
+	ev := Event{Distance: distance, At: at}
+	if m.last != nil {
+		if dt := at.Sub(m.last.at).Hours(); dt > 0 {
+			ev.Closure = (m.last.distance - distance) / 6076.12 / dt
+		}
+	}
+	m.last = &sample{distance: distance, at: at}
+
+	var events []Event
+	emit := func(k Kind) {
+		e := ev
+		e.Kind = k
+		events = append(events, e)
+	}
+	e := m.envelope
+	switch {
+	case distance <= e.ContactRadius:
+		if m.zone != contact && !m.overrun && ev.Closure <= e.MaxClosure {
+			if m.zone == outside {
+				emit(PreContact)
+			}
+			m.zone = contact
+			m.connected = at
+			m.score.Contacts++
+			emit(Contact)
+		} else if m.zone != contact && !m.overrun {
+			m.overrun = true
+			m.score.Overruns++
+			emit(Overrun)
+		}
+	case distance <= e.PreContact:
+		if m.zone == contact {
+			events = append(events, m.disconnect(ev))
+		}
+		if m.zone == outside {
+			emit(PreContact)
+		}
+		m.zone = preContact
+		if ev.Closure > e.MaxClosure && !m.overrun {
+			m.overrun = true
+			m.score.Overruns++
+			emit(Overrun)
+		} else if ev.Closure <= 0 {
+			// backing off clears the overrun for another attempt
+			m.overrun = false
+		}
+	default:
+		if m.zone == contact {
+			events = append(events, m.disconnect(ev))
+		}
+		if m.zone != outside {
+			emit(Exit)
+		}
+		m.zone = outside
+		m.overrun = false
+	}
+	m.mu.Unlock()
+
+	if m.onEvent != nil {
+		for _, e := range events {
+			m.onEvent(e)
+		}
+	}
+}
+
+// disconnect ends a contact; it must be called with the lock held
+func (m *Monitor) disconnect(ev Event) Event {
+	ev.Kind = Disconnect
+	ev.Held = ev.At.Sub(m.connected)
+	m.score.Disconnects++
+	m.score.Connected += ev.Held
+	if ev.Held > m.score.Longest {
+		m.score.Longest = ev.Held
+	}
+	m.zone = preContact
+	return ev
+}
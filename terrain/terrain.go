@@ -0,0 +1,173 @@
+// Package terrain finds the ground elevation at arbitrary positions
+//
+// SimConnect has no terrain query, so the Probe creates a small simulated
+// object, moves it over each position and reads GROUND ALTITUDE under it.
+// The sim only has terrain loaded around the camera, so positions far from
+// the user aircraft may report coarse or zero elevations.
+package terrain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+	"github.com/bmurray/simconnect-go/geo"
+)
+
+// TerrainError is the error type for the package
+type TerrainError string
+
+func (e TerrainError) Error() string { return string(e) }
+
+const (
+	// ErrNotStarted is returned before the connector has started the probe
+	ErrNotStarted TerrainError = "probe not started"
+)
+
+// probePosition moves the probe object
+type probePosition struct {
+	client.RecvSimobjectDataByType
+	Latitude  float64 `name:"PLANE LATITUDE" unit:"Degrees"`
+	Longitude float64 `name:"PLANE LONGITUDE" unit:"Degrees"`
+	Altitude  float64 `name:"PLANE ALTITUDE" unit:"Feet"`
+}
+
+// Probe is a receiver that measures ground elevation
+type Probe struct {
+	title   string
+	settle  time.Duration
+	retries int
+
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	objectID client.DWORD
+	created  bool
+}
+
+// Option is a function that sets options on the Probe
+type Option func(*Probe)
+
+// WithSettle sets how long to wait after moving the probe before reading
+// the default is 100ms
+func WithSettle(d time.Duration) Option {
+	return func(p *Probe) {
+		p.settle = d
+	}
+}
+
+// WithRetries sets how many extra reads are made until two agree
+// the default is 3
+func WithRetries(n int) Option {
+	return func(p *Probe) {
+		p.retries = n
+	}
+}
+
+// New creates a probe using the SimObject title, which must be installed;
+// any small static object will do
+func New(title string, opts ...Option) *Probe {
+	p := &Probe{
+		title:   title,
+		settle:  100 * time.Millisecond,
+		retries: 3,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *Probe) Start(ctx context.Context, sc *client.SimConnect) {
+	p.mu.Lock()
+	p.sc = sc
+	p.created = false
+	p.mu.Unlock()
+	simconnect.Go(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.created {
+			if err := sc.RemoveObject(p.objectID); err != nil {
+				slog.Error("Cannot remove terrain probe", "error", err)
+			}
+			p.created = false
+		}
+		p.sc = nil
+	})
+}
+
+func (p *Probe) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+}
+
+// Elevation returns the ground elevation at the position, in feet
+// calls are serialised, as there is one probe object
+func (p *Probe) Elevation(ctx context.Context, pos geo.Position) (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sc == nil {
+		return 0, ErrNotStarted
+	}
+	if !p.created {
+		id, err := p.sc.CreateSimulatedObject(ctx, p.title, client.InitPosition{
+			Latitude:  pos.Latitude,
+			Longitude: pos.Longitude,
+			OnGround:  1,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("cannot create terrain probe: %w", err)
+		}
+		p.objectID = id
+		p.created = true
+	} else {
+		err := p.sc.SetDataOn(p.objectID, &probePosition{
+			Latitude:  pos.Latitude,
+			Longitude: pos.Longitude,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("cannot move terrain probe: %w", err)
+		}
+	}
+
+	last := math.NaN()
+	for i := 0; i <= p.retries; i++ {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(p.settle):
+		}
+		elev, err := p.sc.ReadObjectFloat(ctx, p.objectID, "GROUND ALTITUDE", "Feet")
+		if err != nil {
+			return 0, err
+		}
+		// the ground under a moved object can lag a frame; wait for it to settle
+		if math.Abs(elev-last) < 1 {
+			return elev, nil
+		}
+		last = elev
+	}
+	return last, nil
+}
+
+// Profile returns the elevations of n evenly spaced points from a to b, inclusive
+func (p *Probe) Profile(ctx context.Context, a, b geo.Position, n int) ([]float64, error) {
+	if n < 2 {
+		n = 2
+	}
+	bearing := geo.Bearing(a, b)
+	dist := geo.Distance(a, b)
+	out := make([]float64, n)
+	for i := range out {
+		pos := geo.Destination(a, bearing, dist*float64(i)/float64(n-1))
+		elev, err := p.Elevation(ctx, pos)
+		if err != nil {
+			return out[:i], err
+		}
+		out[i] = elev
+	}
+	return out, nil
+}
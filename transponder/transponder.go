@@ -0,0 +1,257 @@
+// Package transponder wraps the transponder simvars and key events
+// so that squawk handling can be encapsulated by network clients
+package transponder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	simconnect "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Mode is the transponder mode as reported by TRANSPONDER STATE
+// MSFS 2024 reports the mode through the same TRANSPONDER STATE enum as
+// MSFS 2020, with no separate mode simvars, so one definition serves both
+type Mode int
+
+const (
+	ModeOff     Mode = 0
+	ModeStandby Mode = 1
+	ModeTest    Mode = 2
+	ModeOn      Mode = 3
+	ModeAlt     Mode = 4
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeOff:
+		return "OFF"
+	case ModeStandby:
+		return "STBY"
+	case ModeTest:
+		return "TEST"
+	case ModeOn:
+		return "ON"
+	case ModeAlt:
+		return "ALT"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// SquawkReport is the data structure to report the transponder state
+type SquawkReport struct {
+	client.RecvSimobjectDataByType
+	Code  float64 `name:"TRANSPONDER CODE:1" unit:"BCO16"`
+	State float64 `name:"TRANSPONDER STATE:1" unit:"Enum"`
+	Ident float64 `name:"TRANSPONDER IDENT:1" unit:"Bool"`
+}
+
+// ModeRequest is the data structure to set the transponder mode
+// TRANSPONDER STATE is settable in MSFS 2020 and MSFS 2024
+type ModeRequest struct {
+	client.RecvSimobjectDataByType
+	State float64 `name:"TRANSPONDER STATE:1" unit:"Enum"`
+}
+
+// Status is the decoded transponder state
+type Status struct {
+	Code  int
+	Mode  Mode
+	Ident bool
+}
+
+// Transponder is a receiver that tracks and controls the transponder
+// Add it to a connector with simconnect.WithReceiver
+type Transponder struct {
+	mu       sync.Mutex
+	sc       *client.SimConnect
+	status   Status
+	valid    bool
+	setID    client.DWORD
+	identID  client.DWORD
+	onChange func(Status)
+}
+
+// Option is a function that sets options on the Transponder
+type Option func(*Transponder)
+
+// WithOnChange sets a callback that is called whenever the transponder state changes
+func WithOnChange(fn func(Status)) Option {
+	return func(t *Transponder) {
+		t.onChange = fn
+	}
+}
+
+// New creates a new Transponder
+func New(opts ...Option) *Transponder {
+	t := &Transponder{}
+	for _, o := range opts {
+		o(t)
+	}
+	return t
+}
+
+// Start registers the definitions, maps the transponder events and
+// subscribes to the transponder state, sent each second it changes
+func (t *Transponder) Start(ctx context.Context, sc *client.SimConnect) {
+	if err := sc.RegisterDataDefinition(&SquawkReport{}); err != nil {
+		slog.Error("Cannot register transponder report", "error", err)
+		return
+	}
+	if err := sc.RegisterDataDefinition(&ModeRequest{}); err != nil {
+		slog.Error("Cannot register transponder mode request", "error", err)
+		return
+	}
+	setID := sc.GetEventID()
+	if err := sc.MapClientEventToSimEvent(setID, "XPNDR_SET"); err != nil {
+		slog.Error("Cannot map XPNDR_SET", "error", err)
+		return
+	}
+	identID := sc.GetEventID()
+	if err := sc.MapClientEventToSimEvent(identID, "XPNDR_IDENT_ON"); err != nil {
+		slog.Error("Cannot map XPNDR_IDENT_ON", "error", err)
+		return
+	}
+
+	t.mu.Lock()
+	t.sc = sc
+	t.setID = setID
+	t.identID = identID
+	t.valid = false
+	t.mu.Unlock()
+
+	// the first reply carries the state as it is, later ones what changed,
+	// so a squawk or mode set in the cockpit reaches WithOnChange too
+	defineID := sc.GetDefineID(&SquawkReport{})
+	if err := sc.RequestDataOnSimObject(defineID, defineID, client.OBJECT_ID_USER, client.PERIOD_SECOND, client.DATA_REQUEST_FLAG_CHANGED, 0, 0, 0); err != nil {
+		slog.Error("Cannot subscribe to transponder", "error", err)
+	}
+}
+
+// Update decodes the transponder report and calls the change callback
+func (t *Transponder) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	r, ok := simconnect.IsReport[SquawkReport](sc, ppData)
+	if !ok {
+		return
+	}
+	st := Status{
+		Code:  FromBCO16(uint32(r.Code)),
+		Mode:  Mode(r.State),
+		Ident: r.Ident != 0,
+	}
+
+	t.mu.Lock()
+	changed := !t.valid || st != t.status
+	t.status = st
+	t.valid = true
+	fn := t.onChange
+	t.mu.Unlock()
+
+	if changed && fn != nil {
+		fn(st)
+	}
+}
+
+// Status returns the last known transponder state
+// the boolean is false if no report has been received since the last connect
+func (t *Transponder) Status() (Status, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status, t.valid
+}
+
+// Refresh requests a new transponder report
+func (t *Transponder) Refresh() error {
+	sc, err := t.client()
+	if err != nil {
+		return err
+	}
+	return simconnect.RequestData[SquawkReport](sc)
+}
+
+// Squawk sets the transponder code; code must be four octal digits, eg 7000
+func (t *Transponder) Squawk(code int) error {
+	bco, err := ToBCO16(code)
+	if err != nil {
+		return err
+	}
+	sc, err := t.client()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	id := t.setID
+	t.mu.Unlock()
+	if err := sc.TransmitClientEvent(client.OBJECT_ID_USER, id, client.DWORD(bco), client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY); err != nil {
+		return err
+	}
+	return t.Refresh()
+}
+
+// SetMode sets the transponder mode
+func (t *Transponder) SetMode(m Mode) error {
+	if m < ModeOff || m > ModeAlt {
+		return fmt.Errorf("invalid transponder mode: %d", int(m))
+	}
+	sc, err := t.client()
+	if err != nil {
+		return err
+	}
+	if err := sc.SetData(&ModeRequest{State: float64(m)}); err != nil {
+		return err
+	}
+	return t.Refresh()
+}
+
+// Ident pulses the transponder ident; the sim resets it after a few seconds
+func (t *Transponder) Ident() error {
+	sc, err := t.client()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	id := t.identID
+	t.mu.Unlock()
+	return sc.TransmitClientEvent(client.OBJECT_ID_USER, id, 0, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
+
+func (t *Transponder) client() (*client.SimConnect, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sc == nil {
+		return nil, fmt.Errorf("transponder not started")
+	}
+	return t.sc, nil
+}
+
+// ToBCO16 converts a four digit squawk code to the BCO16 value used by XPNDR_SET
+func ToBCO16(code int) (uint32, error) {
+	if code < 0 || code > 7777 {
+		return 0, fmt.Errorf("invalid squawk code: %04d", code)
+	}
+	var bco uint32
+	for shift := 0; shift < 16; shift += 4 {
+		digit := code % 10
+		if digit > 7 {
+			return 0, fmt.Errorf("invalid squawk code: %04d", code)
+		}
+		bco |= uint32(digit) << shift
+		code /= 10
+	}
+	return bco, nil
+}
+
+// FromBCO16 converts a BCO16 value to a four digit squawk code
+func FromBCO16(bco uint32) int {
+	code := 0
+	mul := 1
+	for shift := 0; shift < 16; shift += 4 {
+		code += int((bco>>shift)&0xf) * mul
+		mul *= 10
+	}
+	return code
+}
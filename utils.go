@@ -1,6 +1,7 @@
 package simconnect
 
 import (
+	"context"
 	"unsafe"
 
 	"github.com/bmurray/simconnect-go/client"
@@ -11,6 +12,13 @@ func IsReport[T any](s *client.SimConnect, ppData *client.RecvSimobjectDataByTyp
 	var typed *T
 	defineId := s.GetDefineID(typed)
 	if ppData.DefineID == defineId {
+		s.MarkUsed(defineId)
+		// structs with offset tags don't share SimConnect's packing
+		if s.Explicit(defineId) {
+			out := new(T)
+			s.DecodeExplicit(ppData, out)
+			return out, true
+		}
 		return (*T)(unsafe.Pointer(ppData)), true
 	}
 	return nil, false
@@ -19,7 +27,55 @@ func IsReport[T any](s *client.SimConnect, ppData *client.RecvSimobjectDataByTyp
 // RequestData Convenience function to request data
 func RequestData[T any](s *client.SimConnect) error {
 	var report *T
+	return requestReport(s, report)
+}
+
+// RequestDataNearby Convenience function to request T for every object of a
+// type (client.SIMOBJECT_TYPE_AIRCRAFT, ...) within radius meters of the
+// user, up to client.MAX_RADIUS
+// each object arrives at Update as its own reply, with its ObjectID and
+// EntryNumber of OutOf set, so a scan is complete once EntryNumber == OutOf
+func RequestDataNearby[T any](s *client.SimConnect, radius, objectType client.DWORD) error {
+	var report *T
+	if err := s.RegisterDataDefinition(report); err != nil {
+		return err
+	}
+	defineId := s.GetDefineID(report)
+	return s.RequestDataOnSimObjectType(defineId, defineId, min(radius, client.MAX_RADIUS), objectType)
+}
+
+// RequestDataPeriodic Convenience function to have the sim push T for an
+// object every period (client.PERIOD_SIM_FRAME, PERIOD_SECOND, ...) instead
+// of polling; flags are DATA_REQUEST_FLAG_*, and interval is the number of
+// periods skipped between sends
+// the replies reach Update as usual; the returned request ID stops them
+// with StopDataPeriodic
+func RequestDataPeriodic[T any](s *client.SimConnect, objectID, period, flags, interval client.DWORD) (client.DWORD, error) {
+	var report *T
+	if err := s.RegisterDataDefinition(report); err != nil {
+		return 0, err
+	}
 	defineId := s.GetDefineID(report)
-	reqId := defineId
-	return s.RequestDataOnSimObjectType(reqId, defineId, 0, client.SIMOBJECT_TYPE_USER)
+	requestID := s.NewRequestID()
+	if err := s.RequestDataOnSimObject(requestID, defineId, objectID, period, flags, 0, interval, 0); err != nil {
+		return 0, err
+	}
+	return requestID, nil
+}
+
+// StopDataPeriodic stops a request made with RequestDataPeriodic
+func StopDataPeriodic[T any](s *client.SimConnect, requestID, objectID client.DWORD) error {
+	var report *T
+	defineId := s.GetDefineID(report)
+	return s.RequestDataOnSimObject(requestID, defineId, objectID, client.PERIOD_NEVER, client.DATA_REQUEST_FLAG_DEFAULT, 0, 0, 0)
+}
+
+// ReadFacility Convenience function to request a facility into a new T
+// see client.RegisterFacilityDefinition for the struct tags
+func ReadFacility[T any](ctx context.Context, s *client.SimConnect, object, icao, region string) (*T, error) {
+	out := new(T)
+	if err := s.ReadFacility(ctx, object, icao, region, out); err != nil {
+		return nil, err
+	}
+	return out, nil
 }
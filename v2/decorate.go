@@ -0,0 +1,112 @@
+package simconnect
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Trace logs every request, write and event sent through the connection
+func Trace(log *slog.Logger) func(Conn) Conn {
+	return func(c Conn) Conn {
+		return &traced{Conn: c, log: log}
+	}
+}
+
+type traced struct {
+	Conn
+	log *slog.Logger
+}
+
+func (t *traced) RequestDataOnSimObjectType(requestID, defineID, radius, simobjectType client.DWORD) error {
+	err := t.Conn.RequestDataOnSimObjectType(requestID, defineID, radius, simobjectType)
+	t.log.Debug("RequestDataOnSimObjectType", "request", requestID, "define", defineID, "error", err)
+	return err
+}
+
+func (t *traced) RequestDataOnSimObject(requestID, defineID, objectID, period, flags, origin, interval, limit client.DWORD) error {
+	err := t.Conn.RequestDataOnSimObject(requestID, defineID, objectID, period, flags, origin, interval, limit)
+	t.log.Debug("RequestDataOnSimObject", "request", requestID, "define", defineID, "period", period, "error", err)
+	return err
+}
+
+func (t *traced) SetData(fr any) error {
+	err := t.Conn.SetData(fr)
+	t.log.Debug("SetData", "type", fmt.Sprintf("%T", fr), "error", err)
+	return err
+}
+
+func (t *traced) TransmitClientEvent(objectID, eventID, dwData, groupID, flags client.DWORD) error {
+	err := t.Conn.TransmitClientEvent(objectID, eventID, dwData, groupID, flags)
+	t.log.Debug("TransmitClientEvent", "event", eventID, "data", dwData, "error", err)
+	return err
+}
+
+// RateLimit spaces data requests and reads at least every apart, waiting
+// as needed; commands and writes are not limited
+func RateLimit(every time.Duration) func(Conn) Conn {
+	return func(c Conn) Conn {
+		return &limited{Conn: c, every: every}
+	}
+}
+
+type limited struct {
+	Conn
+	every time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait blocks until the next slot
+func (l *limited) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.every)
+	l.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(at)):
+		return nil
+	}
+}
+
+func (l *limited) RequestDataOnSimObjectType(requestID, defineID, radius, simobjectType client.DWORD) error {
+	l.wait(context.Background())
+	return l.Conn.RequestDataOnSimObjectType(requestID, defineID, radius, simobjectType)
+}
+
+func (l *limited) RequestDataOnSimObject(requestID, defineID, objectID, period, flags, origin, interval, limit client.DWORD) error {
+	l.wait(context.Background())
+	return l.Conn.RequestDataOnSimObject(requestID, defineID, objectID, period, flags, origin, interval, limit)
+}
+
+func (l *limited) ReadFloat(ctx context.Context, name, unit string) (float64, error) {
+	if err := l.wait(ctx); err != nil {
+		return 0, err
+	}
+	return l.Conn.ReadFloat(ctx, name, unit)
+}
+
+func (l *limited) ReadInt(ctx context.Context, name, unit string) (int64, error) {
+	if err := l.wait(ctx); err != nil {
+		return 0, err
+	}
+	return l.Conn.ReadInt(ctx, name, unit)
+}
+
+func (l *limited) ReadString(ctx context.Context, name string) (string, error) {
+	if err := l.wait(ctx); err != nil {
+		return "", err
+	}
+	return l.Conn.ReadString(ctx, name)
+}
@@ -0,0 +1,150 @@
+// Package simconnect is the v2 API: the high-level helpers take narrow
+// interfaces rather than *client.SimConnect, so callers can pass mocks,
+// decorators (rate limiting, tracing) or alternate backends
+//
+// *client.SimConnect satisfies every interface, and Adapt plugs a v2
+// Receiver into the existing Connector, so v1 and v2 code can be mixed
+package simconnect
+
+import (
+	"context"
+	"unsafe"
+
+	v1 "github.com/bmurray/simconnect-go"
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// Definer resolves and registers data definitions
+type Definer interface {
+	GetDefineID(a interface{}) client.DWORD
+	RegisterDataDefinition(a interface{}) error
+}
+
+// DataRequester requests data from the sim
+type DataRequester interface {
+	Definer
+	RequestDataOnSimObjectType(requestID, defineID, radius, simobjectType client.DWORD) error
+	RequestDataOnSimObject(requestID, defineID, objectID, period, flags, origin, interval, limit client.DWORD) error
+}
+
+// DataSetter writes data to the sim
+type DataSetter interface {
+	SetData(fr any) error
+}
+
+// EventSender maps and sends client events
+type EventSender interface {
+	MapClientEventByName(eventName string) (client.DWORD, error)
+	TransmitClientEvent(objectID, eventID, dwData, groupID, flags client.DWORD) error
+}
+
+// SystemEvents subscribes to system events
+type SystemEvents interface {
+	SubscribeToSystemEventByName(eventName string) (client.DWORD, error)
+}
+
+// Texter shows in-sim text
+type Texter interface {
+	ShowText(textType client.DWORD, duration float64, eventID client.DWORD, text string) error
+}
+
+// Reader reads single simvars
+type Reader interface {
+	ReadFloat(ctx context.Context, name, unit string) (float64, error)
+	ReadInt(ctx context.Context, name, unit string) (int64, error)
+	ReadString(ctx context.Context, name string) (string, error)
+}
+
+// Writer writes single simvars
+type Writer interface {
+	WriteFloat(ctx context.Context, name, unit string, v float64) error
+	WriteInt(ctx context.Context, name, unit string, v int64) error
+	WriteString(ctx context.Context, name string, v string) error
+}
+
+// Conn is everything the v2 helpers use
+type Conn interface {
+	DataRequester
+	DataSetter
+	EventSender
+	SystemEvents
+	Texter
+	Reader
+	Writer
+}
+
+var _ Conn = (*client.SimConnect)(nil)
+
+// Receiver is a v2 receiver; see v1 Receiver for when the methods are called
+type Receiver interface {
+	Start(ctx context.Context, c Conn)
+	Update(ctx context.Context, c Conn, ppData *client.RecvSimobjectDataByType)
+}
+
+// EventReceiver is an optional interface for v2 receivers that handle events
+type EventReceiver interface {
+	Event(ctx context.Context, c Conn, ev *client.RecvEvent)
+}
+
+// Adapt wraps a v2 receiver for the Connector
+// each wrap is applied, in order, to the connection the receiver sees
+func Adapt(r Receiver, wrap ...func(Conn) Conn) v1.Receiver {
+	return &adapter{r: r, wrap: wrap}
+}
+
+type adapter struct {
+	r    Receiver
+	wrap []func(Conn) Conn
+	conn Conn
+}
+
+func (a *adapter) Start(ctx context.Context, sc *client.SimConnect) {
+	var c Conn = sc
+	for _, w := range a.wrap {
+		c = w(c)
+	}
+	a.conn = c
+	a.r.Start(ctx, c)
+}
+
+func (a *adapter) Update(ctx context.Context, sc *client.SimConnect, ppData *client.RecvSimobjectDataByType) {
+	a.r.Update(ctx, a.conn, ppData)
+}
+
+func (a *adapter) Event(ctx context.Context, sc *client.SimConnect, ev *client.RecvEvent) {
+	if er, ok := a.r.(EventReceiver); ok {
+		er.Event(ctx, a.conn, ev)
+	}
+}
+
+// IsReport checks if the data is the report type T
+// it marks the definition used when d can, like simconnect.IsReport
+func IsReport[T any](d Definer, ppData *client.RecvSimobjectDataByType) (*T, bool) {
+	var typed *T
+	if defineID := d.GetDefineID(typed); ppData.DefineID == defineID {
+		if m, ok := d.(interface{ MarkUsed(client.DWORD) }); ok {
+			m.MarkUsed(defineID)
+		}
+		return (*T)(unsafe.Pointer(ppData)), true
+	}
+	return nil, false
+}
+
+// RequestData requests T for the user aircraft, registering it if needed
+func RequestData[T any](r DataRequester) error {
+	var report *T
+	if err := r.RegisterDataDefinition(report); err != nil {
+		return err
+	}
+	defineID := r.GetDefineID(report)
+	return r.RequestDataOnSimObjectType(defineID, defineID, 0, client.SIMOBJECT_TYPE_USER)
+}
+
+// SendEvent maps the sim event by name, if needed, and sends it to the user aircraft
+func SendEvent(s EventSender, name string, data client.DWORD) error {
+	id, err := s.MapClientEventByName(name)
+	if err != nil {
+		return err
+	}
+	return s.TransmitClientEvent(client.OBJECT_ID_USER, id, data, client.GROUP_PRIORITY_HIGHEST, client.EVENT_FLAG_GROUPID_IS_PRIORITY)
+}
@@ -0,0 +1,84 @@
+package simconnect
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bmurray/simconnect-go/client"
+)
+
+// WithQuotaWatchdog reclaims unused definitions and periodic requests when
+// quota exceptions (TOO_MANY_REQUESTS, TOO_MANY_MAPS and the like) start
+// arriving in bursts; anything not used for idle is released and logged
+// see client.Reclaim for what counts as unused
+func WithQuotaWatchdog(idle time.Duration) ConnectorOption {
+	return func(c *Connector) {
+		c.watchdog = &watchdog{idle: idle, threshold: 3, window: 10 * time.Second, cooldown: time.Minute}
+	}
+}
+
+// watchdog counts quota exceptions and reclaims once they form a storm
+type watchdog struct {
+	idle      time.Duration
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+
+	mu      sync.Mutex
+	recent  []time.Time
+	lastRun time.Time
+}
+
+func isQuotaException(ex client.RecvException) bool {
+	switch client.RecvExceptionID(ex.Exception) {
+	case client.SIMCONNECT_EXCEPTION_TOO_MANY_REQUESTS,
+		client.SIMCONNECT_EXCEPTION_TOO_MANY_MAPS,
+		client.SIMCONNECT_EXCEPTION_TOO_MANY_GROUPS,
+		client.SIMCONNECT_EXCEPTION_TOO_MANY_EVENT_NAMES,
+		client.SIMCONNECT_EXCEPTION_TOO_MANY_OBJECTS:
+		return true
+	}
+	return false
+}
+
+// exception records the exception and reclaims if a storm is under way
+func (w *watchdog) exception(log *slog.Logger, sc *client.SimConnect, ex client.RecvException) {
+	if w == nil || !isQuotaException(ex) {
+		return
+	}
+	now := time.Now()
+	w.mu.Lock()
+	recent := w.recent[:0]
+	for _, t := range w.recent {
+		if now.Sub(t) < w.window {
+			recent = append(recent, t)
+		}
+	}
+	w.recent = append(recent, now)
+	storm := len(w.recent) >= w.threshold && now.Sub(w.lastRun) > w.cooldown
+	if storm {
+		w.lastRun = now
+		w.recent = w.recent[:0]
+	}
+	w.mu.Unlock()
+	if !storm {
+		return
+	}
+
+	log.Warn("Quota exception storm, reclaiming unused definitions", "exception", ex.Exception, "idle", w.idle)
+	r, err := sc.Reclaim(w.idle)
+	for _, d := range r.Definitions {
+		log.Info("Reclaimed definition", "id", d.ID, "name", d.Name, "datums", len(d.Datums))
+	}
+	for _, s := range r.Subscriptions {
+		log.Info("Reclaimed subscription", "request", s.RequestID, "define", s.DefineID)
+	}
+	if err != nil {
+		log.Error("Cannot reclaim everything", "error", err)
+	}
+	if len(r.Definitions) == 0 && len(r.Subscriptions) == 0 {
+		// event maps and groups cannot be released without reconnecting
+		log.Warn("Nothing to reclaim", "exception", ex.Exception)
+	}
+}